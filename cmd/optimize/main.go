@@ -19,47 +19,111 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	metrics "github.com/thestormforge/optimize-go/pkg/api/metrics/v1"
 	"github.com/thestormforge/optimize-go/pkg/command"
+	"github.com/thestormforge/optimize-go/pkg/command/recommendation"
 	"github.com/thestormforge/optimize-go/pkg/config"
 	"golang.org/x/oauth2"
 )
 
 func main() {
-	cfg := &config.Config{}
+	cfg := &cliConfig{Config: &config.Config{}}
+	var impersonate, locale, timeZone string
+	errorFormat := "text"
+	var absoluteTime []string
+	var strictVersion bool
 
 	cmd := &cobra.Command{
 		Use:          "optimize",
 		SilenceUsage: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := env.Parse(cfg); err != nil {
+			switch errorFormat {
+			case "text":
+			case "json":
+				cmd.Root().SilenceErrors = true
+			default:
+				return fmt.Errorf("unknown error format: %s", errorFormat)
+			}
+			if err := env.Parse(cfg.Config); err != nil {
+				return err
+			}
+			if err := cfg.Endpoints.Validate(); err != nil {
+				return err
+			}
+			if impersonate != "" {
+				cfg.Impersonate = impersonate
+			}
+			if err := checkStaticToken(cmd, cfg.Config); err != nil {
 				return err
 			}
+			if locale == "" {
+				locale = os.Getenv("STORMFORGE_LOCALE")
+			}
+			if locale != "" {
+				tag, err := command.ParseLocale(locale)
+				if err != nil {
+					return fmt.Errorf("invalid locale %q: %w", locale, err)
+				}
+				command.SetLocale(tag)
+			}
+			if timeZone == "" {
+				timeZone = os.Getenv("STORMFORGE_TZ")
+			}
+			if timeZone != "" {
+				loc, err := time.LoadLocation(timeZone)
+				if err != nil {
+					return fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+				}
+				command.SetTimeZone(loc)
+			}
+			command.SetAbsoluteTimeColumns(absoluteTime...)
+			printerQuiet = cfg.quiet
 
-			http.DefaultTransport = cfg.Transport(cfg.TokenSource(cmd.Context()), http.DefaultTransport)
+			command.SetTransport(&versionCheckTransport{
+				Transport: &clockSkewTransport{
+					Transport: api.NewTransport(api.DefaultTransportOptions),
+					ErrOut:    cmd.ErrOrStderr(),
+				},
+				Strict: strictVersion,
+				ErrOut: cmd.ErrOrStderr(),
+			})
 			return nil
 		},
 	}
 
+	cmd.PersistentFlags().StringVar(&impersonate, "as", impersonate, "act on behalf of the `user` identified by email address")
+	cmd.PersistentFlags().StringVar(&locale, "locale", locale, "the `locale` used to sort and format output")
+	cmd.PersistentFlags().StringVar(&timeZone, "timezone", timeZone, "the time `zone` used to display timestamps (e.g. \"UTC\" or \"Local\")")
+	cmd.PersistentFlags().BoolVar(&strictVersion, "strict-version", strictVersion, "fail instead of warning when the server requires a newer client")
+	cmd.PersistentFlags().BoolVarP(&cfg.quiet, "quiet", "q", cfg.quiet, "suppress informational messages")
+	cmd.PersistentFlags().BoolVar(&cfg.noHeaders, "no-headers", cfg.noHeaders, "omit the header row from tabular output")
+	cmd.PersistentFlags().StringVar(&errorFormat, "error-format", errorFormat, "error `format`; one of: text|json")
+	cmd.PersistentFlags().StringSliceVar(&absoluteTime, "absolute-time", absoluteTime, "timestamp `columns` to display as absolute times instead of relative times, or \"all\"")
+
 	// Aggregate the CREATE commands
 	createCmd := &cobra.Command{
 		Use: "create",
 	}
 
 	createCmd.AddCommand(
-		command.NewCreateApplicationCommand(cfg, &printer{format: `created application %q.`}),
-		command.NewCreateScenarioCommand(cfg, &printer{format: `created scenario %q.`}),
-		command.NewCreateTrialCommand(cfg, &printer{format: `created trial %q.`}),
+		command.NewCreateApplicationCommand(cfg, &printer{message: command.MessageCreatedApplication}),
+		command.NewCreateScenarioCommand(cfg, &printer{message: command.MessageCreatedScenario}),
+		command.NewCreateRecommendationCommand(cfg, &printer{message: command.MessageCreatedRecommendation}),
+		command.NewCreateTrialCommand(cfg, &printer{message: command.MessageCreatedTrial}),
 	)
 
 	// Aggregate the EDIT commands
@@ -68,11 +132,12 @@ func main() {
 	}
 
 	editCmd.AddCommand(
-		command.NewEditApplicationCommand(cfg, &printer{format: `updated application %q.`}),
-		command.NewEditScenarioCommand(cfg, &printer{format: `updated scenario %q.`}),
-		command.NewEditExperimentCommand(cfg, &printer{format: `updated experiment %q.`}),
-		command.NewEditTrialCommand(cfg, &printer{format: `updated trial %q.`}),
-		command.NewEditClusterCommand(cfg, &printer{format: `updated cluster %q.`}),
+		command.NewEditApplicationCommand(cfg, &printer{message: command.MessageUpdatedApplication}),
+		command.NewEditScenarioCommand(cfg, &printer{message: command.MessageUpdatedScenario}),
+		command.NewEditTemplateCommand(cfg, &printer{message: command.MessageUpdatedTemplate}),
+		command.NewEditExperimentCommand(cfg, &printer{message: command.MessageUpdatedExperiment}),
+		command.NewEditTrialCommand(cfg, &printer{message: command.MessageUpdatedTrial}),
+		command.NewEditClusterCommand(cfg, &printer{message: command.MessageUpdatedCluster}),
 	)
 
 	// Aggregate the GET commands
@@ -83,6 +148,7 @@ func main() {
 	getCmd.AddCommand(
 		command.NewGetApplicationsCommand(cfg, &printer{}),
 		command.NewGetScenariosCommand(cfg, &printer{}),
+		command.NewGetTemplateCommand(cfg, &printer{}),
 		command.NewGetRecommendationsCommand(cfg, &printer{}),
 		command.NewGetExperimentsCommand(cfg, &printer{}),
 		command.NewGetTrialsCommand(cfg, &printer{}),
@@ -90,17 +156,18 @@ func main() {
 		command.NewGetActivityCommand(cfg, &printer{}),
 	)
 
-	// Aggregate the DELETE commands
+	// Aggregate the DELETE commands, accepting TYPE/NAME arguments from "-o name"
 	deleteCmd := &cobra.Command{
-		Use: "delete",
+		Use:  "delete [TYPE/NAME ...]",
+		RunE: command.RunNamedResources,
 	}
 
 	deleteCmd.AddCommand(
-		command.NewDeleteApplicationsCommand(cfg, &printer{format: `deleted application %q.`}),
-		command.NewDeleteScenariosCommand(cfg, &printer{format: `deleted scenario %q.`}),
-		command.NewDeleteExperimentsCommand(cfg, &printer{format: `deleted experiment %q.`}),
-		command.NewDeleteTrialsCommand(cfg, &printer{format: `deleted trial %q.`}),
-		command.NewDeleteClustersCommand(cfg, &printer{format: `deleted cluster %q.`}),
+		command.NewDeleteApplicationsCommand(cfg, &printer{message: command.MessageDeletedApplication}),
+		command.NewDeleteScenariosCommand(cfg, &printer{message: command.MessageDeletedScenario}),
+		command.NewDeleteExperimentsCommand(cfg, &printer{message: command.MessageDeletedExperiment}),
+		command.NewDeleteTrialsCommand(cfg, &printer{message: command.MessageDeletedTrial}),
+		command.NewDeleteClustersCommand(cfg, &printer{message: command.MessageDeletedCluster}),
 	)
 
 	// Aggregate the ENABLE commands
@@ -109,7 +176,7 @@ func main() {
 	}
 
 	enableCmd.AddCommand(
-		command.NewEnableApplicationRecommendationsCommand(cfg, &printer{format: `enabled application recommendations.`}),
+		command.NewEnableApplicationRecommendationsCommand(cfg, &printer{message: command.MessageEnabledRecommendations}),
 	)
 
 	// Aggregate the DISABLE commands
@@ -118,7 +185,7 @@ func main() {
 	}
 
 	disableCmd.AddCommand(
-		command.NewDisableApplicationRecommendationsCommand(cfg, &printer{format: `disabled application recommendations.`}),
+		command.NewDisableApplicationRecommendationsCommand(cfg, &printer{message: command.MessageDisabledRecommendations}),
 	)
 
 	// Aggregate the WATCH commands
@@ -130,6 +197,61 @@ func main() {
 		command.NewWatchActivityCommand(cfg),
 	)
 
+	// Aggregate the WAIT commands
+	waitCmd := &cobra.Command{
+		Use: "wait",
+	}
+
+	waitCmd.AddCommand(
+		command.NewWaitBackfillCommand(cfg, &printer{message: command.MessageBackfillComplete}),
+	)
+
+	// Aggregate the RETRY commands
+	retryCmd := &cobra.Command{
+		Use: "retry",
+	}
+
+	retryCmd.AddCommand(
+		command.NewRetryTrialCommand(cfg, &printer{message: command.MessageCreatedTrial}),
+	)
+
+	// Aggregate the STATUS commands
+	statusCmd := &cobra.Command{
+		Use: "status",
+	}
+
+	statusCmd.AddCommand(
+		command.NewStatusRecommendationsCommand(cfg, &printer{}),
+	)
+
+	// Aggregate the PUSH commands
+	pushCmd := &cobra.Command{
+		Use: "push",
+	}
+
+	pushCmd.AddCommand(
+		command.NewPushMetricsCommand(cfg, &printer{message: command.MessagePushedMetrics}),
+	)
+
+	// Aggregate the CONFIG commands
+	configCmd := &cobra.Command{
+		Use: "config",
+	}
+
+	configCmd.AddCommand(
+		uncheckedToken(command.NewConfigDoctorCommand(cfg, &printer{})),
+	)
+
+	// Aggregate the DEBUG commands
+	debugCmd := &cobra.Command{
+		Use: "debug",
+	}
+
+	debugCmd.AddCommand(
+		uncheckedToken(command.NewDebugTokenCommand(cfg, &printer{})),
+		command.NewDebugAPICommand(cfg, &printer{}),
+	)
+
 	// Add the aggregate commends to the root
 	cmd.AddCommand(
 		createCmd,
@@ -138,7 +260,17 @@ func main() {
 		deleteCmd,
 		enableCmd,
 		watchCmd,
+		waitCmd,
+		retryCmd,
+		statusCmd,
+		pushCmd,
+		configCmd,
+		debugCmd,
+		command.NewExplainCommand(cfg, &printer{}),
+		command.NewEnvCommand(cfg, &printer{}),
+		command.NewExporterCommand(cfg),
 		command.NewWhoAmICommand(cfg),
+		command.NewE2ECommand(cfg, &printer{}),
 	)
 
 	// Create a context for the command
@@ -152,17 +284,153 @@ func main() {
 	err := cmd.ExecuteContext(ctx)
 	cancel()
 	if err != nil {
+		if cmd.SilenceErrors {
+			_ = printJSONError(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.Hint != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "Hint: %s\n", apiErr.Hint)
+		}
 		os.Exit(1)
 	}
 }
 
+// printJSONError writes the machine-readable form of an error.
+func printJSONError(w io.Writer, err error) error {
+	var obj interface{}
+	var errList recommendation.ErrorList
+	if errors.As(err, &errList) {
+		obj = errList
+	} else {
+		e := map[string]string{"message": err.Error()}
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			e["type"] = string(apiErr.Type)
+			if apiErr.Hint != "" {
+				e["hint"] = apiErr.Hint
+			}
+		}
+		obj = map[string]interface{}{"errors": []map[string]string{e}}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(obj)
+}
+
+// annotationUncheckedToken marks commands which accept an invalid static token
+// because they report on it themselves.
+const annotationUncheckedToken = "optimize/unchecked-token"
+
+// checkStaticToken fails if the static token is a JWT the server would reject,
+// opaque tokens are left for the server to check.
+func checkStaticToken(cmd *cobra.Command, cfg *config.Config) error {
+	if cfg.Token == "" || cmd.Annotations[annotationUncheckedToken] != "" {
+		return nil
+	}
+	if _, err := cfg.ValidateToken(cfg.Token); err != nil && !errors.Is(err, config.ErrOpaqueToken) {
+		return fmt.Errorf("invalid STORMFORGE_TOKEN: %w", err)
+	}
+	return nil
+}
+
+// uncheckedToken disables the validation of the static token before the command runs.
+func uncheckedToken(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[annotationUncheckedToken] = "true"
+	return cmd
+}
+
+// versionCheckTransport compares the minimum client version advertised by the
+// server against the version of this binary.
+type versionCheckTransport struct {
+	Transport http.RoundTripper
+	Strict    bool
+	ErrOut    io.Writer
+
+	once sync.Once
+}
+
+func (t *versionCheckTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := api.CheckClientVersion(api.Metadata(resp.Header)); err != nil {
+		if t.Strict {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		t.once.Do(func() { _, _ = fmt.Fprintf(t.ErrOut, "WARNING: %v, please upgrade\n", err) })
+	}
+	return resp, nil
+}
+
+// clockSkewTransport warns when the local clock differs from the server clock
+// enough to interfere with token expiry checks.
+type clockSkewTransport struct {
+	Transport http.RoundTripper
+	ErrOut    io.Writer
+
+	once sync.Once
+}
+
+func (t *clockSkewTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if skew, ok := api.Metadata(resp.Header).ClockSkew(time.Now()); ok && (skew > api.ClockSkewThreshold || skew < -api.ClockSkewThreshold) {
+		t.once.Do(func() {
+			_, _ = fmt.Fprintf(t.ErrOut, "WARNING: local clock differs from the server by %s, authorization may fail\n", skew.Round(time.Second))
+		})
+	}
+	return resp, nil
+}
+
+// cliConfig adds the output options of the command line to the client configuration.
+type cliConfig struct {
+	*config.Config
+	quiet     bool
+	noHeaders bool
+}
+
+// WarningOutput returns the writer which receives server warnings.
+func (cfg *cliConfig) WarningOutput() io.Writer {
+	return os.Stderr
+}
+
+// IsQuiet returns true if informational messages should be suppressed.
+func (cfg *cliConfig) IsQuiet() bool {
+	return cfg.quiet
+}
+
+// IsNoHeaders returns true if tabular output should omit the header row.
+func (cfg *cliConfig) IsNoHeaders() bool {
+	return cfg.noHeaders
+}
+
+// printerQuiet suppresses the informational messages of formatted printers.
+var printerQuiet bool
+
 type printer struct {
-	format string
+	message command.MessageKey
 }
 
 func (p *printer) Fprint(w io.Writer, obj interface{}) error {
-	if p.format != "" {
-		format := p.format + "\n"
+	// Lists are always rendered in full
+	if _, isList := obj.(command.Output); p.message != "" && !isList {
+		if printerQuiet {
+			return nil
+		}
+		// Resolve the template late, the locale is not known until the command runs
+		format := command.MessageFormat(p.message) + "\n"
 		var err error
 		switch obj := obj.(type) {
 		case *applications.ApplicationItem:
@@ -177,6 +445,12 @@ func (p *printer) Fprint(w io.Writer, obj interface{}) error {
 			_, err = fmt.Fprintf(w, format, obj.Name)
 		case *experiments.TrialItem:
 			_, err = fmt.Fprintf(w, format, experiments.JoinTrialName(obj.Experiment, obj.Number))
+		case *metrics.WriteRequest:
+			var n int
+			for _, ts := range obj.TimeSeries {
+				n += len(ts.Samples)
+			}
+			_, err = fmt.Fprintf(w, format, n)
 		}
 		return err
 	}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+func TestCheckStaticToken(t *testing.T) {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")}, nil)
+	require.NoError(t, err)
+	expired, err := jwt.Signed(sig).Claims(jwt.Claims{Expiry: jwt.NewNumericDate(time.Now().Add(-time.Hour))}).CompactSerialize()
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc        string
+		token       string
+		unchecked   bool
+		expectedErr string
+	}{
+		{desc: "no token"},
+		{desc: "opaque token", token: "sfo_0123456789abcdef"},
+		{desc: "expired token", token: expired, expectedErr: "invalid STORMFORGE_TOKEN: token expired at "},
+		{desc: "unchecked expired token", token: expired, unchecked: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cfg := &config.Config{Server: "https://api.example.com/", Token: c.token}
+			ran := false
+			root := &cobra.Command{
+				Use:               "optimize",
+				SilenceUsage:      true,
+				SilenceErrors:     true,
+				PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return checkStaticToken(cmd, cfg) },
+			}
+			cmd := &cobra.Command{
+				Use:  "test",
+				RunE: func(*cobra.Command, []string) error { ran = true; return nil },
+			}
+			if c.unchecked {
+				cmd = uncheckedToken(cmd)
+			}
+			root.AddCommand(cmd)
+			root.SetArgs([]string{"test"})
+
+			err := root.Execute()
+			if c.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.expectedErr)
+				}
+				assert.False(t, ran)
+			} else if assert.NoError(t, err) {
+				assert.True(t, ran)
+			}
+		})
+	}
+}
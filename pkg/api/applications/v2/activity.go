@@ -17,19 +17,42 @@ limitations under the License.
 package v2
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+// JSONFeedVersion is the version of the JSON Feed specification supported by
+// the activity feed.
+const JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+
 type ActivityFeed struct {
-	HomePageURL string         `json:"home_page_url,omitempty"`
-	FeedURL     string         `json:"feed_url,omitempty"`
-	NextURL     string         `json:"next_url,omitempty"`
-	Hubs        []ActivityHub  `json:"hubs,omitempty"`
-	Items       []ActivityItem `json:"items"`
+	Version     string           `json:"version,omitempty"`
+	Title       string           `json:"title,omitempty"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	UserComment string           `json:"user_comment,omitempty"`
+	NextURL     string           `json:"next_url,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Favicon     string           `json:"favicon,omitempty"`
+	Authors     []ActivityAuthor `json:"authors,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Expired     bool             `json:"expired,omitempty"`
+	Hubs        []ActivityHub    `json:"hubs,omitempty"`
+	Items       []ActivityItem   `json:"items"`
+}
+
+type ActivityAuthor struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
 }
 
 type ActivityHub struct {
@@ -38,14 +61,30 @@ type ActivityHub struct {
 }
 
 type ActivityItem struct {
-	ID            string             `json:"id"`
-	URL           string             `json:"url,omitempty"`
-	ExternalURL   string             `json:"external_url,omitempty"`
-	Title         string             `json:"title,omitempty"`
-	DatePublished time.Time          `json:"date_published,omitempty"`
-	DateModified  time.Time          `json:"date_modified,omitempty"`
-	Tags          []string           `json:"tags,omitempty"`
-	StormForge    *ActivityExtension `json:"_stormforge,omitempty"`
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	ExternalURL   string               `json:"external_url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html,omitempty"`
+	ContentText   string               `json:"content_text,omitempty"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	BannerImage   string               `json:"banner_image,omitempty"`
+	DatePublished time.Time            `json:"date_published,omitempty"`
+	DateModified  time.Time            `json:"date_modified,omitempty"`
+	Authors       []ActivityAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Language      string               `json:"language,omitempty"`
+	Attachments   []ActivityAttachment `json:"attachments,omitempty"`
+	StormForge    *ActivityExtension   `json:"_stormforge,omitempty"`
+}
+
+type ActivityAttachment struct {
+	URL               string  `json:"url"`
+	MIMEType          string  `json:"mime_type"`
+	Title             string  `json:"title,omitempty"`
+	SizeInBytes       int64   `json:"size_in_bytes,omitempty"`
+	DurationInSeconds float64 `json:"duration_in_seconds,omitempty"`
 }
 
 func (ai *ActivityItem) HasTag(tag string) bool {
@@ -57,13 +96,99 @@ func (ai *ActivityItem) HasTag(tag string) bool {
 	return false
 }
 
+// IsScan checks if the item requests a scan of a scenario.
+func (ai *ActivityItem) IsScan() bool { return ai.HasTag(TagScan) }
+
+// IsRun checks if the item requests a run of a scenario.
+func (ai *ActivityItem) IsRun() bool { return ai.HasTag(TagRun) }
+
+// IsDeploy checks if the item requests the deployment of an approved recommendation.
+func (ai *ActivityItem) IsDeploy() bool { return ai.HasTag(TagApprove) }
+
+// ClusterName returns the name of the cluster expected to handle the activity,
+// or an empty string if the item does not include a cluster hint.
+func (ai *ActivityItem) ClusterName() ClusterName {
+	if ai.StormForge == nil {
+		return ""
+	}
+	return clusterReferenceName(ai.StormForge.Cluster)
+}
+
+// clusterReferenceName returns the name of a cluster referenced by name or URL.
+func clusterReferenceName(ref string) ClusterName {
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+		return ClusterName(path.Base(u.Path))
+	}
+	return ClusterName(ref)
+}
+
+// ActivityTag identifies the kind of activity represented by a feed item.
+type ActivityTag string
+
+func (t ActivityTag) String() string { return string(t) }
+
+// The known activity tags, the constants are untyped so they may be used as
+// either strings or activity tags.
 const (
-	TagApprove string = "approve"
-	TagRefresh string = "refresh"
+	TagScan    = "scan"
+	TagRun     = "run"
+	TagApprove = "approve"
+	TagRefresh = "refresh"
 )
 
+// ActivityTags returns all the known activity tags.
+func ActivityTags() []ActivityTag {
+	return []ActivityTag{TagScan, TagRun, TagApprove, TagRefresh}
+}
+
+// ParseActivityTag returns the activity tag matching the supplied value.
+func ParseActivityTag(s string) (ActivityTag, error) {
+	var names []string
+	for _, t := range ActivityTags() {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+		names = append(names, string(t))
+	}
+	return "", fmt.Errorf("unknown activity tag %q, must be one of: %s", s, strings.Join(names, ", "))
+}
+
 type ActivityExtension struct {
 	ActivityFailure
+	// A reference (name or URL) to the cluster expected to handle the activity.
+	Cluster string `json:"cluster,omitempty"`
+
+	// The raw extension object, including any fields not known to this client.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON tolerates extension values which do not match the expected
+// structure so a newer (or malformed) extension does not prevent the rest of
+// the feed from being read. The original value is always retained.
+func (ae *ActivityExtension) UnmarshalJSON(b []byte) error {
+	type t ActivityExtension
+	_ = json.Unmarshal(b, (*t)(ae))
+	ae.Raw = append(ae.Raw[:0], b...)
+	return nil
+}
+
+// MarshalJSON merges the known fields into the original extension value (if
+// available) so that unknown fields are preserved.
+func (ae ActivityExtension) MarshalJSON() ([]byte, error) {
+	type t ActivityExtension
+	b, err := json.Marshal(t(ae))
+	if err != nil || len(ae.Raw) == 0 {
+		return b, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(ae.Raw, &fields); err != nil {
+		return b, nil
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
 }
 
 type ActivityFeedQuery struct {
@@ -77,6 +202,122 @@ func (q *ActivityFeedQuery) SetType(t ...string) {
 	url.Values(q.Query).Set("type", strings.Join(t, ","))
 }
 
+// SetApplication limits the feed to the activity of the specified applications,
+// identified by name or URL.
+func (q *ActivityFeedQuery) SetApplication(app ...string) {
+	if q.Query == nil {
+		q.Query = make(map[string][]string)
+	}
+	url.Values(q.Query).Set("application", strings.Join(app, ","))
+}
+
+// SetScenario limits the feed to the activity of the specified scenarios,
+// identified by name or URL.
+func (q *ActivityFeedQuery) SetScenario(scn ...string) {
+	if q.Query == nil {
+		q.Query = make(map[string][]string)
+	}
+	url.Values(q.Query).Set("scenario", strings.Join(scn, ","))
+}
+
+// Matches checks the application, scenario and cluster filters of the query
+// against the supplied item; servers which do not support the filters return
+// the entire feed so the filters must also be applied by the client. Items
+// whose application, scenario or cluster cannot be determined are assumed to match.
+func (q *ActivityFeedQuery) Matches(item *ActivityItem) bool {
+	appName, scnName := activityNames(item.URL)
+	if filter := url.Values(q.Query).Get("application"); filter != "" && appName != "" {
+		if !matchesActivityFilter(filter, func(value string) bool {
+			if strings.Contains(value, "://") {
+				value, _ = activityNames(value)
+			}
+			return value == appName
+		}) {
+			return false
+		}
+	}
+	if filter := url.Values(q.Query).Get("scenario"); filter != "" && scnName != "" {
+		if !matchesActivityFilter(filter, func(value string) bool {
+			var app string
+			switch {
+			case strings.Contains(value, "://"):
+				app, value = activityNames(value)
+			case strings.Contains(value, "/"):
+				// Allow scenarios to be qualified by application name
+				a, s := SplitScenarioName(value)
+				app, value = a.String(), s.String()
+			}
+			return value == scnName && (app == "" || appName == "" || app == appName)
+		}) {
+			return false
+		}
+	}
+	if filter := url.Values(q.Query).Get("cluster"); filter != "" {
+		if clusterName := item.ClusterName(); clusterName != "" {
+			if !matchesActivityFilter(filter, func(value string) bool {
+				return clusterReferenceName(value) == clusterName
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasFilter checks if the query includes application, scenario or cluster filters.
+func (q *ActivityFeedQuery) hasFilter() bool {
+	qq := url.Values(q.Query)
+	return qq.Get("application") != "" || qq.Get("scenario") != "" || qq.Get("cluster") != ""
+}
+
+// matchesActivityFilter checks if any of the comma separated filter values match.
+func matchesActivityFilter(filter string, match func(value string) bool) bool {
+	for _, value := range strings.Split(filter, ",") {
+		if match(strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// activityNames returns the application and scenario names of the resource
+// identified by an activity URL, empty values indicate the name is unknown.
+func activityNames(u string) (appName, scnName string) {
+	uu, err := url.Parse(u)
+	if err != nil {
+		return "", ""
+	}
+
+	segments := strings.Split(strings.Trim(uu.Path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		switch segments[i] {
+		case "applications":
+			appName = segments[i+1]
+		case "scenarios":
+			scnName = segments[i+1]
+		}
+	}
+	return appName, scnName
+}
+
+// SetCluster limits the feed to the activity of the specified clusters,
+// identified by name or URL.
+func (q *ActivityFeedQuery) SetCluster(cluster ...string) {
+	if q.Query == nil {
+		q.Query = make(map[string][]string)
+	}
+	url.Values(q.Query).Set("cluster", strings.Join(cluster, ","))
+}
+
+// SetTimeout requests the server hold the request open for up to the specified
+// duration while waiting for new activity (i.e. long polling).
+func (q *ActivityFeedQuery) SetTimeout(d time.Duration) {
+	if q.Query == nil {
+		q.Query = make(map[string][]string)
+	}
+	url.Values(q.Query).Set("timeout", d.String())
+}
+
 type Activity struct {
 	api.Metadata `json:"-"`
 	Run          *RunActivity     `json:"run,omitempty"`
@@ -105,6 +346,28 @@ type RefreshActivity struct {
 	ActivityFailure
 }
 
+// ActivityResult describes what was done to resolve an activity.
+type ActivityResult struct {
+	Run  *RunActivityResult  `json:"run,omitempty"`
+	Scan *ScanActivityResult `json:"scan,omitempty"`
+}
+
+type RunActivityResult struct {
+	// The time spent running the scenario.
+	Duration api.Duration `json:"duration,omitempty"`
+	// The URL of the experiment created for the run.
+	Experiment string `json:"experiment,omitempty"`
+	ActivityFailure
+}
+
+type ScanActivityResult struct {
+	// The time spent scanning the application.
+	Duration api.Duration `json:"duration,omitempty"`
+	// The revision of the template produced by the scan.
+	TemplateRevision int `json:"template_revision,omitempty"`
+	ActivityFailure
+}
+
 type ActivityPatchRequest struct {
 	Title string `json:"title"`
 	// Data is a JSON-serializable value for internal metadata about the Activity
@@ -136,15 +399,70 @@ func (af *ActivityFeed) SetBaseURL(u string) {
 		return u
 	}
 
+	resAuthors := func(authors []ActivityAuthor) {
+		for i := range authors {
+			authors[i].URL = res(authors[i].URL)
+			authors[i].Avatar = res(authors[i].Avatar)
+		}
+	}
+
 	// Resolve all known URLs on the feed
 	af.HomePageURL = res(af.HomePageURL)
 	af.FeedURL = res(af.FeedURL)
 	af.NextURL = res(af.NextURL)
+	af.Icon = res(af.Icon)
+	af.Favicon = res(af.Favicon)
+	resAuthors(af.Authors)
 	for i := range af.Hubs {
 		af.Hubs[i].URL = res(af.Hubs[i].URL)
 	}
 	for i := range af.Items {
 		af.Items[i].URL = res(af.Items[i].URL)
 		af.Items[i].ExternalURL = res(af.Items[i].ExternalURL)
+		af.Items[i].Image = res(af.Items[i].Image)
+		af.Items[i].BannerImage = res(af.Items[i].BannerImage)
+		resAuthors(af.Items[i].Authors)
+		for j := range af.Items[i].Attachments {
+			af.Items[i].Attachments[j].URL = res(af.Items[i].Attachments[j].URL)
+		}
+	}
+}
+
+// Validate checks the activity feed against the requirements of the JSON Feed
+// specification, returning all of the problems that were found.
+func (af *ActivityFeed) Validate() error {
+	var errs []error
+	if !strings.HasPrefix(af.Version, "https://jsonfeed.org/version/") {
+		errs = append(errs, fmt.Errorf("invalid feed version: %q", af.Version))
+	}
+	if af.Title == "" {
+		errs = append(errs, fmt.Errorf("missing feed title"))
 	}
+	for i := range af.Hubs {
+		if af.Hubs[i].Type == "" || af.Hubs[i].URL == "" {
+			errs = append(errs, fmt.Errorf("hub %d must have a type and URL", i))
+		}
+	}
+
+	ids := make(map[string]struct{}, len(af.Items))
+	for i := range af.Items {
+		item := &af.Items[i]
+		if item.ID == "" {
+			errs = append(errs, fmt.Errorf("item %d is missing an identifier", i))
+		} else if _, ok := ids[item.ID]; ok {
+			errs = append(errs, fmt.Errorf("item %d has a duplicate identifier: %q", i, item.ID))
+		} else {
+			ids[item.ID] = struct{}{}
+		}
+		if item.ContentHTML == "" && item.ContentText == "" {
+			errs = append(errs, fmt.Errorf("item %q must have either HTML or text content", item.ID))
+		}
+		for j := range item.Attachments {
+			if item.Attachments[j].URL == "" || item.Attachments[j].MIMEType == "" {
+				errs = append(errs, fmt.Errorf("attachment %d of item %q must have a URL and MIME type", j, item.ID))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
 }
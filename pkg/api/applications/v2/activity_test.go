@@ -17,9 +17,12 @@ limitations under the License.
 package v2
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
 func TestActivityFeed_SetBaseURL(t *testing.T) {
@@ -109,3 +112,253 @@ func TestActivityFeed_SetBaseURL(t *testing.T) {
 		})
 	}
 }
+
+func TestActivityExtension_JSON(t *testing.T) {
+	cases := []struct {
+		desc     string
+		data     string
+		failure  ActivityFailure
+		expected string
+	}{
+		{
+			desc:     "known fields",
+			data:     `{"failure_reason":"r","failure_message":"m"}`,
+			failure:  ActivityFailure{FailureReason: "r", FailureMessage: "m"},
+			expected: `{"failure_message":"m","failure_reason":"r"}`,
+		},
+		{
+			desc:     "cluster",
+			data:     `{"failure_reason":"r","cluster":"c1"}`,
+			failure:  ActivityFailure{FailureReason: "r"},
+			expected: `{"cluster":"c1","failure_reason":"r"}`,
+		},
+		{
+			desc:     "unknown fields",
+			data:     `{"failure_reason":"r","future":{"a":1}}`,
+			failure:  ActivityFailure{FailureReason: "r"},
+			expected: `{"failure_reason":"r","future":{"a":1}}`,
+		},
+		{
+			desc:     "wrong type",
+			data:     `{"failure_reason":1,"failure_message":"m"}`,
+			failure:  ActivityFailure{FailureMessage: "m"},
+			expected: `{"failure_message":"m","failure_reason":1}`,
+		},
+		{
+			desc:     "not an object",
+			data:     `"unexpected"`,
+			expected: `{}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			item := ActivityItem{}
+			if assert.NoError(t, json.Unmarshal([]byte(`{"id":"1","_stormforge":`+c.data+`}`), &item)) {
+				assert.Equal(t, c.failure, item.StormForge.ActivityFailure)
+				data, err := json.Marshal(item.StormForge)
+				if assert.NoError(t, err) {
+					assert.JSONEq(t, c.expected, string(data))
+				}
+			}
+		})
+	}
+}
+
+func TestActivityFeed_Validate(t *testing.T) {
+	cases := []struct {
+		desc     string
+		feed     ActivityFeed
+		expected []string
+	}{
+		{
+			desc: "valid",
+			feed: ActivityFeed{
+				Version: JSONFeedVersion,
+				Title:   "Activity",
+				Items: []ActivityItem{
+					{ID: "1", ContentText: "a"},
+					{ID: "2", ContentHTML: "<p>b</p>"},
+				},
+			},
+		},
+		{
+			desc: "empty",
+			expected: []string{
+				`invalid feed version: ""`,
+				"missing feed title",
+			},
+		},
+		{
+			desc: "invalid items",
+			feed: ActivityFeed{
+				Version: JSONFeedVersion,
+				Title:   "Activity",
+				Hubs:    []ActivityHub{{Type: "poll"}},
+				Items: []ActivityItem{
+					{ContentText: "a"},
+					{ID: "1", ContentText: "b"},
+					{ID: "1", Attachments: []ActivityAttachment{{URL: "https://example.com/a"}}},
+				},
+			},
+			expected: []string{
+				"hub 0 must have a type and URL",
+				"item 0 is missing an identifier",
+				`item 2 has a duplicate identifier: "1"`,
+				`item "1" must have either HTML or text content`,
+				`attachment 0 of item "1" must have a URL and MIME type`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.feed.Validate()
+			if len(c.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				var actual []string
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					actual = append(actual, e.Error())
+				}
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestActivityResult_MarshalJSON(t *testing.T) {
+	r := ActivityResult{
+		Scan: &ScanActivityResult{
+			Duration:         api.Duration(90 * time.Second),
+			TemplateRevision: 3,
+		},
+	}
+
+	data, err := json.Marshal(&r)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"scan":{"duration":"1m30s","template_revision":3}}`, string(data))
+	}
+}
+
+func TestActivityFeedQuery_Matches(t *testing.T) {
+	const base = "https://api.example.com/v2/applications/"
+	cases := []struct {
+		desc         string
+		applications []string
+		scenarios    []string
+		clusters     []string
+		url          string
+		cluster      string
+		expected     bool
+	}{
+		{
+			desc:     "no filter",
+			url:      base + "app1/scenarios/scn1/activity/1",
+			expected: true,
+		},
+		{
+			desc:         "application name",
+			applications: []string{"app2", "app1"},
+			url:          base + "app1/scenarios/scn1/activity/1",
+			expected:     true,
+		},
+		{
+			desc:         "application mismatch",
+			applications: []string{"app2"},
+			url:          base + "app1/activity/1",
+			expected:     false,
+		},
+		{
+			desc:         "application URL",
+			applications: []string{base + "app1"},
+			url:          base + "app1/activity/1",
+			expected:     true,
+		},
+		{
+			desc:      "scenario name",
+			scenarios: []string{"scn1"},
+			url:       base + "app1/scenarios/scn1/activity/1",
+			expected:  true,
+		},
+		{
+			desc:      "qualified scenario name",
+			scenarios: []string{"app2/scn1"},
+			url:       base + "app1/scenarios/scn1/activity/1",
+			expected:  false,
+		},
+		{
+			desc:      "scenario URL",
+			scenarios: []string{base + "app1/scenarios/scn1"},
+			url:       base + "app1/scenarios/scn1/activity/1",
+			expected:  true,
+		},
+		{
+			desc:      "unknown scenario",
+			scenarios: []string{"scn1"},
+			url:       base + "app1/activity/1",
+			expected:  true,
+		},
+		{
+			desc:     "cluster name",
+			clusters: []string{"c1"},
+			url:      base + "app1/activity/1",
+			cluster:  "https://api.example.com/v2/clusters/c1",
+			expected: true,
+		},
+		{
+			desc:     "cluster mismatch",
+			clusters: []string{"c2"},
+			url:      base + "app1/activity/1",
+			cluster:  "c1",
+			expected: false,
+		},
+		{
+			desc:     "unknown cluster",
+			clusters: []string{"c2"},
+			url:      base + "app1/activity/1",
+			expected: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			q := ActivityFeedQuery{}
+			if len(c.applications) > 0 {
+				q.SetApplication(c.applications...)
+			}
+			if len(c.scenarios) > 0 {
+				q.SetScenario(c.scenarios...)
+			}
+			if len(c.clusters) > 0 {
+				q.SetCluster(c.clusters...)
+			}
+			item := &ActivityItem{URL: c.url}
+			if c.cluster != "" {
+				item.StormForge = &ActivityExtension{Cluster: c.cluster}
+			}
+			assert.Equal(t, c.expected, q.Matches(item))
+		})
+	}
+}
+
+func TestParseActivityTag(t *testing.T) {
+	tag, err := ParseActivityTag("Scan")
+	if assert.NoError(t, err) {
+		assert.Equal(t, ActivityTag(TagScan), tag)
+	}
+
+	_, err = ParseActivityTag("deploy")
+	assert.EqualError(t, err, `unknown activity tag "deploy", must be one of: scan, run, approve, refresh`)
+
+	item := ActivityItem{Tags: []string{"RUN"}}
+	assert.True(t, item.IsRun())
+	assert.False(t, item.IsScan())
+	assert.False(t, item.IsDeploy())
+
+	// The tag constants remain usable as plain strings
+	var approve string = TagApprove
+	assert.False(t, item.HasTag(approve))
+	assert.True(t, item.HasTag(TagRun))
+	assert.True(t, item.HasTag(ActivityTag(TagRun).String()))
+}
@@ -18,6 +18,7 @@ package v2
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
@@ -26,15 +27,23 @@ const (
 	ErrApplicationInvalid     api.ErrorType = "application-invalid"
 	ErrApplicationNotFound    api.ErrorType = "application-not-found"
 	ErrApplicationExists      api.ErrorType = "application-exists"
+	ErrApplicationConflict    api.ErrorType = "application-conflict"
 	ErrScenarioInvalid        api.ErrorType = "scenario-invalid"
 	ErrScenarioNotFound       api.ErrorType = "scenario-not-found"
 	ErrScenarioExists         api.ErrorType = "scenario-exists"
+	ErrScenarioConflict       api.ErrorType = "scenario-conflict"
 	ErrScanInvalid            api.ErrorType = "scan-invalid"
 	ErrActivityInvalid        api.ErrorType = "activity-invalid"
 	ErrActivityRateLimited    api.ErrorType = "activity-rate-limited"
 	ErrRecommendationInvalid  api.ErrorType = "recommendation-invalid"
 	ErrRecommendationNotFound api.ErrorType = "recommendation-not-found"
 	ErrClusterNotFound        api.ErrorType = "cluster-not-found"
+	ErrClusterConflict        api.ErrorType = "cluster-conflict"
+
+	ErrTemplateRevisionsNotFound api.ErrorType = "template-revisions-not-found"
+	ErrTemplateConflict          api.ErrorType = "template-conflict"
+	ErrScenarioTypeUnsupported   api.ErrorType = "scenario-type-unsupported"
+	ErrTestCasesNotFound         api.ErrorType = "test-cases-not-found"
 )
 
 // Subscriber describes a strategy for subscribing to feed notifications.
@@ -53,13 +62,16 @@ type API interface {
 	ListApplicationsByPage(ctx context.Context, u string) (ApplicationList, error)
 	// CreateApplication creates a new application.
 	CreateApplication(ctx context.Context, app Application) (api.Metadata, error)
-	// CreateApplicationByName creates a new application.
+	// CreateApplicationByName creates a new application, failing with
+	// ErrApplicationExists if the name is already in use.
 	CreateApplicationByName(ctx context.Context, n ApplicationName, app Application) (api.Metadata, error)
 	// GetApplication retrieves an application.
 	GetApplication(ctx context.Context, u string) (Application, error)
 	// GetApplicationByName retrieves an application.
 	GetApplicationByName(ctx context.Context, n ApplicationName) (Application, error)
-	// UpdateApplication updates an application.
+	// UpdateApplication updates an application. If the application includes
+	// an ETag, the update fails with ErrApplicationConflict if the application
+	// was changed in the meantime.
 	UpdateApplication(ctx context.Context, u string, app Application) (api.Metadata, error)
 	// UpdateApplicationByName updates or creates an application.
 	UpdateApplicationByName(ctx context.Context, n ApplicationName, app Application) (api.Metadata, error)
@@ -72,7 +84,8 @@ type API interface {
 	// CreateScenario creates a scenario.
 	// Deprecated: scenarios should no longer be used.
 	CreateScenario(ctx context.Context, u string, scn Scenario) (api.Metadata, error)
-	// CreateScenarioByName creates a scenario.
+	// CreateScenarioByName creates a scenario, failing with ErrScenarioExists
+	// if the name is already in use.
 	// Deprecated: scenarios should no longer be used.
 	CreateScenarioByName(ctx context.Context, u string, n ScenarioName, scn Scenario) (Scenario, error)
 	// GetScenario retrieves a scenario.
@@ -90,16 +103,23 @@ type API interface {
 	// DeleteScenario deletes a scenario.
 	// Deprecated: scenarios should no longer be used.
 	DeleteScenario(ctx context.Context, u string) error
-	// PatchScenario updates attributes on a scenario.
+	// PatchScenario updates attributes on a scenario. If the scenario includes
+	// an ETag, the update fails with ErrScenarioConflict if the scenario was
+	// changed in the meantime.
 	// Deprecated: scenarios should no longer be used.
 	PatchScenario(ctx context.Context, u string, scn Scenario) error
 
 	// GetTemplate gets the application scenario template.
 	GetTemplate(ctx context.Context, u string) (Template, error)
-	// UpdateTemplate records or updates scenario template.
+	// UpdateTemplate records or updates scenario template. If the template
+	// was obtained using GetTemplate, the update fails with ErrTemplateConflict
+	// if the template was changed in the meantime.
 	UpdateTemplate(ctx context.Context, u string, s Template) error
-	// PatchTemplate updates a partial scenario template.
+	// PatchTemplate updates a partial scenario template, the same conflict
+	// detection as UpdateTemplate applies.
 	PatchTemplate(ctx context.Context, u string, s Template) error
+	// ListTemplateRevisions gets the previous revisions of a scenario template.
+	ListTemplateRevisions(ctx context.Context, u string) (TemplateRevisionList, error)
 
 	// ListActivity gets activity feed for an application.
 	ListActivity(ctx context.Context, u string, q ActivityFeedQuery) (ActivityFeed, error)
@@ -107,6 +127,8 @@ type API interface {
 	CreateActivity(ctx context.Context, u string, a Activity) error
 	// DeleteActivity resolves application activity.
 	DeleteActivity(ctx context.Context, u string) error
+	// ResolveActivity resolves application activity, reporting the outcome.
+	ResolveActivity(ctx context.Context, u string, r ActivityResult) error
 	// PatchApplicationActivity updates application activity.
 	PatchApplicationActivity(ctx context.Context, u string, a ActivityPatchRequest) error
 
@@ -121,6 +143,9 @@ type API interface {
 	ListRecommendations(ctx context.Context, u string) (RecommendationList, error)
 	// PatchRecommendations updates recommendation configuration.
 	PatchRecommendations(ctx context.Context, u string, details RecommendationList) error
+	// MergePatchRecommendations updates recommendation configuration using a
+	// JSON merge patch (see RecommendationsMergePatch).
+	MergePatchRecommendations(ctx context.Context, u string, patch json.RawMessage) error
 
 	// GetCluster retrieves a cluster.
 	GetCluster(ctx context.Context, u string) (Cluster, error)
@@ -128,8 +153,15 @@ type API interface {
 	GetClusterByName(ctx context.Context, n ClusterName) (Cluster, error)
 	// ListClusters lists clusters.
 	ListClusters(ctx context.Context, q ClusterListQuery) (ClusterList, error)
-	// PatchCluster updates a cluster title.
+	// PatchCluster updates a cluster title. If the title includes an ETag,
+	// the update fails with ErrClusterConflict if the cluster was changed in
+	// the meantime.
 	PatchCluster(ctx context.Context, u string, c ClusterTitle) error
 	// DeleteCluster deletes a cluster.
 	DeleteCluster(ctx context.Context, u string) error
+
+	// ListTestCases lists the StormForge Performance test cases available to
+	// scenarios. Fails with ErrTestCasesNotFound if the server does not
+	// expose test cases.
+	ListTestCases(ctx context.Context, q TestCaseListQuery) (TestCaseList, error)
 }
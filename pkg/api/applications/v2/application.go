@@ -17,11 +17,73 @@ limitations under the License.
 package v2
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+// CreateAndGetApplication creates an application, using the name if it is not
+// empty, and returns the representation of the created application. If the
+// created application cannot be fetched, the supplied application is returned
+// with the metadata of the create response.
+func CreateAndGetApplication(ctx context.Context, appAPI API, n ApplicationName, app Application) (Application, error) {
+	var md api.Metadata
+	var selfURL string
+	var err error
+	if n != "" {
+		md, err = appAPI.CreateApplicationByName(ctx, n, app)
+		selfURL = md.Link(api.RelationSelf)
+	} else {
+		md, err = appAPI.CreateApplication(ctx, app)
+		selfURL = md.Location()
+	}
+	if err != nil {
+		return app, err
+	}
+
+	if selfURL != "" {
+		if result, err := appAPI.GetApplication(ctx, selfURL); err == nil {
+			result.Metadata = keepLocation(result.Metadata, md)
+			return result, nil
+		}
+	}
+
+	app.Metadata = md
+	if app.Name == "" {
+		app.Name = n
+	}
+	return app, nil
+}
+
+// UpdateApplicationIfMatch updates an application only if the current entity
+// tag of the application matches the supplied value, failing with an
+// ErrApplicationConflict error if the application was changed in the meantime.
+func UpdateApplicationIfMatch(ctx context.Context, appAPI API, u string, app Application, etag string) (api.Metadata, error) {
+	if etag == "" {
+		return nil, fmt.Errorf("missing entity tag for conditional update of application %q", app.Name)
+	}
+
+	app.Metadata = app.Metadata.WithETag(etag)
+	return appAPI.UpdateApplication(ctx, u, app)
+}
+
+// keepLocation copies the location of a create response onto the metadata of
+// the representation fetched after the create.
+func keepLocation(md, created api.Metadata) api.Metadata {
+	loc := created.Location()
+	if loc == "" || md.Location() != "" {
+		return md
+	}
+	if md == nil {
+		md = api.Metadata{}
+	}
+	http.Header(md).Set("Location", loc)
+	return md
+}
+
 type Application struct {
 	api.Metadata `json:"-"`
 	Name         ApplicationName `json:"name,omitempty"`
@@ -17,11 +17,15 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
@@ -80,3 +84,143 @@ func TestApplicationList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "Test2", l.Applications[1].Title())
 	}
 }
+
+func TestSortApplications(t *testing.T) {
+	t1, t2 := time.Unix(1000, 0), time.Unix(2000, 0)
+	items := []ApplicationItem{
+		{Application: Application{Name: "b", CreatedAt: &t2}},
+		{Application: Application{Name: "c"}},
+		{Application: Application{Name: "a", CreatedAt: &t1}},
+	}
+
+	names := func() (result []ApplicationName) {
+		for _, item := range items {
+			result = append(result, item.Name)
+		}
+		return
+	}
+
+	sortApplications(items, "name", api.SortAscending)
+	assert.Equal(t, []ApplicationName{"a", "b", "c"}, names())
+
+	sortApplications(items, "createdAt", api.SortDescending)
+	assert.Equal(t, []ApplicationName{"b", "a", "c"}, names())
+
+	sortApplications(items, "unknown", api.SortAscending)
+	assert.Equal(t, []ApplicationName{"b", "a", "c"}, names())
+}
+
+func TestCreateAndGetApplication(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "/v2/applications/generated")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			if r.URL.Path != "/v2/applications/generated" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"generated","title":"Generated"}`))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	app, err := CreateAndGetApplication(context.Background(), NewAPI(client), "", Application{DisplayName: "Generated"})
+	require.NoError(t, err)
+	assert.Equal(t, ApplicationName("generated"), app.Name)
+	assert.Equal(t, "Generated", app.DisplayName)
+	assert.Equal(t, srv.URL+"/v2/applications/generated", app.Location())
+}
+
+func TestUpdateApplication_Conflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if match := r.Header.Get("If-Match"); match != "" && match != `"2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+	ctx := context.Background()
+
+	app := Application{Metadata: api.Metadata{}}
+	_, err = appAPI.UpdateApplication(ctx, srv.URL+"/v2/applications/test", app)
+	assert.NoError(t, err, "no precondition")
+
+	http.Header(app.Metadata).Set("ETag", `"2"`)
+	_, err = appAPI.UpdateApplication(ctx, srv.URL+"/v2/applications/test", app)
+	assert.NoError(t, err, "matching precondition")
+
+	http.Header(app.Metadata).Set("ETag", `"1"`)
+	_, err = appAPI.UpdateApplication(ctx, srv.URL+"/v2/applications/test", app)
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrApplicationConflict, apiErr.Type)
+	}
+}
+
+func TestCreateApplicationByName_Exists(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPut || r.Header.Get("If-None-Match") != "*" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusPreconditionFailed)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	_, err = NewAPI(client).CreateApplicationByName(context.Background(), "test", Application{})
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrApplicationExists, apiErr.Type)
+		assert.Equal(t, srv.URL+"/v2/applications/test", apiErr.Location)
+	}
+	assert.Equal(t, 1, requests)
+}
+
+func TestUpdateApplicationIfMatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != `"2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+	ctx := context.Background()
+	u := srv.URL + "/v2/applications/test"
+
+	app := Application{Metadata: api.Metadata{"Etag": []string{`"1"`}}}
+	_, err = UpdateApplicationIfMatch(ctx, appAPI, u, app, `"2"`)
+	assert.NoError(t, err)
+	assert.Equal(t, `"1"`, app.ETag(), "metadata should not be modified")
+
+	_, err = UpdateApplicationIfMatch(ctx, appAPI, u, app, `"1"`)
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrApplicationConflict, apiErr.Type)
+	}
+
+	_, err = UpdateApplicationIfMatch(ctx, appAPI, u, app, "")
+	assert.Error(t, err)
+}
@@ -76,5 +76,7 @@ type ClusterList struct {
 }
 
 type ClusterTitle struct {
-	Title string `json:"title"`
+	// The cluster metadata, used to detect conflicting updates.
+	api.Metadata `json:"-"`
+	Title        string `json:"title"`
 }
@@ -23,30 +23,101 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
 func NewAPI(client api.Client) API {
-	endpoint := os.Getenv("STORMFORGE_APPLICATIONS_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "v2/applications/"
-	} else {
-		endpoint = strings.TrimRight(endpoint, "/") + "/"
+	return NewAPIWithEndpoints(client, Endpoints{})
+}
+
+// Endpoints overrides the default locations of the application service
+// resources, empty values are discovered from the links advertised by the
+// applications endpoint.
+type Endpoints struct {
+	// The applications endpoint, defaults to "v2/applications/".
+	Applications string
+	// The clusters endpoint.
+	Clusters string
+	// The activity feed URL.
+	Activity string
+	// The performance test cases endpoint.
+	TestCases string
+}
+
+// defaultResourceEndpoints are the locations, relative to the applications
+// endpoint, of the resources whose link is not advertised by the applications
+// endpoint.
+var defaultResourceEndpoints = map[string]string{
+	api.RelationClusters:  "../clusters",
+	api.RelationTestCases: "../performance/test-cases",
+}
+
+// NewAPIWithEndpoints returns a new API implementation with alternate endpoints.
+func NewAPIWithEndpoints(client api.Client, endpoints Endpoints) API {
+	h := &httpAPI{client: client, endpoint: "v2/applications/", resources: make(map[string]string)}
+	if endpoints.Applications != "" {
+		h.endpoint = strings.TrimRight(endpoints.Applications, "/") + "/"
+	}
+	if endpoints.Clusters != "" {
+		h.resources[api.RelationClusters] = strings.TrimRight(endpoints.Clusters, "/")
+	}
+	if endpoints.Activity != "" {
+		h.resources[api.RelationActivity] = endpoints.Activity
+	}
+	if endpoints.TestCases != "" {
+		h.resources[api.RelationTestCases] = strings.TrimRight(endpoints.TestCases, "/")
 	}
-	return &httpAPI{client: client, endpoint: endpoint}
+	return h
 }
 
 type httpAPI struct {
 	client   api.Client
 	endpoint string
+
+	mu        sync.Mutex
+	resources map[string]string
 }
 
 var _ API = &httpAPI{}
 
+// resourceEndpoint returns the URL of the resource identified by the supplied
+// link relation. Configured endpoints take precedence over the links advertised
+// by the applications endpoint, which take precedence over the default location.
+func (h *httpAPI) resourceEndpoint(ctx context.Context, rel string) (*url.URL, error) {
+	h.mu.Lock()
+	u, ok := h.resources[rel]
+	h.mu.Unlock()
+
+	if !ok {
+		md, err := h.CheckEndpoint(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		u = md.Link(rel)
+		if u == "" && rel == api.RelationActivity {
+			// TODO Also filter on `type=application/feed+json`
+			u = md.Link(api.RelationAlternate)
+		}
+		if d, ok := defaultResourceEndpoints[rel]; u == "" && ok {
+			u = h.client.URL(h.endpoint).ResolveReference(&url.URL{Path: d}).String()
+		}
+
+		h.mu.Lock()
+		h.resources[rel] = u
+		h.mu.Unlock()
+	}
+
+	if u == "" {
+		return nil, fmt.Errorf("missing %s URL", strings.TrimPrefix(rel, "https://stormforge.io/rel/"))
+	}
+	return h.client.URL(u), nil
+}
+
 func (h *httpAPI) CheckEndpoint(ctx context.Context) (api.Metadata, error) {
 	result := api.Metadata{}
 
@@ -71,7 +142,7 @@ func (h *httpAPI) CheckEndpoint(ctx context.Context) (api.Metadata, error) {
 
 func (h *httpAPI) ListApplications(ctx context.Context, q ApplicationListQuery) (ApplicationList, error) {
 	u := h.client.URL(h.endpoint)
-	u.RawQuery = url.Values(q.IndexQuery).Encode()
+	u.RawQuery = url.Values(api.ApplyDefaultLimit(ctx, q.IndexQuery)).Encode()
 
 	return h.ListApplicationsByPage(ctx, u.String())
 }
@@ -94,6 +165,8 @@ func (h *httpAPI) ListApplicationsByPage(ctx context.Context, u string) (Applica
 		api.UnmarshalMetadata(resp, &result.Metadata)
 		err = json.Unmarshal(body, &result)
 		return result, err
+	case http.StatusNotFound, http.StatusGone:
+		return result, api.NewError(api.ErrPageExpired, resp, body)
 	default:
 		return result, api.NewUnexpectedError(resp, body)
 	}
@@ -138,12 +211,6 @@ func (h *httpAPI) CreateApplicationByName(ctx context.Context, n ApplicationName
 
 	req.Header.Set("If-None-Match", "*")
 
-	// TODO Fake support for conditional PUT
-	if _, err := h.GetApplication(ctx, u.String()); err == nil {
-		msg := fmt.Sprintf("application %q already exists", n)
-		return nil, &api.Error{Type: ErrApplicationExists, Message: msg, Location: u.String()}
-	}
-
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
 		return nil, err
@@ -209,6 +276,9 @@ func (h *httpAPI) UpdateApplication(ctx context.Context, u string, app Applicati
 	if err != nil {
 		return nil, err
 	}
+	if etag := app.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -221,6 +291,8 @@ func (h *httpAPI) UpdateApplication(ctx context.Context, u string, app Applicati
 		return result, nil
 	case http.StatusBadRequest:
 		return nil, api.NewError(ErrApplicationInvalid, resp, body)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return nil, api.NewError(ErrApplicationConflict, resp, body)
 	case http.StatusUnprocessableEntity:
 		return nil, api.NewError(ErrApplicationInvalid, resp, body)
 	default:
@@ -256,7 +328,7 @@ func (h *httpAPI) DeleteApplication(ctx context.Context, u string) error {
 }
 
 func (h *httpAPI) ListScenarios(ctx context.Context, u string, q ScenarioListQuery) (ScenarioList, error) {
-	u = applyQuery(u, url.Values(q.IndexQuery))
+	u = applyQuery(u, url.Values(api.ApplyDefaultLimit(ctx, q.IndexQuery)))
 	result := ScenarioList{}
 
 	req, err := http.NewRequest(http.MethodGet, u, nil)
@@ -319,12 +391,6 @@ func (h *httpAPI) CreateScenarioByName(ctx context.Context, u string, n Scenario
 
 	req.Header.Set("If-None-Match", "*")
 
-	// TODO Fake support for conditional PUT
-	if _, err := h.GetScenario(ctx, uu.String()); err == nil {
-		msg := fmt.Sprintf("scenario %q already exists", n)
-		return result, &api.Error{Type: ErrScenarioExists, Message: msg, Location: uu.String()}
-	}
-
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
 		return result, err
@@ -440,6 +506,9 @@ func (h *httpAPI) PatchScenario(ctx context.Context, u string, scn Scenario) err
 	if err != nil {
 		return err
 	}
+	if etag := scn.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -451,6 +520,8 @@ func (h *httpAPI) PatchScenario(ctx context.Context, u string, scn Scenario) err
 		return nil
 	case http.StatusBadRequest:
 		return api.NewError(ErrScenarioInvalid, resp, body)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return api.NewError(ErrScenarioConflict, resp, body)
 	case http.StatusUnprocessableEntity:
 		return api.NewError(ErrScenarioInvalid, resp, body)
 	default:
@@ -473,6 +544,7 @@ func (h *httpAPI) GetTemplate(ctx context.Context, u string) (Template, error) {
 
 	switch resp.StatusCode {
 	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &result.Metadata)
 		err = json.Unmarshal(body, &result)
 		return result, err
 	default:
@@ -485,6 +557,9 @@ func (h *httpAPI) UpdateTemplate(ctx context.Context, u string, t Template) erro
 	if err != nil {
 		return err
 	}
+	if etag := t.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -496,6 +571,8 @@ func (h *httpAPI) UpdateTemplate(ctx context.Context, u string, t Template) erro
 		return nil
 	case http.StatusBadRequest:
 		return api.NewError(ErrScanInvalid, resp, body)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return api.NewError(ErrTemplateConflict, resp, body)
 	case http.StatusUnprocessableEntity:
 		return api.NewError(ErrScanInvalid, resp, body)
 	default:
@@ -508,6 +585,9 @@ func (h *httpAPI) PatchTemplate(ctx context.Context, u string, t Template) error
 	if err != nil {
 		return err
 	}
+	if etag := t.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -519,6 +599,8 @@ func (h *httpAPI) PatchTemplate(ctx context.Context, u string, t Template) error
 		return nil
 	case http.StatusBadRequest:
 		return api.NewError(ErrScanInvalid, resp, body)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return api.NewError(ErrTemplateConflict, resp, body)
 	case http.StatusUnprocessableEntity:
 		return api.NewError(ErrScanInvalid, resp, body)
 	default:
@@ -526,6 +608,31 @@ func (h *httpAPI) PatchTemplate(ctx context.Context, u string, t Template) error
 	}
 }
 
+func (h *httpAPI) ListTemplateRevisions(ctx context.Context, u string) (TemplateRevisionList, error) {
+	result := TemplateRevisionList{}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(u, "/")+"/revisions", nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		err = json.Unmarshal(body, &result)
+		return result, err
+	case http.StatusNotFound:
+		return result, api.NewError(ErrTemplateRevisionsNotFound, resp, body)
+	default:
+		return result, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) ListActivity(ctx context.Context, u string, q ActivityFeedQuery) (ActivityFeed, error) {
 	u = applyQuery(u, q.Query)
 	result := ActivityFeed{}
@@ -544,12 +651,26 @@ func (h *httpAPI) ListActivity(ctx context.Context, u string, q ActivityFeedQuer
 	case http.StatusOK:
 		err = json.Unmarshal(body, &result)
 		result.SetBaseURL(u)
+		if q.hasFilter() {
+			result.Items = filterActivity(result.Items, &q)
+		}
 		return result, err
 	default:
 		return result, api.NewUnexpectedError(resp, body)
 	}
 }
 
+// filterActivity removes the items which do not match the query filters.
+func filterActivity(items []ActivityItem, q *ActivityFeedQuery) []ActivityItem {
+	result := items[:0]
+	for i := range items {
+		if q.Matches(&items[i]) {
+			result = append(result, items[i])
+		}
+	}
+	return result
+}
+
 func (h *httpAPI) CreateActivity(ctx context.Context, u string, a Activity) error {
 	req, err := httpNewJSONRequest(http.MethodPost, u, a)
 	if err != nil {
@@ -587,6 +708,44 @@ func (h *httpAPI) DeleteActivity(ctx context.Context, u string) error {
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusNoContent:
 		return nil
+	case http.StatusAccepted:
+		return h.waitForActivity(ctx, resp)
+	default:
+		return api.NewUnexpectedError(resp, body)
+	}
+}
+
+// waitForActivity waits for the asynchronous acknowledgement of an activity to complete.
+func (h *httpAPI) waitForActivity(ctx context.Context, resp *http.Response) error {
+	md := api.Metadata{}
+	api.UnmarshalMetadata(resp, &md)
+	if md.Location() == "" {
+		return nil
+	}
+	_, err := api.WaitForOperation(ctx, h.client, md.Location(), api.OperationOptions{})
+	return err
+}
+
+func (h *httpAPI) ResolveActivity(ctx context.Context, u string, r ActivityResult) error {
+	req, err := httpNewJSONRequest(http.MethodDelete, u, r)
+	if err != nil {
+		return err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusAccepted:
+		return h.waitForActivity(ctx, resp)
+	case http.StatusBadRequest:
+		return api.NewError(ErrActivityInvalid, resp, body)
+	case http.StatusUnprocessableEntity:
+		return api.NewError(ErrActivityInvalid, resp, body)
 	default:
 		return api.NewUnexpectedError(resp, body)
 	}
@@ -612,29 +771,23 @@ func (h *httpAPI) PatchApplicationActivity(ctx context.Context, u string, a Acti
 }
 
 func (h *httpAPI) SubscribeActivity(ctx context.Context, q ActivityFeedQuery) (Subscriber, error) {
-	md, err := h.CheckEndpoint(ctx)
+	u, err := h.resourceEndpoint(ctx, api.RelationActivity)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO Also filter on `type=application/feed+json`
-	u := md.Link(api.RelationAlternate)
-	if u == "" {
-		return nil, fmt.Errorf("missing activity feed URL")
-	}
-
-	feed, err := h.ListActivity(ctx, u, q)
+	feed, err := h.ListActivity(ctx, u.String(), q)
 	if err != nil {
 		return nil, err
 	}
 
-	return newSubscriber(h, feed), nil
+	return newSubscriber(h, feed, q), nil
 }
 
 func (h *httpAPI) CreateRecommendation(ctx context.Context, u string) (api.Metadata, error) {
 	result := api.Metadata{}
 
-	req, err := httpNewJSONRequest(http.MethodPost, h.client.URL(h.endpoint).String(), nil)
+	req, err := httpNewJSONRequest(http.MethodPost, u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -648,6 +801,13 @@ func (h *httpAPI) CreateRecommendation(ctx context.Context, u string) (api.Metad
 	case http.StatusOK, http.StatusCreated:
 		api.UnmarshalMetadata(resp, &result)
 		return result, nil
+	case http.StatusAccepted:
+		// The recommendation is created asynchronously
+		api.UnmarshalMetadata(resp, &result)
+		if result.Location() == "" {
+			return result, nil
+		}
+		return api.WaitForOperation(ctx, h.client, result.Location(), api.OperationOptions{})
 	case http.StatusBadRequest:
 		return nil, api.NewError(ErrApplicationInvalid, resp, body)
 	case http.StatusUnprocessableEntity:
@@ -729,10 +889,35 @@ func (h *httpAPI) PatchRecommendations(ctx context.Context, u string, details Re
 	}
 }
 
+func (h *httpAPI) MergePatchRecommendations(ctx context.Context, u string, patch json.RawMessage) error {
+	req, err := http.NewRequest(http.MethodPatch, u, bytes.NewReader(patch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return api.NewError(ErrRecommendationInvalid, resp, body)
+	default:
+		return api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) GetClusterByName(ctx context.Context, n ClusterName) (Cluster, error) {
-	u := h.client.URL(h.endpoint)
-	// TODO This is less then ideal
-	u.Path = path.Join(u.Path, "..", "clusters", n.String())
+	u, err := h.resourceEndpoint(ctx, api.RelationClusters)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	u.Path = path.Join(u.Path, n.String())
 	result, err := h.GetCluster(ctx, u.String())
 
 	// Improve the "not found" error message using the name
@@ -770,9 +955,12 @@ func (h *httpAPI) GetCluster(ctx context.Context, u string) (Cluster, error) {
 }
 
 func (h *httpAPI) ListClusters(ctx context.Context, q ClusterListQuery) (ClusterList, error) {
-	// TODO This is less then ideal
-	u := h.client.URL(h.endpoint + "../clusters")
-	u.RawQuery = url.Values(q.IndexQuery).Encode()
+	u, err := h.resourceEndpoint(ctx, api.RelationClusters)
+	if err != nil {
+		return ClusterList{}, err
+	}
+
+	u.RawQuery = url.Values(api.ApplyDefaultLimit(ctx, q.IndexQuery)).Encode()
 
 	result := ClusterList{}
 
@@ -801,6 +989,9 @@ func (h *httpAPI) PatchCluster(ctx context.Context, u string, c ClusterTitle) er
 	if err != nil {
 		return err
 	}
+	if etag := c.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -810,6 +1001,8 @@ func (h *httpAPI) PatchCluster(ctx context.Context, u string, c ClusterTitle) er
 	switch resp.StatusCode {
 	case http.StatusOK:
 		return nil
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return api.NewError(ErrClusterConflict, resp, body)
 	default:
 		return api.NewUnexpectedError(resp, body)
 	}
@@ -836,6 +1029,38 @@ func (h *httpAPI) DeleteCluster(ctx context.Context, u string) error {
 	}
 }
 
+func (h *httpAPI) ListTestCases(ctx context.Context, q TestCaseListQuery) (TestCaseList, error) {
+	u, err := h.resourceEndpoint(ctx, api.RelationTestCases)
+	if err != nil {
+		return TestCaseList{}, err
+	}
+
+	u.RawQuery = url.Values(api.ApplyDefaultLimit(ctx, q.IndexQuery)).Encode()
+
+	result := TestCaseList{}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		err = json.Unmarshal(body, &result)
+		return result, err
+	case http.StatusNotFound:
+		return result, api.NewError(ErrTestCasesNotFound, resp, body)
+	default:
+		return result, api.NewUnexpectedError(resp, body)
+	}
+}
+
 // httpNewJSONRequest returns a new HTTP request with a JSON payload.
 func httpNewJSONRequest(method, u string, body interface{}) (*http.Request, error) {
 	b, err := json.Marshal(body)
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestResourceEndpoint(t *testing.T) {
+	var advertise bool
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if advertise && r.Method == http.MethodHead {
+			w.Header().Add("Link", `</advertised/clusters>; rel="https://stormforge.io/rel/clusters"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cases := []struct {
+		desc      string
+		advertise bool
+		endpoints Endpoints
+		expected  []string
+	}{
+		{
+			desc:     "default",
+			expected: []string{"HEAD /v2/applications/", "GET /v2/clusters", "GET /v2/clusters"},
+		},
+		{
+			desc:      "relative to applications",
+			endpoints: Endpoints{Applications: srv.URL + "/test/v2/applications"},
+			expected:  []string{"HEAD /test/v2/applications/", "GET /test/v2/clusters", "GET /test/v2/clusters"},
+		},
+		{
+			desc:      "advertised",
+			advertise: true,
+			endpoints: Endpoints{Applications: srv.URL + "/test/v2/applications"},
+			expected:  []string{"HEAD /test/v2/applications/", "GET /advertised/clusters", "GET /advertised/clusters"},
+		},
+		{
+			desc:      "configured",
+			advertise: true,
+			endpoints: Endpoints{Clusters: srv.URL + "/other/clusters/"},
+			expected:  []string{"GET /other/clusters", "GET /other/clusters"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			advertise, requests = c.advertise, nil
+			appAPI := NewAPIWithEndpoints(client, c.endpoints)
+
+			// The endpoint is only discovered once
+			for i := 0; i < 2; i++ {
+				_, err := appAPI.ListClusters(ctx, ClusterListQuery{})
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, c.expected, requests)
+		})
+	}
+}
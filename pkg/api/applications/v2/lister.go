@@ -23,56 +23,169 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+// maxPageRestarts is the number of times a list is restarted from the first
+// page when a "next" link expires mid-iteration.
+const maxPageRestarts = 3
+
 // Lister is a helper to individually visit all items in a list (even across page boundaries).
 type Lister struct {
 	// API is the Application API used to fetch objects.
 	API API
 	// BatchSize overrides the default batch size for fetching lists.
 	BatchSize int
+	// RateLimitThreshold is the remaining request quota below which the lister
+	// slows down to avoid exceeding the rate limit. Zero uses the default
+	// threshold, a negative value disables throttling.
+	RateLimitThreshold int
+	// ContinueOnError causes the ForEachNamed* functions to process all names
+	// before returning the errors as an api.MultiError.
+	ContinueOnError bool
+	// IgnoreNotFound causes the ForEachNamed* functions to skip names which do
+	// not exist, as if the ignoreNotFound argument were always true.
+	IgnoreNotFound bool
+	// Concurrency is the number of pages ForEachApplication may fetch ahead of
+	// the page being processed by the callback. Items are still visited in
+	// order. Zero fetches each page only after the previous one is processed.
+	Concurrency int
+}
+
+// failed records the error for the named item, returning it if the iteration should stop.
+func (l *Lister) failed(errs *api.MultiError, name string, err error) error {
+	if !l.ContinueOnError {
+		return err
+	}
+	errs.Add(name, err)
+	return nil
 }
 
 // ForEachApplication iterates over all the applications matching the supplied query.
+// If the query specifies an ordering, the applications are buffered and sorted
+// on the client in case the server does not support ordering.
 func (l *Lister) ForEachApplication(ctx context.Context, q ApplicationListQuery, f func(*ApplicationItem) error) error {
+	field, direction := q.OrderBy()
+	if field == "" {
+		return l.forEachApplication(ctx, q, f)
+	}
+
+	var items []ApplicationItem
+	if err := l.forEachApplication(ctx, q, func(item *ApplicationItem) error {
+		items = append(items, *item)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sortApplications(items, field, direction)
+	for i := range items {
+		if err := f(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Lister) forEachApplication(ctx context.Context, q ApplicationListQuery, f func(*ApplicationItem) error) error {
 	// Only fetch a single page if an offset was supplied
 	onePage := url.Values(q.IndexQuery).Get(api.ParamOffset) != ""
 
-	// Define a helper to iteratively (NOT recursively) visit applications
-	forEach := func(lst ApplicationList, err error) (string, error) {
-		if err != nil {
-			return "", err
+	// Overwrite the limit
+	if l.BatchSize > 0 {
+		q.SetLimit(l.BatchSize)
+	}
+
+	// Define a helper to iteratively (NOT recursively) fetch pages, starting with the first page
+	fetch := func(ctx context.Context, visit func(ApplicationList) error) error {
+		lst, err := l.API.ListApplications(ctx, q)
+		for restarts := 0; err == nil; {
+			if err := visit(lst); err != nil {
+				return err
+			}
+
+			next := lst.Link(api.RelationNext)
+			if next == "" || onePage {
+				return nil
+			}
+			if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+				return err
+			}
+
+			lst, err = l.API.ListApplicationsByPage(ctx, next)
+
+			// If the next page expired, start over skipping the applications we have already seen
+			if api.IsPageExpired(err) && restarts < maxPageRestarts {
+				restarts++
+				lst, err = l.API.ListApplications(ctx, q)
+			}
 		}
+		return err
+	}
+
+	// Track the visited applications so the list can be resumed after a restart
+	seen := make(map[ApplicationName]struct{})
 
+	// Define a helper to visit the applications on a single page
+	visit := func(lst ApplicationList) error {
 		for i := range lst.Applications {
+			if name := lst.Applications[i].Name; name != "" {
+				if _, ok := seen[name]; ok {
+					continue
+				}
+				seen[name] = struct{}{}
+			}
 			if err := f(&lst.Applications[i]); err != nil {
-				return "", err
+				return err
 			}
 			if err := ctx.Err(); err != nil {
-				return "", err
+				return err
 			}
 		}
-
-		return lst.Link(api.RelationNext), nil
+		return nil
 	}
 
-	// Overwrite the limit
-	if l.BatchSize > 0 {
-		q.SetLimit(l.BatchSize)
+	if l.Concurrency > 0 {
+		return prefetchApplications(ctx, l.Concurrency, fetch, visit)
 	}
+	return fetch(ctx, visit)
+}
 
-	// Iterate over all applications, starting with first page
-	u, err := forEach(l.API.ListApplications(ctx, q))
-	for u != "" && err == nil && !onePage {
-		u, err = forEach(l.API.ListApplicationsByPage(ctx, u))
+// prefetchApplications runs the fetch function in the background, allowing up
+// to n pages to be fetched ahead of the page being visited.
+func prefetchApplications(ctx context.Context, n int, fetch func(context.Context, func(ApplicationList) error) error, visit func(ApplicationList) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make(chan ApplicationList, n)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(pages)
+		errc <- fetch(ctx, func(lst ApplicationList) error {
+			select {
+			case pages <- lst:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	for lst := range pages {
+		if err := visit(lst); err != nil {
+			return err
+		}
 	}
-	return err
+	return <-errc
 }
 
 // ForEachNamedApplication iterates over all the named applications, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedApplication(ctx context.Context, names []string, ignoreNotFound bool, f func(item *ApplicationItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
+	var errs api.MultiError
 	for _, name := range names {
 		app, err := l.API.GetApplicationByName(ctx, ApplicationName(name))
 		if err != nil {
@@ -80,14 +193,19 @@ func (l *Lister) ForEachNamedApplication(ctx context.Context, names []string, ig
 			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrApplicationNotFound && ignoreNotFound {
 				continue
 			}
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		if err := f(&ApplicationItem{Application: app}); err != nil {
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
 
 // ForEachScenario iterates over all scenarios for an application matching the supplied query.
@@ -109,7 +227,13 @@ func (l *Lister) ForEachScenario(ctx context.Context, app *Application, q Scenar
 			}
 		}
 
-		return lst.Link(api.RelationNext), nil
+		next := lst.Link(api.RelationNext)
+		if next != "" {
+			if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+				return "", err
+			}
+		}
+		return next, nil
 	}
 
 	// Overwrite the limit
@@ -131,19 +255,29 @@ func (l *Lister) ForEachScenario(ctx context.Context, app *Application, q Scenar
 // ForEachNamedScenario iterates over all the named scenarios, optionally ignoring those that do not exist.
 // Deprecated: scenarios should no longer be used.
 func (l *Lister) ForEachNamedScenario(ctx context.Context, names []string, ignoreNotFound bool, f func(item *ScenarioItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
+	// Missing applications are cached as nil
 	cache := make(map[ApplicationName]*Application)
-	for _, name := range names {
+	visit := func(name string) error {
 		appName, scnName := SplitScenarioName(name)
 
 		app, ok := cache[appName]
 		if !ok {
 			appByName, err := l.API.GetApplicationByName(ctx, appName)
 			if err != nil {
-				return err
+				var notFoundErr *api.Error
+				if !errors.As(err, &notFoundErr) || notFoundErr.Type != ErrApplicationNotFound || !ignoreNotFound {
+					return err
+				}
+			} else {
+				app = &appByName
 			}
-			app = &appByName
 			cache[appName] = app
 		}
+		if app == nil {
+			return nil
+		}
 
 		scenarioURL := app.Link(api.RelationScenarios)
 		if scenarioURL == "" {
@@ -158,15 +292,22 @@ func (l *Lister) ForEachNamedScenario(ctx context.Context, names []string, ignor
 		if err != nil {
 			var notFoundErr *api.Error
 			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrScenarioNotFound && ignoreNotFound {
-				continue
+				return nil
 			}
 			return err
 		}
-		if err := f(&ScenarioItem{Scenario: scn}); err != nil {
-			return err
+		return f(&ScenarioItem{Scenario: scn})
+	}
+
+	var errs api.MultiError
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
 
 // ForEachRecommendation iterates over all the recommendations for an application.
@@ -187,7 +328,13 @@ func (l *Lister) ForEachRecommendation(ctx context.Context, app *Application, f
 			}
 		}
 
-		return lst.Link(api.RelationNext), nil
+		next := lst.Link(api.RelationNext)
+		if next != "" {
+			if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+				return "", err
+			}
+		}
+		return next, nil
 	}
 
 	// Iterate over all scenario pages, starting with the application's "rel=scenarios"
@@ -200,34 +347,39 @@ func (l *Lister) ForEachRecommendation(ctx context.Context, app *Application, f
 
 // ForEachNamedRecommendation iterates over all the named recommendations, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedRecommendation(ctx context.Context, names []string, ignoreNotFound bool, f func(item *RecommendationItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
 	cache := make(map[ApplicationName]map[string]string)
-	for _, name := range names {
+	visit := func(name string) error {
 		appName, recName := SplitRecommendationName(name)
 
 		// Unlike trials, the recommendation index is incomplete: there is more
 		// information available on the individual resources. However: the only
 		// way to safely traverse to the recommendation is via the index. Here
-		// we are just building up the list of URLs to the recommendations.
+		// we are just building up the list of URLs to the recommendations. Missing
+		// applications are cached as nil.
 		if _, ok := cache[appName]; !ok {
 			app, err := l.API.GetApplicationByName(ctx, appName)
 			if err != nil {
+				var notFoundErr *api.Error
+				if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrApplicationNotFound && ignoreNotFound {
+					cache[appName] = nil
+					return nil
+				}
 				return err
 			}
 
-			cache[appName] = make(map[string]string)
+			urls := map[string]string{"": app.Link(api.RelationRecommendations)}
 			if err := l.ForEachRecommendation(ctx, &app, func(item *RecommendationItem) error {
-				cache[appName][item.Name] = item.Link(api.RelationSelf)
+				urls[item.Name] = item.Link(api.RelationSelf)
 				return nil
 			}); err != nil {
 				return err
 			}
-
-			// HACK: Because the index contains so few entries, if we have a
-			// recommendation name, we are going to take a guess at the URL
-			// while we have the application in scope. Typically, this is strictly verboten.
-			if _, ok := cache[appName][recName]; !ok && recName != "" {
-				cache[appName][recName] = strings.TrimRight(app.Link(api.RelationRecommendations), "/") + "/" + recName
-			}
+			cache[appName] = urls
+		}
+		if cache[appName] == nil {
+			return nil
 		}
 
 		// If there is no recommendation name, emit all recommendations in
@@ -236,7 +388,10 @@ func (l *Lister) ForEachNamedRecommendation(ctx context.Context, names []string,
 		// query support for full inclusion.
 		if recName == "" {
 			result := make([]*RecommendationItem, 0, len(cache[appName]))
-			for _, u := range cache[appName] {
+			for n, u := range cache[appName] {
+				if n == "" {
+					continue
+				}
 				rec, err := l.API.GetRecommendation(ctx, u)
 				if err != nil {
 					var notFoundErr *api.Error
@@ -256,24 +411,35 @@ func (l *Lister) ForEachNamedRecommendation(ctx context.Context, names []string,
 			return nil
 		}
 
-		// Get the recommendation out of the recommendation cache
-		if u, ok := cache[appName][recName]; ok {
-			rec, err := l.API.GetRecommendation(ctx, u)
-			if err != nil {
-				return err
+		// HACK: Because the index contains so few entries, if we have a
+		// recommendation name, we are going to take a guess at the URL using
+		// the application's recommendations link (cached under the empty name).
+		// Typically, this is strictly verboten.
+		u, ok := cache[appName][recName]
+		if !ok {
+			u = strings.TrimRight(cache[appName][""], "/") + "/" + recName
+		}
+
+		rec, err := l.API.GetRecommendation(ctx, u)
+		if err != nil {
+			var notFoundErr *api.Error
+			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrRecommendationNotFound && ignoreNotFound {
+				return nil
 			}
-			if err := f(&RecommendationItem{Recommendation: rec}); err != nil {
-				var notFoundErr *api.Error
-				if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrRecommendationNotFound && ignoreNotFound {
-					continue
-				}
+			return err
+		}
+		return f(&RecommendationItem{Recommendation: rec})
+	}
+
+	var errs api.MultiError
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			if err := l.failed(&errs, name, err); err != nil {
 				return err
 			}
-		} else if !ignoreNotFound {
-			return &api.Error{Type: ErrRecommendationNotFound, Message: fmt.Sprintf("recommendation not found: %q", recName)}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
 
 // GetApplicationByNameOrTitle tries to get an application by name and falls back to a
@@ -366,7 +532,13 @@ func (l *Lister) ForEachCluster(ctx context.Context, q ClusterListQuery, f func(
 			}
 		}
 
-		return lst.Link(api.RelationNext), nil
+		next := lst.Link(api.RelationNext)
+		if next != "" {
+			if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+				return "", err
+			}
+		}
+		return next, nil
 	}
 
 	// Iterate over all clusters, starting with first page
@@ -380,6 +552,9 @@ func (l *Lister) ForEachCluster(ctx context.Context, q ClusterListQuery, f func(
 
 // ForEachNamedCluster iterates over all the named clusters, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedCluster(ctx context.Context, names []string, ignoreNotFound bool, f func(item *ClusterItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
+	var errs api.MultiError
 	for _, name := range names {
 		c, err := l.API.GetClusterByName(ctx, ClusterName(name))
 		if err != nil {
@@ -387,12 +562,50 @@ func (l *Lister) ForEachNamedCluster(ctx context.Context, names []string, ignore
 			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrClusterNotFound && ignoreNotFound {
 				continue
 			}
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		if err := f(&ClusterItem{Cluster: c}); err != nil {
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
+}
+
+// sortApplications stably sorts applications by the named field, unknown fields
+// preserve the order returned by the server.
+func sortApplications(items []ApplicationItem, field string, direction api.SortDirection) {
+	var less func(a, b *ApplicationItem) bool
+	switch field {
+	case "name":
+		less = func(a, b *ApplicationItem) bool { return a.Name < b.Name }
+	case "title":
+		less = func(a, b *ApplicationItem) bool { return a.DisplayName < b.DisplayName }
+	case "createdAt":
+		less = func(a, b *ApplicationItem) bool { return timeBefore(a.CreatedAt, b.CreatedAt) }
+	case "lastDeployedAt":
+		less = func(a, b *ApplicationItem) bool { return timeBefore(a.LastDeployedAt, b.LastDeployedAt) }
+	default:
+		return
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if direction == api.SortDescending {
+			return less(&items[j], &items[i])
+		}
+		return less(&items[i], &items[j])
+	})
+}
+
+// timeBefore compares optional times, missing times sort first.
+func timeBefore(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	return a.Before(*b)
 }
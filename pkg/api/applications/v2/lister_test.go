@@ -0,0 +1,293 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// pagedAPI serves a fixed number of pages of applications.
+type pagedAPI struct {
+	API
+	pages, size int
+}
+
+func (p *pagedAPI) ListApplications(ctx context.Context, q ApplicationListQuery) (ApplicationList, error) {
+	return p.ListApplicationsByPage(ctx, "0")
+}
+
+func (p *pagedAPI) ListApplicationsByPage(_ context.Context, u string) (ApplicationList, error) {
+	page, err := strconv.Atoi(u)
+	if err != nil {
+		return ApplicationList{}, err
+	}
+
+	lst := ApplicationList{Metadata: api.Metadata{}}
+	for i := 0; i < p.size; i++ {
+		lst.Applications = append(lst.Applications, ApplicationItem{Application: Application{Name: ApplicationName(fmt.Sprintf("app-%d-%d", page, i))}})
+	}
+	if page+1 < p.pages {
+		lst.Metadata["Link"] = []string{fmt.Sprintf("<%d>; rel=next", page+1)}
+	}
+	return lst, nil
+}
+
+func TestLister_ForEachApplication(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 3} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			l := Lister{API: &pagedAPI{pages: 5, size: 2}, Concurrency: concurrency}
+
+			var names []string
+			err := l.ForEachApplication(context.Background(), ApplicationListQuery{}, func(item *ApplicationItem) error {
+				names = append(names, item.Name.String())
+				return nil
+			})
+			assert.NoError(t, err)
+			if assert.Len(t, names, 10) {
+				assert.Equal(t, "app-0-0", names[0])
+				assert.Equal(t, "app-2-1", names[5])
+				assert.Equal(t, "app-4-1", names[9])
+			}
+
+			stop := fmt.Errorf("stop")
+			var count int
+			err = l.ForEachApplication(context.Background(), ApplicationListQuery{}, func(item *ApplicationItem) error {
+				if count++; count == 3 {
+					return stop
+				}
+				return nil
+			})
+			assert.ErrorIs(t, err, stop)
+			assert.Equal(t, 3, count)
+		})
+	}
+}
+
+// namedAPI serves a single application with a few scenarios and recommendations.
+type namedAPI struct {
+	API
+}
+
+func link(u, rel string) api.Metadata {
+	return api.Metadata{"Link": []string{fmt.Sprintf("<%s>; rel=%q", u, rel)}}
+}
+
+func (namedAPI) GetApplicationByName(_ context.Context, n ApplicationName) (Application, error) {
+	if n != "app" {
+		return Application{}, &api.Error{Type: ErrApplicationNotFound}
+	}
+	md := link("scenarios", api.RelationScenarios)
+	md["Link"] = append(md["Link"], link("recommendations", api.RelationRecommendations)["Link"]...)
+	return Application{Metadata: md, Name: n}, nil
+}
+
+func (namedAPI) ListScenarios(context.Context, string, ScenarioListQuery) (ScenarioList, error) {
+	return ScenarioList{Scenarios: []ScenarioItem{{Scenario: Scenario{Name: "scn1"}}, {Scenario: Scenario{Name: "scn2"}}}}, nil
+}
+
+func (namedAPI) GetScenarioByName(_ context.Context, _ string, n ScenarioName) (Scenario, error) {
+	if n != "scn1" && n != "scn2" {
+		return Scenario{}, &api.Error{Type: ErrScenarioNotFound}
+	}
+	return Scenario{Name: n}, nil
+}
+
+func (namedAPI) ListRecommendations(context.Context, string) (RecommendationList, error) {
+	return RecommendationList{Recommendations: []RecommendationItem{
+		{Recommendation: Recommendation{Metadata: link("recommendations/rec1", api.RelationSelf), Name: "rec1"}},
+	}}, nil
+}
+
+func (namedAPI) GetRecommendation(_ context.Context, u string) (Recommendation, error) {
+	switch u {
+	case "recommendations/rec1", "recommendations/rec2": // rec2 is not in the index
+		return Recommendation{Name: strings.TrimPrefix(u, "recommendations/")}, nil
+	}
+	return Recommendation{}, &api.Error{Type: ErrRecommendationNotFound}
+}
+
+func (namedAPI) GetClusterByName(_ context.Context, n ClusterName) (Cluster, error) {
+	if n != "c1" {
+		return Cluster{}, &api.Error{Type: ErrClusterNotFound}
+	}
+	return Cluster{Name: n}, nil
+}
+
+func TestLister_ForEachNamed(t *testing.T) {
+	l := Lister{API: namedAPI{}}
+	ctx := context.Background()
+
+	cases := []struct {
+		desc           string
+		forEach        func(names []string, ignoreNotFound bool) ([]string, error)
+		names          []string
+		ignoreNotFound bool
+		expected       []string
+		errType        api.ErrorType
+	}{
+		{
+			desc:     "applications",
+			forEach:  namedApplications(ctx, &l),
+			names:    []string{"app", "missing", "app"},
+			expected: []string{"app"},
+			errType:  ErrApplicationNotFound,
+		},
+		{
+			desc:           "applications ignore not found",
+			forEach:        namedApplications(ctx, &l),
+			names:          []string{"app", "missing", "app"},
+			ignoreNotFound: true,
+			expected:       []string{"app", "app"},
+		},
+		{
+			desc:     "scenarios",
+			forEach:  namedScenarios(ctx, &l),
+			names:    []string{"app/scn1", "app/missing", "app/scn2"},
+			expected: []string{"scn1"},
+			errType:  ErrScenarioNotFound,
+		},
+		{
+			desc:     "scenarios missing application",
+			forEach:  namedScenarios(ctx, &l),
+			names:    []string{"missing/scn1", "app/scn1"},
+			expected: nil,
+			errType:  ErrApplicationNotFound,
+		},
+		{
+			desc:           "scenarios ignore not found",
+			forEach:        namedScenarios(ctx, &l),
+			names:          []string{"missing/scn1", "app/missing", "app", "app/scn2"},
+			ignoreNotFound: true,
+			expected:       []string{"scn1", "scn2", "scn2"},
+		},
+		{
+			desc:     "recommendations",
+			forEach:  namedRecommendations(ctx, &l),
+			names:    []string{"app/rec1", "app/rec2", "app/missing", "app/rec1"},
+			expected: []string{"rec1", "rec2"},
+			errType:  ErrRecommendationNotFound,
+		},
+		{
+			desc:     "recommendations missing application",
+			forEach:  namedRecommendations(ctx, &l),
+			names:    []string{"missing/rec1", "app/rec1"},
+			expected: nil,
+			errType:  ErrApplicationNotFound,
+		},
+		{
+			desc:           "recommendations ignore not found",
+			forEach:        namedRecommendations(ctx, &l),
+			names:          []string{"missing/rec1", "app/missing", "app", "app/rec2"},
+			ignoreNotFound: true,
+			expected:       []string{"rec1", "rec2"},
+		},
+		{
+			desc:     "clusters",
+			forEach:  namedClusters(ctx, &l),
+			names:    []string{"c1", "missing", "c1"},
+			expected: []string{"c1"},
+			errType:  ErrClusterNotFound,
+		},
+		{
+			desc:           "clusters ignore not found",
+			forEach:        namedClusters(ctx, &l),
+			names:          []string{"c1", "missing", "c1"},
+			ignoreNotFound: true,
+			expected:       []string{"c1", "c1"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := c.forEach(c.names, c.ignoreNotFound)
+			assert.Equal(t, c.expected, actual)
+			if c.errType == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr *api.Error
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, c.errType, apiErr.Type)
+			}
+		})
+	}
+
+	t.Run("continue on error", func(t *testing.T) {
+		l := Lister{API: namedAPI{}, ContinueOnError: true}
+		for _, forEach := range []func([]string, bool) ([]string, error){namedScenarios(ctx, &l), namedRecommendations(ctx, &l)} {
+			actual, err := forEach([]string{"missing/x", "app/missing", "app"}, false)
+			var errs api.MultiError
+			if assert.ErrorAs(t, err, &errs) {
+				assert.Len(t, errs, 2)
+			}
+			assert.NotEmpty(t, actual)
+		}
+	})
+
+	t.Run("lister ignore not found", func(t *testing.T) {
+		l := Lister{API: namedAPI{}, IgnoreNotFound: true}
+		actual, err := namedRecommendations(ctx, &l)([]string{"missing/rec1", "app/missing", "app/rec1"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"rec1"}, actual)
+	})
+}
+
+func namedApplications(ctx context.Context, l *Lister) func([]string, bool) ([]string, error) {
+	return func(names []string, ignoreNotFound bool) (result []string, err error) {
+		err = l.ForEachNamedApplication(ctx, names, ignoreNotFound, func(item *ApplicationItem) error {
+			result = append(result, item.Name.String())
+			return nil
+		})
+		return
+	}
+}
+
+func namedScenarios(ctx context.Context, l *Lister) func([]string, bool) ([]string, error) {
+	return func(names []string, ignoreNotFound bool) (result []string, err error) {
+		err = l.ForEachNamedScenario(ctx, names, ignoreNotFound, func(item *ScenarioItem) error {
+			result = append(result, item.Name.String())
+			return nil
+		})
+		return
+	}
+}
+
+func namedRecommendations(ctx context.Context, l *Lister) func([]string, bool) ([]string, error) {
+	return func(names []string, ignoreNotFound bool) (result []string, err error) {
+		err = l.ForEachNamedRecommendation(ctx, names, ignoreNotFound, func(item *RecommendationItem) error {
+			result = append(result, item.Name)
+			return nil
+		})
+		return
+	}
+}
+
+func namedClusters(ctx context.Context, l *Lister) func([]string, bool) ([]string, error) {
+	return func(names []string, ignoreNotFound bool) (result []string, err error) {
+		err = l.ForEachNamedCluster(ctx, names, ignoreNotFound, func(item *ClusterItem) error {
+			result = append(result, item.Name.String())
+			return nil
+		})
+		return
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package v2
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -43,6 +46,37 @@ type TargetRef struct {
 	Workload  string `json:"workload,omitempty"`
 }
 
+// RecommendationContainer is the recommended resources of a single workload container.
+type RecommendationContainer struct {
+	// The workload the container belongs to.
+	Target TargetRef `json:"target"`
+	// The name of the container.
+	Container string `json:"container"`
+	// The recommended requests.
+	Requests *ResourceList `json:"requests,omitempty"`
+	// The recommended limits.
+	Limits *ResourceList `json:"limits,omitempty"`
+}
+
+// Containers returns the typed container resources of the recommendation
+// parameters, entries which cannot be interpreted are skipped.
+func (r *Recommendation) Containers() []RecommendationContainer {
+	var result []RecommendationContainer
+	for _, p := range r.Parameters {
+		for _, cr := range p.ContainerResources {
+			if c, ok := parseContainerResources(cr); ok {
+				result = append(result, RecommendationContainer{
+					Target:    p.Target,
+					Container: c.Name,
+					Requests:  c.Requests,
+					Limits:    c.Limits,
+				})
+			}
+		}
+	}
+	return result
+}
+
 type RecommendationItem struct {
 	Recommendation
 }
@@ -101,6 +135,30 @@ type BoundsRange struct {
 type ResourceList struct {
 	CPU    *api.NumberOrString `json:"cpu,omitempty"`
 	Memory *api.NumberOrString `json:"memory,omitempty"`
+
+	// The names of resources which were explicitly cleared.
+	cleared map[string]bool
+}
+
+// MarshalJSON renders cleared resources as null so they are removed when the
+// resource list is merged over an existing value.
+func (rl ResourceList) MarshalJSON() ([]byte, error) {
+	type t ResourceList
+	data, err := json.Marshal(t(rl))
+	if err != nil || len(rl.cleared) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name := range rl.cleared {
+		if _, ok := fields[name]; !ok {
+			fields[name] = json.RawMessage("null")
+		}
+	}
+	return json.Marshal(fields)
 }
 
 func (rl *ResourceList) Get(name string) *api.NumberOrString {
@@ -119,9 +177,30 @@ func (rl *ResourceList) Set(name string, value api.NumberOrString) {
 	switch name {
 	case "cpu", "c":
 		rl.CPU = &value
+		delete(rl.cleared, "cpu")
 	case "memory", "mem", "m":
 		rl.Memory = &value
+		delete(rl.cleared, "memory")
+	}
+}
+
+// Clear removes a resource, when merged the resource is also removed from the
+// existing resource list.
+func (rl *ResourceList) Clear(name string) {
+	switch name {
+	case "cpu", "c":
+		rl.CPU = nil
+		name = "cpu"
+	case "memory", "mem", "m":
+		rl.Memory = nil
+		name = "memory"
+	default:
+		return
+	}
+	if rl.cleared == nil {
+		rl.cleared = make(map[string]bool)
 	}
+	rl.cleared[name] = true
 }
 
 // NOTE: tolerance is a number or string type to allow it in a resource list
@@ -144,8 +223,17 @@ type BackfillProgress struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Window returns the amount of history that has been backfilled as of the
+// supplied time.
+func (p *BackfillProgress) Window(now time.Time) time.Duration {
+	if p == nil || p.Timestamp.IsZero() || p.Timestamp.After(now) {
+		return 0
+	}
+	return now.Sub(p.Timestamp)
+}
+
 // MergeConfigurations combines the supplied configurations into a new
-// configuration.
+// configuration. Resources cleared in the second configuration are removed.
 func MergeConfigurations(a, b *Configuration) (*Configuration, error) {
 	dataA, err := json.Marshal(a)
 	if err != nil {
@@ -164,3 +252,152 @@ func MergeConfigurations(a, b *Configuration) (*Configuration, error) {
 	}
 	return c, nil
 }
+
+// MergeSource is a named layer of configuration values.
+type MergeSource struct {
+	// The name of the source reported for the values it provides.
+	Name string
+	// The values of the source, compared using their JSON representation.
+	Value interface{}
+}
+
+// MergedValue is an effective configuration value and the source it came from.
+type MergedValue struct {
+	// The dot separated path of the value.
+	Path string `json:"path"`
+	// The effective value.
+	Value interface{} `json:"value"`
+	// The name of the source which provided the value.
+	Source string `json:"source"`
+}
+
+// ExplainMerge overlays the JSON representation of each source in order (the
+// same semantics used by MergeConfigurations) and reports which source
+// provided each effective value. Objects are merged while all other values,
+// including arrays, are replaced and nulls remove the existing value; a source
+// only claims a value that it changes.
+func ExplainMerge(sources ...MergeSource) ([]MergedValue, error) {
+	values := make(map[string]interface{})
+	origins := make(map[string]string)
+	for _, s := range sources {
+		data, err := json.Marshal(s.Value)
+		if err != nil {
+			return nil, err
+		}
+		// Preserve the original representation of numbers
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		leaves := make(map[string]interface{})
+		flattenJSON("", v, leaves)
+		for p, lv := range leaves {
+			if cur, ok := values[p]; ok && reflect.DeepEqual(cur, lv) {
+				continue
+			}
+
+			// Replace anything nested below or above this value
+			for k := range values {
+				if k == p || strings.HasPrefix(k, p+".") || strings.HasPrefix(p, k+".") {
+					delete(values, k)
+					delete(origins, k)
+				}
+			}
+
+			// An explicit null removes the value
+			if lv != nil {
+				values[p] = lv
+				origins[p] = s.Name
+			}
+		}
+	}
+
+	result := make([]MergedValue, 0, len(values))
+	for p, v := range values {
+		result = append(result, MergedValue{Path: p, Value: v, Source: origins[p]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+// flattenJSON collects the non-object values of a decoded JSON document by path.
+func flattenJSON(prefix string, v interface{}, leaves map[string]interface{}) {
+	if m, ok := v.(map[string]interface{}); ok {
+		for k, mv := range m {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flattenJSON(p, mv, leaves)
+		}
+		return
+	}
+	if prefix != "" {
+		leaves[prefix] = v
+	}
+}
+
+// RecommendationsMergePatch computes a JSON merge patch (RFC 7386) containing
+// only the recommendation configuration changes needed to turn the current list
+// into the desired list. Fields removed from the desired list are explicitly
+// set to null so they are deleted; read-only fields are never included.
+func RecommendationsMergePatch(current, desired *RecommendationList) (json.RawMessage, error) {
+	configOnly := func(rl *RecommendationList) (map[string]interface{}, error) {
+		data, err := json.Marshal(&RecommendationList{
+			DeployConfiguration: rl.DeployConfiguration,
+			Configuration:       rl.Configuration,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{})
+		return result, json.Unmarshal(data, &result)
+	}
+
+	original, err := configOnly(current)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := configOnly(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, _ := mergePatch(original, modified)
+	if patch == nil {
+		patch = map[string]interface{}{}
+	}
+	return json.Marshal(patch)
+}
+
+// mergePatch returns the merge patch between two decoded JSON values, the
+// returned flag is false if there are no differences.
+func mergePatch(original, modified interface{}) (interface{}, bool) {
+	o, oOk := original.(map[string]interface{})
+	m, mOk := modified.(map[string]interface{})
+	if !oOk || !mOk {
+		// Merge patches replace anything that is not an object (including arrays)
+		return modified, !reflect.DeepEqual(original, modified)
+	}
+
+	patch := make(map[string]interface{})
+	for k, ov := range o {
+		mv, ok := m[k]
+		if !ok {
+			patch[k] = nil
+			continue
+		}
+		if p, changed := mergePatch(ov, mv); changed {
+			patch[k] = p
+		}
+	}
+	for k, mv := range m {
+		if _, ok := o[k]; !ok {
+			patch[k] = mv
+		}
+	}
+	return patch, len(patch) > 0
+}
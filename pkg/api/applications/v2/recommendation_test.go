@@ -1,9 +1,15 @@
 package v2
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
@@ -146,6 +152,43 @@ func TestMergeConfigurations(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "clear bound",
+			first: Configuration{
+				ContainerResources: &ContainerResources{
+					Bounds: &Bounds{
+						Requests: &BoundsRange{
+							Max: &ResourceList{
+								CPU:    &api.NumberOrString{NumVal: "3"},
+								Memory: &api.NumberOrString{NumVal: "4"},
+							},
+						},
+					},
+				},
+			},
+			second: Configuration{
+				ContainerResources: &ContainerResources{
+					Bounds: &Bounds{
+						Requests: &BoundsRange{
+							Max: &ResourceList{
+								cleared: map[string]bool{"cpu": true},
+							},
+						},
+					},
+				},
+			},
+			expected: Configuration{
+				ContainerResources: &ContainerResources{
+					Bounds: &Bounds{
+						Requests: &BoundsRange{
+							Max: &ResourceList{
+								Memory: &api.NumberOrString{NumVal: "4"},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -156,3 +199,166 @@ func TestMergeConfigurations(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateRecommendation(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Location", "/v2/applications/test/recommendations/r1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+
+	md, err := appAPI.CreateRecommendation(context.Background(), srv.URL+"/v2/applications/test/recommendations")
+	if assert.NoError(t, err) {
+		assert.Equal(t, srv.URL+"/v2/applications/test/recommendations/r1", md.Location())
+		assert.Equal(t, []string{"POST /v2/applications/test/recommendations"}, requests)
+	}
+}
+
+func TestResourceList_Clear(t *testing.T) {
+	rl := &ResourceList{}
+	rl.Set("cpu", api.FromInt64(1))
+	rl.Set("memory", api.FromInt64(2))
+	rl.Clear("mem")
+
+	data, err := json.Marshal(rl)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"cpu":1,"memory":null}`, string(data))
+	}
+
+	rl.Set("memory", api.FromInt64(3))
+	data, err = json.Marshal(rl)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"cpu":1,"memory":3}`, string(data))
+	}
+}
+
+func TestExplainMerge(t *testing.T) {
+	current := Configuration{ContainerResources: &ContainerResources{
+		Selector: "app=test",
+		Bounds: &Bounds{Limits: &BoundsRange{
+			Max: &ResourceList{CPU: &api.NumberOrString{NumVal: "2"}},
+		}},
+	}}
+	patch := Configuration{ContainerResources: &ContainerResources{
+		Selector: "app=test",
+		Bounds: &Bounds{Limits: &BoundsRange{
+			Min: &ResourceList{CPU: &api.NumberOrString{StrVal: "100m", IsString: true}},
+		}},
+	}}
+	final, err := MergeConfigurations(&current, &patch)
+	require.NoError(t, err)
+	final.ContainerResources.Interval = api.Duration(time.Hour)
+
+	actual, err := ExplainMerge(
+		MergeSource{Name: "current", Value: &current},
+		MergeSource{Name: "patch", Value: &patch},
+		MergeSource{Name: "default", Value: final},
+	)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []MergedValue{
+			{Path: "containerResources.bounds.limits.max.cpu", Value: json.Number("2"), Source: "current"},
+			{Path: "containerResources.bounds.limits.min.cpu", Value: "100m", Source: "patch"},
+			{Path: "containerResources.interval", Value: "1h0m0s", Source: "default"},
+			{Path: "containerResources.selector", Value: "app=test", Source: "current"},
+		}, actual)
+	}
+}
+
+func TestBackfillProgress_Window(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		desc     string
+		progress *BackfillProgress
+		expected time.Duration
+	}{
+		{
+			desc: "nil",
+		},
+		{
+			desc:     "zero",
+			progress: &BackfillProgress{},
+		},
+		{
+			desc:     "future",
+			progress: &BackfillProgress{Timestamp: now.Add(time.Hour)},
+		},
+		{
+			desc:     "week",
+			progress: &BackfillProgress{Timestamp: now.AddDate(0, 0, -7)},
+			expected: 7 * 24 * time.Hour,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.progress.Window(now))
+		})
+	}
+}
+
+func TestRecommendationsMergePatch(t *testing.T) {
+	cases := []struct {
+		desc     string
+		current  RecommendationList
+		desired  RecommendationList
+		expected string
+	}{
+		{
+			desc:     "empty",
+			expected: `{}`,
+		},
+		{
+			desc: "unchanged",
+			current: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsAuto, Interval: api.Duration(time.Hour)},
+			},
+			desired: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsAuto, Interval: api.Duration(time.Hour)},
+			},
+			expected: `{}`,
+		},
+		{
+			desc: "changed mode",
+			current: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsAuto, Interval: api.Duration(time.Hour)},
+			},
+			desired: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsManual, Interval: api.Duration(time.Hour)},
+			},
+			expected: `{"deploy":{"mode":"manual"}}`,
+		},
+		{
+			desc: "removed field",
+			current: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsAuto, Clusters: []string{"foo"}},
+			},
+			desired: RecommendationList{
+				DeployConfiguration: &DeployConfiguration{Mode: RecommendationsAuto},
+			},
+			expected: `{"deploy":{"clusters":null}}`,
+		},
+		{
+			desc: "ignore read only",
+			current: RecommendationList{
+				BackfillProgress: &BackfillProgress{},
+			},
+			desired: RecommendationList{
+				Recommendations: []RecommendationItem{{}},
+			},
+			expected: `{}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := RecommendationsMergePatch(&c.current, &c.desired)
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, c.expected, string(actual))
+			}
+		})
+	}
+}
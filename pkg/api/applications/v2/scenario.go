@@ -17,7 +17,12 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
@@ -30,9 +35,146 @@ type Scenario struct {
 	Objective     []interface{} `json:"objective,omitempty"`
 	Clusters      []string      `json:"clusters,omitempty"`
 
-	StormForgePerformance interface{} `json:"stormforgePerf,omitempty"`
-	Locust                interface{} `json:"locust,omitempty"`
-	Custom                interface{} `json:"custom,omitempty"`
+	StormForgePerformance interface{}     `json:"stormforgePerf,omitempty"`
+	Locust                *LocustScenario `json:"locust,omitempty"`
+	K6                    *K6Scenario     `json:"k6,omitempty"`
+	Custom                interface{}     `json:"custom,omitempty"`
+}
+
+type LocustScenario struct {
+	// The Locust file contents, or the URL of the Locust file.
+	Locustfile string `json:"locustfile,omitempty"`
+	// The number of concurrent Locust users.
+	Users int `json:"users,omitempty"`
+	// The rate per second in which users are spawned.
+	SpawnRate int `json:"spawnRate,omitempty"`
+	// Stop after the specified amount of time.
+	RunTime api.Duration `json:"runTime,omitempty"`
+}
+
+// UnmarshalJSON accepts run times persisted as a number of seconds in addition
+// to the string formatted duration.
+func (s *LocustScenario) UnmarshalJSON(b []byte) error {
+	type t LocustScenario
+	raw := struct {
+		*t
+		RunTime json.RawMessage `json:"runTime,omitempty"`
+	}{t: (*t)(s)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(raw.RunTime, &seconds); err == nil {
+		s.RunTime = api.Duration(seconds * float64(time.Second))
+		return nil
+	}
+	if len(raw.RunTime) > 0 {
+		return json.Unmarshal(raw.RunTime, &s.RunTime)
+	}
+	return nil
+}
+
+type K6Scenario struct {
+	// The k6 script contents, or the URL of the k6 script.
+	Script string `json:"script,omitempty"`
+	// The number of concurrent virtual users.
+	VUs int `json:"vus,omitempty"`
+	// Stop after the specified amount of time.
+	Duration api.Duration `json:"duration,omitempty"`
+}
+
+// Scenario types, as advertised by the server.
+const (
+	ScenarioTypeStormForgePerformance = "stormforgePerf"
+	ScenarioTypeLocust                = "locust"
+	ScenarioTypeK6                    = "k6"
+	ScenarioTypeCustom                = "custom"
+)
+
+// legacyScenarioTypes are the scenario types supported by servers which do not
+// advertise the types they support.
+var legacyScenarioTypes = []string{ScenarioTypeStormForgePerformance, ScenarioTypeCustom}
+
+// SupportedScenarioTypes returns the scenario types supported by the server
+// using the metadata returned from `CheckEndpoint`.
+func SupportedScenarioTypes(md api.Metadata) []string {
+	var result []string
+	for _, v := range http.Header(md).Values("Scenario-Types") {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				result = append(result, t)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return legacyScenarioTypes
+	}
+	return result
+}
+
+// CheckScenarioSupport returns an error if the scenario uses settings of a
+// scenario type that the server does not support (and would not persist).
+func CheckScenarioSupport(md api.Metadata, scn *Scenario) error {
+	supported := SupportedScenarioTypes(md)
+	check := func(t string, used bool) error {
+		if !used {
+			return nil
+		}
+		for _, s := range supported {
+			if strings.EqualFold(s, t) {
+				return nil
+			}
+		}
+		return &api.Error{
+			Type:    ErrScenarioTypeUnsupported,
+			Message: fmt.Sprintf("%s scenarios are not supported by the server", t),
+		}
+	}
+
+	if err := check(ScenarioTypeLocust, scn.Locust != nil); err != nil {
+		return err
+	}
+	return check(ScenarioTypeK6, scn.K6 != nil)
+}
+
+// CreateAndGetScenario creates a scenario in the supplied scenarios collection,
+// using the name if it is not empty, and returns the representation of the
+// created scenario. The scenario is only fetched when the create response does
+// not include it.
+func CreateAndGetScenario(ctx context.Context, appAPI API, u string, n ScenarioName, scn Scenario) (Scenario, error) {
+	var md api.Metadata
+	var selfURL string
+	if n != "" {
+		result, err := appAPI.CreateScenarioByName(ctx, u, n, scn)
+		if err != nil {
+			return scn, err
+		}
+		if result.Name != "" {
+			return result, nil
+		}
+		md, selfURL = result.Metadata, result.Link(api.RelationSelf)
+	} else {
+		var err error
+		md, err = appAPI.CreateScenario(ctx, u, scn)
+		if err != nil {
+			return scn, err
+		}
+		selfURL = md.Location()
+	}
+
+	if selfURL != "" {
+		if result, err := appAPI.GetScenario(ctx, selfURL); err == nil {
+			result.Metadata = keepLocation(result.Metadata, md)
+			return result, nil
+		}
+	}
+
+	scn.Metadata = md
+	if scn.Name == "" {
+		scn.Name = n
+	}
+	return scn, nil
 }
 
 // NOTE: Use `DisplayName` as the field since `Title()` is a function on the embedded `Metadata`
@@ -97,8 +239,26 @@ type TemplateMetric struct {
 }
 
 type Template struct {
+	// The template metadata, used to detect conflicting updates.
+	api.Metadata `json:"-"`
 	// The list of parameters for this template.
 	Parameters []TemplateParameter `json:"parameters,omitempty"`
 	// The list of metrics for this template.
 	Metrics []TemplateMetric `json:"metrics,omitempty"`
 }
+
+type TemplateRevision struct {
+	// The revision number, increasing with each update of the template.
+	Revision int `json:"revision"`
+	// The time the revision was recorded.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// The template as of this revision.
+	Template Template `json:"template"`
+}
+
+type TemplateRevisionList struct {
+	// The template revision list metadata.
+	api.Metadata `json:"-"`
+	// The list of template revisions.
+	Revisions []TemplateRevision `json:"revisions,omitempty"`
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestUpdateTemplate_Conflict(t *testing.T) {
+	etag := `"1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"parameters":[{"name":"cpu","type":"int"}]}`))
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			etag = `"2"`
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+	ctx := context.Background()
+
+	first, err := appAPI.GetTemplate(ctx, srv.URL)
+	require.NoError(t, err)
+	second, err := appAPI.GetTemplate(ctx, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, `"1"`, first.ETag())
+
+	assert.NoError(t, appAPI.UpdateTemplate(ctx, srv.URL, first))
+
+	err = appAPI.UpdateTemplate(ctx, srv.URL, second)
+	var apiErr *api.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, ErrTemplateConflict, apiErr.Type)
+	}
+}
+
+func TestCheckScenarioSupport(t *testing.T) {
+	cases := []struct {
+		desc    string
+		types   string
+		scn     Scenario
+		wantErr bool
+	}{
+		{
+			desc: "legacy custom",
+			scn:  Scenario{Custom: map[string]interface{}{"image": "example"}},
+		},
+		{
+			desc:    "legacy locust",
+			scn:     Scenario{Locust: &LocustScenario{Locustfile: "locustfile.py"}},
+			wantErr: true,
+		},
+		{
+			desc:  "advertised locust",
+			types: "stormforgePerf, locust, custom",
+			scn:   Scenario{Locust: &LocustScenario{Locustfile: "locustfile.py"}},
+		},
+		{
+			desc:    "unadvertised k6",
+			types:   "stormforgePerf, locust, custom",
+			scn:     Scenario{K6: &K6Scenario{Script: "script.js"}},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			md := api.Metadata{}
+			if c.types != "" {
+				http.Header(md).Set("Scenario-Types", c.types)
+			}
+
+			err := CheckScenarioSupport(md, &c.scn)
+			if !c.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var apiErr *api.Error
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, ErrScenarioTypeUnsupported, apiErr.Type)
+			}
+		})
+	}
+}
+
+func TestScenario_Locust(t *testing.T) {
+	var updated Scenario
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"test","locust":{"locustfile":"locustfile.py","users":10,"spawnRate":2,"runTime":300}}`))
+		case http.MethodPut:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&updated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+	ctx := context.Background()
+	u := srv.URL + "/v2/applications/test/scenarios/test"
+
+	scn, err := appAPI.GetScenario(ctx, u)
+	require.NoError(t, err)
+	if assert.NotNil(t, scn.Locust) {
+		assert.Equal(t, LocustScenario{
+			Locustfile: "locustfile.py",
+			Users:      10,
+			SpawnRate:  2,
+			RunTime:    api.Duration(5 * time.Minute),
+		}, *scn.Locust)
+	}
+
+	scn.Locust.Users = 20
+	_, err = appAPI.UpdateScenario(ctx, u, scn)
+	require.NoError(t, err)
+	if assert.NotNil(t, updated.Locust) {
+		assert.Equal(t, 20, updated.Locust.Users)
+		assert.Equal(t, api.Duration(5*time.Minute), updated.Locust.RunTime)
+	}
+}
+
+func TestLocustScenario_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		desc        string
+		data        string
+		expected    api.Duration
+		expectedErr bool
+	}{
+		{desc: "seconds", data: `{"runTime":90}`, expected: api.Duration(90 * time.Second)},
+		{desc: "string", data: `{"runTime":"1m30s"}`, expected: api.Duration(90 * time.Second)},
+		{desc: "missing", data: `{"users":1}`},
+		{desc: "invalid", data: `{"runTime":"soon"}`, expectedErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var scn LocustScenario
+			err := json.Unmarshal([]byte(c.data), &scn)
+			if c.expectedErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, scn.RunTime)
+			}
+		})
+	}
+
+	// Other durations still require the string format
+	var k6 K6Scenario
+	assert.Error(t, json.Unmarshal([]byte(`{"duration":90}`), &k6))
+}
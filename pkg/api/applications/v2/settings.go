@@ -0,0 +1,177 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// Settings are free-form scenario settings, for example the StormForge
+// Performance or custom scenario configurations.
+type Settings map[string]interface{}
+
+// NewSettings returns the settings represented by an arbitrary value, such as
+// the `Custom` field of a scenario. Values which are not already maps are
+// converted using their JSON representation, which must be an object.
+func NewSettings(v interface{}) (Settings, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case Settings:
+		return v, nil
+	case map[string]interface{}:
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	return s, nil
+}
+
+// CustomSettings returns the settings of a custom scenario.
+func (s *Scenario) CustomSettings() (Settings, error) {
+	return NewSettings(s.Custom)
+}
+
+// StormForgePerformanceSettings returns the settings of a StormForge Performance scenario.
+func (s *Scenario) StormForgePerformanceSettings() (Settings, error) {
+	return NewSettings(s.StormForgePerformance)
+}
+
+// Get returns the raw value of a setting.
+func (s Settings) Get(key string) (interface{}, bool) {
+	v, ok := s[key]
+	return v, ok && v != nil
+}
+
+// GetSettings returns a nested settings object.
+func (s Settings) GetSettings(key string) (Settings, bool) {
+	switch v := s[key].(type) {
+	case Settings:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// GetString returns a setting as a string. Numbers and booleans are formatted.
+func (s Settings) GetString(key string) (string, bool) {
+	switch v := s[key].(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case fmt.Stringer:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// GetInt returns a setting as an integer. Numeric strings are parsed, numbers
+// with a fractional part are rejected.
+func (s Settings) GetInt(key string) (int64, bool) {
+	switch v := s[key].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// GetDuration returns a setting as a duration. Strings may include units
+// (e.g. "90s" or "5m"), plain numbers are interpreted as seconds.
+func (s Settings) GetDuration(key string) (time.Duration, bool) {
+	switch v := s[key].(type) {
+	case time.Duration:
+		return v, true
+	case api.Duration:
+		return time.Duration(v), true
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(f * float64(time.Second)), true
+		}
+		return 0, false
+	}
+
+	if str, ok := s.GetString(key); ok {
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return time.Duration(f * float64(time.Second)), true
+		}
+	}
+	return 0, false
+}
+
+// Set changes the value of a setting, durations are stored in their string
+// form so they survive serialization. A nil value removes the setting.
+func (s *Settings) Set(key string, value interface{}) {
+	if value == nil {
+		delete(*s, key)
+		return
+	}
+
+	if *s == nil {
+		*s = make(Settings)
+	}
+
+	switch v := value.(type) {
+	case time.Duration:
+		value = v.String()
+	case api.Duration:
+		value = v.String()
+	}
+	(*s)[key] = value
+}
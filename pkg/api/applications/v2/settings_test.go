@@ -0,0 +1,111 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings(t *testing.T) {
+	var scn Scenario
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "name": "test",
+  "custom": {
+    "image": "example/load:latest",
+    "users": 10,
+    "rate": "2",
+    "fraction": 1.5,
+    "duration": "5m",
+    "timeout": 30,
+    "env": { "DEBUG": true }
+  }
+}`), &scn))
+
+	s, err := scn.CustomSettings()
+	require.NoError(t, err)
+
+	str, ok := s.GetString("image")
+	assert.True(t, ok)
+	assert.Equal(t, "example/load:latest", str)
+
+	str, ok = s.GetString("users")
+	assert.True(t, ok)
+	assert.Equal(t, "10", str)
+
+	i, ok := s.GetInt("users")
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), i)
+
+	i, ok = s.GetInt("rate")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), i)
+
+	_, ok = s.GetInt("fraction")
+	assert.False(t, ok)
+
+	d, ok := s.GetDuration("duration")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, d)
+
+	d, ok = s.GetDuration("timeout")
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, ok = s.GetDuration("image")
+	assert.False(t, ok)
+
+	env, ok := s.GetSettings("env")
+	assert.True(t, ok)
+	str, ok = env.GetString("DEBUG")
+	assert.True(t, ok)
+	assert.Equal(t, "true", str)
+
+	_, ok = s.GetString("missing")
+	assert.False(t, ok)
+}
+
+func TestSettings_Set(t *testing.T) {
+	var s Settings
+	s.Set("duration", 90*time.Second)
+	s.Set("users", 5)
+	s.Set("users", nil)
+
+	scn := Scenario{Custom: s}
+	data, err := json.Marshal(&scn)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"custom":{"duration":"1m30s"}}`, string(data))
+}
+
+func TestNewSettings(t *testing.T) {
+	s, err := NewSettings(struct {
+		Image string `json:"image"`
+	}{Image: "example"})
+	require.NoError(t, err)
+	assert.Equal(t, Settings{"image": "example"}, s)
+
+	s, err = NewSettings(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = NewSettings([]string{"not", "an", "object"})
+	assert.Error(t, err)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v2
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"math/rand"
@@ -28,18 +29,26 @@ import (
 )
 
 // newSubscriber returns a subscriber for the supplied feed.
-func newSubscriber(api API, feed ActivityFeed) Subscriber {
+func newSubscriber(api API, feed ActivityFeed, q ActivityFeedQuery) Subscriber {
+	var filter *ActivityFeedQuery
+	if q.hasFilter() {
+		filter = &q
+	}
+
 	// Check the feed hubs for any subscription strategies we support
 	for _, hub := range feed.Hubs {
 		switch hub.Type {
 		case "poll":
 			// Allow the server to force polling
-			return &PollingSubscriber{API: api, FeedURL: hub.URL}
+			return &PollingSubscriber{API: api, FeedURL: hub.URL, Filter: filter}
+		case "long-poll":
+			// Prefer long polling when the server advertises support for it
+			return &LongPollSubscriber{PollingSubscriber: PollingSubscriber{API: api, FeedURL: hub.URL, Filter: filter}}
 		}
 	}
 
 	// By default, return a simple polling subscriber on the feed URL
-	return &PollingSubscriber{API: api, FeedURL: feed.FeedURL}
+	return &PollingSubscriber{API: api, FeedURL: feed.FeedURL, Filter: filter}
 }
 
 // PollingSubscriber is a primitive strategy that simply polls for changes.
@@ -50,29 +59,42 @@ type PollingSubscriber struct {
 	FeedURL string
 	// Time between polling requests. Defaults to 30 seconds.
 	PollInterval time.Duration
+	// The shortest time between polling requests. When either this or the
+	// maximum poll interval are set, the interval is adjusted based on the
+	// activity volume: moving towards the minimum when new items are found and
+	// towards the maximum when the feed is idle.
+	MinPollInterval time.Duration
+	// The longest time between polling requests when adaptive polling is enabled.
+	MaxPollInterval time.Duration
+	// The weight given to the most recent poll when adjusting the interval, must
+	// be between 0 and 1. Defaults to 0.5.
+	SmoothingFactor float64
 	// Adjust the poll duration by a random amount. Defaults to 1.0, effectively
 	// a random amount up to the full poll interval.
 	JitterFactor float64
 	// Flag indicating that failed activities should still be reported.
 	ReportFailedActivities bool // TODO Should this be part of the ActivityFeedQuery?
+	// Optional query whose application and scenario filters are applied to the
+	// items of the feed, in case the server does not apply them itself.
+	Filter *ActivityFeedQuery
+	// The number of recently seen item identifiers to remember in order to
+	// prevent duplicate delivery. Defaults to 1024, the window is always
+	// large enough to hold every item from the most recent poll.
+	DeduplicationWindow int
 
 	// The server may periodically request a longer delay.
 	rateLimit time.Duration
-	// The last feed item identifier acknowledged by this subscriber.
-	lastID string
+	// The current adaptive polling interval.
+	interval time.Duration
+	// The feed item identifiers already processed by this subscriber.
+	seen seenItems
 }
 
 // PollTimer returns a new timer for the next polling operation.
 func (s *PollingSubscriber) PollTimer() *time.Timer {
-	// Allow the default polling interval to be configured via an environment variable
-	interval := s.PollInterval
+	interval := s.interval
 	if interval <= 0 {
-		if d, err := time.ParseDuration(os.Getenv("STORMFORGE_API_POLL_INTERVAL")); err != nil {
-			// Default to 30 seconds
-			interval = 30 * time.Second
-		} else {
-			interval = d
-		}
+		interval = s.pollInterval()
 	}
 
 	// Default to a factor of 1.0 (i.e. a random value from 0 to a full extra interval)
@@ -89,6 +111,68 @@ func (s *PollingSubscriber) PollTimer() *time.Timer {
 	return time.NewTimer(interval + time.Duration(jitter))
 }
 
+// pollInterval returns the configured (non-adaptive) polling interval.
+func (s *PollingSubscriber) pollInterval() time.Duration {
+	if s.PollInterval > 0 {
+		return s.PollInterval
+	}
+
+	// Allow the default polling interval to be configured via an environment variable
+	if d, err := time.ParseDuration(os.Getenv("STORMFORGE_API_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+
+	// Default to 30 seconds
+	return 30 * time.Second
+}
+
+// feedQuery returns a new query for fetching the feed. The filters are included
+// so servers which support them are asked to apply them on every poll.
+func (s *PollingSubscriber) feedQuery() ActivityFeedQuery {
+	q := ActivityFeedQuery{}
+	if s.Filter != nil && len(s.Filter.Query) > 0 {
+		q.Query = make(map[string][]string, len(s.Filter.Query))
+		for k, v := range s.Filter.Query {
+			q.Query[k] = append([]string(nil), v...)
+		}
+	}
+	return q
+}
+
+// adapt applies exponential smoothing to the polling interval using the number
+// of new items found by the most recent poll.
+func (s *PollingSubscriber) adapt(n int) {
+	if s.MinPollInterval <= 0 && s.MaxPollInterval <= 0 {
+		return
+	}
+
+	// Busy feeds move towards the floor, idle feeds back off towards the ceiling
+	target := s.MaxPollInterval
+	if n > 0 {
+		target = s.MinPollInterval
+	}
+	if target <= 0 {
+		target = s.pollInterval()
+	}
+
+	alpha := s.SmoothingFactor
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.5
+	}
+
+	if s.interval <= 0 {
+		s.interval = s.pollInterval()
+	}
+	s.interval = time.Duration(alpha*float64(target) + (1-alpha)*float64(s.interval))
+
+	if s.MinPollInterval > 0 && s.interval < s.MinPollInterval {
+		s.interval = s.MinPollInterval
+	}
+	if s.MaxPollInterval > 0 && s.interval > s.MaxPollInterval {
+		s.interval = s.MaxPollInterval
+	}
+}
+
 // Subscribe polls for activity, blocking until the supplied context is finished
 // or a fatal error occurs talking to the activity endpoint.
 func (s *PollingSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityItem) error {
@@ -105,7 +189,7 @@ func (s *PollingSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityIte
 		}
 
 		// Fetch the feed and send new items to the channel
-		f, err := s.API.ListActivity(ctx, s.FeedURL, ActivityFeedQuery{})
+		f, err := s.API.ListActivity(ctx, s.FeedURL, s.feedQuery())
 		if err != nil {
 			var apiErr *api.Error
 			if errors.As(err, &apiErr) {
@@ -119,18 +203,135 @@ func (s *PollingSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityIte
 			return err
 		}
 
-		s.notify(f.Items, ch)
+		s.adapt(s.notify(f.Items, ch))
+	}
+}
+
+// LongPollSubscriber is a strategy that asks the server to hold each request open
+// until new activity is available. If the server does not appear to support long
+// polling, the subscriber falls back to regular polling.
+type LongPollSubscriber struct {
+	PollingSubscriber
+	// The maximum amount of time the server should hold each request open, this
+	// must be less than the API client's timeout. Defaults to half of the
+	// default client timeout.
+	Timeout time.Duration
+	// The number of consecutive client timeouts tolerated before falling back
+	// to regular polling. Defaults to 3.
+	MaxTimeouts int
+
+	// Flag indicating the server did not hold a request open.
+	unsupported bool
+}
+
+// Subscribe long polls for activity, blocking until the supplied context is
+// finished or a fatal error occurs talking to the activity endpoint.
+func (s *LongPollSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityItem) error {
+	if s.unsupported {
+		return s.PollingSubscriber.Subscribe(ctx, ch)
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = api.DefaultTimeout / 2
+	}
+
+	maxTimeouts := s.MaxTimeouts
+	if maxTimeouts <= 0 {
+		maxTimeouts = 3
+	}
+
+	q := s.feedQuery()
+	q.SetTimeout(timeout)
+
+	var timeouts int
+	for {
+		start := time.Now()
+		f, err := s.API.ListActivity(ctx, s.FeedURL, q)
+		if err != nil {
+			if ctx.Err() != nil {
+				close(ch)
+				return ctx.Err()
+			}
+
+			// The client may give up before the server does, back off before trying
+			// again and switch over to regular polling if it keeps happening
+			var apiErr *api.Error
+			if errors.As(err, &apiErr) && apiErr.Type == api.ErrTimeout {
+				if timeouts++; timeouts >= maxTimeouts {
+					s.unsupported = true
+					return s.PollingSubscriber.Subscribe(ctx, ch)
+				}
+				if err := s.wait(ctx); err != nil {
+					close(ch)
+					return err
+				}
+				continue
+			}
+
+			// Honor server requested delays before trying again
+			if errors.As(err, &apiErr) && apiErr.Type == ErrActivityRateLimited {
+				s.rateLimit = apiErr.RetryAfter
+				if err := s.wait(ctx); err != nil {
+					close(ch)
+					return err
+				}
+				continue
+			}
+
+			close(ch)
+			return err
+		}
+		timeouts = 0
+
+		// If the server responded early without anything new, it is not holding
+		// requests open: switch over to regular polling
+		if s.notify(f.Items, ch) == 0 && time.Since(start) < timeout/2 {
+			s.unsupported = true
+			return s.PollingSubscriber.Subscribe(ctx, ch)
+		}
+	}
+}
+
+// wait blocks until the next poll timer fires or the context is done.
+func (s *PollingSubscriber) wait(ctx context.Context) error {
+	t := s.PollTimer()
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
 }
 
 // notify sends all the items from the supplied feed to the channel.
-// IMPORTANT: this function assumes item identifiers can be compared lexicographically.
-func (s *PollingSubscriber) notify(items []ActivityItem, ch chan<- ActivityItem) {
+// Items are delivered in identifier order, however identifiers are not assumed
+// to increase monotonically (e.g. ULIDs generated by different shards may be
+// interleaved), so any item not previously seen is delivered exactly once. The
+// number of previously unseen items is returned.
+func (s *PollingSubscriber) notify(items []ActivityItem, ch chan<- ActivityItem) int {
+	// Make sure the window can hold the entire feed so nothing is redelivered
+	s.seen.size = s.DeduplicationWindow
+	if s.seen.size <= 0 {
+		s.seen.size = 1024
+	}
+	if s.seen.size < len(items) {
+		s.seen.size = len(items)
+	}
+
 	// Make sure the items are sorted by their identifier
 	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	var n int
 	for i := range items {
 		// Ignore items that we have already seen
-		if s.lastID != "" && items[i].ID <= s.lastID {
+		if !s.seen.add(items[i].ID) {
+			continue
+		}
+		n++
+
+		// Skip items that do not match the filters
+		if s.Filter != nil && !s.Filter.Matches(&items[i]) {
 			continue
 		}
 
@@ -139,8 +340,116 @@ func (s *PollingSubscriber) notify(items []ActivityItem, ch chan<- ActivityItem)
 			continue
 		}
 
-		// Send the item to the channel and update the last ID
+		// Send the item to the channel
 		ch <- items[i]
-		s.lastID = items[i].ID
+	}
+	return n
+}
+
+// seenItems is a bounded set of identifiers, the least recently seen
+// identifiers are evicted first.
+type seenItems struct {
+	size  int
+	order list.List
+	index map[string]*list.Element
+}
+
+// add records the supplied identifier, returning false if it was already present.
+func (s *seenItems) add(id string) bool {
+	if s.index == nil {
+		s.index = make(map[string]*list.Element)
+	}
+
+	if e, ok := s.index[id]; ok {
+		s.order.MoveToFront(e)
+		return false
+	}
+
+	s.index[id] = s.order.PushFront(id)
+	for s.size > 0 && s.order.Len() > s.size {
+		e := s.order.Back()
+		delete(s.index, e.Value.(string))
+		s.order.Remove(e)
+	}
+	return true
+}
+
+// Subscription dispatches the items produced by a subscriber to a handler. Use
+// Drain to gracefully shut down a subscription: polling stops immediately but
+// any queued items are still handled (e.g. acknowledged) before it returns.
+type Subscription struct {
+	stop  context.CancelFunc
+	abort context.CancelFunc
+	done  chan struct{}
+	err   error
+}
+
+// NewSubscription starts the supplied subscriber, invoking the handler for each
+// item. If the handler fails, polling stops and the error is reported by Err
+// once the remaining queued items have been handled.
+func NewSubscription(ctx context.Context, s Subscriber, handler func(context.Context, ActivityItem) error) *Subscription {
+	handlerCtx, abort := context.WithCancel(ctx)
+	pollCtx, stop := context.WithCancel(handlerCtx)
+	sub := &Subscription{stop: stop, abort: abort, done: make(chan struct{})}
+
+	ch := make(chan ActivityItem)
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Subscribe(pollCtx, ch) }()
+
+	go func() {
+		defer close(sub.done)
+		defer abort()
+
+		// Handle everything until the subscriber closes the channel
+		var handlerErr error
+		for item := range ch {
+			if err := handler(handlerCtx, item); err != nil && handlerErr == nil {
+				handlerErr = err
+				stop()
+			}
+		}
+
+		// Report the first error that was not caused by stopping the subscriber
+		sub.err = handlerErr
+		if err := <-errCh; sub.err == nil && err != nil && pollCtx.Err() == nil {
+			sub.err = err
+		}
+		if sub.err == nil && ctx.Err() != nil {
+			sub.err = ctx.Err()
+		}
+	}()
+
+	return sub
+}
+
+// Done returns a channel that is closed once the subscription has finished and
+// all of the queued items have been handled.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error which ended the subscription, if any. It is only valid
+// once the subscription is done.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Drain stops polling for new items and waits for queued items to be handled.
+// If the supplied context finishes first, in-flight handlers are canceled and
+// the context error is returned.
+func (s *Subscription) Drain(ctx context.Context) error {
+	s.stop()
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		s.abort()
+		<-s.done
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,359 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestPollingSubscriber_notify(t *testing.T) {
+	cases := []struct {
+		desc     string
+		window   int
+		polls    [][]string
+		expected []string
+	}{
+		{
+			desc:     "monotonic",
+			polls:    [][]string{{"01", "02"}, {"01", "02", "03"}},
+			expected: []string{"01", "02", "03"},
+		},
+		{
+			desc:     "interleaved",
+			polls:    [][]string{{"01", "03"}, {"01", "02", "03", "04"}},
+			expected: []string{"01", "03", "02", "04"},
+		},
+		{
+			desc:     "unsorted",
+			polls:    [][]string{{"03", "01"}, {"02", "03"}},
+			expected: []string{"01", "03", "02"},
+		},
+		{
+			desc:     "window grows with feed",
+			window:   1,
+			polls:    [][]string{{"01", "02", "03"}, {"01", "02", "03"}},
+			expected: []string{"01", "02", "03"},
+		},
+		{
+			desc:     "evicted",
+			window:   2,
+			polls:    [][]string{{"01", "02"}, {"03", "04"}, {"01"}},
+			expected: []string{"01", "02", "03", "04", "01"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &PollingSubscriber{DeduplicationWindow: c.window}
+			var actual []string
+			for _, poll := range c.polls {
+				items := make([]ActivityItem, 0, len(poll))
+				for _, id := range poll {
+					items = append(items, ActivityItem{ID: id})
+				}
+
+				ch := make(chan ActivityItem, len(items))
+				s.notify(items, ch)
+				close(ch)
+				for item := range ch {
+					actual = append(actual, item.ID)
+				}
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestPollingSubscriber_adapt(t *testing.T) {
+	cases := []struct {
+		desc       string
+		subscriber PollingSubscriber
+		counts     []int
+		expected   []time.Duration
+	}{
+		{
+			desc:       "disabled",
+			subscriber: PollingSubscriber{PollInterval: 10 * time.Second},
+			counts:     []int{1, 0},
+			expected:   []time.Duration{0, 0},
+		},
+		{
+			desc: "busy",
+			subscriber: PollingSubscriber{
+				PollInterval:    10 * time.Second,
+				MinPollInterval: 2 * time.Second,
+				MaxPollInterval: time.Minute,
+			},
+			counts:   []int{3, 1, 5},
+			expected: []time.Duration{6 * time.Second, 4 * time.Second, 3 * time.Second},
+		},
+		{
+			desc: "idle",
+			subscriber: PollingSubscriber{
+				PollInterval:    10 * time.Second,
+				MinPollInterval: 2 * time.Second,
+				MaxPollInterval: 50 * time.Second,
+				SmoothingFactor: 0.75,
+			},
+			counts:   []int{0, 0, 1},
+			expected: []time.Duration{40 * time.Second, 47500 * time.Millisecond, 13375 * time.Millisecond},
+		},
+		{
+			desc: "floor only",
+			subscriber: PollingSubscriber{
+				PollInterval:    10 * time.Second,
+				MinPollInterval: 2 * time.Second,
+				SmoothingFactor: 1,
+			},
+			counts:   []int{1, 0},
+			expected: []time.Duration{2 * time.Second, 10 * time.Second},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := c.subscriber
+			var actual []time.Duration
+			for _, n := range c.counts {
+				s.adapt(n)
+				actual = append(actual, s.interval)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestLongPollSubscriber_Subscribe(t *testing.T) {
+	// The server ignores the timeout and responds immediately, the third request
+	// should only be made after falling back to regular polling
+	var timeouts, apps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeouts = append(timeouts, r.URL.Query().Get("timeout"))
+		apps = append(apps, r.URL.Query().Get("application"))
+		w.Header().Set("Content-Type", "application/json")
+		switch len(timeouts) {
+		case 1, 2:
+			_, _ = fmt.Fprint(w, `{"items":[{"id":"01"}]}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"items":[{"id":"01"},{"id":"02"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	filter := &ActivityFeedQuery{}
+	filter.SetApplication("my-app")
+	s := &LongPollSubscriber{
+		PollingSubscriber: PollingSubscriber{
+			API:          NewAPI(client),
+			FeedURL:      srv.URL,
+			PollInterval: time.Millisecond,
+			Filter:       filter,
+		},
+		Timeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := make(chan ActivityItem)
+	done := make(chan error, 1)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for item := range ch {
+		ids = append(ids, item.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []string{"01", "02"}, ids)
+	assert.Equal(t, []string{"1m0s", "1m0s", ""}, timeouts[:3])
+	assert.Equal(t, []string{"my-app", "my-app", "my-app"}, apps[:3])
+	assert.Equal(t, url.Values{"application": {"my-app"}}, url.Values(filter.Query))
+}
+
+func TestLongPollSubscriber_Subscribe_defaultTimeout(t *testing.T) {
+	// The server holds the first request open for as long as it is asked to,
+	// the client must not give up before the server responds
+	var timeouts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := r.URL.Query().Get("timeout")
+		timeouts = append(timeouts, timeout)
+		w.Header().Set("Content-Type", "application/json")
+		if len(timeouts) == 1 {
+			d, err := time.ParseDuration(timeout)
+			if !assert.NoError(t, err) {
+				return
+			}
+			time.Sleep(d)
+			_, _ = fmt.Fprint(w, `{"items":[{"id":"01"}]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"items":[{"id":"01"},{"id":"02"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	s := &LongPollSubscriber{
+		PollingSubscriber: PollingSubscriber{
+			API:          NewAPI(client),
+			FeedURL:      srv.URL,
+			PollInterval: time.Millisecond,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*api.DefaultTimeout)
+	defer cancel()
+
+	ch := make(chan ActivityItem)
+	done := make(chan error, 1)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for item := range ch {
+		ids = append(ids, item.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []string{"01", "02"}, ids)
+	assert.Equal(t, []string{"5s", "5s"}, timeouts[:2])
+	assert.False(t, s.unsupported)
+}
+
+func TestLongPollSubscriber_Subscribe_clientTimeout(t *testing.T) {
+	// The client gives up on every long poll before the server responds, after
+	// backing off a few times the subscriber should fall back to regular polling
+	var timeouts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := r.URL.Query().Get("timeout")
+		timeouts = append(timeouts, timeout)
+		if timeout != "" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"items":[{"id":"01"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil, api.WithTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+
+	s := &LongPollSubscriber{
+		PollingSubscriber: PollingSubscriber{
+			API:          NewAPI(client),
+			FeedURL:      srv.URL,
+			PollInterval: time.Millisecond,
+		},
+		Timeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := make(chan ActivityItem)
+	done := make(chan error, 1)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for item := range ch {
+		ids = append(ids, item.ID)
+		cancel()
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []string{"01"}, ids)
+	assert.Equal(t, []string{"1m0s", "1m0s", "1m0s", ""}, timeouts[:4])
+	assert.True(t, s.unsupported)
+}
+
+// fakeSubscriber sends a fixed list of items and then waits to be stopped.
+type fakeSubscriber []string
+
+func (s fakeSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityItem) error {
+	defer close(ch)
+	for _, id := range s {
+		ch <- ActivityItem{ID: id}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestSubscription_Drain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drained", func(t *testing.T) {
+		received := make(chan string, 3)
+		sub := NewSubscription(ctx, fakeSubscriber{"1", "2", "3"}, func(_ context.Context, item ActivityItem) error {
+			received <- item.ID
+			return nil
+		})
+
+		for i := 0; i < 3; i++ {
+			<-received
+		}
+		assert.NoError(t, sub.Drain(ctx))
+		assert.NoError(t, sub.Err())
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		var handled []string
+		sub := NewSubscription(ctx, fakeSubscriber{"1", "2"}, func(_ context.Context, item ActivityItem) error {
+			handled = append(handled, item.ID)
+			return fmt.Errorf("failed %s", item.ID)
+		})
+
+		<-sub.Done()
+		assert.EqualError(t, sub.Err(), "failed 1")
+		assert.Equal(t, []string{"1", "2"}, handled)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		started := make(chan struct{})
+		sub := NewSubscription(ctx, fakeSubscriber{"1"}, func(ctx context.Context, item ActivityItem) error {
+			close(started)
+			<-ctx.Done()
+			return nil
+		})
+
+		<-started
+		drainCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, sub.Drain(drainCtx), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// TestCase is a StormForge Performance test case which can be used by scenarios.
+type TestCase struct {
+	api.Metadata `json:"-"`
+	// The name of the test case.
+	Name string `json:"name"`
+	// The title of the test case.
+	DisplayName string `json:"title,omitempty"`
+}
+
+type TestCaseListQuery struct{ api.IndexQuery }
+
+type TestCaseItem struct {
+	TestCase
+}
+
+func (ti *TestCaseItem) UnmarshalJSON(b []byte) error {
+	type t TestCaseItem
+	return api.UnmarshalJSON(b, (*t)(ti))
+}
+
+type TestCaseList struct {
+	// The test case list metadata.
+	api.Metadata `json:"-"`
+	// The total number of items in the collection.
+	TotalCount int `json:"totalCount,omitempty"`
+	// The list of test cases.
+	Items []TestCaseItem `json:"items"`
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestListTestCases(t *testing.T) {
+	available := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/applications/" {
+			return
+		}
+		if !available || r.URL.Path != "/v2/performance/test-cases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"totalCount":1,"items":[{"name":"checkout","title":"Checkout Flow"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	appAPI := NewAPI(client)
+	ctx := context.Background()
+
+	list, err := appAPI.ListTestCases(ctx, TestCaseListQuery{})
+	require.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "checkout", list.Items[0].Name)
+		assert.Equal(t, "Checkout Flow", list.Items[0].DisplayName)
+	}
+
+	available = false
+	_, err = appAPI.ListTestCases(ctx, TestCaseListQuery{})
+	var apiErr *api.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, ErrTestCasesNotFound, apiErr.Type)
+	}
+}
+
+func TestListTestCases_endpoint(t *testing.T) {
+	var requested string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/applications/" {
+			w.Header().Add("Link", `</perf/v1/test-cases>; rel="`+api.RelationTestCases+`"`)
+			return
+		}
+		requested = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = NewAPI(client).ListTestCases(ctx, TestCaseListQuery{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/perf/v1/test-cases", requested)
+	}
+
+	_, err = NewAPIWithEndpoints(client, Endpoints{TestCases: srv.URL + "/override/test-cases/"}).ListTestCases(ctx, TestCaseListQuery{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/override/test-cases", requested)
+	}
+}
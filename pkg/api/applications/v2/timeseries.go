@@ -0,0 +1,147 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContainerResourcesPoint is the recommended resources of a container at a point in time.
+type ContainerResourcesPoint struct {
+	// The time the recommendation was deployed, zero if it was never deployed.
+	Time time.Time `json:"time,omitempty"`
+	// The name of the recommendation.
+	Recommendation string `json:"recommendation"`
+	// The recommended requests.
+	Requests *ResourceList `json:"requests,omitempty"`
+	// The recommended limits.
+	Limits *ResourceList `json:"limits,omitempty"`
+}
+
+// ContainerTimeSeries is the history of recommendations for a single container.
+type ContainerTimeSeries struct {
+	// The workload the container belongs to.
+	Target TargetRef `json:"target"`
+	// The name of the container.
+	Container string `json:"container"`
+	// The recommended values ordered by time.
+	Points []ContainerResourcesPoint `json:"points"`
+}
+
+// containerResources is the expected representation of container resources parameters.
+type containerResources struct {
+	Name          string        `json:"name"`
+	ContainerName string        `json:"containerName"`
+	Requests      *ResourceList `json:"requests"`
+	Limits        *ResourceList `json:"limits"`
+}
+
+// parseContainerResources converts an untyped container resources parameter.
+func parseContainerResources(cr interface{}) (containerResources, bool) {
+	c := containerResources{}
+
+	// The container resources are untyped, round-trip them through JSON
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return c, false
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, false
+	}
+	if c.Name == "" {
+		c.Name = c.ContainerName
+	}
+	return c, true
+}
+
+// NewContainerTimeSeries pivots a list of recommendations into a time series
+// per workload container. The optional filter is used to select workloads.
+func NewContainerTimeSeries(recs []RecommendationItem, filter func(TargetRef) bool) []ContainerTimeSeries {
+	type key struct {
+		target    TargetRef
+		container string
+	}
+
+	index := make(map[key]int)
+	var result []ContainerTimeSeries
+	for i := range recs {
+		for _, p := range recs[i].Parameters {
+			if filter != nil && !filter(p.Target) {
+				continue
+			}
+
+			for _, cr := range p.ContainerResources {
+				c, ok := parseContainerResources(cr)
+				if !ok {
+					continue
+				}
+
+				k := key{target: p.Target, container: c.Name}
+				n, ok := index[k]
+				if !ok {
+					n = len(result)
+					index[k] = n
+					result = append(result, ContainerTimeSeries{Target: p.Target, Container: c.Name})
+				}
+
+				point := ContainerResourcesPoint{
+					Recommendation: recs[i].Name,
+					Requests:       c.Requests,
+					Limits:         c.Limits,
+				}
+				if recs[i].DeployedAt != nil {
+					point.Time = *recs[i].DeployedAt
+				}
+				result[n].Points = append(result[n].Points, point)
+			}
+		}
+	}
+
+	for i := range result {
+		points := result[i].Points
+		sort.SliceStable(points, func(i, j int) bool {
+			if !points[i].Time.Equal(points[j].Time) {
+				return points[i].Time.Before(points[j].Time)
+			}
+			return points[i].Recommendation < points[j].Recommendation
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if a, b := result[i].Target.String(), result[j].Target.String(); a != b {
+			return a < b
+		}
+		return result[i].Container < result[j].Container
+	})
+
+	return result
+}
+
+// String returns the "namespace/kind/workload" representation of the target.
+func (t TargetRef) String() string {
+	var parts []string
+	if t.Namespace != "" {
+		parts = append(parts, t.Namespace)
+	}
+	if t.Kind != "" {
+		parts = append(parts, strings.ToLower(t.Kind))
+	}
+	return strings.Join(append(parts, t.Workload), "/")
+}
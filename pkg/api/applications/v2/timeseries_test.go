@@ -0,0 +1,106 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContainerTimeSeries(t *testing.T) {
+	data := []byte(`{
+  "recommendations": [
+    {
+      "name": "rec-2",
+      "deployedAt": "2023-05-02T00:00:00Z",
+      "parameters": [
+        {
+          "target": {"kind": "Deployment", "namespace": "default", "workload": "foo"},
+          "containerResources": [{"name": "app", "requests": {"cpu": 200, "memory": "256Mi"}}]
+        },
+        {
+          "target": {"kind": "Deployment", "namespace": "default", "workload": "bar"},
+          "containerResources": [{"name": "app", "requests": {"cpu": 50}}]
+        }
+      ]
+    },
+    {
+      "name": "rec-1",
+      "deployedAt": "2023-05-01T00:00:00Z",
+      "parameters": [
+        {
+          "target": {"kind": "Deployment", "namespace": "default", "workload": "foo"},
+          "containerResources": [
+            {"name": "app", "requests": {"cpu": 100, "memory": "128Mi"}, "limits": {"memory": "256Mi"}},
+            {"name": "sidecar", "requests": {"cpu": 10}}
+          ]
+        }
+      ]
+    }
+  ]
+}`)
+
+	lst := RecommendationList{}
+	require.NoError(t, json.Unmarshal(data, &lst))
+
+	series := NewContainerTimeSeries(lst.Recommendations, func(ref TargetRef) bool {
+		return ref.Workload == "foo"
+	})
+	require.Len(t, series, 2)
+
+	assert.Equal(t, "default/deployment/foo", series[0].Target.String())
+	assert.Equal(t, "app", series[0].Container)
+	if assert.Len(t, series[0].Points, 2) {
+		assert.Equal(t, "rec-1", series[0].Points[0].Recommendation)
+		assert.Equal(t, "100", series[0].Points[0].Requests.CPU.String())
+		assert.Equal(t, "256Mi", series[0].Points[0].Limits.Memory.String())
+		assert.Equal(t, "rec-2", series[0].Points[1].Recommendation)
+		assert.Equal(t, "256Mi", series[0].Points[1].Requests.Memory.String())
+	}
+
+	assert.Equal(t, "sidecar", series[1].Container)
+	assert.Len(t, series[1].Points, 1)
+}
+
+func TestRecommendation_Containers(t *testing.T) {
+	data := []byte(`{
+  "name": "rec-1",
+  "parameters": [
+    {
+      "target": {"kind": "Deployment", "namespace": "default", "workload": "foo"},
+      "containerResources": [
+        {"containerName": "app", "requests": {"cpu": 100, "memory": "128Mi"}},
+        "invalid"
+      ]
+    }
+  ]
+}`)
+
+	rec := Recommendation{}
+	require.NoError(t, json.Unmarshal(data, &rec))
+
+	containers := rec.Containers()
+	if assert.Len(t, containers, 1) {
+		assert.Equal(t, "default/deployment/foo", containers[0].Target.String())
+		assert.Equal(t, "app", containers[0].Container)
+		assert.Equal(t, "128Mi", containers[0].Requests.Memory.String())
+		assert.Nil(t, containers[0].Limits)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerState represents the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed indicates requests are allowed.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen indicates requests are rejected without being sent.
+	BreakerOpen
+	// BreakerHalfOpen indicates a single probe request is allowed to test the server.
+	BreakerHalfOpen
+)
+
+// String returns the name of the breaker state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker prevents a client from overwhelming a degraded server. After
+// a number of consecutive failures (server errors or transport errors) the
+// breaker opens and requests fail immediately with an `ErrCircuitOpen` error.
+// Once the cool down period has elapsed, a single probe request is allowed
+// through: if it succeeds the breaker closes, otherwise it opens again.
+type CircuitBreaker struct {
+	// The number of consecutive failures required to open the breaker. Defaults to 5.
+	Threshold int
+	// The amount of time the breaker stays open before allowing a probe. Defaults to 30 seconds.
+	Cooldown time.Duration
+	// Optional callback invoked whenever the state of the breaker changes.
+	OnStateChange func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	changes  [][2]BreakerState
+	now      func() time.Time
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.clock().Before(b.openedAt.Add(b.cooldown())) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow returns an error if a request should not be sent.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.openedAt.Add(b.cooldown()).Sub(b.clock())
+		if remaining > 0 {
+			return &Error{Type: ErrCircuitOpen, Message: "circuit breaker is open, the server is unavailable", RetryAfter: remaining}
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+
+	case BreakerHalfOpen:
+		if b.probing {
+			return &Error{Type: ErrCircuitOpen, Message: "circuit breaker is half-open, waiting for the server to recover", RetryAfter: b.cooldown()}
+		}
+		b.probing = true
+		return nil
+	}
+
+	return nil
+}
+
+// record updates the state of the breaker using the outcome of a request.
+func (b *CircuitBreaker) record(resp *http.Response, err error) {
+	// Canceled requests do not say anything about the server
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold() {
+		b.openedAt = b.clock()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState changes the state of the breaker, the lock must be held.
+func (b *CircuitBreaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.changes = append(b.changes, [2]BreakerState{b.state, s})
+	b.state = s
+}
+
+// unlock releases the lock and then reports any state changes.
+func (b *CircuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	if b.OnStateChange != nil {
+		for _, c := range changes {
+			b.OnStateChange(c[0], c[1])
+		}
+	}
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.Threshold > 0 {
+		return b.Threshold
+	}
+	return 5
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown > 0 {
+		return b.Cooldown
+	}
+	return 30 * time.Second
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
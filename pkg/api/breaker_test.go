@@ -0,0 +1,118 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	var changes []string
+	b := &CircuitBreaker{
+		Threshold: 2,
+		Cooldown:  time.Minute,
+		OnStateChange: func(from, to BreakerState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+		now: func() time.Time { return now },
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK}
+	unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	// Failures below the threshold do not open the breaker
+	require.NoError(t, b.allow())
+	b.record(unavailable, nil)
+	require.NoError(t, b.allow())
+	b.record(ok, nil)
+	require.NoError(t, b.allow())
+	b.record(unavailable, nil)
+	assert.Equal(t, BreakerClosed, b.State())
+
+	// Consecutive failures open the breaker
+	require.NoError(t, b.allow())
+	b.record(nil, errors.New("connection refused"))
+	assert.Equal(t, BreakerOpen, b.State())
+
+	err := b.allow()
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrCircuitOpen, apiErr.Type)
+		assert.Equal(t, time.Minute, apiErr.RetryAfter)
+	}
+
+	// After the cool down, only a single probe is allowed
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	require.NoError(t, b.allow())
+	assert.Error(t, b.allow())
+
+	// A failed probe re-opens the breaker
+	b.record(unavailable, nil)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// A canceled probe does not count against the server
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	b.record(nil, context.Canceled)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	// A successful probe closes the breaker
+	require.NoError(t, b.allow())
+	b.record(ok, nil)
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, changes)
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	b := &CircuitBreaker{Threshold: 1, Cooldown: time.Hour}
+	client, err := NewClient(srv.URL, nil, WithCircuitBreaker(b))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		_, _, _ = client.Do(ctx, req)
+	}
+
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, BreakerOpen, b.State())
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResponseCache stores the validators (`ETag` and `Last-Modified` headers) and
+// bodies of successful GET requests. When a cached resource is requested again
+// a conditional request is sent and the cached body is returned if the server
+// responds with "304 Not Modified".
+type ResponseCache struct {
+	// The maximum number of responses to retain. Defaults to 100.
+	MaxEntries int
+	// The file used to persist responses between processes, responses are
+	// only kept in memory if empty. Failures to read or write the file are
+	// treated as cache misses.
+	Filename string
+	// Indicates credentials are added to every request by the transport (and
+	// are therefore not visible to the cache). Responses to authorized requests
+	// are only stored if the server explicitly allows it using the `public`,
+	// `s-maxage` or `must-revalidate` cache directives.
+	Authorized bool
+
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]*cacheEntry
+	keys    []string
+	vary    map[string][]string
+}
+
+// cacheEntry is a single cached response.
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// cacheFile is the persisted representation of the cache.
+type cacheFile struct {
+	Keys    []string               `json:"keys"`
+	Entries map[string]*cacheEntry `json:"entries"`
+	Vary    map[string][]string    `json:"vary,omitempty"`
+}
+
+// WithResponseCache returns a client option which uses the supplied cache to
+// send conditional requests for previously fetched resources.
+func WithResponseCache(rc *ResponseCache) ClientOption {
+	return func(c *httpClient) { c.cache = rc }
+}
+
+// Len returns the number of cached responses.
+func (rc *ResponseCache) Len() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.load()
+	return len(rc.entries)
+}
+
+// prepare returns the request to send, adding conditional headers if a cached
+// response exists for the requested resource.
+func (rc *ResponseCache) prepare(req *http.Request) *http.Request {
+	if !cacheable(req) {
+		return req
+	}
+
+	rc.mu.Lock()
+	rc.load()
+	e := rc.entries[cacheKey(req, rc.vary[req.URL.String()])]
+	rc.mu.Unlock()
+	if e == nil {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	if etag := e.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return req
+}
+
+// update records the response to a request, returning the cached response in
+// place of a "304 Not Modified".
+func (rc *ResponseCache) update(req *http.Request, resp *http.Response, body []byte) (*http.Response, []byte) {
+	u := req.URL.String()
+
+	// Any successful change to the resource invalidates the cached responses
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		if resp.StatusCode < 300 {
+			rc.remove(u)
+		}
+		return resp, body
+	}
+
+	if !cacheable(req) {
+		return resp, body
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		rc.mu.Lock()
+		rc.load()
+		e := rc.entries[cacheKey(req, rc.vary[u])]
+		rc.mu.Unlock()
+		if e == nil {
+			return resp, body
+		}
+
+		// Headers on the 304 response take precedence over the stored ones
+		header := e.Header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+
+		cached := *resp
+		cached.StatusCode = http.StatusOK
+		cached.Status = "200 OK"
+		cached.Header = header
+		cached.ContentLength = int64(len(e.Body))
+		return &cached, e.Body
+
+	case http.StatusOK:
+		vary, ok := varyFields(resp.Header)
+		if !ok || !rc.storable(req, resp) {
+			rc.remove(u)
+			return resp, body
+		}
+		rc.add(cacheKey(req, vary), vary, &cacheEntry{URL: u, Header: resp.Header.Clone(), Body: body})
+	}
+
+	return resp, body
+}
+
+// storable checks if a successful response may be stored.
+func (rc *ResponseCache) storable(req *http.Request, resp *http.Response) bool {
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return false
+	}
+
+	directives := cacheControl(resp.Header)
+	if directives["no-store"] {
+		return false
+	}
+
+	if rc.Authorized || req.Header.Get("Authorization") != "" {
+		return directives["public"] || directives["s-maxage"] || directives["must-revalidate"]
+	}
+	return true
+}
+
+// add stores a cache entry, evicting the oldest entries if necessary.
+func (rc *ResponseCache) add(key string, vary []string, e *cacheEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.load()
+
+	if rc.entries == nil {
+		rc.entries = make(map[string]*cacheEntry)
+	}
+	if rc.vary == nil {
+		rc.vary = make(map[string][]string)
+	}
+
+	// A change to the varying headers invalidates the other variants
+	if !equalFields(rc.vary[e.URL], vary) {
+		rc.removeURL(e.URL)
+	}
+	if len(vary) > 0 {
+		rc.vary[e.URL] = vary
+	} else {
+		delete(rc.vary, e.URL)
+	}
+
+	if _, ok := rc.entries[key]; !ok {
+		rc.keys = append(rc.keys, key)
+	}
+	rc.entries[key] = e
+
+	maxEntries := rc.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 100
+	}
+	for len(rc.keys) > maxEntries {
+		delete(rc.entries, rc.keys[0])
+		rc.keys = rc.keys[1:]
+	}
+
+	rc.save()
+}
+
+// remove discards the cache entries for a URL.
+func (rc *ResponseCache) remove(u string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.load()
+
+	if rc.removeURL(u) {
+		delete(rc.vary, u)
+		rc.save()
+	}
+}
+
+// removeURL discards the cache entries for a URL, the lock must be held.
+func (rc *ResponseCache) removeURL(u string) bool {
+	var removed bool
+	keys := rc.keys[:0]
+	for _, key := range rc.keys {
+		if e := rc.entries[key]; e != nil && e.URL == u {
+			delete(rc.entries, key)
+			removed = true
+			continue
+		}
+		keys = append(keys, key)
+	}
+	rc.keys = keys
+	return removed
+}
+
+// load reads the persisted responses the first time it is called, the lock
+// must be held.
+func (rc *ResponseCache) load() {
+	if rc.loaded {
+		return
+	}
+	rc.loaded = true
+
+	if rc.Filename == "" {
+		return
+	}
+
+	data, err := os.ReadFile(rc.Filename)
+	if err != nil {
+		return
+	}
+
+	cf := cacheFile{}
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return
+	}
+
+	for _, key := range cf.Keys {
+		if e := cf.Entries[key]; e != nil {
+			if rc.entries == nil {
+				rc.entries = make(map[string]*cacheEntry)
+			}
+			rc.entries[key] = e
+			rc.keys = append(rc.keys, key)
+		}
+	}
+	rc.vary = cf.Vary
+}
+
+// save writes the persisted responses, the lock must be held.
+func (rc *ResponseCache) save() {
+	if rc.Filename == "" {
+		return
+	}
+
+	data, err := json.Marshal(&cacheFile{Keys: rc.keys, Entries: rc.entries, Vary: rc.vary})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rc.Filename), 0700); err != nil {
+		return
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial cache
+	f, err := os.CreateTemp(filepath.Dir(rc.Filename), filepath.Base(rc.Filename)+".*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), rc.Filename)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+// cacheable checks if the request is a candidate for caching. Requests which
+// already include their own conditional headers are left alone.
+func cacheable(req *http.Request) bool {
+	return req.Method == http.MethodGet &&
+		req.Header.Get("If-None-Match") == "" &&
+		req.Header.Get("If-Modified-Since") == "" &&
+		req.Header.Get("Range") == ""
+}
+
+// cacheKey returns the key of a request. Requests for the same URL are stored
+// separately if they differ in the headers added from the context or in the
+// request headers named by the `Vary` header of the response.
+func cacheKey(req *http.Request, vary []string) string {
+	fields := append([]string(nil), vary...)
+	for k := range HeaderFromContext(req.Context()) {
+		fields = append(fields, http.CanonicalHeaderKey(k))
+	}
+	if len(fields) == 0 {
+		return req.URL.String()
+	}
+	sort.Strings(fields)
+
+	var sb strings.Builder
+	sb.WriteString(req.URL.String())
+	for i, f := range fields {
+		if i > 0 && fields[i-1] == f {
+			continue
+		}
+		sb.WriteString("\n" + f + ": " + strings.Join(req.Header.Values(f), ", "))
+	}
+	return sb.String()
+}
+
+// varyFields returns the canonical request header names listed by the `Vary`
+// header of a response; a response varying on "*" cannot be cached.
+func varyFields(h http.Header) ([]string, bool) {
+	var fields []string
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			switch f = strings.TrimSpace(f); f {
+			case "":
+			case "*":
+				return nil, false
+			default:
+				fields = append(fields, http.CanonicalHeaderKey(f))
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields, true
+}
+
+// cacheControl returns the names of the `Cache-Control` directives of a response.
+func cacheControl(h http.Header) map[string]bool {
+	directives := make(map[string]bool)
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+			directives[strings.ToLower(name)] = true
+		}
+	}
+	return directives
+}
+
+// equalFields checks if two sorted lists of header names are the same.
+func equalFields(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResponseCache(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"test"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	rc := &ResponseCache{}
+	client, err := NewClient(srv.URL, nil, WithResponseCache(rc))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	get := func() (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/test", nil)
+		require.NoError(t, err)
+		resp, body, err := client.Do(ctx, req)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	resp, body := get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"name":"test"}`, string(body))
+	assert.Equal(t, 1, rc.Len())
+
+	resp, body = get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"name":"test"}`, string(body))
+	assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+	assert.Equal(t, 1, notModified)
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL+"/test", nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rc.Len())
+
+	_, body = get()
+	assert.Equal(t, `{"name":"test"}`, string(body))
+	assert.Equal(t, 1, notModified)
+	assert.Equal(t, 4, requests)
+}
+
+func TestResponseCache_MaxEntries(t *testing.T) {
+	rc := &ResponseCache{MaxEntries: 2}
+	rc.add("a", nil, &cacheEntry{URL: "a"})
+	rc.add("b", nil, &cacheEntry{URL: "b"})
+	rc.add("a", nil, &cacheEntry{URL: "a"})
+	rc.add("c", nil, &cacheEntry{URL: "c"})
+	assert.Equal(t, 2, rc.Len())
+	assert.NotContains(t, rc.entries, "a")
+	assert.Contains(t, rc.entries, "c")
+}
+
+func TestResponseCache_key(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := `"` + r.Header.Get("Accept-Language") + r.Header.Get("X-Tenant") + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Accept-Language")
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(etag))
+	}))
+	defer srv.Close()
+
+	rc := &ResponseCache{}
+	client, err := NewClient(srv.URL, nil, WithResponseCache(rc))
+	require.NoError(t, err)
+
+	get := func(ctx context.Context, lang string) string {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/test", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", lang)
+		_, body, err := client.Do(ctx, req)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	ctx := context.Background()
+	tenantCtx := WithHeader(ctx, "X-Tenant", "a")
+
+	assert.Equal(t, `"en"`, get(ctx, "en"))
+	assert.Equal(t, `"de"`, get(ctx, "de"))
+	assert.Equal(t, `"ena"`, get(tenantCtx, "en"))
+	assert.Equal(t, 0, notModified)
+	assert.Equal(t, 3, rc.Len())
+
+	assert.Equal(t, `"en"`, get(ctx, "en"))
+	assert.Equal(t, `"de"`, get(ctx, "de"))
+	assert.Equal(t, `"ena"`, get(tenantCtx, "en"))
+	assert.Equal(t, 3, notModified)
+	assert.Equal(t, 6, requests)
+}
+
+func TestResponseCache_authorized(t *testing.T) {
+	cases := []struct {
+		desc         string
+		authorized   bool
+		cacheControl string
+		expected     int
+	}{
+		{desc: "anonymous", expected: 1},
+		{desc: "authorized", authorized: true, expected: 0},
+		{desc: "authorized public", authorized: true, cacheControl: "public, max-age=0", expected: 1},
+		{desc: "authorized must revalidate", authorized: true, cacheControl: "no-cache, must-revalidate", expected: 1},
+		{desc: "authorized shared", authorized: true, cacheControl: "s-maxage=60", expected: 1},
+		{desc: "authorized private", authorized: true, cacheControl: "private", expected: 0},
+		{desc: "no store", cacheControl: "public, no-store", expected: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if c.cacheControl != "" {
+					w.Header().Set("Cache-Control", c.cacheControl)
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			rc := &ResponseCache{Authorized: c.authorized}
+			client, err := NewClient(srv.URL, nil, WithResponseCache(rc))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/test", nil)
+			require.NoError(t, err)
+			_, _, err = client.Do(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, rc.Len())
+		})
+	}
+}
+
+func TestResponseCache_Filename(t *testing.T) {
+	var notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"test"}`))
+	}))
+	defer srv.Close()
+
+	filename := filepath.Join(t.TempDir(), "cache", "responses.json")
+	get := func() string {
+		client, err := NewClient(srv.URL, nil, WithResponseCache(&ResponseCache{Filename: filename}))
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/test", nil)
+		require.NoError(t, err)
+		_, body, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// Each client has a new cache instance, the second request must be conditional
+	assert.Equal(t, `{"name":"test"}`, get())
+	assert.FileExists(t, filename)
+	assert.Equal(t, `{"name":"test"}`, get())
+	assert.Equal(t, 1, notModified)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// limitHeaderPrefix is the prefix of headers used to advertise server limits.
+const limitHeaderPrefix = "Limit-"
+
+// Capabilities describes the features advertised by a server endpoint, for
+// example in response to an OPTIONS request.
+type Capabilities struct {
+	// The HTTP methods allowed on the endpoint.
+	Methods []string `json:"methods,omitempty"`
+	// The version of the server API.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// The oldest client version supported by the server.
+	MinimumClientVersion string `json:"minimumClientVersion,omitempty"`
+	// Numeric limits advertised by the server (e.g. "page-size" from a
+	// `Limit-Page-Size` header), keyed by lower case name.
+	Limits map[string]int64 `json:"limits,omitempty"`
+}
+
+// Capabilities returns the capabilities advertised using the `Allow`,
+// `Api-Version`, `Minimum-Client-Version` and `Limit-*` headers.
+func (m Metadata) Capabilities() Capabilities {
+	h := http.Header(m)
+	c := Capabilities{
+		APIVersion:           h.Get("Api-Version"),
+		MinimumClientVersion: m.MinimumClientVersion(),
+	}
+
+	for _, v := range h.Values("Allow") {
+		for _, method := range strings.Split(v, ",") {
+			if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+				c.Methods = append(c.Methods, method)
+			}
+		}
+	}
+
+	for k := range h {
+		if !strings.HasPrefix(k, limitHeaderPrefix) {
+			continue
+		}
+		if n, err := strconv.ParseInt(h.Get(k), 10, 64); err == nil {
+			if c.Limits == nil {
+				c.Limits = make(map[string]int64)
+			}
+			c.Limits[strings.ToLower(strings.TrimPrefix(k, limitHeaderPrefix))] = n
+		}
+	}
+
+	return c
+}
+
+// Allows checks if the endpoint supports the supplied method. Servers which do
+// not advertise their methods are assumed to support everything.
+func (c *Capabilities) Allows(method string) bool {
+	if len(c.Methods) == 0 {
+		return true
+	}
+	for _, m := range c.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Limit returns a named limit advertised by the server.
+func (c *Capabilities) Limit(name string) (int64, bool) {
+	n, ok := c.Limits[strings.ToLower(name)]
+	return n, ok
+}
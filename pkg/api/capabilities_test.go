@@ -0,0 +1,51 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_Capabilities(t *testing.T) {
+	h := http.Header{}
+	h.Set("Allow", "GET, head,POST")
+	h.Set("Api-Version", "v1alpha1")
+	h.Set("Minimum-Client-Version", "v1.2.0")
+	h.Set("Limit-Page-Size", "500")
+	h.Set("Limit-Invalid", "lots")
+
+	c := Metadata(h).Capabilities()
+	assert.Equal(t, []string{"GET", "HEAD", "POST"}, c.Methods)
+	assert.Equal(t, "v1alpha1", c.APIVersion)
+	assert.Equal(t, "v1.2.0", c.MinimumClientVersion)
+	assert.Equal(t, map[string]int64{"page-size": 500}, c.Limits)
+
+	assert.True(t, c.Allows(http.MethodPost))
+	assert.False(t, c.Allows(http.MethodDelete))
+
+	n, ok := c.Limit("Page-Size")
+	assert.True(t, ok)
+	assert.Equal(t, int64(500), n)
+}
+
+func TestCapabilities_Allows(t *testing.T) {
+	c := Metadata{}.Capabilities()
+	assert.True(t, c.Allows(http.MethodDelete), "unadvertised methods should be allowed")
+}
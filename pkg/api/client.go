@@ -18,6 +18,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -32,25 +33,77 @@ type Client interface {
 	Do(context.Context, *http.Request) (*http.Response, []byte, error)
 }
 
-// NewClient returns a new client for accessing API server.
-func NewClient(address string, transport http.RoundTripper) (Client, error) {
+// ClientOption is used to customize the behavior of a client.
+type ClientOption func(*httpClient)
+
+// WithCircuitBreaker returns a client option which rejects requests while the
+// supplied circuit breaker is open.
+func WithCircuitBreaker(b *CircuitBreaker) ClientOption {
+	return func(c *httpClient) { c.breaker = b }
+}
+
+// WithReadOnly returns a client option which rejects any request other than a
+// GET or HEAD before it is sent, guaranteeing the client never changes state.
+func WithReadOnly() ClientOption {
+	return func(c *httpClient) { c.readOnly = true }
+}
+
+// WithMaxResponseSize returns a client option which fails any request whose
+// response body exceeds n bytes with an `ErrResponseTooLarge` error instead of
+// buffering the entire body. A value of zero or less removes the limit.
+func WithMaxResponseSize(n int64) ClientOption {
+	return func(c *httpClient) { c.maxResponseSize = n }
+}
+
+// DefaultTimeout is the limit on the duration of each request attempt made by
+// clients which were not created using the `WithTimeout` option.
+const DefaultTimeout = 10 * time.Second
+
+// WithTimeout returns a client option which limits the total duration of each
+// request attempt, including reading the response body. A value of zero
+// removes the limit.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *httpClient) { c.client.Timeout = d }
+}
+
+// NewClient returns a new client for accessing API server. If the transport is
+// nil, a transport tuned using the `DefaultTransportOptions` is used.
+func NewClient(address string, transport http.RoundTripper, opts ...ClientOption) (Client, error) {
 	u, err := url.Parse(address)
 	if err != nil {
 		return nil, err
 	}
 
-	return &httpClient{
+	if transport == nil {
+		transport = NewTransport(DefaultTransportOptions)
+	}
+
+	c := &httpClient{
 		client: http.Client{
 			Transport: transport,
-			Timeout:   10 * time.Second, // TODO This should be configurable, e.g. for debugging
+			Timeout:   DefaultTimeout, // Override using WithTimeout, e.g. for debugging
 		},
 		base: *u,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 type httpClient struct {
-	client http.Client
-	base   url.URL
+	client  http.Client
+	base    url.URL
+	breaker *CircuitBreaker
+	retry   RetryPolicy
+	cache   *ResponseCache
+
+	readOnly bool
+
+	maxResponseSize int64
+
+	rateLimitFunc func(RateLimit)
+	warningFunc   func(string)
 }
 
 // URL resolves an endpoint to a fully qualified URL.
@@ -65,20 +118,90 @@ func (c *httpClient) URL(ep string) *url.URL {
 }
 
 // Do executes an HTTP request using this client and the supplied context.
+// Transient failures are retried according to the retry policy.
 func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	if c.readOnly && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, nil, &Error{
+			Type:    ErrReadOnly,
+			Message: fmt.Sprintf("refusing to send %s request to %s, the client is read-only", req.Method, req.URL.Redacted()),
+			Hint:    "disable read-only mode to make changes",
+		}
+	}
+
 	if ctx != nil {
-		req = req.WithContext(ctx)
+		req = applyContextHeader(ctx, req)
+	}
+
+	policy := c.retry
+	if p, ok := RetryPolicyFromContext(ctx); ok {
+		policy = p
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, body, err := c.do(ctx, req)
+		if err != nil {
+			return resp, body, err
+		}
+
+		delay, ok := policy.shouldRetry(attempt, req, resp)
+		if !ok {
+			return resp, body, err
+		}
+
+		// Return the last response if the context is done before the next attempt
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, body, err
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return resp, body, err
+			}
+		}
+	}
+}
+
+// do sends a single HTTP request.
+func (c *httpClient) do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return nil, nil, err
+		}
+	}
+	sent := req
+	if c.cache != nil {
+		sent = c.cache.prepare(req)
+	}
+	resp, err := c.client.Do(sent)
+	if c.breaker != nil {
+		c.breaker.record(resp, err)
 	}
-	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, NewTransportError(err)
 	}
 	defer resp.Body.Close()
 
+	if c.rateLimitFunc != nil {
+		if rl, ok := Metadata(resp.Header).RateLimit(); ok {
+			c.rateLimitFunc(rl)
+		}
+	}
+
+	if c.maxResponseSize > 0 && resp.ContentLength > c.maxResponseSize {
+		return resp, nil, newResponseTooLargeError(req, c.maxResponseSize)
+	}
+
 	var body []byte
 	done := make(chan struct{})
 	go func() {
-		body, err = io.ReadAll(resp.Body)
+		body, err = readBody(resp.Body, c.maxResponseSize)
+		if err == nil && c.maxResponseSize > 0 && int64(len(body)) > c.maxResponseSize {
+			body, err = nil, newResponseTooLargeError(req, c.maxResponseSize)
+		}
 		close(done)
 	}()
 
@@ -92,5 +215,37 @@ func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 	case <-done:
 	}
 
+	if c.cache != nil && err == nil {
+		resp, body = c.cache.update(req, resp, body)
+	}
+
+	if err == nil && resp.StatusCode < http.StatusBadRequest {
+		addBodyWarnings(resp, body)
+		if c.warningFunc != nil {
+			for _, w := range Metadata(resp.Header).Warnings() {
+				c.warningFunc(w)
+			}
+		}
+	}
+
 	return resp, body, err
 }
+
+// readBody reads the response body, stopping after one byte more than the
+// maximum size (if any) so oversized responses can be detected.
+func readBody(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	return io.ReadAll(r)
+}
+
+// newResponseTooLargeError returns an error indicating the response to the
+// supplied request exceeded the maximum size.
+func newResponseTooLargeError(req *http.Request, maxSize int64) error {
+	return &Error{
+		Type:    ErrResponseTooLarge,
+		Message: fmt.Sprintf("response to %s %s exceeds the maximum size of %d bytes", req.Method, req.URL.Redacted(), maxSize),
+		Hint:    "reduce the batch size to fetch fewer items per request",
+	}
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package api
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHttpClient_URL(t *testing.T) {
@@ -68,3 +72,75 @@ func TestHttpClient_URL(t *testing.T) {
 		})
 	}
 }
+
+func TestWithReadOnly(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil, WithReadOnly())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req, err := http.NewRequest(method, srv.URL, nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		assert.NoError(t, err, method)
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		req, err := http.NewRequest(method, srv.URL, nil)
+		require.NoError(t, err)
+		_, _, err = client.Do(ctx, req)
+		var apiErr *Error
+		if assert.ErrorAs(t, err, &apiErr, method) {
+			assert.Equal(t, ErrReadOnly, apiErr.Type)
+		}
+	}
+
+	assert.Equal(t, 2, requests)
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("chunked") {
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(r.URL.Query().Get("body")))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil, WithMaxResponseSize(5))
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc  string
+		query string
+		ok    bool
+	}{
+		{desc: "within limit", query: "body=12345", ok: true},
+		{desc: "content length", query: "body=123456"},
+		{desc: "chunked within limit", query: "chunked&body=12345", ok: true},
+		{desc: "chunked", query: "chunked&body=123456"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"?"+c.query, nil)
+			require.NoError(t, err)
+			_, body, err := client.Do(context.Background(), req)
+			if c.ok {
+				assert.NoError(t, err)
+				assert.Len(t, body, 5)
+				return
+			}
+
+			var apiErr *Error
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, ErrResponseTooLarge, apiErr.Type)
+			}
+			assert.Nil(t, body)
+		})
+	}
+}
@@ -17,10 +17,15 @@ limitations under the License.
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -34,6 +39,16 @@ type ErrorType string
 const (
 	ErrUnauthorized ErrorType = "unauthorized"
 	ErrUnexpected   ErrorType = "unexpected"
+	ErrCircuitOpen  ErrorType = "circuit-open"
+	ErrReadOnly     ErrorType = "read-only"
+	ErrPageExpired  ErrorType = "page-expired"
+
+	ErrClientVersionUnsupported ErrorType = "client-version-unsupported"
+	ErrResponseTooLarge         ErrorType = "response-too-large"
+
+	ErrNetwork ErrorType = "network"
+	ErrTimeout ErrorType = "timeout"
+	ErrTLS     ErrorType = "tls"
 )
 
 // Error represents the API specific error messages and may be used in response to HTTP status codes
@@ -42,6 +57,10 @@ type Error struct {
 	Message    string        `json:"error"`
 	RetryAfter time.Duration `json:"-"`
 	Location   string        `json:"-"`
+	// Hint is a user-facing suggestion for resolving the error.
+	Hint string `json:"-"`
+	// Cause is the underlying error (e.g. from the transport), if any.
+	Cause error `json:"-"`
 }
 
 // Error returns the message associated with this API error.
@@ -49,6 +68,72 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// NewTransportError returns an error with a network specific error condition
+// for failures that prevented the request from completing. Errors which are
+// already API errors, or which are the result of cancellation, are returned
+// unchanged.
+func NewTransportError(err error) error {
+	var apiErr *Error
+	if err == nil || errors.As(err, &apiErr) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	// The URL error itself is a net.Error, only consider what it wraps
+	cause := err
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		cause = urlErr.Err
+	}
+
+	var (
+		netErr     net.Error
+		dnsErr     *net.DNSError
+		certErr    *tls.CertificateVerificationError
+		recordErr  tls.RecordHeaderError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownErr),
+		errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return &Error{
+			Type:    ErrTLS,
+			Message: err.Error(),
+			Hint:    "check the server address and any proxy or certificate configuration",
+			Cause:   err,
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(cause, &netErr) && netErr.Timeout():
+		return &Error{
+			Type:    ErrTimeout,
+			Message: err.Error(),
+			Hint:    "the server did not respond in time, try again later",
+			Cause:   err,
+		}
+	case errors.As(err, &dnsErr):
+		return &Error{
+			Type:    ErrNetwork,
+			Message: err.Error(),
+			Hint:    "check the server address and your network connection",
+			Cause:   err,
+		}
+	case errors.As(cause, &netErr):
+		return &Error{
+			Type:    ErrNetwork,
+			Message: err.Error(),
+			Hint:    "check your network connection and try again",
+			Cause:   err,
+		}
+	default:
+		return err
+	}
+}
+
 // NewUnexpectedError returns an error in situations where the API returned an
 // undocumented status for the requested resource.
 func NewUnexpectedError(resp *http.Response, body []byte) *Error {
@@ -86,7 +171,10 @@ func NewError(t ErrorType, resp *http.Response, body []byte) *Error {
 	if err.Message == "" {
 		switch resp.StatusCode {
 		case http.StatusNotFound:
-			err.Message = fmt.Sprintf("not found: %s", err.Location)
+			err.Message = "not found"
+			if resp.Request != nil {
+				err.Message += ": " + RedactURL(resp.Request.URL)
+			}
 		case http.StatusUnauthorized:
 			err.Message = "unauthorized"
 		case http.StatusPaymentRequired:
@@ -104,6 +192,83 @@ func NewError(t ErrorType, resp *http.Response, body []byte) *Error {
 	return err
 }
 
+// NamedError associates an error with the name of the item that caused it.
+type NamedError struct {
+	Name string
+	Err  error
+}
+
+// Error returns the message of the wrapped error prefixed with the name.
+func (e *NamedError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *NamedError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is a collection of errors which occurred while processing multiple items.
+type MultiError []error
+
+// Add records an error which occurred processing the named item.
+func (e *MultiError) Add(name string, err error) {
+	*e = append(*e, &NamedError{Name: name, Err: err})
+}
+
+// ErrorOrNil returns nil if no errors were recorded.
+func (e MultiError) ErrorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error returns the messages of all the errors, one per line.
+func (e MultiError) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e)+1)
+	msgs = append(msgs, fmt.Sprintf("%d errors occurred:", len(e)))
+	for _, err := range e {
+		msgs = append(msgs, "  * "+err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the recorded errors.
+func (e MultiError) Unwrap() []error {
+	return e
+}
+
+// IsPageExpired checks to see if the error indicates a page of a list is no
+// longer available, for example because the cursor in the "next" link expired.
+func IsPageExpired(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Type == ErrPageExpired
+}
+
+// IsTransportError checks to see if the error is a network, timeout or TLS error.
+func IsTransportError(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Type {
+	case ErrNetwork, ErrTimeout, ErrTLS:
+		return true
+	}
+	return false
+}
+
+// IsClientVersionUnsupported checks to see if the error is a "client version unsupported" error.
+func IsClientVersionUnsupported(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Type == ErrClientVersionUnsupported
+}
+
 // IsUnauthorized checks to see if the error is an "unauthorized" error.
 func IsUnauthorized(err error) bool {
 	// OAuth errors (e.g. fetching tokens) will have a full HTTP response
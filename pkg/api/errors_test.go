@@ -17,7 +17,10 @@ limitations under the License.
 package api
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"testing"
@@ -123,3 +126,74 @@ func TestIsUnauthorized(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPageExpired(t *testing.T) {
+	assert.False(t, IsPageExpired(nil))
+	assert.False(t, IsPageExpired(&Error{Type: ErrUnexpected}))
+	assert.True(t, IsPageExpired(fmt.Errorf("test: %w", &Error{Type: ErrPageExpired})))
+}
+
+func TestMultiError(t *testing.T) {
+	var errs MultiError
+	assert.NoError(t, errs.ErrorOrNil())
+
+	errs.Add("a", &Error{Type: ErrUnauthorized, Message: "unauthorized"})
+	assert.EqualError(t, errs.ErrorOrNil(), "a: unauthorized")
+	assert.True(t, IsUnauthorized(errs))
+
+	errs.Add("b", fmt.Errorf("test"))
+	assert.EqualError(t, errs, "2 errors occurred:\n  * a: unauthorized\n  * b: test")
+
+	var namedErr *NamedError
+	if assert.ErrorAs(t, errs, &namedErr) {
+		assert.Equal(t, "a", namedErr.Name)
+	}
+}
+
+func TestNewTransportError(t *testing.T) {
+	cases := []struct {
+		desc     string
+		err      error
+		expected ErrorType
+	}{
+		{
+			desc:     "dns",
+			err:      &url.Error{Op: "Get", URL: "https://example.invalid", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid"}}},
+			expected: ErrNetwork,
+		},
+		{
+			desc:     "timeout",
+			err:      &url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded},
+			expected: ErrTimeout,
+		},
+		{
+			desc:     "tls",
+			err:      &url.Error{Op: "Get", URL: "https://example.com", Err: x509.UnknownAuthorityError{}},
+			expected: ErrTLS,
+		},
+		{
+			desc:     "refused",
+			err:      &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}},
+			expected: ErrNetwork,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := NewTransportError(c.err)
+			var apiErr *Error
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, c.expected, apiErr.Type)
+				assert.NotEmpty(t, apiErr.Hint)
+				assert.ErrorIs(t, err, c.err)
+			}
+			assert.True(t, IsTransportError(err))
+		})
+	}
+
+	// Errors that are not network related are unchanged
+	assert.NoError(t, NewTransportError(nil))
+	canceled := &url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled}
+	assert.Equal(t, canceled, NewTransportError(canceled))
+	badScheme := &url.Error{Op: "Get", URL: "example.com", Err: fmt.Errorf("unsupported protocol scheme")}
+	assert.Equal(t, badScheme, NewTransportError(badScheme))
+}
@@ -57,5 +57,27 @@ type API interface {
 	NextTrial(context.Context, string) (TrialAssignments, error)
 	ReportTrial(context.Context, string, TrialValues) error
 	AbandonRunningTrial(context.Context, string) error
+	AbandonRunningTrialWithReason(context.Context, string, string) error
 	LabelTrial(context.Context, string, TrialLabels) error
 }
+
+// GetCapabilities returns the capabilities advertised by the experiments
+// endpoint. An OPTIONS request is used when the API supports it, otherwise the
+// metadata from `CheckEndpoint` is used.
+func GetCapabilities(ctx context.Context, expAPI API) (api.Capabilities, error) {
+	if o, ok := expAPI.(interface {
+		Options(context.Context) (api.Metadata, error)
+	}); ok {
+		md, err := o.Options(ctx)
+		if err != nil {
+			return api.Capabilities{}, err
+		}
+		return md.Capabilities(), nil
+	}
+
+	md, err := expAPI.CheckEndpoint(ctx)
+	if err != nil {
+		return api.Capabilities{}, err
+	}
+	return md.Capabilities(), nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NewOrderConstraint returns a constraint requiring the value of the lower
+// parameter to be less than the value of the upper parameter.
+func NewOrderConstraint(lower, upper string) *Constraint {
+	return &Constraint{
+		ConstraintType: ConstraintOrder,
+		OrderConstraint: &OrderConstraint{
+			LowerParameter: lower,
+			UpperParameter: upper,
+		},
+	}
+}
+
+// NewSumConstraint returns a constraint on the weighted sum of the supplied
+// parameters, the bound is either an upper or a lower bound.
+func NewSumConstraint(bound float64, isUpperBound bool, params ...SumConstraintParameter) *Constraint {
+	return &Constraint{
+		ConstraintType: ConstraintSum,
+		SumConstraint: &SumConstraint{
+			IsUpperBound: isUpperBound,
+			Bound:        bound,
+			Parameters:   params,
+		},
+	}
+}
+
+// Weighted returns a parameter reference for use in a sum constraint.
+func Weighted(name string, weight float64) SumConstraintParameter {
+	return SumConstraintParameter{ParameterName: name, Weight: weight}
+}
+
+// WithName sets the optional name of the constraint.
+func (c *Constraint) WithName(name string) *Constraint {
+	c.Name = name
+	return c
+}
+
+// WithParameter adds a weighted parameter to a sum constraint.
+func (c *Constraint) WithParameter(name string, weight float64) *Constraint {
+	if c.SumConstraint == nil {
+		c.SumConstraint = &SumConstraint{}
+	}
+	c.Parameters = append(c.Parameters, Weighted(name, weight))
+	return c
+}
+
+// Validate checks that the constraint is well-formed and only references
+// numeric parameters from the supplied list.
+func (c *Constraint) Validate(params []Parameter) error {
+	types := make(map[string]ParameterType, len(params))
+	for _, p := range params {
+		types[p.Name] = p.Type
+	}
+
+	checkParameter := func(name string) error {
+		t, ok := types[name]
+		switch {
+		case name == "":
+			return errors.New("missing parameter name")
+		case !ok:
+			return fmt.Errorf("unknown parameter %q", name)
+		case t == ParameterTypeCategorical:
+			return fmt.Errorf("categorical parameter %q cannot be constrained", name)
+		}
+		return nil
+	}
+
+	var err error
+	switch c.ConstraintType {
+	case ConstraintOrder:
+		err = c.validateOrder(checkParameter)
+	case ConstraintSum:
+		err = c.validateSum(checkParameter)
+	default:
+		err = fmt.Errorf("unknown constraint type %q", c.ConstraintType)
+	}
+
+	if err != nil && c.Name != "" {
+		return fmt.Errorf("constraint %q: %w", c.Name, err)
+	}
+	return err
+}
+
+func (c *Constraint) validateOrder(checkParameter func(string) error) error {
+	switch {
+	case c.OrderConstraint == nil:
+		return errors.New("missing order constraint")
+	case c.SumConstraint != nil:
+		return errors.New("order constraint must not include a sum constraint")
+	case c.LowerParameter == c.UpperParameter && c.LowerParameter != "":
+		return fmt.Errorf("parameter %q cannot be ordered with itself", c.LowerParameter)
+	}
+	if err := checkParameter(c.LowerParameter); err != nil {
+		return err
+	}
+	return checkParameter(c.UpperParameter)
+}
+
+func (c *Constraint) validateSum(checkParameter func(string) error) error {
+	switch {
+	case c.SumConstraint == nil:
+		return errors.New("missing sum constraint")
+	case c.OrderConstraint != nil:
+		return errors.New("sum constraint must not include an order constraint")
+	case len(c.Parameters) == 0:
+		return errors.New("sum constraint must include at least one parameter")
+	}
+
+	seen := make(map[string]bool, len(c.Parameters))
+	for _, p := range c.Parameters {
+		if err := checkParameter(p.ParameterName); err != nil {
+			return err
+		}
+		if seen[p.ParameterName] {
+			return fmt.Errorf("duplicate parameter %q", p.ParameterName)
+		}
+		seen[p.ParameterName] = true
+	}
+	return nil
+}
+
+// ValidateConstraints checks all the constraints of the experiment against its parameters.
+func (e *Experiment) ValidateConstraints() error {
+	for i := range e.Constraints {
+		if err := e.Constraints[i].Validate(e.Parameters); err != nil {
+			return err
+		}
+	}
+	return nil
+}
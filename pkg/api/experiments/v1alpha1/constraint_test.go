@@ -0,0 +1,94 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraint_Validate(t *testing.T) {
+	params := []Parameter{
+		{Name: "a", Type: ParameterTypeInteger},
+		{Name: "b", Type: ParameterTypeDouble},
+		{Name: "c", Type: ParameterTypeCategorical},
+	}
+
+	cases := []struct {
+		desc       string
+		constraint *Constraint
+		err        string
+	}{
+		{
+			desc:       "order",
+			constraint: NewOrderConstraint("a", "b"),
+		},
+		{
+			desc:       "order unknown",
+			constraint: NewOrderConstraint("a", "x").WithName("test"),
+			err:        `constraint "test": unknown parameter "x"`,
+		},
+		{
+			desc:       "order self",
+			constraint: NewOrderConstraint("a", "a"),
+			err:        `parameter "a" cannot be ordered with itself`,
+		},
+		{
+			desc:       "sum",
+			constraint: NewSumConstraint(10, true, Weighted("a", 1)).WithParameter("b", 2),
+		},
+		{
+			desc:       "sum empty",
+			constraint: NewSumConstraint(10, true),
+			err:        `sum constraint must include at least one parameter`,
+		},
+		{
+			desc:       "sum categorical",
+			constraint: NewSumConstraint(10, false, Weighted("c", 1)),
+			err:        `categorical parameter "c" cannot be constrained`,
+		},
+		{
+			desc:       "sum duplicate",
+			constraint: NewSumConstraint(10, false).WithParameter("a", 1).WithParameter("a", 2),
+			err:        `duplicate parameter "a"`,
+		},
+		{
+			desc:       "mismatched type",
+			constraint: &Constraint{ConstraintType: ConstraintSum, OrderConstraint: &OrderConstraint{LowerParameter: "a", UpperParameter: "b"}},
+			err:        `missing sum constraint`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.constraint.Validate(params)
+			if c.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		})
+	}
+}
+
+func TestNewSumConstraint_JSON(t *testing.T) {
+	data, err := json.Marshal(NewSumConstraint(1.5, true, Weighted("a", 2)).WithName("limit"))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"name":"limit","constraintType":"sum","isUpperBound":true,"bound":1.5,"parameters":[{"parameterName":"a","weight":2}]}`, string(data))
+	}
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
@@ -36,6 +40,13 @@ type Metric struct {
 	Minimize bool `json:"minimize,omitempty"`
 	// The flag indicating this metric is optimized (nil defaults to true).
 	Optimize *bool `json:"optimize,omitempty"`
+	// The unit of the metric values (e.g. "ms" or "USD").
+	Unit string `json:"unit,omitempty"`
+	// The format pattern used to display metric values (e.g. "$%.2f").
+	DisplayFormat string `json:"displayFormat,omitempty"`
+
+	// Additional metric fields not otherwise represented.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type ConstraintType string
@@ -139,6 +150,49 @@ func (e *Experiment) UnmarshalJSON(data []byte) error {
 
 type ExperimentListQuery struct{ api.IndexQuery }
 
+// EnsureLabelsLink returns the URL used to label the experiment. If the source
+// of the experiment did not include a labels link, the experiment is fetched and
+// the canonical labels URL (relative to the experiment) is used as a fallback.
+func EnsureLabelsLink(ctx context.Context, expAPI API, exp *Experiment) (string, error) {
+	if u := exp.Link(api.RelationLabels); u != "" {
+		return u, nil
+	}
+
+	var fetched Experiment
+	var err error
+	switch {
+	case exp.Link(api.RelationSelf) != "":
+		fetched, err = expAPI.GetExperiment(ctx, exp.Link(api.RelationSelf))
+	case exp.Name != "":
+		fetched, err = expAPI.GetExperimentByName(ctx, exp.Name)
+	default:
+		return "", fmt.Errorf("unable to determine experiment labels link")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if u := fetched.Link(api.RelationLabels); u != "" {
+		exp.Metadata = fetched.Metadata
+		return u, nil
+	}
+
+	self := fetched.Link(api.RelationSelf)
+	if self == "" {
+		self = fetched.Location()
+	}
+	if self == "" {
+		return "", fmt.Errorf("unable to determine experiment labels link")
+	}
+
+	u, err := url.Parse(self)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, "labels")
+	return u.String(), nil
+}
+
 type ExperimentItem struct {
 	Experiment
 }
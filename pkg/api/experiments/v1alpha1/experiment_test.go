@@ -17,11 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
@@ -76,3 +79,81 @@ func TestExperimentList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "", l.Experiments[1].Title())
 	}
 }
+
+func TestEnsureLabelsLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/experiments/linked":
+			w.Header().Add("Link", "</v1/experiments/linked/labels>; rel=https://stormforge.io/rel/labels")
+		case "/v1/experiments/unlinked":
+			w.Header().Add("Link", "</v1/experiments/unlinked>; rel=self")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"metrics":[],"parameters":[]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	expAPI := NewAPI(client)
+	ctx := context.Background()
+
+	cases := []struct {
+		desc     string
+		exp      Experiment
+		expected string
+	}{
+		{
+			desc: "existing link",
+			exp: Experiment{Metadata: api.Metadata{
+				"Link": {"<http://example.com/labels>; rel=https://stormforge.io/rel/labels"},
+			}},
+			expected: "http://example.com/labels",
+		},
+		{
+			desc:     "fetched link",
+			exp:      Experiment{Name: "linked"},
+			expected: srv.URL + "/v1/experiments/linked/labels",
+		},
+		{
+			desc:     "canonical link",
+			exp:      Experiment{Name: "unlinked"},
+			expected: srv.URL + "/v1/experiments/unlinked/labels",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := EnsureLabelsLink(ctx, expAPI, &c.exp)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+
+	_, err = EnsureLabelsLink(ctx, expAPI, &Experiment{Name: "missing"})
+	assert.Error(t, err)
+}
+
+func TestGetCapabilities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		w.Header().Set("Api-Version", "v1alpha1")
+		if r.Method != http.MethodOptions {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	c, err := GetCapabilities(context.Background(), NewAPI(client))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET", "HEAD", "POST"}, c.Methods)
+	assert.Equal(t, "v1alpha1", c.APIVersion)
+}
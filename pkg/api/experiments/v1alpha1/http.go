@@ -23,9 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
-	"strings"
 	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
@@ -33,13 +31,7 @@ import (
 
 // NewAPI returns a new API implementation for the specified client.
 func NewAPI(client api.Client) API {
-	endpoint := os.Getenv("STORMFORGE_EXPERIMENTS_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "v1/experiments/"
-	} else {
-		endpoint = strings.TrimRight(endpoint, "/") + "/"
-	}
-	return &httpAPI{client: client, endpoint: endpoint}
+	return &httpAPI{client: client, endpoint: "v1/experiments/"}
 }
 
 // NewAPIWithEndpoint returns a new API implementation with an alternate endpoint.
@@ -82,9 +74,36 @@ func (h *httpAPI) CheckEndpoint(ctx context.Context) (api.Metadata, error) {
 	}
 }
 
+// Options returns the metadata of an OPTIONS request to the endpoint.
+func (h *httpAPI) Options(ctx context.Context) (api.Metadata, error) {
+	md := api.Metadata{}
+
+	req, err := http.NewRequest(http.MethodOptions, h.client.URL(h.endpoint).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		api.UnmarshalMetadata(resp, &md)
+		return md, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		// Servers without OPTIONS support may still advertise the allowed methods
+		api.UnmarshalMetadata(resp, &md)
+		return md, nil
+	default:
+		return nil, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) GetAllExperiments(ctx context.Context, q ExperimentListQuery) (ExperimentList, error) {
 	u := h.client.URL(h.endpoint)
-	u.RawQuery = url.Values(q.IndexQuery).Encode()
+	u.RawQuery = url.Values(api.ApplyDefaultLimit(ctx, q.IndexQuery)).Encode()
 
 	return h.GetAllExperimentsByPage(ctx, u.String())
 }
@@ -107,6 +126,8 @@ func (h *httpAPI) GetAllExperimentsByPage(ctx context.Context, u string) (Experi
 		api.UnmarshalMetadata(resp, &lst.Metadata)
 		err = json.Unmarshal(body, &lst)
 		return lst, err
+	case http.StatusNotFound, http.StatusGone:
+		return lst, api.NewError(api.ErrPageExpired, resp, body)
 	default:
 		return lst, api.NewUnexpectedError(resp, body)
 	}
@@ -209,7 +230,8 @@ func (h *httpAPI) DeleteExperiment(ctx context.Context, u string) error {
 func (h *httpAPI) GetAllTrials(ctx context.Context, u string, q TrialListQuery) (TrialList, error) {
 	lst := TrialList{}
 
-	u, err := q.IndexQuery.AppendToURL(u)
+	iq := api.ApplyDefaultLimit(ctx, q.IndexQuery)
+	u, err := iq.AppendToURL(u)
 	if err != nil {
 		return lst, err
 	}
@@ -325,6 +347,21 @@ func (h *httpAPI) ReportTrial(ctx context.Context, u string, vls TrialValues) er
 }
 
 func (h *httpAPI) AbandonRunningTrial(ctx context.Context, u string) error {
+	return h.AbandonRunningTrialWithReason(ctx, u, "")
+}
+
+func (h *httpAPI) AbandonRunningTrialWithReason(ctx context.Context, u string, reason string) error {
+	if reason != "" {
+		ru, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+		q := ru.Query()
+		q.Set("reason", reason)
+		ru.RawQuery = q.Encode()
+		u = ru.String()
+	}
+
 	req, err := http.NewRequest(http.MethodDelete, u, nil)
 	if err != nil {
 		return err
@@ -25,16 +25,71 @@ import (
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+// maxPageRestarts is the number of times a list is restarted from the first
+// page when a "next" link expires mid-iteration.
+const maxPageRestarts = 3
+
 // Lister is a helper to individually visit all items in a list (even across page boundaries).
 type Lister struct {
 	// API is the Experiment API used to fetch objects.
 	API API
 	// BatchSize overrides the default batch size for fetching lists.
 	BatchSize int
+	// RateLimitThreshold is the remaining request quota below which the lister
+	// slows down to avoid exceeding the rate limit. Zero uses the default
+	// threshold, a negative value disables throttling.
+	RateLimitThreshold int
+	// ContinueOnError causes ForEachNamedExperiment and ForEachNamedTrial to
+	// process all names before returning the errors as an api.MultiError.
+	ContinueOnError bool
+	// IgnoreNotFound causes the ForEachNamed* functions to skip names which do
+	// not exist, as if the ignoreNotFound argument were always true.
+	IgnoreNotFound bool
+	// Concurrency is the number of pages ForEachTrial may fetch ahead of the
+	// page being processed by the callback. Items are still visited in order.
+	// Zero fetches each page only after the previous one is processed.
+	Concurrency int
+}
+
+// failed records the error for the named item, returning it if the iteration should stop.
+func (l *Lister) failed(errs *api.MultiError, name string, err error) error {
+	if !l.ContinueOnError {
+		return err
+	}
+	errs.Add(name, err)
+	return nil
 }
 
 // ForEachExperiment iterates over all the experiments matching the supplied query.
+// If the query specifies an ordering, the experiments are buffered and sorted
+// on the client in case the server does not support ordering.
 func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f func(*ExperimentItem) error) error {
+	field, direction := q.OrderBy()
+	if field == "" {
+		return l.forEachExperiment(ctx, q, f)
+	}
+
+	var items []ExperimentItem
+	if err := l.forEachExperiment(ctx, q, func(item *ExperimentItem) error {
+		items = append(items, *item)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sortExperiments(items, field, direction)
+	for i := range items {
+		if err := f(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Lister) forEachExperiment(ctx context.Context, q ExperimentListQuery, f func(*ExperimentItem) error) error {
+	// Track the visited experiments so the list can be resumed after a restart
+	seen := make(map[ExperimentName]struct{})
+
 	// Define a helper to iteratively (NOT recursively) visit experiments
 	forEach := func(lst ExperimentList, err error) (string, error) {
 		if err != nil {
@@ -42,6 +97,12 @@ func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f
 		}
 
 		for i := range lst.Experiments {
+			if name := lst.Experiments[i].Name; name != "" {
+				if _, ok := seen[name]; ok {
+					continue
+				}
+				seen[name] = struct{}{}
+			}
 			if err := f(&lst.Experiments[i]); err != nil {
 				return "", err
 			}
@@ -50,7 +111,13 @@ func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f
 			}
 		}
 
-		return lst.Link(api.RelationNext), nil
+		next := lst.Link(api.RelationNext)
+		if next != "" {
+			if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+				return "", err
+			}
+		}
+		return next, nil
 	}
 
 	// Overwrite the limit
@@ -60,14 +127,23 @@ func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f
 
 	// Iterate over all experiments, starting with first page
 	u, err := forEach(l.API.GetAllExperiments(ctx, q))
-	for u != "" && err == nil {
+	for restarts := 0; u != "" && err == nil; {
 		u, err = forEach(l.API.GetAllExperimentsByPage(ctx, u))
+
+		// If the next page expired, start over skipping the experiments we have already seen
+		if api.IsPageExpired(err) && restarts < maxPageRestarts {
+			restarts++
+			u, err = forEach(l.API.GetAllExperiments(ctx, q))
+		}
 	}
 	return err
 }
 
 // ForEachNamedExperiment iterates over all the named experiments, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedExperiment(ctx context.Context, names []string, ignoreNotFound bool, f func(*ExperimentItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
+	var errs api.MultiError
 	for _, name := range names {
 		exp, err := l.API.GetExperimentByName(ctx, ExperimentName(name))
 		if err != nil {
@@ -75,61 +151,111 @@ func (l *Lister) ForEachNamedExperiment(ctx context.Context, names []string, ign
 			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrExperimentNotFound && ignoreNotFound {
 				continue
 			}
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		if err := f(&ExperimentItem{Experiment: exp}); err != nil {
-			return err
+			if err := l.failed(&errs, name, err); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
 
 // ForEachTrial iterates over all trials for an experiment matching the supplied query.
-func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQuery, f func(*TrialItem) error) (err error) {
-	// Define a helper to iteratively (NOT recursively) list and visit scenarios
-	forEach := func(u string) (string, error) {
-		lst, err := l.API.GetAllTrials(ctx, u, q)
-		if err != nil {
-			return "", err
+func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQuery, f func(*TrialItem) error) error {
+	// Overwrite the limit
+	if l.BatchSize > 0 {
+		q.SetLimit(l.BatchSize)
+	}
+
+	// Define a helper to iteratively (NOT recursively) fetch all trial pages, starting with the experiment's "rel=trials"
+	fetch := func(ctx context.Context, visit func(TrialList) error) error {
+		for u := exp.Link(api.RelationTrials); u != ""; {
+			lst, err := l.API.GetAllTrials(ctx, u, q)
+			if err != nil {
+				return err
+			}
+
+			// Reset the query so it is only used once
+			q = TrialListQuery{}
+
+			if err := visit(lst); err != nil {
+				return err
+			}
+
+			u = lst.Link(api.RelationNext)
+			if u != "" {
+				if err := api.Throttle(ctx, lst.Metadata, l.RateLimitThreshold); err != nil {
+					return err
+				}
+			}
 		}
+		return nil
+	}
 
+	// Define a helper to visit the trials on a single page
+	visit := func(lst TrialList) error {
 		for i := range lst.Trials {
 			lst.Trials[i].Experiment = exp
 			if err := f(&lst.Trials[i]); err != nil {
-				return "", err
+				return err
 			}
 			if err := ctx.Err(); err != nil {
-				return "", err
+				return err
 			}
 		}
-
-		return lst.Link(api.RelationNext), nil
+		return nil
 	}
 
-	// Overwrite the limit
-	if l.BatchSize > 0 {
-		q.SetLimit(l.BatchSize)
+	if l.Concurrency > 0 {
+		return prefetchTrials(ctx, l.Concurrency, fetch, visit)
 	}
+	return fetch(ctx, visit)
+}
 
-	// Iterate over all trial pages, starting with the experiment's "rel=trials"
-	u := exp.Link(api.RelationTrials)
-	for u != "" && err == nil {
-		u, err = forEach(u)
+// prefetchTrials runs the fetch function in the background, allowing up to n
+// pages to be fetched ahead of the page being visited.
+func prefetchTrials(ctx context.Context, n int, fetch func(context.Context, func(TrialList) error) error, visit func(TrialList) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		// Reset the query so it is only used once
-		q = TrialListQuery{}
+	pages := make(chan TrialList, n)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(pages)
+		errc <- fetch(ctx, func(lst TrialList) error {
+			select {
+			case pages <- lst:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	for lst := range pages {
+		if err := visit(lst); err != nil {
+			return err
+		}
 	}
-	return
+	return <-errc
 }
 
 // ForEachNamedTrial iterates over all the named trials, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialListQuery, ignoreNotFound bool, f func(*TrialItem) error) error {
+	ignoreNotFound = ignoreNotFound || l.IgnoreNotFound
+
 	// Overwrite the limit
 	if l.BatchSize > 0 {
 		q.SetLimit(l.BatchSize)
 	}
 
+	var errs api.MultiError
 	cache := make(map[ExperimentName]map[int64]*TrialItem)
 	for _, n := range names {
 		expName, trialNum := SplitTrialName(n)
@@ -139,7 +265,14 @@ func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialL
 		if _, ok := cache[expName]; !ok {
 			exp, err := l.API.GetExperimentByName(ctx, expName)
 			if err != nil {
-				return err
+				var notFoundErr *api.Error
+				if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrExperimentNotFound && ignoreNotFound {
+					continue
+				}
+				if err := l.failed(&errs, n, err); err != nil {
+					return err
+				}
+				continue
 			}
 
 			cache[expName] = make(map[int64]*TrialItem)
@@ -147,7 +280,10 @@ func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialL
 				cache[expName][item.Number] = item
 				return nil
 			}); err != nil {
-				return err
+				if err := l.failed(&errs, n, err); err != nil {
+					return err
+				}
+				continue
 			}
 		}
 
@@ -160,20 +296,52 @@ func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialL
 			sort.Slice(result, func(i, j int) bool { return result[i].Number > result[j].Number })
 			for _, r := range result {
 				if err := f(r); err != nil {
-					return err
+					if err := l.failed(&errs, JoinTrialName(r.Experiment, r.Number), err); err != nil {
+						return err
+					}
 				}
 			}
-			return nil
+			return errs.ErrorOrNil()
 		}
 
 		// Get the trial out of the trial cache
 		if t, ok := cache[expName][trialNum]; ok {
 			if err := f(t); err != nil {
-				return err
+				if err := l.failed(&errs, n, err); err != nil {
+					return err
+				}
 			}
 		} else if !ignoreNotFound {
-			return &api.Error{Type: ErrTrialNotFound, Message: fmt.Sprintf("trial not found: %q", n)}
+			err := &api.Error{Type: ErrTrialNotFound, Message: fmt.Sprintf("trial not found: %q", n)}
+			if err := l.failed(&errs, n, err); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
+}
+
+// sortExperiments stably sorts experiments by the named field, unknown fields
+// preserve the order returned by the server.
+func sortExperiments(items []ExperimentItem, field string, direction api.SortDirection) {
+	var less func(a, b *ExperimentItem) bool
+	switch field {
+	case "name":
+		less = func(a, b *ExperimentItem) bool { return a.Name < b.Name }
+	case "displayName":
+		less = func(a, b *ExperimentItem) bool { return a.DisplayName < b.DisplayName }
+	case "observations":
+		less = func(a, b *ExperimentItem) bool { return a.Observations < b.Observations }
+	case "budget":
+		less = func(a, b *ExperimentItem) bool { return a.Budget < b.Budget }
+	default:
+		return
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if direction == api.SortDescending {
+			return less(&items[j], &items[i])
+		}
+		return less(&items[i], &items[j])
+	})
 }
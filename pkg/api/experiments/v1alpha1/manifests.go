@@ -0,0 +1,178 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// workloadKinds maps the lower-case kind names used in parameter paths to
+// their API version and kind.
+var workloadKinds = map[string][2]string{
+	"deployment":   {"apps/v1", "Deployment"},
+	"deployments":  {"apps/v1", "Deployment"},
+	"deploy":       {"apps/v1", "Deployment"},
+	"statefulset":  {"apps/v1", "StatefulSet"},
+	"statefulsets": {"apps/v1", "StatefulSet"},
+	"sts":          {"apps/v1", "StatefulSet"},
+	"daemonset":    {"apps/v1", "DaemonSet"},
+	"daemonsets":   {"apps/v1", "DaemonSet"},
+	"ds":           {"apps/v1", "DaemonSet"},
+	"replicaset":   {"apps/v1", "ReplicaSet"},
+	"replicasets":  {"apps/v1", "ReplicaSet"},
+	"rs":           {"apps/v1", "ReplicaSet"},
+}
+
+// KubernetesPatches converts trial assignments into Kubernetes strategic merge
+// patches, one per workload. Parameter names must follow the path convention
+// "KIND/NAME/PARAMETER" (e.g. "deployment/nginx/cpu") or, to target a specific
+// container, "KIND/NAME/CONTAINER/PARAMETER"; when the container is omitted, it
+// is assumed to have the same name as the workload. Supported parameters are
+// "cpu" and "memory" (numeric values are interpreted as millicores and mebibytes,
+// respectively) and "replicas". The names of any assignments that do not follow
+// the convention are also returned.
+func KubernetesPatches(ta *TrialAssignments) ([]map[string]interface{}, []string) {
+	type container struct {
+		name      string
+		resources map[string]interface{}
+	}
+	type workload struct {
+		apiVersion, kind, name string
+		replicas               *int64
+		containers             []*container
+	}
+
+	var unmatched []string
+	index := make(map[string]*workload)
+	for _, a := range ta.Assignments {
+		path := strings.Split(a.ParameterName, "/")
+		if len(path) != 3 && len(path) != 4 {
+			unmatched = append(unmatched, a.ParameterName)
+			continue
+		}
+
+		gvk, ok := workloadKinds[strings.ToLower(path[0])]
+		if !ok || path[1] == "" {
+			unmatched = append(unmatched, a.ParameterName)
+			continue
+		}
+
+		key := gvk[1] + "/" + path[1]
+		w := index[key]
+		if w == nil {
+			w = &workload{apiVersion: gvk[0], kind: gvk[1], name: path[1]}
+		}
+
+		containerName, parameterName := path[1], path[len(path)-1]
+		if len(path) == 4 {
+			containerName = path[2]
+		}
+
+		switch strings.ToLower(parameterName) {
+		case "replicas":
+			if len(path) != 3 {
+				unmatched = append(unmatched, a.ParameterName)
+				continue
+			}
+			replicas := a.Value.Int64Value()
+			w.replicas = &replicas
+
+		case "cpu", "memory":
+			var c *container
+			for i := range w.containers {
+				if w.containers[i].name == containerName {
+					c = w.containers[i]
+				}
+			}
+			if c == nil {
+				c = &container{name: containerName, resources: map[string]interface{}{}}
+				w.containers = append(w.containers, c)
+			}
+			c.resources[strings.ToLower(parameterName)] = resourceQuantity(strings.ToLower(parameterName), a.Value)
+
+		default:
+			unmatched = append(unmatched, a.ParameterName)
+			continue
+		}
+
+		index[key] = w
+	}
+
+	// Produce the patches in a stable order
+	keys := make([]string, 0, len(index))
+	for k := range index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	patches := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		w := index[k]
+		spec := map[string]interface{}{}
+		if w.replicas != nil {
+			spec["replicas"] = *w.replicas
+		}
+		if len(w.containers) > 0 {
+			sort.Slice(w.containers, func(i, j int) bool { return w.containers[i].name < w.containers[j].name })
+			containers := make([]interface{}, 0, len(w.containers))
+			for _, c := range w.containers {
+				containers = append(containers, map[string]interface{}{
+					"name": c.name,
+					"resources": map[string]interface{}{
+						"requests": c.resources,
+					},
+				})
+			}
+			spec["template"] = map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": containers,
+				},
+			}
+		}
+
+		patches = append(patches, map[string]interface{}{
+			"apiVersion": w.apiVersion,
+			"kind":       w.kind,
+			"metadata": map[string]interface{}{
+				"name": w.name,
+			},
+			"spec": spec,
+		})
+	}
+
+	return patches, unmatched
+}
+
+// resourceQuantity returns the string representation of a resource quantity
+// using the units assumed for numeric parameter values.
+func resourceQuantity(resourceName string, v api.NumberOrString) string {
+	if v.IsString {
+		return v.StrVal
+	}
+
+	switch resourceName {
+	case "cpu":
+		return v.String() + "m"
+	case "memory":
+		return v.String() + "Mi"
+	default:
+		return v.String()
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestKubernetesPatches(t *testing.T) {
+	cases := []struct {
+		desc        string
+		assignments []Assignment
+		patches     []map[string]interface{}
+		unmatched   []string
+	}{
+		{
+			desc:    "empty",
+			patches: []map[string]interface{}{},
+		},
+		{
+			desc: "container resources",
+			assignments: []Assignment{
+				{ParameterName: "deployment/nginx/cpu", Value: api.FromInt64(500)},
+				{ParameterName: "deployment/nginx/memory", Value: api.FromInt64(256)},
+				{ParameterName: "deployment/nginx/sidecar/cpu", Value: api.FromString("100m")},
+			},
+			patches: []map[string]interface{}{
+				{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata":   map[string]interface{}{"name": "nginx"},
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": []interface{}{
+									map[string]interface{}{
+										"name": "nginx",
+										"resources": map[string]interface{}{
+											"requests": map[string]interface{}{"cpu": "500m", "memory": "256Mi"},
+										},
+									},
+									map[string]interface{}{
+										"name": "sidecar",
+										"resources": map[string]interface{}{
+											"requests": map[string]interface{}{"cpu": "100m"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "replicas and unmatched",
+			assignments: []Assignment{
+				{ParameterName: "sts/postgres/replicas", Value: api.FromInt64(3)},
+				{ParameterName: "work_mem", Value: api.FromInt64(4)},
+				{ParameterName: "service/postgres/cpu", Value: api.FromInt64(4)},
+			},
+			patches: []map[string]interface{}{
+				{
+					"apiVersion": "apps/v1",
+					"kind":       "StatefulSet",
+					"metadata":   map[string]interface{}{"name": "postgres"},
+					"spec":       map[string]interface{}{"replicas": int64(3)},
+				},
+			},
+			unmatched: []string{"work_mem", "service/postgres/cpu"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			patches, unmatched := KubernetesPatches(&TrialAssignments{Assignments: c.assignments})
+			assert.Equal(t, c.patches, patches)
+			assert.Equal(t, c.unmatched, unmatched)
+		})
+	}
+}
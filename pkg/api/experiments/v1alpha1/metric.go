@@ -0,0 +1,97 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatValue returns the display representation of a value for this metric.
+// The default format is used to render the number when the metric does not
+// specify a display format, if nil the shortest exact representation is used.
+func (m *Metric) FormatValue(v float64, defaultFormat func(float64) string) string {
+	var s string
+	if m.DisplayFormat != "" {
+		s = fmt.Sprintf(m.DisplayFormat, v)
+	}
+	if s == "" || strings.Contains(s, "%!") {
+		if defaultFormat != nil {
+			s = defaultFormat(v)
+		} else {
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	if m.Unit != "" {
+		s += " " + m.Unit
+	}
+	return s
+}
+
+// UnmarshalJSON retains any unknown fields of the metric.
+func (m *Metric) UnmarshalJSON(b []byte) error {
+	type t Metric
+	if err := json.Unmarshal(b, (*t)(m)); err != nil {
+		return err
+	}
+
+	extra := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return err
+	}
+	for _, k := range []string{"name", "minimize", "optimize", "unit", "displayFormat"} {
+		delete(extra, k)
+	}
+
+	m.Extra = nil
+	if len(extra) > 0 {
+		m.Extra = extra
+	}
+	return nil
+}
+
+// MarshalJSON includes any unknown fields of the metric.
+func (m Metric) MarshalJSON() ([]byte, error) {
+	type t Metric
+	b, err := json.Marshal(t(m))
+	if err != nil || len(m.Extra) == 0 {
+		return b, err
+	}
+
+	fields := make(map[string]json.RawMessage, len(m.Extra))
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range m.Extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// Metric returns the named metric, or nil if it does not exist.
+func (e *Experiment) Metric(name string) *Metric {
+	for i := range e.Metrics {
+		if e.Metrics[i].Name == name {
+			return &e.Metrics[i]
+		}
+	}
+	return nil
+}
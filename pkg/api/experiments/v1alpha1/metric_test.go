@@ -0,0 +1,79 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetric_FormatValue(t *testing.T) {
+	cases := []struct {
+		desc     string
+		metric   Metric
+		value    float64
+		expected string
+	}{
+		{
+			desc:     "default",
+			metric:   Metric{Name: "m"},
+			value:    123.5,
+			expected: "123.5",
+		},
+		{
+			desc:     "unit",
+			metric:   Metric{Name: "m", Unit: "ms"},
+			value:    123,
+			expected: "123 ms",
+		},
+		{
+			desc:     "display format",
+			metric:   Metric{Name: "m", DisplayFormat: "$%.2f"},
+			value:    1.234,
+			expected: "$1.23",
+		},
+		{
+			desc:     "invalid display format",
+			metric:   Metric{Name: "m", DisplayFormat: "%d"},
+			value:    1.5,
+			expected: "1.5",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.metric.FormatValue(c.value, nil))
+		})
+	}
+}
+
+func TestMetric_JSON(t *testing.T) {
+	in := `{"name":"cost","unit":"USD","displayFormat":"$%.2f","goal":{"max":10}}`
+
+	var m Metric
+	if assert.NoError(t, json.Unmarshal([]byte(in), &m)) {
+		assert.Equal(t, "USD", m.Unit)
+		assert.Equal(t, "$%.2f", m.DisplayFormat)
+		assert.Contains(t, m.Extra, "goal")
+	}
+
+	out, err := json.Marshal(&m)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, in, string(out))
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Names of the optimization parameters understood by the optimizer.
+const (
+	// OptimizationExperimentBudget is the total number of trials to run.
+	OptimizationExperimentBudget = "experimentBudget"
+	// OptimizationParallelism is the number of trials which may run concurrently.
+	OptimizationParallelism = "parallelism"
+)
+
+// OptimizationValue returns the value of the named optimization parameter.
+func (e *Experiment) OptimizationValue(name string) (string, bool) {
+	for i := range e.Optimization {
+		if e.Optimization[i].Name == name {
+			return e.Optimization[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// SetOptimizationValue adds or replaces the value of the named optimization parameter.
+func (e *Experiment) SetOptimizationValue(name, value string) {
+	for i := range e.Optimization {
+		if e.Optimization[i].Name == name {
+			e.Optimization[i].Value = value
+			return
+		}
+	}
+	e.Optimization = append(e.Optimization, Optimization{Name: name, Value: value})
+}
+
+// ExperimentBudget returns the total number of trials to run, zero indicates
+// the budget is not set (or is not valid).
+func (e *Experiment) ExperimentBudget() int {
+	return e.optimizationInt(OptimizationExperimentBudget)
+}
+
+// Parallelism returns the number of trials which may run concurrently, zero
+// indicates the parallelism is not set (or is not valid).
+func (e *Experiment) Parallelism() int {
+	return e.optimizationInt(OptimizationParallelism)
+}
+
+// optimizationInt returns the integer value of the named optimization parameter.
+func (e *Experiment) optimizationInt(name string) int {
+	value, ok := e.OptimizationValue(name)
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0
+	}
+	return i
+}
+
+// ValidateOptimization checks the values of the known optimization parameters.
+func ValidateOptimization(opts []Optimization) error {
+	values := make(map[string]int, len(opts))
+	for _, o := range opts {
+		switch o.Name {
+		case OptimizationExperimentBudget, OptimizationParallelism:
+			i, err := strconv.Atoi(o.Value)
+			if err != nil || i < 1 {
+				return fmt.Errorf("invalid %s %q, must be a positive integer", o.Name, o.Value)
+			}
+			values[o.Name] = i
+		}
+	}
+
+	budget, parallelism := values[OptimizationExperimentBudget], values[OptimizationParallelism]
+	if budget > 0 && parallelism > budget {
+		return fmt.Errorf("invalid %s %d, must not exceed the %s %d", OptimizationParallelism, parallelism, OptimizationExperimentBudget, budget)
+	}
+	return nil
+}
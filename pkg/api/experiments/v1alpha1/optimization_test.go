@@ -0,0 +1,68 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperiment_Optimization(t *testing.T) {
+	exp := Experiment{}
+	assert.Equal(t, 0, exp.ExperimentBudget())
+
+	exp.SetOptimizationValue(OptimizationExperimentBudget, "20")
+	exp.SetOptimizationValue(OptimizationParallelism, "2")
+	exp.SetOptimizationValue(OptimizationParallelism, "4")
+	assert.Len(t, exp.Optimization, 2)
+	assert.Equal(t, 20, exp.ExperimentBudget())
+	assert.Equal(t, 4, exp.Parallelism())
+}
+
+func TestValidateOptimization(t *testing.T) {
+	cases := []struct {
+		desc string
+		opts []Optimization
+		err  string
+	}{
+		{
+			desc: "valid",
+			opts: []Optimization{{Name: "experimentBudget", Value: "20"}, {Name: "parallelism", Value: "2"}, {Name: "other", Value: "x"}},
+		},
+		{
+			desc: "not a number",
+			opts: []Optimization{{Name: "experimentBudget", Value: "lots"}},
+			err:  `invalid experimentBudget "lots", must be a positive integer`,
+		},
+		{
+			desc: "parallelism exceeds budget",
+			opts: []Optimization{{Name: "experimentBudget", Value: "2"}, {Name: "parallelism", Value: "4"}},
+			err:  `invalid parallelism 4, must not exceed the experimentBudget 2`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := ValidateOptimization(c.opts)
+			if c.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		})
+	}
+}
@@ -27,8 +27,12 @@ import (
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+// DefaultBehaviors are the supported default behaviors of NewTrialAssignments.
+var DefaultBehaviors = []string{"none", "baseline", "min", "max", "rand"}
+
 // NewTrialAssignments constructs a trial assignments instance using the supplied string values.
-// The default behavior can be "none", "baseline", "minimum", "maximum", or "random".
+// The default behavior can be "none", "baseline", "minimum", "maximum", or "random"; baseline
+// values can be obtained from the trials of the experiment using `TrialList.Baselines`.
 func NewTrialAssignments(e *Experiment, assignments map[string]string, baselines map[string]*api.NumberOrString, defaultBehavior string) (*TrialAssignments, error) {
 	ta := &TrialAssignments{}
 	for _, p := range e.Parameters {
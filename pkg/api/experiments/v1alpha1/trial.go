@@ -18,6 +18,8 @@ package v1alpha1
 
 import (
 	"net/url"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,6 +42,50 @@ type TrialAssignments struct {
 	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// PendingTrial returns the identifier of the trial created from these
+// assignments, taken from the location returned by the server. The identifier
+// is empty if the server did not return a location.
+func (ta *TrialAssignments) PendingTrial() string {
+	u, err := url.Parse(ta.Location())
+	if err != nil || u.Path == "" {
+		return ""
+	}
+
+	id := path.Base(u.Path)
+	if id == "/" || id == "." {
+		return ""
+	}
+	return id
+}
+
+// PendingTrialNumber returns the number of the trial created from these
+// assignments, if the server has already assigned one. The number will be
+// less than zero if it is not yet known.
+func (ta *TrialAssignments) PendingTrialNumber() int64 {
+	if num, err := strconv.ParseInt(ta.PendingTrial(), 10, 64); err == nil {
+		return num
+	}
+	return -1
+}
+
+// SameAssignments checks if the supplied assignments have the same values.
+func (ta *TrialAssignments) SameAssignments(other *TrialAssignments) bool {
+	if len(ta.Assignments) != len(other.Assignments) {
+		return false
+	}
+
+	values := make(map[string]string, len(ta.Assignments))
+	for _, a := range ta.Assignments {
+		values[a.ParameterName] = a.Value.String()
+	}
+	for _, a := range other.Assignments {
+		if v, ok := values[a.ParameterName]; !ok || v != a.Value.String() {
+			return false
+		}
+	}
+	return true
+}
+
 type Value struct {
 	// The name of the metric in the experiment the value corresponds to.
 	MetricName string `json:"metricName"`
@@ -62,6 +108,33 @@ type TrialValues struct {
 	StartTime *time.Time `json:"startTime,omitempty"`
 	// CompletionTime is the time at which the trial was completed.
 	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// Diagnostics is optional information used to analyze the outcome of the trial.
+	Diagnostics *TrialDiagnostics `json:"diagnostics,omitempty"`
+}
+
+// MaxDiagnosticLogsLength is the maximum length of the logs excerpt included with trial diagnostics.
+const MaxDiagnosticLogsLength = 64 * 1024
+
+type TrialDiagnostics struct {
+	// An excerpt of the logs produced while running the trial.
+	Logs string `json:"logs,omitempty"`
+	// Information about the runtime environment (e.g. the Kubernetes version) keyed by name.
+	Runtime map[string]string `json:"runtime,omitempty"`
+	// The versions of the tools used to run the trial keyed by tool name.
+	ToolVersions map[string]string `json:"toolVersions,omitempty"`
+}
+
+// SetLogs sets the logs excerpt, retaining only the most recent lines that fit
+// within the maximum length.
+func (d *TrialDiagnostics) SetLogs(logs string) {
+	if n := len(logs) - MaxDiagnosticLogsLength; n > 0 {
+		// Drop the partial line at the start of the excerpt
+		if i := strings.IndexByte(logs[n:], '\n'); logs[n-1] != '\n' && i >= 0 && n+i < len(logs)-1 {
+			n += i + 1
+		}
+		logs = logs[n:]
+	}
+	d.Logs = logs
 }
 
 type TrialStatus string
@@ -108,6 +181,8 @@ type TrialItem struct {
 	Status TrialStatus `json:"status"`
 	// Ordinal number indicating when during an experiment the trail was generated.
 	Number int64 `json:"number"`
+	// The reason given when the trial was abandoned, if the status is abandoned.
+	AbandonedReason string `json:"abandonedReason,omitempty"`
 
 	// Experiment is a reference back to the experiment this trial item is associated with. This field is never
 	// populated by the API, but may be useful for consumers to maintain a connection between resources.
@@ -130,6 +205,28 @@ type TrialList struct {
 	Experiment *Experiment `json:"-"`
 }
 
+// LabelBaseline is the trial label identifying the baseline trial of an experiment.
+const LabelBaseline = "baseline"
+
+// Baselines returns the parameter assignments of the baseline trial (the trial
+// labeled "baseline=true") for use as default values. The result is nil if the
+// list does not contain a baseline trial.
+func (tl *TrialList) Baselines() map[string]*api.NumberOrString {
+	for i := range tl.Trials {
+		if tl.Trials[i].Labels[LabelBaseline] != "true" {
+			continue
+		}
+
+		baselines := make(map[string]*api.NumberOrString, len(tl.Trials[i].Assignments))
+		for j := range tl.Trials[i].Assignments {
+			a := &tl.Trials[i].Assignments[j]
+			baselines[a.ParameterName] = &a.Value
+		}
+		return baselines
+	}
+	return nil
+}
+
 type TrialLabels struct {
 	// New labels for this trial.
 	Labels map[string]string `json:"labels"`
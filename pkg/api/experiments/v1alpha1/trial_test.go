@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,3 +57,102 @@ func TestTrialList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "true", l.Trials[1].Labels["manually_created"])
 	}
 }
+
+func TestTrialDiagnostics_SetLogs(t *testing.T) {
+	d := TrialDiagnostics{}
+	d.SetLogs("short\n")
+	assert.Equal(t, "short\n", d.Logs)
+
+	line := strings.Repeat("x", 1023) + "\n"
+	tail := strings.Repeat(line, MaxDiagnosticLogsLength/len(line))
+
+	d.SetLogs("first\n" + tail)
+	assert.True(t, d.Logs == tail, "expected only complete lines")
+
+	d.SetLogs("partial" + tail)
+	assert.True(t, d.Logs == tail[len(line):], "expected partial line to be dropped")
+
+	d.SetLogs(line + "partial" + tail[7:])
+	assert.True(t, d.Logs == "partial"+tail[7:], "expected only complete lines")
+}
+
+func TestTrialAssignments_PendingTrial(t *testing.T) {
+	cases := []struct {
+		desc     string
+		location string
+		id       string
+		number   int64
+	}{
+		{
+			desc: "no location",
+			id:   "",
+		},
+		{
+			desc:     "numbered",
+			location: "https://example.com/v1/experiments/foo/trials/7",
+			id:       "7",
+			number:   7,
+		},
+		{
+			desc:     "queued",
+			location: "https://example.com/v1/experiments/foo/trials/queue/abc",
+			id:       "abc",
+			number:   -1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ta := TrialAssignments{Metadata: api.Metadata{}}
+			if c.location != "" {
+				ta.Metadata["Location"] = []string{c.location}
+			}
+			assert.Equal(t, c.id, ta.PendingTrial())
+			if c.id != "" {
+				assert.Equal(t, c.number, ta.PendingTrialNumber())
+			}
+		})
+	}
+}
+
+func TestTrialAssignments_SameAssignments(t *testing.T) {
+	ta := TrialAssignments{Assignments: []Assignment{
+		{ParameterName: "a", Value: api.FromInt64(1)},
+		{ParameterName: "b", Value: api.FromString("x")},
+	}}
+
+	assert.True(t, ta.SameAssignments(&TrialAssignments{Assignments: []Assignment{
+		{ParameterName: "b", Value: api.FromString("x")},
+		{ParameterName: "a", Value: api.FromInt64(1)},
+	}}))
+	assert.False(t, ta.SameAssignments(&TrialAssignments{Assignments: []Assignment{
+		{ParameterName: "a", Value: api.FromInt64(2)},
+		{ParameterName: "b", Value: api.FromString("x")},
+	}}))
+	assert.False(t, ta.SameAssignments(&TrialAssignments{Assignments: []Assignment{
+		{ParameterName: "a", Value: api.FromInt64(1)},
+	}}))
+}
+
+func TestTrialList_Baselines(t *testing.T) {
+	l := TrialList{}
+	assert.Nil(t, l.Baselines())
+
+	l.Trials = []TrialItem{
+		{
+			TrialAssignments: TrialAssignments{
+				Assignments: []Assignment{{ParameterName: "a", Value: api.FromInt64(2)}},
+			},
+			Number: 2,
+		},
+		{
+			TrialAssignments: TrialAssignments{
+				Assignments: []Assignment{{ParameterName: "a", Value: api.FromInt64(1)}},
+				Labels:      map[string]string{LabelBaseline: "true"},
+			},
+			Number: 1,
+		},
+	}
+	if baselines := l.Baselines(); assert.Len(t, baselines, 1) {
+		assert.Equal(t, "1", baselines["a"].String())
+	}
+}
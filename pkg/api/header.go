@@ -0,0 +1,68 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+type headerKey struct{}
+
+// WithHeader returns a context which causes the client to send an additional
+// header on every request made with it. Values accumulate, calling this multiple
+// times with the same key sends multiple values.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	h := HeaderFromContext(ctx).Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Add(key, value)
+	return context.WithValue(ctx, headerKey{}, h)
+}
+
+// HeaderFromContext returns the additional headers associated with the supplied
+// context. The result must not be modified.
+func HeaderFromContext(ctx context.Context) http.Header {
+	if ctx == nil {
+		return nil
+	}
+	h, _ := ctx.Value(headerKey{}).(http.Header)
+	return h
+}
+
+// applyContextHeader returns a copy of the request using the supplied context
+// with the context headers added, headers already present on the request take
+// precedence. The header of the original request is never modified.
+func applyContextHeader(ctx context.Context, req *http.Request) *http.Request {
+	h := HeaderFromContext(ctx)
+	if len(h) == 0 {
+		return req.WithContext(ctx)
+	}
+
+	req = req.Clone(ctx)
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	for k, vv := range h {
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		req.Header[k] = append([]string(nil), vv...)
+	}
+	return req
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHeader(t *testing.T) {
+	var actual http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actual = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil)
+	require.NoError(t, err)
+
+	parent := WithHeader(context.Background(), "X-Tenant", "a")
+	ctx := WithHeader(parent, "X-Tenant", "b")
+	ctx = WithHeader(ctx, "User-Agent", "test/1.0")
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Explicit", "yes")
+	_, _, err = client.Do(ctx, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, actual.Values("X-Tenant"))
+	assert.Equal(t, "test/1.0", actual.Get("User-Agent"))
+	assert.Equal(t, "yes", actual.Get("X-Explicit"))
+	assert.Equal(t, []string{"a"}, HeaderFromContext(parent).Values("X-Tenant"))
+	assert.Equal(t, http.Header{"X-Explicit": {"yes"}}, req.Header, "request header should not be modified")
+
+	// Reusing the request with a different context must not send the old headers
+	_, _, err = client.Do(WithHeader(context.Background(), "X-Other", "c"), req)
+	require.NoError(t, err)
+	assert.Empty(t, actual.Values("X-Tenant"))
+	assert.Equal(t, "c", actual.Get("X-Other"))
+}
@@ -28,6 +28,18 @@ const (
 	ParamOffset        = "offset"
 	ParamLimit         = "limit"
 	ParamLabelSelector = "labelSelector"
+	ParamOrganization  = "organization"
+	ParamTeam          = "team"
+	ParamWorkspace     = "workspace"
+	ParamOrderBy       = "orderBy"
+)
+
+// SortDirection is the direction used to order an index.
+type SortDirection string
+
+const (
+	SortAscending  SortDirection = "asc"
+	SortDescending SortDirection = "desc"
 )
 
 // IndexQuery represents the query parameter of an index resource.
@@ -72,6 +84,59 @@ func (q *IndexQuery) SetLabelSelector(kv map[string]string) {
 	}
 }
 
+// SetOrganization restricts the index to the specified organization, useful
+// when the caller's token grants access to multiple organizations.
+func (q *IndexQuery) SetOrganization(organization string) {
+	q.setScope(ParamOrganization, organization)
+}
+
+// SetTeam restricts the index to the specified team.
+func (q *IndexQuery) SetTeam(team string) {
+	q.setScope(ParamTeam, team)
+}
+
+// SetWorkspace restricts the index to the specified workspace.
+func (q *IndexQuery) SetWorkspace(workspace string) {
+	q.setScope(ParamWorkspace, workspace)
+}
+
+func (q *IndexQuery) setScope(key, value string) {
+	if *q == nil {
+		*q = IndexQuery{}
+	}
+	if value != "" {
+		url.Values(*q).Set(key, value)
+	} else {
+		url.Values(*q).Del(key)
+	}
+}
+
+// SetOrderBy requests the index be ordered by the specified field. Servers which
+// do not support ordering ignore this value, however listers will fall back to
+// sorting on the client.
+func (q *IndexQuery) SetOrderBy(field string, direction SortDirection) {
+	if *q == nil {
+		*q = IndexQuery{}
+	}
+	if field != "" {
+		if direction == "" {
+			direction = SortAscending
+		}
+		url.Values(*q).Set(ParamOrderBy, field+":"+string(direction))
+	} else {
+		url.Values(*q).Del(ParamOrderBy)
+	}
+}
+
+// OrderBy returns the field and direction the index is ordered by.
+func (q IndexQuery) OrderBy() (string, SortDirection) {
+	field, direction, _ := strings.Cut(url.Values(q).Get(ParamOrderBy), ":")
+	if field != "" && direction == "" {
+		direction = string(SortAscending)
+	}
+	return field, SortDirection(direction)
+}
+
 // AppendToURL adds this index query to an existing URL.
 func (q *IndexQuery) AppendToURL(u string) (string, error) {
 	if q == nil || len(*q) == 0 {
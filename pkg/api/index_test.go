@@ -48,6 +48,37 @@ func TestIndexQuery_SetLabelSelector(t *testing.T) {
 	assert.Equal(t, []string{"application=my-app,scenario=cyber-monday", "best=true"}, q[ParamLabelSelector])
 }
 
+func TestIndexQuery_SetScope(t *testing.T) {
+	q := IndexQuery{}
+
+	q.SetOrganization("acme")
+	q.SetTeam("platform")
+	q.SetWorkspace("staging")
+	assert.Equal(t, IndexQuery{
+		ParamOrganization: []string{"acme"},
+		ParamTeam:         []string{"platform"},
+		ParamWorkspace:    []string{"staging"},
+	}, q)
+
+	q.SetTeam("")
+	assert.NotContains(t, q, ParamTeam)
+}
+
+func TestIndexQuery_SetOrderBy(t *testing.T) {
+	q := IndexQuery{}
+
+	q.SetOrderBy("name", "")
+	assert.Equal(t, []string{"name:asc"}, q[ParamOrderBy])
+
+	q.SetOrderBy("createdAt", SortDescending)
+	field, direction := q.OrderBy()
+	assert.Equal(t, "createdAt", field)
+	assert.Equal(t, SortDescending, direction)
+
+	q.SetOrderBy("", "")
+	assert.NotContains(t, q, ParamOrderBy)
+}
+
 func TestIndexQuery_nil(t *testing.T) {
 	// Ensure the setter on a nil value allocates a map, otherwise embedding the
 	// IndexQuery will have unexpected results
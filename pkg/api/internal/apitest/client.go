@@ -30,7 +30,7 @@ import (
 func NewClient(ctx context.Context) (api.Client, error) {
 	address := os.Getenv("STORMFORGE_SERVER")
 
-	transport := &userAgentTransport{}
+	var transport http.RoundTripper
 
 	if clientID := os.Getenv("STORMFORGE_CLIENT_ID"); clientID != "" {
 		cc := clientcredentials.Config{
@@ -42,11 +42,11 @@ func NewClient(ctx context.Context) (api.Client, error) {
 				"audience": {address},
 			},
 		}
-		transport.Base = &oauth2.Transport{
+		transport = &oauth2.Transport{
 			Source: cc.TokenSource(ctx),
 		}
 	} else if accessToken := os.Getenv("STORMFORGE_TOKEN"); accessToken != "" {
-		transport.Base = &oauth2.Transport{
+		transport = &oauth2.Transport{
 			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}),
 		}
 	}
@@ -54,28 +54,7 @@ func NewClient(ctx context.Context) (api.Client, error) {
 	return api.NewClient(address, transport)
 }
 
-type uaKey struct{}
-
 // WithUserAgent updates the value of the User-Agent header to send with the supplied context.
 func WithUserAgent(ctx context.Context, ua string) context.Context {
-	return context.WithValue(ctx, uaKey{}, ua)
-}
-
-type userAgentTransport struct {
-	Base http.RoundTripper
-}
-
-// RoundTrip updates the User-Agent header based on the current context value.
-func (u *userAgentTransport) RoundTrip(r *http.Request) (*http.Response, error) {
-	// Check the context for a User-Agent value
-	if ua, ok := r.Context().Value(uaKey{}).(string); ok {
-		r = r.WithContext(r.Context())
-		r.Header.Set("User-Agent", ua)
-	}
-
-	// Finish the request
-	if u.Base != nil {
-		return u.Base.RoundTrip(r)
-	}
-	return http.DefaultTransport.RoundTrip(r)
+	return api.WithHeader(ctx, "User-Agent", ua)
 }
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+type defaultLimitKey struct{}
+
+// WithDefaultLimit returns a context which causes list calls made with it to
+// request at most n items per page unless the query explicitly sets a limit.
+// A value of zero or less removes the default.
+func WithDefaultLimit(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, defaultLimitKey{}, n)
+}
+
+// DefaultLimitFromContext returns the default list limit associated with the
+// supplied context, or zero if there is no default.
+func DefaultLimitFromContext(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	n, _ := ctx.Value(defaultLimitKey{}).(int)
+	return n
+}
+
+// ApplyDefaultLimit returns a copy of the supplied query with the context's
+// default limit applied if the query does not already have a limit.
+func ApplyDefaultLimit(ctx context.Context, q IndexQuery) IndexQuery {
+	n := DefaultLimitFromContext(ctx)
+	if n <= 0 || url.Values(q).Has(ParamLimit) {
+		return q
+	}
+
+	result := make(IndexQuery, len(q)+1)
+	for k, v := range q {
+		result[k] = append([]string(nil), v...)
+	}
+	url.Values(result).Set(ParamLimit, strconv.Itoa(n))
+	return result
+}
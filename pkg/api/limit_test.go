@@ -0,0 +1,41 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDefaultLimit(t *testing.T) {
+	ctx := context.Background()
+
+	q := IndexQuery{}
+	assert.NotContains(t, ApplyDefaultLimit(ctx, q), ParamLimit)
+
+	ctx = WithDefaultLimit(ctx, 25)
+	assert.Equal(t, []string{"25"}, ApplyDefaultLimit(ctx, q)[ParamLimit])
+	assert.NotContains(t, q, ParamLimit, "original query should not be modified")
+
+	q.SetLimit(100)
+	assert.Equal(t, []string{"100"}, ApplyDefaultLimit(ctx, q)[ParamLimit])
+
+	assert.Equal(t, []string{"25"}, ApplyDefaultLimit(ctx, nil)[ParamLimit])
+	assert.NotContains(t, ApplyDefaultLimit(WithDefaultLimit(ctx, 0), nil), ParamLimit)
+}
@@ -37,12 +37,16 @@ const (
 
 	// StormForge extension relations
 
+	RelationActivity        = "https://stormforge.io/rel/activity"
+	RelationClusters        = "https://stormforge.io/rel/clusters"
 	RelationExperiments     = "https://stormforge.io/rel/experiments"
 	RelationLabels          = "https://stormforge.io/rel/labels"
 	RelationNextTrial       = "https://stormforge.io/rel/next-trial"
 	RelationRecommendations = "https://stormforge.io/rel/recommendations"
+	RelationRemoteWrite     = "https://stormforge.io/rel/remote-write"
 	RelationScenarios       = "https://stormforge.io/rel/scenarios"
 	RelationTemplate        = "https://stormforge.io/rel/template"
+	RelationTestCases       = "https://stormforge.io/rel/test-cases"
 	RelationTrials          = "https://stormforge.io/rel/trials"
 )
 
@@ -57,6 +61,22 @@ func (m Metadata) Location() string {
 	return http.Header(m).Get("Location")
 }
 
+func (m Metadata) ETag() string {
+	return http.Header(m).Get("ETag")
+}
+
+// WithETag returns a copy of the metadata with the supplied entity tag. The
+// entity tag of an object's metadata is sent as an "If-Match" precondition
+// when the object is updated.
+func (m Metadata) WithETag(etag string) Metadata {
+	result := Metadata(http.Header(m).Clone())
+	if result == nil {
+		result = Metadata{}
+	}
+	http.Header(result).Set("ETag", etag)
+	return result
+}
+
 func (m Metadata) LastModified() time.Time {
 	value, _ := http.ParseTime(http.Header(m).Get("Last-Modified"))
 	return value
@@ -136,6 +156,12 @@ func CanonicalLinkRelation(rel string) string {
 	case "previous":
 		return RelationPrev
 
+	case "https://stormforge.io/rel/application-activity":
+		return RelationActivity
+
+	case "https://carbonrelay.com/rel/experiments":
+		return RelationExperiments
+
 	case "https://carbonrelay.com/rel/labels",
 		"https://carbonrelay.com/rel/triallabels":
 		return RelationLabels
@@ -147,6 +173,10 @@ func CanonicalLinkRelation(rel string) string {
 		"https://carbonrelay.com/rel/nexttrial":
 		return RelationNextTrial
 
+	case "https://stormforge.io/rel/remotewrite",
+		"https://stormforge.io/rel/remote_write":
+		return RelationRemoteWrite
+
 	default:
 		return rel
 	}
@@ -58,6 +58,26 @@ func TestMetadata(t *testing.T) {
 	assert.Equal(t, "/list?offset=10", md.Link(RelationNext))
 }
 
+func TestCanonicalLinkRelation(t *testing.T) {
+	cases := []struct {
+		rel      string
+		expected string
+	}{
+		{rel: "previous", expected: RelationPrev},
+		{rel: "https://carbonrelay.com/rel/triallabels", expected: RelationLabels},
+		{rel: "https://carbonrelay.com/rel/nexttrial", expected: RelationNextTrial},
+		{rel: "https://stormforge.io/rel/application-activity", expected: RelationActivity},
+		{rel: "https://stormforge.io/rel/remote_write", expected: RelationRemoteWrite},
+		{rel: RelationClusters, expected: RelationClusters},
+		{rel: "unknown", expected: "unknown"},
+	}
+	for _, c := range cases {
+		t.Run(c.rel, func(t *testing.T) {
+			assert.Equal(t, c.expected, CanonicalLinkRelation(c.rel))
+		})
+	}
+}
+
 func TestJsonMetadata_UnmarshalJSON(t *testing.T) {
 	// Verify last-entry-wins
 	data := []byte(`
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+const (
+	ErrMetricsInvalid api.ErrorType = "metrics-invalid"
+)
+
+// Label is a single name/value pair identifying a time series.
+type Label struct {
+	// The label name, the metric name uses the reserved "__name__" label.
+	Name string `json:"name"`
+	// The label value.
+	Value string `json:"value"`
+}
+
+// Sample is a single observed value of a time series.
+type Sample struct {
+	// The observed value.
+	Value float64 `json:"value"`
+	// The time of the observation in milliseconds since the epoch.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// TimeSeries is a labeled sequence of samples.
+type TimeSeries struct {
+	// The labels identifying the time series.
+	Labels []Label `json:"labels"`
+	// The samples to write.
+	Samples []Sample `json:"samples"`
+}
+
+// MetricName returns the value of the reserved metric name label.
+func (ts *TimeSeries) MetricName() string {
+	for _, l := range ts.Labels {
+		if l.Name == LabelMetricName {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// LabelMetricName is the reserved label used to hold the metric name.
+const LabelMetricName = "__name__"
+
+// WriteRequest is the JSON form of a remote write request.
+type WriteRequest struct {
+	// The time series to write.
+	TimeSeries []TimeSeries `json:"timeseries"`
+}
+
+type API interface {
+	// Write sends the supplied time series to the remote write endpoint.
+	Write(ctx context.Context, wr WriteRequest) error
+}
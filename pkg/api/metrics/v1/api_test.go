@@ -0,0 +1,168 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestParseWriteRequest(t *testing.T) {
+	now := time.UnixMilli(1000)
+	name := Label{Name: LabelMetricName, Value: "cpu_usage"}
+
+	cases := []struct {
+		desc     string
+		data     string
+		expected []TimeSeries
+		err      string
+	}{
+		{
+			desc: "text",
+			data: `
+# HELP cpu_usage CPU usage.
+# TYPE cpu_usage gauge
+cpu_usage{pod="b",namespace="default"} 0.5 2000
+cpu_usage{namespace="default", pod="b"} 0.75
+cpu_usage 1e3
+`,
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{name, {Name: "namespace", Value: "default"}, {Name: "pod", Value: "b"}},
+					Samples: []Sample{{Value: 0.5, Timestamp: 2000}, {Value: 0.75, Timestamp: 1000}},
+				},
+				{
+					Labels:  []Label{name},
+					Samples: []Sample{{Value: 1000, Timestamp: 1000}},
+				},
+			},
+		},
+		{
+			desc: "text escapes",
+			data: `cpu_usage{path="C:\\tmp",quote="\"",nl="a\nb",} +Inf`,
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{name, {Name: "nl", Value: "a\nb"}, {Name: "path", Value: `C:\tmp`}, {Name: "quote", Value: `"`}},
+					Samples: []Sample{{Value: math.Inf(1), Timestamp: 1000}},
+				},
+			},
+		},
+		{
+			desc: "json",
+			data: `{"timeseries":[{"labels":[{"name":"__name__","value":"cpu_usage"}],"samples":[{"value":2},{"value":3,"timestamp":5}]}]}`,
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{name},
+					Samples: []Sample{{Value: 2, Timestamp: 1000}, {Value: 3, Timestamp: 5}},
+				},
+			},
+		},
+		{
+			desc: "json list",
+			data: `[{"labels":[{"name":"__name__","value":"cpu_usage"}],"samples":[{"value":2}]}]`,
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{name},
+					Samples: []Sample{{Value: 2, Timestamp: 1000}},
+				},
+			},
+		},
+		{
+			desc: "json missing name",
+			data: `[{"labels":[{"name":"pod","value":"a"}],"samples":[{"value":2}]}]`,
+			err:  "time series 0 is missing a metric name",
+		},
+		{
+			desc: "invalid value",
+			data: "cpu_usage abc",
+			err:  `line 1: invalid value "abc"`,
+		},
+		{
+			desc: "unquoted label",
+			data: "cpu_usage{pod=a} 1",
+			err:  `line 1: label "pod" value must be quoted`,
+		},
+		{
+			desc: "unterminated label",
+			data: `cpu_usage{pod="a} 1`,
+			err:  `line 1: label "pod" value is not terminated`,
+		},
+		{
+			desc: "invalid name",
+			data: "\n1cpu 1",
+			err:  `line 2: invalid metric name "1cpu"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			wr, err := ParseWriteRequest([]byte(c.data), now)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, wr.TimeSeries)
+			}
+		})
+	}
+}
+
+func TestAPI_Write(t *testing.T) {
+	var received WriteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/custom/write", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil || len(received.TimeSeries) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"no samples"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	metricsAPI, err := NewAPIWithEndpoint(client, srv.URL+"/custom/write")
+	require.NoError(t, err)
+
+	wr := WriteRequest{TimeSeries: []TimeSeries{{
+		Labels:  []Label{{Name: LabelMetricName, Value: "up"}},
+		Samples: []Sample{{Value: 1, Timestamp: 1}},
+	}}}
+	if assert.NoError(t, metricsAPI.Write(context.Background(), wr)) {
+		assert.Equal(t, wr, received)
+	}
+
+	err = metricsAPI.Write(context.Background(), WriteRequest{})
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrMetricsInvalid, apiErr.Type)
+		assert.Equal(t, "no samples", apiErr.Message)
+	}
+}
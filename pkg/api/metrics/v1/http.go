@@ -0,0 +1,73 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// NewAPI returns a new API implementation for the specified client.
+func NewAPI(client api.Client) API {
+	return &httpAPI{client: client, endpoint: "v1/metrics/write"}
+}
+
+// NewAPIWithEndpoint returns a new API implementation with an alternate endpoint.
+func NewAPIWithEndpoint(client api.Client, endpoint string) (API, error) {
+	// If endpoint is not a valid URL, calling `c.URL(endpoint)` would panic
+	_, err := url.Parse(endpoint)
+	return &httpAPI{client: client, endpoint: endpoint}, err
+}
+
+type httpAPI struct {
+	client   api.Client
+	endpoint string
+}
+
+var _ API = &httpAPI{}
+
+func (h *httpAPI) Write(ctx context.Context, wr WriteRequest) error {
+	data, err := json.Marshal(wr)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.client.URL(h.endpoint).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return api.NewError(ErrMetricsInvalid, resp, body)
+	default:
+		return api.NewUnexpectedError(resp, body)
+	}
+}
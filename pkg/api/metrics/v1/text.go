@@ -0,0 +1,219 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseWriteRequest decodes either the JSON form of a write request (or a bare
+// list of time series) or the Prometheus text exposition format. Samples
+// without a timestamp are recorded at the supplied time.
+func ParseWriteRequest(data []byte, now time.Time) (WriteRequest, error) {
+	var wr WriteRequest
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		if err := json.Unmarshal(trimmed, &wr); err != nil {
+			return wr, err
+		}
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &wr.TimeSeries); err != nil {
+			return wr, err
+		}
+	default:
+		ts, err := ParseText(data, now)
+		if err != nil {
+			return wr, err
+		}
+		wr.TimeSeries = ts
+	}
+
+	ms := now.UnixMilli()
+	for i := range wr.TimeSeries {
+		if wr.TimeSeries[i].MetricName() == "" {
+			return wr, fmt.Errorf("time series %d is missing a metric name", i)
+		}
+		for j := range wr.TimeSeries[i].Samples {
+			if wr.TimeSeries[i].Samples[j].Timestamp == 0 {
+				wr.TimeSeries[i].Samples[j].Timestamp = ms
+			}
+		}
+	}
+	return wr, nil
+}
+
+// ParseText parses samples in the Prometheus text exposition format. Comments
+// (including the HELP and TYPE metadata) are ignored; samples of the same
+// series are grouped in the order they first appear.
+func ParseText(data []byte, now time.Time) ([]TimeSeries, error) {
+	var result []TimeSeries
+	index := make(map[string]int)
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		labels, sample, err := parseTextLine(line, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		key := seriesKey(labels)
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, TimeSeries{Labels: labels})
+		}
+		result[i].Samples = append(result[i].Samples, sample)
+	}
+	return result, s.Err()
+}
+
+// parseTextLine parses a single "name{labels} value [timestamp]" sample line.
+func parseTextLine(line string, now time.Time) ([]Label, Sample, error) {
+	var sample Sample
+
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return nil, sample, fmt.Errorf("missing value")
+	}
+	name := line[:end]
+	if !validMetricName(name) {
+		return nil, sample, fmt.Errorf("invalid metric name %q", name)
+	}
+	labels := []Label{{Name: LabelMetricName, Value: name}}
+
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		var ls []Label
+		ls, rest, err = parseTextLabels(rest[1:])
+		if err != nil {
+			return nil, sample, err
+		}
+		labels = append(labels, ls...)
+	}
+
+	fields := strings.Fields(rest)
+	switch len(fields) {
+	case 1:
+		sample.Timestamp = now.UnixMilli()
+	case 2:
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, sample, fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+		sample.Timestamp = ts
+	default:
+		return nil, sample, fmt.Errorf("expected a value and optional timestamp")
+	}
+
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, sample, fmt.Errorf("invalid value %q", fields[0])
+	}
+	sample.Value = v
+
+	sort.Slice(labels[1:], func(i, j int) bool { return labels[i+1].Name < labels[j+1].Name })
+	return labels, sample, nil
+}
+
+// parseTextLabels parses the label pairs following the opening brace, returning the remainder of the line.
+func parseTextLabels(s string) ([]Label, string, error) {
+	var labels []Label
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, "", fmt.Errorf("invalid label set")
+		}
+		name := strings.TrimSpace(s[:eq])
+		if !validMetricName(name) || strings.Contains(name, ":") {
+			return nil, "", fmt.Errorf("invalid label name %q", name)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, "\"") {
+			return nil, "", fmt.Errorf("label %q value must be quoted", name)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("label %q value is not terminated", name)
+		}
+		labels = append(labels, Label{Name: name, Value: value.String()})
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+// validMetricName checks the name against the Prometheus metric name syntax.
+func validMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// seriesKey returns a string uniquely identifying a set of sorted labels.
+func seriesKey(labels []Label) string {
+	var sb strings.Builder
+	for _, l := range labels {
+		sb.WriteString(l.Name)
+		sb.WriteByte(0)
+		sb.WriteString(l.Value)
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OperationOptions control how the status of an asynchronous operation is polled.
+type OperationOptions struct {
+	// The time to wait before the first poll. Defaults to 1 second.
+	InitialInterval time.Duration
+	// The longest time to wait between polls. Defaults to 30 seconds.
+	MaxInterval time.Duration
+	// The factor applied to the interval after each poll. Defaults to 2.
+	Multiplier float64
+	// Optional function to check if a successful response represents a terminal
+	// state. By default, any successful response other than "202 Accepted" is
+	// considered terminal.
+	Done func(resp *http.Response, body []byte) (bool, error)
+}
+
+// WaitForOperation polls the location of an asynchronous operation (typically
+// the location returned with a "202 Accepted" response) until the operation
+// reaches a terminal state, returning the metadata of the final response. The
+// server may delay polling using the "Retry-After" header.
+func WaitForOperation(ctx context.Context, client Client, location string, opts OperationOptions) (Metadata, error) {
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		delay := interval
+		req, err := http.NewRequest(http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, body, err := client.Do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusAccepted:
+			if ra := retryAfter(resp); ra > 0 {
+				delay = ra
+			}
+
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			done := true
+			if opts.Done != nil {
+				if done, err = opts.Done(resp, body); err != nil {
+					return nil, err
+				}
+			}
+			if done {
+				md := Metadata{}
+				UnmarshalMetadata(resp, &md)
+				if md.Location() == "" && resp.Request != nil && resp.Request.URL != nil {
+					// Redirects to the final resource are followed automatically
+					http.Header(md).Set("Location", resp.Request.URL.String())
+				}
+				return md, nil
+			}
+
+		case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable:
+			if ra := retryAfter(resp); ra > 0 {
+				delay = ra
+			}
+
+		default:
+			return nil, NewUnexpectedError(resp, body)
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		interval = nextOperationInterval(interval, opts)
+	}
+}
+
+// nextOperationInterval returns the backoff interval used for the next poll.
+func nextOperationInterval(interval time.Duration, opts OperationOptions) time.Duration {
+	multiplier := opts.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+
+	interval = time.Duration(float64(interval) * multiplier)
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// retryAfter returns the delay requested using the "Retry-After" header.
+func retryAfter(resp *http.Response) time.Duration {
+	ra := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(ra); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(ra); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForOperation(t *testing.T) {
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/operations/1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		http.Redirect(w, r, "/resources/1", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/resources/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/operations/failed", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := OperationOptions{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+	md, err := WaitForOperation(ctx, client, srv.URL+"/operations/1", opts)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, polls)
+		assert.Equal(t, srv.URL+"/resources/1", md.Location())
+		assert.Equal(t, `"1"`, md.ETag())
+	}
+
+	_, err = WaitForOperation(ctx, client, srv.URL+"/operations/failed", opts)
+	assert.Error(t, err)
+
+	polls = -100
+	cancelCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = WaitForOperation(cancelCtx, client, srv.URL+"/operations/1", opts)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNextOperationInterval(t *testing.T) {
+	opts := OperationOptions{MaxInterval: 5 * time.Second}
+	assert.Equal(t, 2*time.Second, nextOperationInterval(time.Second, opts))
+	assert.Equal(t, 5*time.Second, nextOperationInterval(4*time.Second, opts))
+}
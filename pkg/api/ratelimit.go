@@ -0,0 +1,117 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRateLimitThreshold is the remaining request quota below which
+// requests are spread out over the remainder of the rate limit window.
+const DefaultRateLimitThreshold = 10
+
+// RateLimit is the request quota reported by the server.
+type RateLimit struct {
+	// The maximum number of requests allowed in the current window.
+	Limit int
+	// The number of requests remaining in the current window.
+	Remaining int
+	// The time at which the current window ends, may be zero if unknown.
+	Reset time.Time
+}
+
+// RateLimit returns the request quota from the `X-RateLimit-*` headers.
+func (m Metadata) RateLimit() (RateLimit, bool) {
+	h := http.Header(m)
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	rl := RateLimit{Remaining: remaining}
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+
+	// The reset value may be a Unix timestamp or a number of seconds
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if reset > 1e9 {
+			rl.Reset = time.Unix(reset, 0)
+		} else {
+			now, err := http.ParseTime(h.Get("Date"))
+			if err != nil {
+				now = time.Now()
+			}
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	return rl, true
+}
+
+// Delay returns the amount of time to wait before the next request in order to
+// spread the remaining quota over the remainder of the window. No delay is
+// necessary until the remaining quota drops below the supplied threshold.
+func (rl RateLimit) Delay(threshold int) time.Duration {
+	if rl.Remaining >= threshold || rl.Reset.IsZero() {
+		return 0
+	}
+
+	d := time.Until(rl.Reset)
+	if d <= 0 {
+		return 0
+	}
+	return d / time.Duration(rl.Remaining+1)
+}
+
+// Throttle blocks for the delay necessary to avoid exhausting the request
+// quota reported by the supplied metadata. A negative threshold disables
+// throttling, zero uses the default threshold.
+func Throttle(ctx context.Context, md Metadata, threshold int) error {
+	switch {
+	case threshold < 0:
+		return nil
+	case threshold == 0:
+		threshold = DefaultRateLimitThreshold
+	}
+
+	rl, ok := md.RateLimit()
+	if !ok {
+		return nil
+	}
+
+	d := rl.Delay(threshold)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WithRateLimitFunc returns a client option which invokes the supplied callback
+// for every response that reports a request quota.
+func WithRateLimitFunc(f func(RateLimit)) ClientOption {
+	return func(c *httpClient) { c.rateLimitFunc = f }
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata_RateLimit(t *testing.T) {
+	date := time.Date(2023, time.May, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		desc     string
+		md       Metadata
+		expected RateLimit
+		ok       bool
+	}{
+		{
+			desc: "missing",
+			md:   Metadata{},
+		},
+		{
+			desc: "relative reset",
+			md: Metadata{
+				"X-Ratelimit-Limit":     []string{"100"},
+				"X-Ratelimit-Remaining": []string{"5"},
+				"X-Ratelimit-Reset":     []string{"30"},
+				"Date":                  []string{date.Format(http.TimeFormat)},
+			},
+			expected: RateLimit{Limit: 100, Remaining: 5, Reset: date.Add(30 * time.Second)},
+			ok:       true,
+		},
+		{
+			desc: "absolute reset",
+			md: Metadata{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{"1682942400"},
+			},
+			expected: RateLimit{Remaining: 0, Reset: time.Unix(1682942400, 0)},
+			ok:       true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, ok := c.md.RateLimit()
+			assert.Equal(t, c.ok, ok)
+			assert.True(t, c.expected.Reset.Equal(actual.Reset), "reset %s != %s", c.expected.Reset, actual.Reset)
+			c.expected.Reset, actual.Reset = time.Time{}, time.Time{}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestRateLimit_Delay(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+
+	assert.Zero(t, RateLimit{Remaining: 50, Reset: reset}.Delay(10))
+	assert.Zero(t, RateLimit{Remaining: 1}.Delay(10))
+	assert.InDelta(t, 30*time.Minute, RateLimit{Remaining: 1, Reset: reset}.Delay(10), float64(time.Second))
+}
+
+func TestThrottle(t *testing.T) {
+	md := Metadata{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Reset":     []string{"60"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, Throttle(ctx, md, 0), context.DeadlineExceeded)
+	assert.NoError(t, Throttle(ctx, md, -1))
+}
+
+func TestWithRateLimitFunc(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+	}))
+	defer srv.Close()
+
+	var actual []int
+	client, err := NewClient(srv.URL, nil, WithRateLimitFunc(func(rl RateLimit) {
+		actual = append(actual, rl.Remaining)
+	}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, _, err = client.Do(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{42}, actual)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted is the placeholder used in place of sensitive values.
+const Redacted = "REDACTED"
+
+// sensitiveHeaders are the canonical names of headers which carry credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// sensitiveNames are the normalized (see `isSensitiveName`) names of query
+// parameters, form values and JSON fields which carry credentials.
+var sensitiveNames = map[string]bool{
+	"accesstoken":   true,
+	"actortoken":    true,
+	"apikey":        true,
+	"assertion":     true,
+	"authorization": true,
+	"clientsecret":  true,
+	"idtoken":       true,
+	"password":      true,
+	"refreshtoken":  true,
+	"subjecttoken":  true,
+	"token":         true,
+}
+
+// RedactURL returns the string representation of a URL with any password and
+// sensitive query parameters redacted.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	r := *u
+	if _, ok := r.User.Password(); ok {
+		r.User = url.UserPassword(r.User.Username(), Redacted)
+	}
+	if r.RawQuery != "" {
+		if q, err := url.ParseQuery(r.RawQuery); err == nil {
+			redactValues(q)
+			r.RawQuery = q.Encode()
+		} else {
+			r.RawQuery = Redacted
+		}
+	}
+	return r.String()
+}
+
+// RedactHeader returns a copy of the supplied header with the values of
+// sensitive headers redacted.
+func RedactHeader(h http.Header) http.Header {
+	result := h.Clone()
+	for k, v := range result {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			for i := range v {
+				v[i] = redactCredentials(v[i])
+			}
+		}
+	}
+	return result
+}
+
+// RedactBody returns a copy of the supplied body with sensitive values redacted.
+// JSON and form encoded bodies are supported, bodies of other content types
+// are returned unmodified. Note that redacting a JSON body does not preserve
+// the original formatting or field order.
+func RedactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		q, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		redactValues(q)
+		return []byte(q.Encode())
+
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return body
+		}
+		result, err := json.Marshal(redactJSON(v))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		return result
+	}
+
+	return body
+}
+
+// redactValues redacts sensitive values in place.
+func redactValues(q url.Values) {
+	for k, v := range q {
+		if isSensitiveName(k) {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+	}
+}
+
+// redactJSON redacts sensitive fields of a generic JSON value.
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range v {
+			if _, ok := vv.(string); ok && isSensitiveName(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = redactJSON(vv)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}
+
+// redactCredentials redacts a header value while preserving the authorization
+// scheme (e.g. "Bearer").
+func redactCredentials(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok && !strings.Contains(scheme, "=") {
+		return scheme + " " + Redacted
+	}
+	return Redacted
+}
+
+// isSensitiveName checks a name after removing case and separator differences,
+// e.g. "client_secret", "clientSecret" and "Client-Secret" are all equivalent.
+func isSensitiveName(name string) bool {
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	return sensitiveNames[name]
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how requests which fail with a transient error are
+// retried. Only idempotent requests (or requests with an "Idempotency-Key"
+// header) whose body can be replayed are retried, and only when the server
+// responds with a 429, 502, 503 or 504 status. A "Retry-After" header on the
+// response takes precedence over the computed backoff, but is capped at the
+// maximum interval.
+type RetryPolicy struct {
+	// The maximum number of retries, zero or less disables retries.
+	MaxRetries int
+	// The delay before the first retry. Defaults to 500 milliseconds.
+	InitialInterval time.Duration
+	// The maximum delay between retries. Defaults to 30 seconds.
+	MaxInterval time.Duration
+	// The factor applied to the delay after each retry. Defaults to 2.
+	Multiplier float64
+	// The maximum fraction of the delay randomly added or removed, zero disables jitter.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is a reasonable retry policy for interactive clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:      3,
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2,
+}
+
+// WithRetryPolicy returns a client option which retries transient failures
+// using the supplied policy.
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(c *httpClient) { c.retry = p }
+}
+
+type retryPolicyKey struct{}
+
+// WithRetry returns a context which overrides the retry policy of the client
+// for requests made with it. Use a zero policy to disable retries.
+func WithRetry(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// RetryPolicyFromContext returns the retry policy associated with the supplied
+// context, if there is one.
+func RetryPolicyFromContext(ctx context.Context) (RetryPolicy, bool) {
+	if ctx == nil {
+		return RetryPolicy{}, false
+	}
+	p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy)
+	return p, ok
+}
+
+// shouldRetry returns the delay before the next attempt and true if the
+// request should be retried.
+func (p *RetryPolicy) shouldRetry(attempt int, req *http.Request, resp *http.Response) (time.Duration, bool) {
+	if attempt >= p.MaxRetries || resp == nil || !isReplayable(req) {
+		return 0, false
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+
+	if d := retryAfter(resp); d > 0 {
+		return p.capInterval(d), true
+	}
+	return p.backoff(attempt), true
+}
+
+// capInterval limits the supplied delay to the maximum interval.
+func (p *RetryPolicy) capInterval(d time.Duration) time.Duration {
+	maxInterval := p.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+	if d > maxInterval {
+		return maxInterval
+	}
+	return d
+}
+
+// backoff returns the exponential backoff delay for the specified attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	interval := p.InitialInterval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	opts := OperationOptions{Multiplier: p.Multiplier, MaxInterval: p.MaxInterval}
+	for i := 0; i < attempt; i++ {
+		interval = nextOperationInterval(interval, opts)
+	}
+
+	if p.Jitter > 0 {
+		interval += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(interval))
+	}
+	return interval
+}
+
+// isReplayable checks if a request is idempotent and can be sent again.
+func isReplayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Retry(t *testing.T) {
+	var requests int
+	var failures int
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if requests <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond}
+	client, err := NewClient(srv.URL, nil, WithRetryPolicy(policy))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cases := []struct {
+		desc     string
+		ctx      context.Context
+		method   string
+		body     string
+		header   string
+		failures int
+		status   int
+		requests int
+	}{
+		{
+			desc:     "success",
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			requests: 1,
+		},
+		{
+			desc:     "retried",
+			method:   http.MethodGet,
+			failures: 2,
+			status:   http.StatusOK,
+			requests: 3,
+		},
+		{
+			desc:     "exhausted",
+			method:   http.MethodGet,
+			failures: 3,
+			status:   http.StatusServiceUnavailable,
+			requests: 3,
+		},
+		{
+			desc:     "not idempotent",
+			method:   http.MethodPost,
+			body:     "test",
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			requests: 1,
+		},
+		{
+			desc:     "idempotency key",
+			method:   http.MethodPost,
+			body:     "test",
+			header:   "abc",
+			failures: 1,
+			status:   http.StatusOK,
+			requests: 2,
+		},
+		{
+			desc:     "context override",
+			ctx:      WithRetry(ctx, RetryPolicy{}),
+			method:   http.MethodGet,
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			requests: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			requests, failures, bodies = 0, c.failures, nil
+			reqCtx := c.ctx
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+
+			req, err := http.NewRequest(c.method, srv.URL, bytes.NewReader([]byte(c.body)))
+			require.NoError(t, err)
+			if c.header != "" {
+				req.Header.Set("Idempotency-Key", c.header)
+			}
+
+			resp, _, err := client.Do(reqCtx, req)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.status, resp.StatusCode)
+				assert.Equal(t, c.requests, requests)
+				for _, b := range bodies {
+					assert.Equal(t, c.body, b)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Retry_canceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil, WithRetryPolicy(RetryPolicy{MaxRetries: 1}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, _, err := client.Do(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Less(t, time.Since(start), time.Second, "should not wait for the server requested delay")
+}
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 3, InitialInterval: time.Second, MaxInterval: 3 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	d, ok := p.shouldRetry(0, req, resp)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, d)
+
+	d, ok = p.shouldRetry(2, req, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d, "capped")
+
+	resp.Header.Set("Retry-After", "2")
+	d, ok = p.shouldRetry(0, req, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	resp.Header.Set("Retry-After", "86400")
+	d, ok = p.shouldRetry(0, req, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d, "server delay capped")
+
+	d, ok = (&RetryPolicy{MaxRetries: 1}).shouldRetry(0, req, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d, "server delay capped by default")
+
+	_, ok = p.shouldRetry(3, req, resp)
+	assert.False(t, ok, "max retries")
+
+	resp.StatusCode = http.StatusInternalServerError
+	_, ok = p.shouldRetry(0, req, resp)
+	assert.False(t, ok, "not transient")
+}
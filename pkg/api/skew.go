@@ -0,0 +1,39 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClockSkewThreshold is the amount of clock skew beyond which token expiry
+// checks become unreliable.
+const ClockSkewThreshold = time.Minute
+
+// ClockSkew returns the difference between the supplied local time and the
+// server time reported by the "Date" header; a positive value indicates the
+// local clock is ahead of the server. Returns false if the server time is unknown.
+func (m Metadata) ClockSkew(now time.Time) (time.Duration, bool) {
+	date, err := http.ParseTime(http.Header(m).Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+
+	// The header only has a resolution of one second
+	return now.Truncate(time.Second).Sub(date), true
+}
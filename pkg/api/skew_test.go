@@ -0,0 +1,43 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_ClockSkew(t *testing.T) {
+	now := time.Date(2023, time.June, 1, 12, 0, 0, 500, time.UTC)
+
+	md := Metadata{}
+	_, ok := md.ClockSkew(now)
+	assert.False(t, ok)
+
+	http.Header(md).Set("Date", now.Add(-5*time.Minute).Format(http.TimeFormat))
+	skew, ok := md.ClockSkew(now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, skew)
+
+	http.Header(md).Set("Date", now.Format(http.TimeFormat))
+	skew, ok = md.ClockSkew(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), skew)
+}
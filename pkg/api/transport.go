@@ -0,0 +1,88 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// TransportOptions control the connection pooling of the HTTP transport used
+// to communicate with the API.
+type TransportOptions struct {
+	// The maximum number of idle connections to keep for each host.
+	MaxIdleConnsPerHost int
+	// The amount of time an idle connection is kept before it is closed.
+	IdleConnTimeout time.Duration
+	// Attempt to use HTTP/2, even when the TLS or dial configuration is customized.
+	ForceAttemptHTTP2 bool
+}
+
+// DefaultTransportOptions are suitable for bursts of requests to the same
+// host, e.g. when a lister fetches many pages or items concurrently. The
+// standard library default only keeps two idle connections per host, causing
+// additional TLS handshakes once more than two requests are in flight.
+var DefaultTransportOptions = TransportOptions{
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     90 * time.Second,
+	ForceAttemptHTTP2:   true,
+}
+
+// NewTransport returns a copy of the default HTTP transport tuned using the
+// supplied options. If the default transport has been replaced with something
+// other than an `*http.Transport`, a new transport is created instead.
+func NewTransport(opts TransportOptions) *http.Transport {
+	var t *http.Transport
+	if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = dt.Clone()
+	} else {
+		t = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	opts.apply(t)
+	return t
+}
+
+// WithTransportOptions returns a client option which tunes the connection
+// pooling of the client. The options are only applied when the client uses an
+// `*http.Transport` (which is copied), including the transport of clients
+// created without one; wrapping round trippers must be configured using
+// `NewTransport` instead.
+func WithTransportOptions(opts TransportOptions) ClientOption {
+	return func(c *httpClient) {
+		if t, ok := c.client.Transport.(*http.Transport); ok {
+			t = t.Clone()
+			opts.apply(t)
+			c.client.Transport = t
+		}
+	}
+}
+
+// apply updates the supplied transport, zero values are ignored.
+func (o *TransportOptions) apply(t *http.Transport) {
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		if t.MaxIdleConns > 0 && t.MaxIdleConns < o.MaxIdleConnsPerHost {
+			t.MaxIdleConns = o.MaxIdleConnsPerHost
+		}
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if o.ForceAttemptHTTP2 {
+		t.ForceAttemptHTTP2 = true
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport(t *testing.T) {
+	tr := NewTransport(TransportOptions{MaxIdleConnsPerHost: 32, IdleConnTimeout: time.Minute, ForceAttemptHTTP2: true})
+	assert.Equal(t, 32, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	assert.GreaterOrEqual(t, tr.MaxIdleConns, 32)
+	assert.NotSame(t, http.DefaultTransport, tr)
+}
+
+func TestWithTransportOptions(t *testing.T) {
+	base := &http.Transport{MaxIdleConnsPerHost: 1}
+	c, err := NewClient("http://example.com/", base, WithTransportOptions(TransportOptions{MaxIdleConnsPerHost: 8}))
+	require.NoError(t, err)
+
+	tr, ok := c.(*httpClient).client.Transport.(*http.Transport)
+	if assert.True(t, ok) {
+		assert.Equal(t, 8, tr.MaxIdleConnsPerHost)
+	}
+	assert.Equal(t, 1, base.MaxIdleConnsPerHost, "supplied transport should not be modified")
+
+	c, err = NewClient("http://example.com/", nil)
+	require.NoError(t, err)
+	tr, ok = c.(*httpClient).client.Transport.(*http.Transport)
+	if assert.True(t, ok) {
+		assert.Equal(t, DefaultTransportOptions.MaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// modulePath is the path of the module that contains this package.
+const modulePath = "github.com/thestormforge/optimize-go"
+
+// ClientVersion returns the version of this module embedded in the build
+// information of the running binary, or an empty string if it is unknown
+// (for example, during development).
+func ClientVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	version := bi.Main.Version
+	if bi.Main.Path != modulePath {
+		version = ""
+		for _, dep := range bi.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+				if dep.Replace != nil {
+					version = dep.Replace.Version
+				}
+				break
+			}
+		}
+	}
+
+	if version == "(devel)" {
+		return ""
+	}
+	return version
+}
+
+// MinimumClientVersion returns the oldest client version supported by the server.
+func (m Metadata) MinimumClientVersion() string {
+	return http.Header(m).Get("Minimum-Client-Version")
+}
+
+// CheckClientVersion returns an error if the server metadata (e.g. from a
+// `CheckEndpoint` call) advertises a minimum client version newer than this
+// client. Unknown versions are always considered supported.
+func CheckClientVersion(md Metadata) error {
+	c := Capabilities{MinimumClientVersion: md.MinimumClientVersion()}
+	return c.CheckClientVersion()
+}
+
+// CheckClientVersion returns an error if the advertised minimum client version
+// is newer than this client.
+func (c *Capabilities) CheckClientVersion() error {
+	minVersion, version := c.MinimumClientVersion, ClientVersion()
+	if minVersion == "" || version == "" || compareVersions(version, minVersion) >= 0 {
+		return nil
+	}
+
+	return &Error{
+		Type:    ErrClientVersionUnsupported,
+		Message: fmt.Sprintf("client version %s is older than the minimum supported version %s", version, minVersion),
+	}
+}
+
+// compareVersions compares the numeric components of two semantic versions,
+// pre-release and build information is ignored.
+func compareVersions(a, b string) int {
+	splitVersion := func(v string) []string {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		return strings.Split(v, ".")
+	}
+
+	aa, bb := splitVersion(a), splitVersion(b)
+	for i := 0; i < len(aa) || i < len(bb); i++ {
+		var x, y int
+		if i < len(aa) {
+			x, _ = strconv.Atoi(aa[i])
+		}
+		if i < len(bb) {
+			y, _ = strconv.Atoi(bb[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", expected: 0},
+		{a: "v1.2.3", b: "1.2.3", expected: 0},
+		{a: "v1.2.3", b: "v1.10.0", expected: -1},
+		{a: "v2.0.0", b: "v1.10.0", expected: 1},
+		{a: "v1.2", b: "v1.2.0", expected: 0},
+		{a: "v1.2.3-rc.1", b: "v1.2.3", expected: 0},
+		{a: "v0.0.0-20230101000000-abcdef123456", b: "v0.1.0", expected: -1},
+	}
+	for _, c := range cases {
+		t.Run(c.a+" "+c.b, func(t *testing.T) {
+			assert.Equal(t, c.expected, compareVersions(c.a, c.b))
+		})
+	}
+}
+
+func TestCheckClientVersion(t *testing.T) {
+	// Test binaries do not have a module version, so they are always supported
+	assert.NoError(t, CheckClientVersion(Metadata{}))
+	assert.NoError(t, CheckClientVersion(Metadata{"Minimum-Client-Version": {"v99.0.0"}}))
+}
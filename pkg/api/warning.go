@@ -0,0 +1,97 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WithWarningFunc returns a client option which invokes the supplied callback
+// for every warning included with a response.
+func WithWarningFunc(f func(string)) ClientOption {
+	return func(c *httpClient) { c.warningFunc = f }
+}
+
+// warningValue matches a single "Warning" header value: a three-digit code, the
+// agent and the quoted text, optionally followed by a quoted date.
+var warningValue = regexp.MustCompile(`(\d{3})\s+(\S+)\s+("(?:[^"\\]|\\.)*")(?:\s+"[^"]*")?`)
+
+// Warnings returns the text of the warnings included with a response, either
+// as "Warning" headers or in a top-level "warnings" array of the body.
+func (m Metadata) Warnings() []string {
+	var result []string
+	for _, v := range http.Header(m).Values("Warning") {
+		matches := warningValue.FindAllStringSubmatch(v, -1)
+		if len(matches) == 0 {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+			continue
+		}
+
+		for _, match := range matches {
+			text, err := strconv.Unquote(match[3])
+			if err != nil {
+				text = strings.Trim(match[3], `"`)
+			}
+			result = append(result, text)
+		}
+	}
+	return result
+}
+
+// addBodyWarnings copies the warnings from the top-level "warnings" array of a
+// JSON response body to the "Warning" headers so they are included in the
+// metadata of the response. Warnings may be strings or objects with a "message".
+func addBodyWarnings(resp *http.Response, body []byte) {
+	if !bytes.Contains(body, []byte(`"warnings"`)) {
+		return
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "application/json" {
+		return
+	}
+
+	var b struct {
+		Warnings []json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &b); err != nil {
+		return
+	}
+
+	for _, w := range b.Warnings {
+		var text string
+		if err := json.Unmarshal(w, &text); err != nil {
+			var obj struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(w, &obj); err != nil {
+				continue
+			}
+			text = obj.Message
+		}
+		if text != "" {
+			resp.Header.Add("Warning", fmt.Sprintf("299 - %s", strconv.Quote(text)))
+		}
+	}
+}
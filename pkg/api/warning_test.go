@@ -0,0 +1,91 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata_Warnings(t *testing.T) {
+	cases := []struct {
+		desc     string
+		md       Metadata
+		expected []string
+	}{
+		{
+			desc: "none",
+			md:   Metadata{},
+		},
+		{
+			desc: "single",
+			md: Metadata{
+				"Warning": []string{`299 - "The field \"foo\" is deprecated"`},
+			},
+			expected: []string{`The field "foo" is deprecated`},
+		},
+		{
+			desc: "combined with date",
+			md: Metadata{
+				"Warning": []string{`299 api.stormforge.io "first" "Mon, 01 May 2023 12:00:00 GMT", 199 - "second"`},
+			},
+			expected: []string{"first", "second"},
+		},
+		{
+			desc: "malformed",
+			md: Metadata{
+				"Warning": []string{"something is wrong"},
+			},
+			expected: []string{"something is wrong"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.md.Warnings())
+		})
+	}
+}
+
+func TestWithWarningFunc(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Warning", `299 - "from header"`)
+		_, _ = w.Write([]byte(`{"name":"test","warnings":["from body",{"message":"from object"}]}`))
+	}))
+	defer srv.Close()
+
+	var warnings []string
+	client, err := NewClient(srv.URL, nil, WithWarningFunc(func(w string) { warnings = append(warnings, w) }))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, _, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+
+	expected := []string{"from header", "from body", "from object"}
+	assert.Equal(t, expected, warnings)
+
+	var md Metadata
+	UnmarshalMetadata(resp, &md)
+	assert.Equal(t, expected, md.Warnings())
+}
@@ -26,14 +26,16 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
-	"golang.org/x/oauth2"
 	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
 // NewGetActivityCommand returns a command for getting activity feed items.
 func NewGetActivityCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		tags []string
+		tags    []string
+		filters activityFilterOptions
+		output  outputOptions
+		watch   watchOptions
 	)
 
 	cmd := &cobra.Command{
@@ -42,20 +44,35 @@ func NewGetActivityCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringSliceVar(&tags, "tags", nil, "limit activity items to the specified `tag`s")
+	_ = cmd.RegisterFlagCompletionFunc("tags", completeActivityTags)
+	filters.AddFlags(cmd)
+	output.AddFlags(cmd)
+	watch.AddFlags(cmd)
+	_ = cmd.RegisterFlagCompletionFunc("cluster", validClusterArgs(cfg))
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		appAPI := applications.NewAPI(client)
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		appAPI := newApplicationsAPI(cfg, client)
 
 		q := applications.ActivityFeedQuery{}
 		if len(tags) > 0 {
-			q.SetType(tags...)
+			types, err := activityTypes(tags)
+			if err != nil {
+				return err
+			}
+			q.SetType(types...)
 		}
+		filters.Apply(&q)
 
 		md, err := appAPI.CheckEndpoint(ctx)
 		if err != nil {
@@ -67,19 +84,21 @@ func NewGetActivityCommand(cfg Config, p Printer) *cobra.Command {
 			return fmt.Errorf("missing activity feed URL")
 		}
 
-		feed, err := appAPI.ListActivity(ctx, u, q)
-		if err != nil {
-			return err
-		}
+		return watch.Run(ctx, out, p, func(ctx context.Context) (Output, error) {
+			feed, err := appAPI.ListActivity(ctx, u, q)
+			if err != nil {
+				return nil, err
+			}
 
-		result := &ActivityOutput{
-			Items:        make([]ActivityRow, 0, len(feed.Items)),
-			ActivityFeed: feed,
-		}
-		for i := range feed.Items {
-			result.Add(&feed.Items[i])
-		}
-		return p.Fprint(out, result)
+			result := &ActivityOutput{
+				Items:        make([]ActivityRow, 0, len(feed.Items)),
+				ActivityFeed: feed,
+			}
+			for i := range feed.Items {
+				result.Add(&feed.Items[i])
+			}
+			return result, nil
+		})
 	}
 	return cmd
 }
@@ -88,10 +107,14 @@ func NewGetActivityCommand(cfg Config, p Printer) *cobra.Command {
 func NewWatchActivityCommand(cfg Config) *cobra.Command {
 	var (
 		pollInterval         time.Duration
+		minPollInterval      time.Duration
+		maxPollInterval      time.Duration
 		jitterFactor         float64
 		hideFailedActivities bool
 		tags                 []string
+		filters              activityFilterOptions
 		deleteItems          bool
+		longPoll             bool
 
 		feedTemplateText string
 		itemTemplateText string
@@ -102,10 +125,16 @@ func NewWatchActivityCommand(cfg Config) *cobra.Command {
 	}
 
 	cmd.Flags().DurationVar(&pollInterval, "poll", 30*time.Second, "polling `interval` to refresh the feed")
+	cmd.Flags().DurationVar(&minPollInterval, "min-poll", 0, "shortest polling `interval` used when the feed is busy")
+	cmd.Flags().DurationVar(&maxPollInterval, "max-poll", 0, "longest polling `interval` used when the feed is idle")
 	cmd.Flags().Float64Var(&jitterFactor, "jitter", 1.0, "polling jitter `factor` to refresh the feed")
 	cmd.Flags().BoolVar(&hideFailedActivities, "no-failed", false, "do not show items with a failure reason")
 	cmd.Flags().StringSliceVar(&tags, "tags", nil, "limit activity items to the specified `tag`s")
+	_ = cmd.RegisterFlagCompletionFunc("tags", completeActivityTags)
+	filters.AddFlags(cmd)
+	_ = cmd.RegisterFlagCompletionFunc("cluster", validClusterArgs(cfg))
 	cmd.Flags().BoolVar(&deleteItems, "delete", false, "delete new items")
+	cmd.Flags().BoolVar(&longPoll, "long-poll", false, "hold feed requests open until new activity is available")
 	cmd.Flags().StringVar(&feedTemplateText, "feed-template", `{{ template "ActivityFeed" . }}`, "the feed `template` used to render the activity feed")
 	cmd.Flags().StringVar(&itemTemplateText, "item-template", `{{ template "ActivityItem" . }}`, "the item `template` used to render the items")
 	cmd.Flag("feed-template").Hidden = true
@@ -113,7 +142,7 @@ func NewWatchActivityCommand(cfg Config) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
@@ -144,16 +173,23 @@ func NewWatchActivityCommand(cfg Config) *cobra.Command {
 		}
 
 		s := &applications.PollingSubscriber{
-			API:                    applications.NewAPI(client),
+			API:                    newApplicationsAPI(cfg, client),
 			PollInterval:           pollInterval,
+			MinPollInterval:        minPollInterval,
+			MaxPollInterval:        maxPollInterval,
 			JitterFactor:           jitterFactor,
 			ReportFailedActivities: !hideFailedActivities,
 		}
 
 		q := applications.ActivityFeedQuery{}
 		if len(tags) > 0 {
-			q.SetType(tags...)
+			types, err := activityTypes(tags)
+			if err != nil {
+				return err
+			}
+			q.SetType(types...)
 		}
+		filters.Apply(&q)
 
 		// Normally you would just call API.Subscribe; but we want to display additional information
 		md, err := s.API.CheckEndpoint(ctx)
@@ -175,37 +211,104 @@ func NewWatchActivityCommand(cfg Config) *cobra.Command {
 			return err
 		}
 
-		// Create a channel to watch for new items
-		activity := make(chan applications.ActivityItem)
-		go func() {
-			for item := range activity {
-				// Render each item
-				if err := itemTemplate.Execute(out, item); err != nil {
-					_, _ = fmt.Fprintf(out, "Error: failed to render activity %q: %v", item.URL, err)
-				}
+		// Render each new item
+		handler := func(ctx context.Context, item applications.ActivityItem) error {
+			if err := itemTemplate.Execute(out, item); err != nil {
+				_, _ = fmt.Fprintf(out, "Error: failed to render activity %q: %v", item.URL, err)
+			}
 
-				// If requested, delete the item to prevent it from being processed again
-				if deleteItems {
-					if err := s.API.DeleteActivity(ctx, item.URL); err != nil {
-						_, _ = fmt.Fprintf(out, "Error: failed to delete activity %q: %v\n", item.URL, err)
-					}
+			// If requested, delete the item to prevent it from being processed again
+			if deleteItems {
+				if err := s.API.DeleteActivity(ctx, item.URL); err != nil {
+					_, _ = fmt.Fprintf(out, "Error: failed to delete activity %q: %v\n", item.URL, err)
 				}
 			}
-		}()
+			return nil
+		}
 
-		// Set the feed URL and start polling
+		// Set the feed URL and start polling, the server may not filter the feed
 		s.FeedURL = feed.FeedURL
-		return s.Subscribe(ctx, activity)
+		if filters.Enabled() {
+			s.Filter = &q
+		}
+		var subscriber applications.Subscriber = s
+		if longPoll {
+			subscriber = &applications.LongPollSubscriber{PollingSubscriber: *s}
+		}
+		sub := applications.NewSubscription(context.WithoutCancel(ctx), subscriber, handler)
+
+		// When interrupted, stop polling but finish handling anything already received
+		select {
+		case <-sub.Done():
+			return sub.Err()
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+			return sub.Drain(drainCtx)
+		}
 	}
 	return cmd
 }
 
+// activityTypes validates the supplied activity tags, returning the canonical values.
+func activityTypes(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, err := applications.ParseActivityTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t.String())
+	}
+	return result, nil
+}
+
+// completeActivityTags returns the known activity tags for completion.
+func completeActivityTags(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	var result []string
+	for _, t := range applications.ActivityTags() {
+		result = append(result, t.String())
+	}
+	return result, cobra.ShellCompDirectiveNoFileComp
+}
+
+// activityFilterOptions are the flags used to limit the activity feed to a
+// subset of applications or scenarios.
+type activityFilterOptions struct {
+	Applications []string
+	Scenarios    []string
+	Clusters     []string
+}
+
+// AddFlags registers the activity filter flags on the supplied command.
+func (o *activityFilterOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.Applications, "application", o.Applications, "limit activity items to the specified application `name`s")
+	cmd.Flags().StringSliceVar(&o.Scenarios, "scenario", o.Scenarios, "limit activity items to the specified scenario `name`s")
+	cmd.Flags().StringSliceVar(&o.Clusters, "cluster", o.Clusters, "limit activity items to the specified cluster `name`s")
+}
+
+// Enabled checks if any filters were specified.
+func (o *activityFilterOptions) Enabled() bool {
+	return len(o.Applications) > 0 || len(o.Scenarios) > 0 || len(o.Clusters) > 0
+}
+
+// Apply adds the filters to the supplied activity feed query.
+func (o *activityFilterOptions) Apply(q *applications.ActivityFeedQuery) {
+	if len(o.Applications) > 0 {
+		q.SetApplication(o.Applications...)
+	}
+	if len(o.Scenarios) > 0 {
+		q.SetScenario(o.Scenarios...)
+	}
+	if len(o.Clusters) > 0 {
+		q.SetCluster(o.Clusters...)
+	}
+}
+
 // subject is a function we can use in templates to extract the subject claim from
 // an authorization token.
 func subject(ctx context.Context, cfg Config) func() (string, error) {
-	tcfg, ok := cfg.(interface {
-		TokenSource(context.Context) oauth2.TokenSource
-	})
+	tcfg, ok := cfg.(TokenSourceConfig)
 	if !ok {
 		return func() (string, error) { return "<unavailable>", nil }
 	}
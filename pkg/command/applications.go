@@ -17,12 +17,17 @@ limitations under the License.
 package command
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/command/recommendation"
 )
 
@@ -31,6 +36,7 @@ func NewCreateApplicationCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		title    string
 		resource applications.Resource
+		output   outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -43,15 +49,21 @@ func NewCreateApplicationCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().StringArrayVar(&resource.Kubernetes.Namespaces, "namespace", nil, "select application resources from a specific `namespace`")
 	cmd.Flags().StringVar(&resource.Kubernetes.NamespaceSelector, "ns-selector", "", "`sel`ect application resources from labeled namespaces")
 	cmd.Flags().StringVarP(&resource.Kubernetes.Selector, "selector", "l", "", "`sel`ect only labeled application resources")
+	output.AddResultFlags(cmd, "create")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		appAPI := applications.NewAPI(client)
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		appAPI := newApplicationsAPI(cfg, client)
 
 		// Construct the application we want to create
 		app := applications.Application{
@@ -63,27 +75,13 @@ func NewCreateApplicationCommand(cfg Config, p Printer) *cobra.Command {
 		}
 
 		// Upsert the application if we have a name, otherwise create it with a generated name
-		var selfURL string
-		if len(args) > 0 && args[0] != "" {
-			name := applications.ApplicationName(args[0])
-			md, err := appAPI.CreateApplicationByName(ctx, name, app)
-			if err != nil {
-				return err
-			}
-			selfURL = md.Link(api.RelationSelf)
-		} else {
-			md, err := appAPI.CreateApplication(ctx, app)
-			if err != nil {
-				return err
-			}
-			selfURL = md.Location()
+		var name applications.ApplicationName
+		if len(args) > 0 {
+			name = applications.ApplicationName(args[0])
 		}
-
-		// Fetch the application back for display
-		if selfURL != "" {
-			if a, err := appAPI.GetApplication(ctx, selfURL); err == nil {
-				app = a
-			}
+		app, err = applications.CreateAndGetApplication(ctx, appAPI, name, app)
+		if err != nil {
+			return err
 		}
 
 		return p.Fprint(out, NewApplicationRow(&applications.ApplicationItem{Application: app}))
@@ -94,8 +92,11 @@ func NewCreateApplicationCommand(cfg Config, p Printer) *cobra.Command {
 // NewEditApplicationCommand returns a command for editing an application.
 func NewEditApplicationCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		title    string
-		resource applications.Resource
+		title          string
+		resource       applications.Resource
+		preconditions  preconditionOptions
+		ignoreNotFound bool
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -109,16 +110,25 @@ func NewEditApplicationCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().StringArrayVar(&resource.Kubernetes.Namespaces, "namespace", nil, "select application resources from a specific `namespace`")
 	cmd.Flags().StringVar(&resource.Kubernetes.NamespaceSelector, "ns-selector", "", "`sel`ect application resources from labeled namespaces")
 	cmd.Flags().StringVarP(&resource.Kubernetes.Selector, "selector", "l", "", "`sel`ect only labeled application resources")
+	preconditions.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	output.AddFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:            newApplicationsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
 		}
 
 		return l.ForEachNamedApplication(ctx, args, false, func(item *applications.ApplicationItem) error {
@@ -149,8 +159,10 @@ func NewEditApplicationCommand(cfg Config, p Printer) *cobra.Command {
 				return nil
 			}
 
-			if _, err := l.API.UpdateApplication(ctx, selfURL, item.Application); err != nil {
-				return err
+			app := item.Application
+			app.Metadata = preconditions.IfMatch(item.Application.Metadata)
+			if _, err := l.API.UpdateApplication(ctx, selfURL, app); err != nil {
+				return preconditions.Check(err)
 			}
 			return p.Fprint(out, NewApplicationRow(item))
 		})
@@ -163,6 +175,7 @@ func NewEnableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Co
 	var (
 		deployConfiguration recommendation.DeployConfigurationOptions
 		containerResources  recommendation.ContainerResourcesOptions
+		explain             bool
 	)
 
 	cmd := &cobra.Command{
@@ -174,17 +187,18 @@ func NewEnableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Co
 
 	deployConfiguration.AddFlags(cmd)
 	containerResources.AddFlags(cmd)
+	cmd.Flags().BoolVar(&explain, "explain", explain, "show where each effective setting comes from without making changes")
 
 	_ = cmd.RegisterFlagCompletionFunc("cluster", validClusterArgs(cfg, applications.ClusterRecommendations))
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		appAPI := applications.NewAPI(client)
+		appAPI := newApplicationsAPI(cfg, client)
 
 		appName := applications.ApplicationName(args[0])
 		app, err := appAPI.GetApplicationByName(ctx, appName)
@@ -205,10 +219,28 @@ func NewEnableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Co
 		patch := applications.RecommendationList{}
 		deployConfiguration.Apply(&patch.DeployConfiguration)
 		containerResources.Apply(&patch.Configuration)
+		requested := configurationSource(&patch)
 		if err := recommendation.Finish(cmd, appAPI, app, recs, &patch); err != nil {
 			return err
 		}
 
+		if explain {
+			merged, err := applications.ExplainMerge(
+				applications.MergeSource{Name: "current", Value: configurationSource(&recs)},
+				applications.MergeSource{Name: "patch", Value: requested},
+				applications.MergeSource{Name: "default", Value: configurationSource(&patch)},
+			)
+			if err != nil {
+				return err
+			}
+
+			result := &MergedValueOutput{}
+			for i := range merged {
+				result.Items = append(result.Items, *NewMergedValueRow(&merged[i]))
+			}
+			return p.Fprint(out, result)
+		}
+
 		if err := appAPI.PatchRecommendations(ctx, recommendationsURL, patch); err != nil {
 			return err
 		}
@@ -226,6 +258,22 @@ func NewEnableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Co
 	return cmd
 }
 
+// configurationSource returns a JSON snapshot of the settings used to produce
+// and deploy recommendations, only the first configuration is included as it
+// is the only one that is merged.
+func configurationSource(rl *applications.RecommendationList) json.RawMessage {
+	src := make(map[string]interface{})
+	if rl.DeployConfiguration != nil {
+		src["deploy"] = rl.DeployConfiguration
+	}
+	if len(rl.Configuration) > 0 {
+		src["configuration"] = &rl.Configuration[0]
+	}
+
+	data, _ := json.Marshal(src)
+	return data
+}
+
 // NewDisableApplicationRecommendationsCommand returns a new command for disabling recommendations.
 func NewDisableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Command {
 	cmd := &cobra.Command{
@@ -237,12 +285,12 @@ func NewDisableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.C
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		appAPI := applications.NewAPI(client)
+		appAPI := newApplicationsAPI(cfg, client)
 
 		appName := applications.ApplicationName(args[0])
 		app, err := appAPI.GetApplicationByName(ctx, appName)
@@ -276,9 +324,14 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 		product   string
 		batchSize int
 		sortBy    string
+		output    outputOptions
+		scope     scopeOptions
 
+		continueOnError         bool
 		pageOffset              int
 		skipRecommendationLimit int
+		ignoreNotFound          bool
+		with                    []string
 	)
 
 	cmd := &cobra.Command{
@@ -290,6 +343,15 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().StringVar(&product, "for", product, "show only clusters for a specific `product`; one of: optimize-pro|optimize-live")
 	cmd.Flags().IntVar(&batchSize, "chunk-size", 500, "fetch large lists in chu`n`ks rather then all at once")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd)
+	scope.AddFlags(cmd)
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	cmd.Flags().StringSliceVar(&with, "with", with, "inline the `sub-resources` of each application; one of: scenarios|recommendations")
+
+	_ = cmd.RegisterFlagCompletionFunc("with", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"scenarios", "recommendations"}, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	// Hidden flags to deal with large application lists
 	cmd.Flags().IntVar(&pageOffset, "page-offset", pageOffset, "fetch a partial list starti`n`g from the specified offset")
@@ -305,19 +367,40 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
+		var withScenarios, withRecommendations bool
+		for _, w := range with {
+			switch strings.ToLower(w) {
+			case "scenarios", "scenario", "scn":
+				withScenarios = true
+			case "recommendations", "recommendation", "recs":
+				withRecommendations = true
+			default:
+				return fmt.Errorf("unknown sub-resource: %s", w)
+			}
+		}
+
 		l := applications.Lister{
-			API:       applications.NewAPI(client),
+			API:       newApplicationsAPI(cfg, client),
 			BatchSize: batchSize,
+
+			ContinueOnError: continueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
+		var partial partialResult
 		result := &ApplicationOutput{Items: make([]ApplicationRow, 0, len(args))}
 		if len(args) > 0 {
-			if err := l.ForEachNamedApplication(ctx, args, false, result.Add); err != nil {
+			if err := partial.Check(l.ForEachNamedApplication(ctx, args, false, result.Add)); err != nil {
 				return err
 			}
 		} else {
@@ -331,6 +414,7 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 					q.IndexQuery = api.IndexQuery{api.ParamOffset: []string{"0"}}
 				}
 			}
+			scope.Apply(&q.IndexQuery)
 
 			if err := l.ForEachApplication(ctx, q, result.Add); err != nil {
 				return err
@@ -340,7 +424,7 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 		// This is a hack to get around the fact that we cannot provide recommendation configurations in a reasonable amount of time
 		var skipRecommendations bool
 		if len(result.Items) > skipRecommendationLimit {
-			_, _ = fmt.Fprintf(cmd.OutOrStderr(), "WARNING: Too many applications to fetch recommendations (%d, limit is %d), try fetching individual applications\n", len(result.Items), skipRecommendationLimit)
+			_, _ = fmt.Fprintf(cmd.OutOrStderr(), MessageFormat(MessageTooManyApplicationsToInspect)+"\n", len(result.Items), skipRecommendationLimit)
 			skipRecommendations = true
 		}
 
@@ -360,6 +444,27 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 			result.Items[i].SetBackfillProgress(rl.BackfillProgress)
 		}
 
+		// Inline the requested sub-resources
+		for i := range result.Items {
+			row := &result.Items[i]
+			if withScenarios {
+				if err := l.ForEachScenario(ctx, &row.ApplicationItem.Application, applications.ScenarioListQuery{}, func(item *applications.ScenarioItem) error {
+					row.Scenarios = append(row.Scenarios, *item)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if withRecommendations {
+				if err := l.ForEachRecommendation(ctx, &row.ApplicationItem.Application, func(item *applications.RecommendationItem) error {
+					row.RecommendationItems = append(row.RecommendationItems, *item)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Filter applications by product
 		if product != "" {
 			items := make([]ApplicationRow, 0, len(result.Items))
@@ -391,7 +496,7 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 			return err
 		}
 
-		return p.Fprint(out, result)
+		return partial.Print(out, p, result)
 	}
 	return cmd
 }
@@ -400,6 +505,12 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 func NewDeleteApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		ignoreNotFound bool
+		cascade        = cascadeBackground
+		force          bool
+		timeout        = 5 * time.Minute
+
+		continueOnError bool
+		output          outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -409,16 +520,41 @@ func NewDeleteApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "treat not found errors as successful deletes")
+	cmd.Flags().StringVar(&cascade, "cascade", cascade, "deletion `mode`; one of: "+strings.Join(cascadeModes, "|"))
+	cmd.Flags().BoolVar(&force, "force", force, "delete applications with active experiments when using orphan-check")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "maximum `duration` to wait for a foreground deletion")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	output.AddResultFlags(cmd, "delete")
+
+	_ = cmd.RegisterFlagCompletionFunc("cascade", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return cascadeModes, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
+		switch cascade {
+		case cascadeBackground, cascadeForeground, cascadeOrphanCheck:
+		default:
+			return fmt.Errorf("unknown cascade mode: %s", cascade)
+		}
+
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
+		}
+
+		el := experiments.Lister{
+			API: newExperimentsAPI(cfg, client),
 		}
 
 		return l.ForEachNamedApplication(ctx, args, ignoreNotFound, func(item *applications.ApplicationItem) error {
@@ -427,16 +563,101 @@ func NewDeleteApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 				return fmt.Errorf("malformed response, missing self link")
 			}
 
+			if cascade == cascadeOrphanCheck && !force {
+				active, err := applicationExperiments(ctx, &el, item.Name, true)
+				if err != nil {
+					return err
+				}
+				if len(active) > 0 {
+					return fmt.Errorf("application %q has %d active experiment(s): %s (use --force to delete anyway)",
+						item.Name, len(active), strings.Join(active, ", "))
+				}
+			}
+
 			if err := l.API.DeleteApplication(ctx, selfURL); err != nil {
 				return err
 			}
 
+			if cascade == cascadeForeground {
+				if err := waitForApplicationDeletion(ctx, &l, &el, item.Name, selfURL, timeout); err != nil {
+					return err
+				}
+			}
+
 			return p.Fprint(out, NewApplicationRow(item))
 		})
 	}
 	return cmd
 }
 
+const (
+	// cascadeBackground deletes the application and lets the server delete
+	// the dependent resources asynchronously.
+	cascadeBackground = "background"
+	// cascadeForeground waits for the dependent resources to be deleted.
+	cascadeForeground = "foreground"
+	// cascadeOrphanCheck refuses to delete applications with active experiments.
+	cascadeOrphanCheck = "orphan-check"
+)
+
+var cascadeModes = []string{cascadeBackground, cascadeForeground, cascadeOrphanCheck}
+
+// applicationExperiments returns the names of the experiments labeled with the
+// supplied application name, optionally only those which have not exhausted
+// their budget.
+func applicationExperiments(ctx context.Context, l *experiments.Lister, name applications.ApplicationName, activeOnly bool) ([]string, error) {
+	q := experiments.ExperimentListQuery{}
+	q.SetLabelSelector(map[string]string{"application": name.String()})
+
+	var result []string
+	err := l.ForEachExperiment(ctx, q, func(item *experiments.ExperimentItem) error {
+		if !activeOnly || item.Budget == 0 || item.Observations < item.Budget {
+			result = append(result, item.Name.String())
+		}
+		return nil
+	})
+	return result, err
+}
+
+// waitForApplicationDeletion polls until the application (and therefore its
+// scenarios) and its experiments no longer exist.
+func waitForApplicationDeletion(ctx context.Context, l *applications.Lister, el *experiments.Lister, name applications.ApplicationName, selfURL string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		// The scenarios are deleted with the application, experiments are not
+		_, err := l.API.GetApplication(ctx, selfURL)
+		if err != nil && !isNotFound(err, applications.ErrApplicationNotFound) {
+			return err
+		}
+		if err != nil {
+			remaining, err := applicationExperiments(ctx, el, name, false)
+			if err != nil {
+				return err
+			}
+			if len(remaining) == 0 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for application %q to be deleted", name)
+		case <-ticker.C:
+		}
+	}
+}
+
+// isNotFound checks if the supplied error is an API error of the given type.
+func isNotFound(err error, t api.ErrorType) bool {
+	var apiErr *api.Error
+	return errors.As(err, &apiErr) && apiErr.Type == t
+}
+
 func validApplicationArgs(cfg Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return validArgs(cfg, func(l *completionLister, toComplete string) (completions []string, directive cobra.ShellCompDirective) {
 		directive |= cobra.ShellCompDirectiveNoFileComp
@@ -17,6 +17,7 @@ limitations under the License.
 package command
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -28,42 +29,61 @@ import (
 // NewEditClusterCommand returns a command for editing a cluster.
 func NewEditClusterCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		title string
+		title          string
+		preconditions  preconditionOptions
+		concurrency    concurrencyOptions
+		ignoreNotFound bool
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
-		Use:               "cluster NAME",
-		Args:              cobra.ExactArgs(1),
+		Use:               "cluster NAME ...",
+		Args:              cobra.MinimumNArgs(1),
 		ValidArgsFunction: validClusterArgs(cfg),
 	}
 
 	cmd.Flags().StringVar(&title, "title", "", "update the `title` value")
+	preconditions.AddFlags(cmd)
+	concurrency.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	output.AddFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		out := cmd.OutOrStdout()
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:            newApplicationsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
 		}
 
-		return l.ForEachNamedCluster(ctx, args, false, func(item *applications.ClusterItem) error {
-			selfURL := item.Link(api.RelationSelf)
-			if selfURL == "" {
-				return fmt.Errorf("malformed response, missing self link")
-			}
+		p = &syncPrinter{p: p}
+		concurrency.Quiet = isQuiet(cfg)
+		return concurrency.Run(cmd.Context(), cmd.ErrOrStderr(), args, func(ctx context.Context, i int) error {
+			return l.ForEachNamedCluster(ctx, args[i:i+1], false, func(item *applications.ClusterItem) error {
+				selfURL := item.Link(api.RelationSelf)
+				if selfURL == "" {
+					return fmt.Errorf("malformed response, missing self link")
+				}
 
-			// Update the title
-			if title != "" {
-				if err := l.API.PatchCluster(ctx, selfURL, applications.ClusterTitle{Title: title}); err != nil {
-					return err
+				// Update the title
+				if title != "" {
+					c := applications.ClusterTitle{Metadata: preconditions.IfMatch(item.Metadata), Title: title}
+					if err := l.API.PatchCluster(ctx, selfURL, c); err != nil {
+						return preconditions.Check(err)
+					}
 				}
-			}
 
-			return p.Fprint(out, NewClusterRow(item))
+				return p.Fprint(out, NewClusterRow(item))
+			})
 		})
 	}
 	return cmd
@@ -74,6 +94,11 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		product string
 		sortBy  string
+		output  outputOptions
+		scope   scopeOptions
+
+		continueOnError bool
+		ignoreNotFound  bool
 	)
 
 	cmd := &cobra.Command{
@@ -84,6 +109,10 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.Flags().StringVar(&product, "for", product, "show only clusters for a specific `product`; one of: optimize-pro|optimize-live")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd)
+	scope.AddFlags(cmd)
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
 
 	_ = cmd.RegisterFlagCompletionFunc("for", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"optimize-pro", "optimize-live"}, cobra.ShellCompDirectiveDefault
@@ -91,18 +120,26 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
+		var partial partialResult
 		result := &ClusterOutput{Items: make([]ClusterRow, 0, len(args))}
 		if len(args) > 0 {
-			if err := l.ForEachNamedCluster(ctx, args, false, result.Add); err != nil {
+			if err := partial.Check(l.ForEachNamedCluster(ctx, args, false, result.Add)); err != nil {
 				return err
 			}
 		} else {
@@ -113,6 +150,7 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 			case "optimize-live", "live":
 				q.SetModules(applications.ClusterRecommendations)
 			}
+			scope.Apply(&q.IndexQuery)
 			if err := l.ForEachCluster(ctx, q, result.Add); err != nil {
 				return err
 			}
@@ -122,7 +160,7 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 			return err
 		}
 
-		return p.Fprint(out, result)
+		return partial.Print(out, p, result)
 	}
 	return cmd
 }
@@ -130,7 +168,9 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 // NewDeleteClustersCommand returns a command for deleting clusters.
 func NewDeleteClustersCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		ignoreNotFound bool
+		ignoreNotFound  bool
+		continueOnError bool
+		output          outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -140,16 +180,24 @@ func NewDeleteClustersCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "treat not found errors as successful deletes")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	output.AddResultFlags(cmd, "delete")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
 		}
 
 		return l.ForEachNamedCluster(ctx, args, ignoreNotFound, func(item *applications.ClusterItem) error {
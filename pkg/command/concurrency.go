@@ -0,0 +1,206 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// concurrencyOptions holds the flags of commands which operate on multiple names.
+type concurrencyOptions struct {
+	// The maximum number of items to process at the same time.
+	Concurrency int
+	// Keep processing the remaining items after a failure.
+	ContinueOnError bool
+	// Omit the summary reported once all items are done.
+	Quiet bool
+}
+
+// AddFlags registers the concurrency flags on the supplied command.
+func (o *concurrencyOptions) AddFlags(cmd *cobra.Command) {
+	if o.Concurrency == 0 {
+		o.Concurrency = 1
+	}
+
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "the `number` of items to process concurrently")
+	cmd.Flags().BoolVar(&o.ContinueOnError, "continue-on-error", o.ContinueOnError, "process all names before reporting errors")
+}
+
+var (
+	// errSkipped is returned from the function passed to `concurrencyOptions.Run`
+	// to indicate the item was intentionally not processed (e.g. it did not exist).
+	errSkipped = errors.New("skipped")
+	// errNotAttempted is recorded by `concurrencyOptions.forEach` for the items
+	// which were not started because an earlier item failed.
+	errNotAttempted = errors.New("not attempted")
+)
+
+// Run invokes the supplied function for each of the named items using up to
+// the configured number of concurrent workers. The individual failures are
+// reported as they occur and a summary is reported once all items are done.
+// Unless continuing on error, no new items are started after the first failure.
+func (o *concurrencyOptions) Run(ctx context.Context, errOut io.Writer, names []string, f func(ctx context.Context, i int) error) error {
+	var mu sync.Mutex
+	errs := o.forEach(ctx, len(names), func(ctx context.Context, i int) error {
+		err := f(ctx, i)
+		if err != nil && !errors.Is(err, errSkipped) {
+			mu.Lock()
+			_, _ = fmt.Fprintf(errOut, "%s: %v\n", names[i], err)
+			mu.Unlock()
+		}
+		return err
+	})
+
+	var failed, skipped, notAttempted int
+	var firstErr error
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, errSkipped):
+			skipped++
+		case errors.Is(err, errNotAttempted):
+			notAttempted++
+		case err != nil:
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if len(names) > 1 && !o.Quiet {
+		summary := fmt.Sprintf("%d succeeded", len(names)-failed-skipped-notAttempted)
+		if skipped > 0 {
+			summary += fmt.Sprintf(", %d skipped", skipped)
+		}
+		summary += fmt.Sprintf(", %d failed", failed)
+		if notAttempted > 0 {
+			summary += fmt.Sprintf(", %d not attempted", notAttempted)
+		}
+		_, _ = fmt.Fprintln(errOut, summary)
+	}
+
+	switch {
+	case failed == 1 && (len(names) == 1 || !o.ContinueOnError):
+		return firstErr
+	case failed > 0:
+		return fmt.Errorf("%d of %d operations failed", failed, len(names))
+	}
+	return nil
+}
+
+// forEach invokes the supplied function for indexes up to count using up to the
+// configured number of concurrent workers, returning the error for each index.
+// Unless continuing on error, the remaining indexes are not started once a
+// failure occurs; their error is `errNotAttempted`. Items already in progress
+// are allowed to finish.
+func (o *concurrencyOptions) forEach(ctx context.Context, count int, f func(ctx context.Context, i int) error) []error {
+	n := o.Concurrency
+	if n < 1 {
+		n = 1
+	}
+
+	errs := make([]error, count)
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var stopped bool
+	for i := 0; i < count; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			mu.Lock()
+			stop := stopped
+			mu.Unlock()
+			if stop {
+				errs[i] = errNotAttempted
+				return
+			}
+
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+
+			errs[i] = f(ctx, i)
+			if errs[i] != nil && !errors.Is(errs[i], errSkipped) && !o.ContinueOnError {
+				mu.Lock()
+				stopped = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// syncPrinter serializes access to a printer shared by concurrent workers.
+type syncPrinter struct {
+	mu sync.Mutex
+	p  Printer
+}
+
+// Fprint renders the object using the wrapped printer.
+func (sp *syncPrinter) Fprint(out io.Writer, obj interface{}) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.p.Fprint(out, obj)
+}
+
+// partialResult holds the errors of a named lister which continued past the
+// names it failed to find, so the results that were found can be printed
+// before the errors are reported.
+type partialResult struct {
+	err error
+}
+
+// Check returns the errors which must stop the command. Listers which continue
+// on error report every failed name together in an `api.MultiError` once the
+// remaining names have been visited, that error is kept to be reported later.
+func (r *partialResult) Check(err error) error {
+	var multiErr api.MultiError
+	if errors.As(err, &multiErr) {
+		r.err = err
+		return nil
+	}
+	return err
+}
+
+// Err returns the errors kept to be reported later.
+func (r *partialResult) Err() error {
+	return r.err
+}
+
+// Print renders the results that were found and then returns the errors kept
+// to be reported later.
+func (r *partialResult) Print(out io.Writer, p Printer, obj interface{}) error {
+	if err := p.Fprint(out, obj); err != nil {
+		return err
+	}
+	return r.err
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestConcurrencyOptions_Run(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+	fail := func(failing ...string) func(context.Context, int) error {
+		return func(_ context.Context, i int) error {
+			for _, name := range failing {
+				if names[i] == name {
+					return fmt.Errorf("failed %s", name)
+				}
+			}
+			return nil
+		}
+	}
+
+	cases := []struct {
+		desc        string
+		opts        concurrencyOptions
+		f           func(context.Context, int) error
+		expectedErr string
+		expectedOut string
+	}{
+		{
+			desc:        "success",
+			f:           fail(),
+			expectedOut: "4 succeeded, 0 failed\n",
+		},
+		{
+			desc:        "stop on error",
+			f:           fail("b", "d"),
+			expectedErr: "failed b",
+			expectedOut: "b: failed b\n1 succeeded, 1 failed, 2 not attempted\n",
+		},
+		{
+			desc:        "continue on error",
+			opts:        concurrencyOptions{ContinueOnError: true},
+			f:           fail("b", "d"),
+			expectedErr: "2 of 4 operations failed",
+			expectedOut: "b: failed b\nd: failed d\n2 succeeded, 2 failed\n",
+		},
+		{
+			desc: "skipped",
+			f: func(_ context.Context, i int) error {
+				if i%2 == 0 {
+					return errSkipped
+				}
+				return nil
+			},
+			expectedOut: "2 succeeded, 2 skipped, 0 failed\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var errOut bytes.Buffer
+			err := c.opts.Run(context.Background(), &errOut, names, c.f)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expectedOut, errOut.String())
+		})
+	}
+}
+
+func TestPartialResult(t *testing.T) {
+	var errs api.MultiError
+	errs.Add("b", fmt.Errorf("not found"))
+
+	cases := []struct {
+		desc        string
+		err         error
+		expectedErr string
+		expectedOut string
+	}{
+		{
+			desc:        "success",
+			expectedOut: "a\n",
+		},
+		{
+			desc:        "stop on error",
+			err:         errors.New("failed"),
+			expectedErr: "failed",
+		},
+		{
+			desc:        "continue on error",
+			err:         errs.ErrorOrNil(),
+			expectedErr: errs.Error(),
+			expectedOut: "a\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var partial partialResult
+			var out bytes.Buffer
+			err := partial.Check(c.err)
+			if err == nil {
+				err = partial.Print(&out, &yamlPrinter{}, "a")
+			}
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expectedOut, out.String())
+		})
+	}
+}
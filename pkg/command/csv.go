@@ -0,0 +1,166 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvColumn describes a single column (or set of flattened columns) of CSV output.
+type csvColumn struct {
+	// The name of the column, or the prefix of flattened column names.
+	name string
+	// The index of the row field used to populate the column.
+	field int
+	// The sorted map keys used to populate flattened columns.
+	keys []string
+}
+
+// csvPrinter renders the rows of the supplied output using the `csv` struct tags.
+// Fields with the "flatten" option must be string maps, each key becomes a
+// separate column with the tag name as a prefix. Values containing the delimiter,
+// quotes or line breaks are quoted.
+type csvPrinter struct {
+	// The field delimiter, defaults to a comma.
+	Comma rune
+	// Omit the header row.
+	NoHeader bool
+}
+
+// Fprint renders the supplied output as delimiter separated values.
+func (p *csvPrinter) Fprint(out io.Writer, obj interface{}) error {
+	o, ok := asOutput(obj)
+	if !ok {
+		return fmt.Errorf("unable to render %T as CSV", obj)
+	}
+	if o.Len() == 0 {
+		return nil
+	}
+
+	columns, err := csvColumns(o)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(out)
+	if p.Comma != 0 {
+		w.Comma = p.Comma
+	}
+
+	var header []string
+	for _, c := range columns {
+		if c.keys == nil {
+			header = append(header, c.name)
+			continue
+		}
+		for _, k := range c.keys {
+			header = append(header, c.name+k)
+		}
+	}
+	if !p.NoHeader {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < o.Len(); i++ {
+		rv := reflect.Indirect(reflect.ValueOf(o.Item(i)))
+		var record []string
+		for _, c := range columns {
+			fv := rv.Field(c.field)
+			if c.keys == nil {
+				record = append(record, fmt.Sprint(fv.Interface()))
+				continue
+			}
+			for _, k := range c.keys {
+				var value string
+				if v := fv.MapIndex(reflect.ValueOf(k)); v.IsValid() {
+					value = v.String()
+				}
+				record = append(record, value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// csvColumns returns the columns for the supplied output. Flattened columns
+// include the sorted union of keys across all rows so the header is stable.
+func csvColumns(o Output) ([]csvColumn, error) {
+	rt := reflect.Indirect(reflect.ValueOf(o.Item(0))).Type()
+	var columns []csvColumn
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("csv")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		c := csvColumn{name: opts[0], field: i}
+		for _, opt := range opts[1:] {
+			if opt != "flatten" {
+				continue
+			}
+
+			if rt.Field(i).Type.Kind() != reflect.Map || rt.Field(i).Type.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("unable to flatten CSV field %s", rt.Field(i).Name)
+			}
+
+			keys := make(map[string]struct{})
+			for j := 0; j < o.Len(); j++ {
+				for _, k := range reflect.Indirect(reflect.ValueOf(o.Item(j))).Field(i).MapKeys() {
+					keys[k.String()] = struct{}{}
+				}
+			}
+
+			c.keys = make([]string, 0, len(keys))
+			for k := range keys {
+				c.keys = append(c.keys, k)
+			}
+			sort.Strings(c.keys)
+		}
+
+		columns = append(columns, c)
+	}
+
+	return columns, nil
+}
+
+// csvDelimiter parses a delimiter flag value. The escape sequence `\t` may be
+// used to specify a tab character.
+func csvDelimiter(s string) (rune, error) {
+	if s == `\t` {
+		return '\t', nil
+	}
+
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' || r[0] == utf8.RuneError {
+		return 0, fmt.Errorf("invalid CSV delimiter: %q", s)
+	}
+	return r[0], nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestCSVPrinter_trials(t *testing.T) {
+	trial := func(number int64, values map[string]float64, assignments ...experiments.Assignment) *experiments.TrialItem {
+		item := &experiments.TrialItem{Number: number, Status: experiments.TrialCompleted}
+		item.Assignments = assignments
+		for name, v := range values {
+			item.Values = append(item.Values, experiments.Value{MetricName: name, Value: v})
+		}
+		return item
+	}
+
+	cases := []struct {
+		desc     string
+		format   NumberFormat
+		expected string
+	}{
+		{
+			desc: "exact",
+			expected: "experiment,number,status,parameter_cpu,parameter_memory,metric_cost,failure_reason,failure_message,abandoned_reason\n" +
+				",1,Completed,0.123456789,1Gi,12.3456,,,\n" +
+				",2,Completed,250m,,0.1,,,\n",
+		},
+		{
+			desc:   "formatted",
+			format: NumberFormat{Precision: 3, NormalizeQuantities: true},
+			expected: "experiment,number,status,parameter_cpu,parameter_memory,metric_cost,failure_reason,failure_message,abandoned_reason\n" +
+				",1,Completed,0.123,1070000000,12.3,,,\n" +
+				",2,Completed,0.25,,0.1,,,\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := &TrialOutput{Format: c.format}
+			_ = o.Add(trial(1, map[string]float64{"cost": 12.3456},
+				experiments.Assignment{ParameterName: "cpu", Value: api.FromNumber("0.123456789")},
+				experiments.Assignment{ParameterName: "memory", Value: api.FromString("1Gi")}))
+			_ = o.Add(trial(2, map[string]float64{"cost": 0.1},
+				experiments.Assignment{ParameterName: "cpu", Value: api.FromString("250m")}))
+
+			var out bytes.Buffer
+			if assert.NoError(t, (&csvPrinter{}).Fprint(&out, o)) {
+				assert.Equal(t, c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestCSVPrinter_delimiter(t *testing.T) {
+	o := csvTestOutput{
+		{Name: "a", Labels: map[string]string{"team": "x;y"}},
+		{Name: "b", Labels: map[string]string{"tier": `say "hi"`}},
+	}
+
+	cases := []struct {
+		desc     string
+		printer  csvPrinter
+		expected string
+	}{
+		{
+			desc:    "semicolon",
+			printer: csvPrinter{Comma: ';'},
+			expected: "name;label_team;label_tier\n" +
+				"a;\"x;y\";\n" +
+				"b;;\"say \"\"hi\"\"\"\n",
+		},
+		{
+			desc:    "tab",
+			printer: csvPrinter{Comma: '\t'},
+			expected: "name\tlabel_team\tlabel_tier\n" +
+				"a\tx;y\t\n" +
+				"b\t\t\"say \"\"hi\"\"\"\n",
+		},
+		{
+			desc:    "tab without header",
+			printer: csvPrinter{Comma: '\t', NoHeader: true},
+			expected: "a\tx;y\t\n" +
+				"b\t\t\"say \"\"hi\"\"\"\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			if assert.NoError(t, c.printer.Fprint(&out, o)) {
+				assert.Equal(t, c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestCSVPrinter_empty(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, (&csvPrinter{}).Fprint(&out, csvTestOutput{}))
+	assert.Empty(t, out.String())
+	assert.EqualError(t, (&csvPrinter{}).Fprint(&out, "test"), "unable to render string as CSV")
+}
+
+func TestCSVDelimiter(t *testing.T) {
+	cases := []struct {
+		value       string
+		expected    rune
+		expectedErr string
+	}{
+		{value: ",", expected: ','},
+		{value: ";", expected: ';'},
+		{value: `\t`, expected: '\t'},
+		{value: "\t", expected: '\t'},
+		{value: "|", expected: '|'},
+		{value: "", expectedErr: `invalid CSV delimiter: ""`},
+		{value: ",,", expectedErr: `invalid CSV delimiter: ",,"`},
+		{value: `"`, expectedErr: `invalid CSV delimiter: "\""`},
+		{value: "\n", expectedErr: `invalid CSV delimiter: "\n"`},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			comma, err := csvDelimiter(c.value)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, comma)
+			}
+		})
+	}
+}
+
+// csvTestRow is a row with a flattened column.
+type csvTestRow struct {
+	Name   string            `csv:"name"`
+	Labels map[string]string `csv:"label_,flatten"`
+	Hidden string            `csv:"-"`
+}
+
+func (r *csvTestRow) Lookup(string) (interface{}, bool) { return nil, false }
+
+// csvTestOutput is a list of test rows.
+type csvTestOutput []csvTestRow
+
+func (o csvTestOutput) Len() int       { return len(o) }
+func (o csvTestOutput) Swap(i, j int)  { o[i], o[j] = o[j], o[i] }
+func (o csvTestOutput) Item(i int) Row { return &o[i] }
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// NewDebugTokenCommand returns a command for validating an access token before it is used.
+func NewDebugTokenCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		filename string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Validate an access token",
+		Long:  "Validate an access token, by default the token obtained using the current configuration is validated.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.Flags().StringVar(&filename, "file", filename, "`file` containing the token to validate")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+
+		vcfg, ok := cfg.(interface {
+			ValidateToken(token string) (*config.TokenClaims, error)
+		})
+		if !ok {
+			return fmt.Errorf("token validation is not supported")
+		}
+
+		var token string
+		if filename != "" {
+			data, err := readInput(cmd, filename)
+			if err != nil {
+				return err
+			}
+			token = strings.TrimSpace(string(data))
+		} else if tcfg, ok := cfg.(TokenSourceConfig); ok {
+			ts := tcfg.TokenSource(ctx)
+			if ts == nil {
+				return fmt.Errorf("no credentials are configured")
+			}
+			tok, err := ts.Token()
+			if err != nil {
+				return err
+			}
+			token = tok.AccessToken
+		}
+
+		claims, err := vcfg.ValidateToken(token)
+		if claims != nil {
+			if err := p.Fprint(out, claims); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	return cmd
+}
+
+// DebugAPIOutput is the capabilities advertised by each API endpoint.
+type DebugAPIOutput struct {
+	Applications *api.Capabilities `json:"applications,omitempty"`
+	Experiments  *api.Capabilities `json:"experiments,omitempty"`
+}
+
+// NewDebugAPICommand returns a command for inspecting the capabilities advertised by the server.
+func NewDebugAPICommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		includeApplications bool
+		includeExperiments  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Show the capabilities advertised by the server",
+		Long:  "Show the capabilities advertised by the server, by default all endpoints are included.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.Flags().BoolVar(&includeApplications, "applications", includeApplications, "include the applications endpoint")
+	cmd.Flags().BoolVar(&includeExperiments, "experiments", includeExperiments, "include the experiments endpoint")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		if !includeApplications && !includeExperiments {
+			includeApplications, includeExperiments = true, true
+		}
+
+		result := &DebugAPIOutput{}
+		if includeApplications {
+			md, err := newApplicationsAPI(cfg, client).CheckEndpoint(ctx)
+			if err != nil {
+				return err
+			}
+			caps := md.Capabilities()
+			result.Applications = &caps
+		}
+		if includeExperiments {
+			caps, err := experiments.GetCapabilities(ctx, newExperimentsAPI(cfg, client))
+			if err != nil {
+				return err
+			}
+			result.Experiments = &caps
+		}
+
+		return p.Fprint(out, result)
+	}
+	return cmd
+}
+
+// formatCapabilities returns a single line summary of the supplied capabilities.
+func formatCapabilities(c *api.Capabilities) string {
+	var parts []string
+	if c.APIVersion != "" {
+		parts = append(parts, "API "+c.APIVersion)
+	}
+	if len(c.Methods) > 0 {
+		parts = append(parts, "methods "+strings.Join(c.Methods, ", "))
+	}
+	if len(c.Limits) > 0 {
+		limits := make([]string, 0, len(c.Limits))
+		for k, v := range c.Limits {
+			limits = append(limits, fmt.Sprintf("%s=%d", k, v))
+		}
+		sort.Strings(limits)
+		parts = append(parts, "limits "+strings.Join(limits, ", "))
+	}
+	if len(parts) == 0 {
+		return "no capabilities advertised"
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,411 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	"golang.org/x/oauth2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorError   = "error"
+	doctorFixed   = "fixed"
+)
+
+// defaultServer is the address used to replace legacy server values.
+const defaultServer = "https://api.stormforge.io/"
+
+// legacyPrefixes are the prefixes of environment variables and host names left
+// over from previous incarnations of the product.
+var legacyPrefixes = []string{"carbonrelay", "redsky"}
+
+// NewConfigDoctorCommand returns a command for diagnosing configuration problems.
+func NewConfigDoctorCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		output   outputOptions
+		filename string
+		fix      bool
+		timeout  = 5 * time.Second
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration for problems",
+		Args:  cobra.NoArgs,
+	}
+
+	output.AddFlags(cmd)
+	cmd.Flags().StringVar(&filename, "file", filename, "configuration `file` to check in addition to the environment")
+	cmd.Flags().BoolVar(&fix, "fix", fix, "apply migrations to the configuration file")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "maximum `duration` to wait for each endpoint")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		d := &doctor{Timeout: timeout}
+		d.checkEnvironment()
+		d.checkEndpoint(ctx, "server", cfg.Address())
+		if icfg, ok := cfg.(EndpointsConfig); ok {
+			d.checkEndpoint(ctx, "issuer", icfg.IssuerAddress())
+		}
+		d.checkCredentials(ctx, cfg)
+		d.checkVersion(ctx, cfg)
+		d.checkExperiments(ctx, cfg)
+		d.checkClock(ctx, cfg)
+
+		if filename != "" {
+			if err := d.checkFile(filename, fix); err != nil {
+				return err
+			}
+		} else if fix {
+			return fmt.Errorf("--fix requires a configuration --file")
+		}
+
+		if err := p.Fprint(out, &d.DoctorOutput); err != nil {
+			return err
+		}
+
+		if n := d.problems(); n > 0 {
+			return fmt.Errorf("found %d configuration problem(s)", n)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// DoctorRow is a table row representation of a configuration check.
+type DoctorRow struct {
+	Check   string `table:"check" csv:"check" json:"check"`
+	Status  string `table:"status" csv:"status" json:"status"`
+	Message string `table:"message" csv:"message" json:"message,omitempty"`
+}
+
+func (r *DoctorRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "check":
+		return r.Check, true
+	case "status":
+		return r.Status, true
+	default:
+		return nil, false
+	}
+}
+
+// DoctorOutput wraps the results of the configuration checks for output.
+type DoctorOutput struct {
+	Items []DoctorRow `json:"checks"`
+}
+
+// Len returns the number of items being output.
+func (o *DoctorOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *DoctorOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *DoctorOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *DoctorOutput) SortBy(key string) error { return SortBy(o, key) }
+
+// doctor accumulates the results of the configuration checks.
+type doctor struct {
+	DoctorOutput
+	// The maximum amount of time to wait for an endpoint.
+	Timeout time.Duration
+}
+
+func (d *doctor) report(check, status, format string, args ...interface{}) {
+	d.Items = append(d.Items, DoctorRow{Check: check, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// problems returns the number of checks that did not pass.
+func (d *doctor) problems() int {
+	var n int
+	for _, r := range d.Items {
+		if r.Status == doctorError || r.Status == doctorWarning {
+			n++
+		}
+	}
+	return n
+}
+
+// checkEnvironment reports environment variables which are no longer used.
+func (d *doctor) checkEnvironment() {
+	var names []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if isLegacyEnv(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		d.report("environment", doctorOK, "")
+		return
+	}
+	for _, name := range names {
+		d.report("environment", doctorWarning, "%s is no longer used; use the corresponding STORMFORGE_ variable instead", name)
+	}
+}
+
+// checkEndpoint reports if the supplied address cannot be reached.
+func (d *doctor) checkEndpoint(ctx context.Context, check, address string) {
+	u, err := url.Parse(address)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		d.report(check, doctorError, "invalid address %q", address)
+		return
+	}
+	if isLegacyHost(u.Hostname()) {
+		d.report(check, doctorWarning, "%s is a legacy address", api.RedactURL(u))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		d.report(check, doctorError, "%v", err)
+		return
+	}
+
+	// Any response (even an error status) means the endpoint is reachable
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		d.report(check, doctorError, "%s is unreachable", api.RedactURL(u))
+		return
+	}
+	_ = resp.Body.Close()
+	d.report(check, doctorOK, "%s", api.RedactURL(u))
+}
+
+// checkCredentials reports if a valid token cannot be obtained.
+func (d *doctor) checkCredentials(ctx context.Context, cfg Config) {
+	tcfg, ok := cfg.(TokenSourceConfig)
+	if !ok {
+		return
+	}
+
+	ts := tcfg.TokenSource(ctx)
+	if ts == nil {
+		d.report("credentials", doctorWarning, "no credentials are configured")
+		return
+	}
+
+	tok, err := ts.Token()
+	if err != nil {
+		d.report("credentials", doctorError, "unable to obtain a token: %v", err)
+		return
+	}
+
+	if exp := tokenExpiry(tok); !exp.IsZero() && !exp.After(time.Now()) {
+		d.report("credentials", doctorError, "token expired %s", formatTime(&exp, "ago"))
+		return
+	}
+	d.report("credentials", doctorOK, "")
+}
+
+// checkVersion reports if the server requires a newer client.
+func (d *doctor) checkVersion(ctx context.Context, cfg Config) {
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	md, err := newApplicationsAPI(cfg, client).CheckEndpoint(ctx)
+	if err != nil && !api.IsClientVersionUnsupported(err) {
+		d.report("version", doctorWarning, "unable to check the supported client version: %v", err)
+		return
+	}
+	caps := md.Capabilities()
+	if err == nil {
+		err = caps.CheckClientVersion()
+	}
+	if err != nil {
+		d.report("version", doctorError, "%v", err)
+		return
+	}
+	if caps.APIVersion != "" {
+		d.report("version", doctorOK, "%s (server API %s)", api.ClientVersion(), caps.APIVersion)
+		return
+	}
+	d.report("version", doctorOK, "%s", api.ClientVersion())
+}
+
+// checkExperiments reports the capabilities of the experiments endpoint.
+func (d *doctor) checkExperiments(ctx context.Context, cfg Config) {
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	caps, err := experiments.GetCapabilities(ctx, newExperimentsAPI(cfg, client))
+	if err != nil {
+		d.report("experiments", doctorWarning, "unable to discover the experiments capabilities: %v", err)
+		return
+	}
+	if !caps.Allows(http.MethodPost) {
+		d.report("experiments", doctorWarning, "the server does not allow creating experiments (allowed: %s)", strings.Join(caps.Methods, ", "))
+		return
+	}
+	d.report("experiments", doctorOK, "%s", formatCapabilities(&caps))
+}
+
+// checkClock reports if the local clock differs from the server clock enough
+// to interfere with token expiry checks.
+func (d *doctor) checkClock(ctx context.Context, cfg Config) {
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	md, err := newApplicationsAPI(cfg, client).CheckEndpoint(ctx)
+	if err != nil {
+		return
+	}
+
+	skew, ok := md.ClockSkew(time.Now())
+	switch {
+	case !ok:
+		d.report("clock", doctorWarning, "unable to determine the server time")
+	case skew > api.ClockSkewThreshold || skew < -api.ClockSkewThreshold:
+		d.report("clock", doctorWarning, "local clock differs from the server by %s, consider increasing STORMFORGE_TOKEN_LEEWAY", skew.Round(time.Second))
+	default:
+		d.report("clock", doctorOK, "")
+	}
+}
+
+// checkFile reports (and optionally fixes) problems in a configuration file.
+func (d *doctor) checkFile(filename string, fix bool) error {
+	fcfg, err := config.LoadFile(filename)
+	if err != nil {
+		d.report("file", doctorError, "%v", err)
+		return nil
+	}
+
+	var changed bool
+	migrate := func(check string, value *string, replacement string) {
+		u, err := url.Parse(*value)
+		if err != nil || !isLegacyHost(u.Hostname()) {
+			return
+		}
+		if !fix {
+			d.report(check, doctorWarning, "%s: %s is a legacy address, use --fix to replace it with %s", filename, *value, replacement)
+			return
+		}
+		d.report(check, doctorFixed, "%s: replaced %s with %s", filename, *value, replacement)
+		*value, changed = replacement, true
+	}
+
+	migrate("file server", &fcfg.Server, defaultServer)
+	migrate("file issuer", &fcfg.Issuer, defaultServer)
+
+	for prefix := range fcfg.Audiences {
+		u, err := url.Parse(prefix)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			d.report("file audiences", doctorError, "%s: invalid audience prefix %q", filename, prefix)
+		}
+	}
+
+	if fcfg.Token != "" {
+		exp := tokenExpiry(&oauth2.Token{AccessToken: fcfg.Token})
+		switch {
+		case exp.IsZero() || exp.After(time.Now()):
+		case fix:
+			d.report("file token", doctorFixed, "%s: removed token which expired %s", filename, formatTime(&exp, "ago"))
+			fcfg.Token, changed = "", true
+		default:
+			d.report("file token", doctorError, "%s: token expired %s, use --fix to remove it", filename, formatTime(&exp, "ago"))
+		}
+	}
+
+	if changed {
+		return fcfg.Write()
+	}
+	return nil
+}
+
+// tokenExpiry returns the expiration time of a token, falling back to the
+// claims of JWT access tokens when the expiry is unknown.
+func tokenExpiry(tok *oauth2.Token) time.Time {
+	if !tok.Expiry.IsZero() {
+		return tok.Expiry
+	}
+
+	accessToken, err := jwt.ParseSigned(tok.AccessToken)
+	if err != nil {
+		return time.Time{}
+	}
+	claims := jwt.Claims{}
+	if err := accessToken.UnsafeClaimsWithoutVerification(&claims); err != nil || claims.Expiry == nil {
+		return time.Time{}
+	}
+	return claims.Expiry.Time()
+}
+
+// isLegacyEnv checks for a legacy environment variable name.
+func isLegacyEnv(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range legacyPrefixes {
+		if strings.HasPrefix(name, prefix+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// isLegacyHost checks for a legacy host name.
+func isLegacyHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, prefix := range legacyPrefixes {
+		if strings.Contains(host, prefix) {
+			return true
+		}
+	}
+	return false
+}
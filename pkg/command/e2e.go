@@ -0,0 +1,290 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+const (
+	e2ePass = "pass"
+	e2eFail = "fail"
+	e2eSkip = "skip"
+)
+
+// NewE2ECommand returns a command which exercises the full API pipeline against
+// the configured tenant: an application and scenario are created, a scan is
+// requested and an experiment is run through a number of trials.
+func NewE2ECommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		output  = outputOptions{Format: "table"}
+		prefix  = "e2e"
+		trials  = 3
+		cleanup bool
+	)
+
+	cmd := &cobra.Command{
+		Use:    "e2e",
+		Short:  "Verify the full API pipeline end-to-end",
+		Args:   cobra.NoArgs,
+		Hidden: true,
+	}
+
+	output.AddFlags(cmd)
+	cmd.Flags().StringVar(&prefix, "name-prefix", prefix, "`prefix` of the names of the created resources")
+	cmd.Flags().IntVar(&trials, "trials", trials, "`number` of trials to run")
+	cmd.Flags().BoolVar(&cleanup, "cleanup", cleanup, "delete the created resources when done")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		e := &e2e{
+			AppAPI:   newApplicationsAPI(cfg, client),
+			ExpAPI:   newExperimentsAPI(cfg, client),
+			Name:     fmt.Sprintf("%s-%d", prefix, time.Now().Unix()),
+			Trials:   trials,
+			progress: cmd.ErrOrStderr(),
+		}
+		if isQuiet(cfg) {
+			e.progress = io.Discard
+		}
+
+		e.run(ctx)
+		if cleanup {
+			e.cleanup(ctx)
+		}
+
+		if err := p.Fprint(out, &e.E2EOutput); err != nil {
+			return err
+		}
+
+		if n := e.failures(); n > 0 {
+			return fmt.Errorf("%d end-to-end step(s) failed", n)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// E2ERow is a table row representation of an end-to-end step.
+type E2ERow struct {
+	Step     string `table:"step" csv:"step" json:"step"`
+	Status   string `table:"status" csv:"status" json:"status"`
+	Duration string `table:"duration" csv:"duration" json:"duration,omitempty"`
+	Message  string `table:"message" csv:"message" json:"message,omitempty"`
+}
+
+func (r *E2ERow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "step":
+		return r.Step, true
+	case "status":
+		return r.Status, true
+	default:
+		return nil, false
+	}
+}
+
+// E2EOutput wraps the results of the end-to-end steps for output.
+type E2EOutput struct {
+	Items []E2ERow `json:"steps"`
+}
+
+// Len returns the number of items being output.
+func (o *E2EOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *E2EOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *E2EOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *E2EOutput) SortBy(key string) error { return SortBy(o, key) }
+
+// e2e accumulates the results of the end-to-end steps.
+type e2e struct {
+	E2EOutput
+	AppAPI applications.API
+	ExpAPI experiments.API
+	// The name of the created resources.
+	Name string
+	// The number of trials to run.
+	Trials int
+
+	progress io.Writer
+
+	app applications.Application
+	scn applications.Scenario
+	exp experiments.Experiment
+}
+
+// step runs a single step, unless skip is true, and records the outcome. It
+// returns true if the step passed.
+func (e *e2e) step(ctx context.Context, name string, skip bool, f func(context.Context) (string, error)) bool {
+	row := E2ERow{Step: name, Status: e2eSkip}
+	if !skip {
+		start := time.Now()
+		msg, err := f(ctx)
+		row.Duration = time.Since(start).Round(time.Millisecond).String()
+		switch {
+		case errors.Is(err, errSkipped):
+			row.Message = msg
+		case err != nil:
+			row.Status, row.Message = e2eFail, err.Error()
+		default:
+			row.Status, row.Message = e2ePass, msg
+		}
+	}
+
+	_, _ = fmt.Fprintf(e.progress, "%s: %s\n", row.Step, row.Status)
+	e.Items = append(e.Items, row)
+	return row.Status == e2ePass
+}
+
+// failures returns the number of steps that failed.
+func (e *e2e) failures() int {
+	var n int
+	for _, r := range e.Items {
+		if r.Status == e2eFail {
+			n++
+		}
+	}
+	return n
+}
+
+// run executes the end-to-end steps, steps are skipped if the steps they depend on did not pass.
+func (e *e2e) run(ctx context.Context) {
+	appOK := e.step(ctx, "create application", false, func(ctx context.Context) (string, error) {
+		var err error
+		e.app, err = applications.CreateAndGetApplication(ctx, e.AppAPI, applications.ApplicationName(e.Name), applications.Application{
+			DisplayName: "End-to-end test",
+		})
+		return e.app.Name.String(), err
+	})
+
+	scnOK := e.step(ctx, "create scenario", !appOK, func(ctx context.Context) (string, error) {
+		u := e.app.Link(api.RelationScenarios)
+		if u == "" {
+			return "", fmt.Errorf("malformed response, missing scenarios link")
+		}
+
+		var err error
+		e.scn, err = applications.CreateAndGetScenario(ctx, e.AppAPI, u, "e2e", applications.Scenario{
+			DisplayName: "End-to-end test",
+			Custom:      applications.Settings{"image": "busybox"},
+		})
+		return e.scn.Name.String(), err
+	})
+
+	e.step(ctx, "request scan", !scnOK, func(ctx context.Context) (string, error) {
+		u := e.scn.Link(api.RelationActivity)
+		if u == "" {
+			u = e.app.Link(api.RelationActivity)
+		}
+		if u == "" {
+			return "activity link not advertised", errSkipped
+		}
+
+		return "", e.AppAPI.CreateActivity(ctx, u, applications.Activity{
+			Scan: &applications.ScanActivity{Scenario: e.scn.Name.String()},
+		})
+	})
+
+	expOK := e.step(ctx, "create experiment", false, func(ctx context.Context) (string, error) {
+		var err error
+		e.exp, err = e.ExpAPI.CreateExperimentByName(ctx, experiments.ExperimentName(e.Name), experiments.Experiment{
+			DisplayName: "End-to-end test",
+			Budget:      int64(e.Trials + 1),
+			Parameters: []experiments.Parameter{
+				{Name: "x", Type: experiments.ParameterTypeInteger, Bounds: &experiments.Bounds{Min: "1", Max: "10"}},
+			},
+			Metrics: []experiments.Metric{
+				{Name: "y", Minimize: true},
+			},
+		})
+		if err == nil && (e.exp.Link(api.RelationTrials) == "" || e.exp.Link(api.RelationNextTrial) == "") {
+			err = fmt.Errorf("malformed response, missing trial links")
+		}
+		return e.exp.Name.String(), err
+	})
+
+	baselineOK := e.step(ctx, "create baseline", !expOK, func(ctx context.Context) (string, error) {
+		_, err := e.ExpAPI.CreateTrial(ctx, e.exp.Link(api.RelationTrials), experiments.TrialAssignments{
+			Labels:      map[string]string{experiments.LabelBaseline: "true"},
+			Assignments: []experiments.Assignment{{ParameterName: "x", Value: api.FromInt64(5)}},
+		})
+		return "", err
+	})
+
+	e.step(ctx, "run trials", !baselineOK, func(ctx context.Context) (string, error) {
+		for i := 0; i <= e.Trials; i++ {
+			ta, err := e.ExpAPI.NextTrial(ctx, e.exp.Link(api.RelationNextTrial))
+			var apiErr *api.Error
+			if errors.As(err, &apiErr) && apiErr.Type == experiments.ErrExperimentStopped {
+				return fmt.Sprintf("%d trials completed, experiment stopped", i), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			if ta.Location() == "" {
+				return "", fmt.Errorf("malformed response, missing trial location")
+			}
+
+			now := time.Now()
+			if err := e.ExpAPI.ReportTrial(ctx, ta.Location(), experiments.TrialValues{
+				StartTime:      &now,
+				CompletionTime: &now,
+				Values:         []experiments.Value{{MetricName: "y", Value: float64(i)}},
+			}); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%d trials completed", e.Trials+1), nil
+	})
+}
+
+// cleanup deletes the resources created by the end-to-end steps.
+func (e *e2e) cleanup(ctx context.Context) {
+	e.step(ctx, "delete experiment", e.exp.Link(api.RelationSelf) == "", func(ctx context.Context) (string, error) {
+		return "", e.ExpAPI.DeleteExperiment(ctx, e.exp.Link(api.RelationSelf))
+	})
+	e.step(ctx, "delete scenario", e.scn.Link(api.RelationSelf) == "", func(ctx context.Context) (string, error) {
+		return "", e.AppAPI.DeleteScenario(ctx, e.scn.Link(api.RelationSelf))
+	})
+	e.step(ctx, "delete application", e.app.Link(api.RelationSelf) == "", func(ctx context.Context) (string, error) {
+		return "", e.AppAPI.DeleteApplication(ctx, e.app.Link(api.RelationSelf))
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// jsonPrinter renders indented JSON.
+type jsonPrinter struct{}
+
+// Fprint renders the supplied object as JSON.
+func (p *jsonPrinter) Fprint(out io.Writer, obj interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(obj)
+}
+
+// yamlPrinter renders YAML using the JSON field names.
+type yamlPrinter struct{}
+
+// Fprint renders the supplied object as YAML.
+func (p *yamlPrinter) Fprint(out io.Writer, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// templateFuncs are the additional functions available to Go templates.
+var templateFuncs = template.FuncMap{
+	"toJson": func(v interface{}) (string, error) {
+		var buf strings.Builder
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(v)
+		return buf.String(), err
+	},
+	"toYaml": func(v interface{}) (string, error) {
+		data, err := yaml.Marshal(v)
+		return string(data), err
+	},
+}
+
+// goTemplatePrinter renders a Go template, the template is executed against
+// the JSON representation of the printed object so field names match the
+// "json" output format.
+type goTemplatePrinter struct {
+	tmpl *template.Template
+}
+
+// newGoTemplatePrinter parses the supplied Go template.
+func newGoTemplatePrinter(text string) (*goTemplatePrinter, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &goTemplatePrinter{tmpl: tmpl}, nil
+}
+
+// Fprint renders the supplied object using the template.
+func (p *goTemplatePrinter) Fprint(out io.Writer, obj interface{}) error {
+	data, err := jsonValue(obj)
+	if err != nil {
+		return err
+	}
+	return p.tmpl.Execute(out, data)
+}
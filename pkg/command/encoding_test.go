@@ -0,0 +1,44 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPrinter(t *testing.T) {
+	var out strings.Builder
+	p := &jsonPrinter{}
+	if assert.NoError(t, p.Fprint(&out, map[string]interface{}{"name": "a&b", "items": []int{1}})) {
+		assert.Equal(t, "{\n  \"items\": [\n    1\n  ],\n  \"name\": \"a&b\"\n}\n", out.String())
+	}
+}
+
+func TestYAMLPrinter(t *testing.T) {
+	var out strings.Builder
+	p := &yamlPrinter{}
+	obj := struct {
+		DisplayName string `json:"title"`
+		Hidden      string `json:"-"`
+	}{DisplayName: "Test", Hidden: "x"}
+	if assert.NoError(t, p.Fprint(&out, obj)) {
+		assert.Equal(t, "title: Test\n", out.String())
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// NewEnvCommand returns a command for listing the supported environment variables.
+func NewEnvCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		output outputOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "List the supported environment variables",
+		Args:  cobra.NoArgs,
+	}
+
+	output.AddFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		result := &EnvOutput{}
+		for _, v := range config.EnvVars() {
+			result.Items = append(result.Items, NewEnvRow(v))
+		}
+		return p.Fprint(out, result)
+	}
+	return cmd
+}
+
+// EnvRow is a table row representation of an environment variable.
+type EnvRow struct {
+	Name    string `table:"name" csv:"name" json:"name"`
+	Default string `table:"default" csv:"default" json:"default,omitempty"`
+	Value   string `table:"value" csv:"value" json:"value,omitempty"`
+	Source  string `table:"source" csv:"source" json:"source,omitempty"`
+}
+
+func NewEnvRow(v config.EnvVar) EnvRow {
+	row := EnvRow{Name: v.Name, Default: v.Default}
+
+	if value, ok := os.LookupEnv(v.Name); ok {
+		row.Value, row.Source = value, "environment"
+	} else if v.Default != "" {
+		row.Value, row.Source = v.Default, "default"
+	}
+
+	if v.Secret && row.Value != "" {
+		row.Value = api.Redacted
+	}
+	return row
+}
+
+func (r *EnvRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "name":
+		return r.Name, true
+	case "source":
+		return r.Source, true
+	default:
+		return nil, false
+	}
+}
+
+// EnvOutput wraps the environment variables for output.
+type EnvOutput struct {
+	Items []EnvRow `json:"env"`
+}
+
+// Len returns the number of items being output.
+func (o *EnvOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *EnvOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *EnvOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *EnvOutput) SortBy(key string) error { return SortBy(o, key) }
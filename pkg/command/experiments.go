@@ -17,6 +17,8 @@ limitations under the License.
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -28,7 +30,9 @@ import (
 // NewEditExperimentCommand returns a command for editing an experiment.
 func NewEditExperimentCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		labels map[string]string
+		labels         map[string]string
+		ignoreNotFound bool
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -39,24 +43,32 @@ func NewEditExperimentCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringToStringVar(&labels, "set-label", nil, "label `key=value` pairs to assign")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	output.AddFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API:            newExperimentsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
 		}
 
 		return l.ForEachNamedExperiment(ctx, args, false, func(item *experiments.ExperimentItem) error {
 			// Apply label changes
 			if len(labels) > 0 {
-				labelsURL := item.Link(api.RelationLabels)
-				if labelsURL == "" {
-					return fmt.Errorf("malformed response, missing labels link")
+				labelsURL, err := experiments.EnsureLabelsLink(ctx, l.API, &item.Experiment)
+				if err != nil {
+					return err
 				}
 
 				if err := l.API.LabelExperiment(ctx, labelsURL, experiments.ExperimentLabels{Labels: labels}); err != nil {
@@ -76,6 +88,12 @@ func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 		batchSize int
 		selector  string
 		sortBy    string
+		output    outputOptions
+		scope     scopeOptions
+
+		details        bool
+		concurrency    = concurrencyOptions{Concurrency: 4}
+		ignoreNotFound bool
 	)
 
 	cmd := &cobra.Command{
@@ -87,37 +105,69 @@ func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().IntVar(&batchSize, "chunk-size", 500, "fetch large lists in chu`n`ks rather then all at once")
 	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "selector (label `query`) to filter on")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd)
+	scope.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	cmd.Flags().BoolVar(&details, "details", details, "include trial counts and the best metric value (requires listing the trials of each experiment)")
+	concurrency.AddFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API:       experiments.NewAPI(client),
+			API:       newExperimentsAPI(cfg, client),
 			BatchSize: batchSize,
+
+			ContinueOnError: concurrency.ContinueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
+		var partial partialResult
 		result := &ExperimentOutput{Items: make([]ExperimentRow, 0, len(args))}
 		if len(args) > 0 {
-			if err := l.ForEachNamedExperiment(ctx, args, false, result.Add); err != nil {
+			if err := partial.Check(l.ForEachNamedExperiment(ctx, args, false, result.Add)); err != nil {
 				return err
 			}
 		} else {
 			q := experiments.ExperimentListQuery{}
 			q.SetLabelSelector(parseLabelSelector(selector))
+			scope.Apply(&q.IndexQuery)
 			if err := l.ForEachExperiment(ctx, q, result.Add); err != nil {
 				return err
 			}
 		}
 
+		if details {
+			errs := concurrency.forEach(ctx, len(result.Items), func(ctx context.Context, i int) error {
+				row := &result.Items[i]
+				return l.ForEachTrial(ctx, &row.Experiment, experiments.TrialListQuery{}, row.AddTrial)
+			})
+
+			var detailsErr api.MultiError
+			for i, err := range errs {
+				if err != nil && !errors.Is(err, errNotAttempted) {
+					detailsErr.Add(result.Items[i].Name, err)
+				}
+			}
+			if err := detailsErr.ErrorOrNil(); err != nil {
+				return err
+			}
+		}
+
 		if err := result.SortBy(sortBy); err != nil {
 			return err
 		}
 
-		return p.Fprint(out, result)
+		return partial.Print(out, p, result)
 	}
 	return cmd
 }
@@ -126,6 +176,11 @@ func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		ignoreNotFound bool
+		selector       string
+		yes            bool
+		dryRun         bool
+		concurrency    concurrencyOptions
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -135,19 +190,78 @@ func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "treat not found errors as successful deletes")
+	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "delete all experiments matching the selector (label `query`)")
+	cmd.Flags().BoolVar(&yes, "yes", yes, "delete the experiments matching the selector without prompting for confirmation")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "only print the experiments which would be deleted")
+	concurrency.AddFlags(cmd)
+	output.AddResultFlags(cmd, "delete")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API: newExperimentsAPI(cfg, client),
 		}
 
-		return l.ForEachNamedExperiment(ctx, args, ignoreNotFound, func(item *experiments.ExperimentItem) error {
+		// Resolve the experiments matching the selector up front so they can be confirmed
+		names := args
+		var items []experiments.ExperimentItem
+		if selector != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("experiment names cannot be combined with a selector")
+			}
+
+			sel := parseLabelSelector(selector)
+			q := experiments.ExperimentListQuery{}
+			q.SetLabelSelector(sel)
+			if err := l.ForEachExperiment(ctx, q, func(item *experiments.ExperimentItem) error {
+				// Do not rely on the server to filter, ignoring the selector would delete everything
+				if matchLabelSelector(sel, item.Labels) {
+					items = append(items, *item)
+					names = append(names, item.Name.String())
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			if !yes && !dryRun {
+				if err := confirmDelete(cmd, "experiments", names); err != nil {
+					return err
+				}
+			}
+		}
+
+		p = &syncPrinter{p: p}
+		concurrency.Quiet = isQuiet(cfg)
+		return concurrency.Run(ctx, cmd.ErrOrStderr(), names, func(ctx context.Context, i int) error {
+			var item *experiments.ExperimentItem
+			if selector != "" {
+				item = &items[i]
+			} else if err := l.ForEachNamedExperiment(ctx, names[i:i+1], ignoreNotFound, func(found *experiments.ExperimentItem) error {
+				item = found
+				return nil
+			}); err != nil {
+				return err
+			}
+			if item == nil {
+				return errSkipped
+			}
+
+			if dryRun {
+				_, err := fmt.Fprintf(out, MessageFormat(MessageDryRunDeletedExperiment)+"\n", item.Name)
+				return err
+			}
+
 			selfURL := item.Link(api.RelationSelf)
 			if selfURL == "" {
 				return fmt.Errorf("malformed response, missing self link")
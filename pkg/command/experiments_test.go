@@ -0,0 +1,126 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig is a command configuration for an API server address.
+type testConfig string
+
+func (c testConfig) Address() string { return string(c) }
+
+// experimentsServer serves a list of labeled experiments (ignoring any
+// selector) and records the deletes.
+type experimentsServer struct {
+	mu      sync.Mutex
+	labels  map[string]string
+	deleted []string
+}
+
+func (s *experimentsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/experiments/")
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		var items []string
+		for n, l := range s.labels {
+			items = append(items, fmt.Sprintf(`{"_metadata":{"Link":"<http://%s/v1/experiments/%s>;rel=self"},"name":%q,"labels":{"app":%q}}`, r.Host, n, n, l))
+		}
+		sort.Strings(items)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"experiments":[%s]}`, strings.Join(items, ","))
+	case r.Method == http.MethodDelete && name != "":
+		s.mu.Lock()
+		s.deleted = append(s.deleted, name)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestDeleteExperimentsCommand_selector(t *testing.T) {
+	cases := []struct {
+		desc            string
+		args            []string
+		expectedErr     string
+		expectedOut     string
+		expectedDeleted []string
+	}{
+		{
+			desc:            "yes",
+			args:            []string{"-l", "app=x", "--yes"},
+			expectedOut:     "experiment/a\nexperiment/c\n",
+			expectedDeleted: []string{"a", "c"},
+		},
+		{
+			desc:        "dry run",
+			args:        []string{"-l", "app=x", "--dry-run"},
+			expectedOut: "deleted experiment \"a\" (dry run).\ndeleted experiment \"c\" (dry run).\n",
+		},
+		{
+			desc:        "no confirmation",
+			args:        []string{"-l", "app=x"},
+			expectedErr: "refusing to delete 2 experiments without confirmation, use --yes to proceed",
+		},
+		{
+			desc:        "no match",
+			args:        []string{"-l", "app=z"},
+			expectedOut: "",
+		},
+		{
+			desc:        "names and selector",
+			args:        []string{"-l", "app=x", "a"},
+			expectedErr: "experiment names cannot be combined with a selector",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &experimentsServer{labels: map[string]string{"a": "x", "b": "y", "c": "x"}}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			var out bytes.Buffer
+			cmd := NewDeleteExperimentsCommand(testConfig(srv.URL), &namePrinter{})
+			cmd.SetArgs(c.args)
+			cmd.SetIn(strings.NewReader(""))
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedOut, out.String())
+			}
+			assert.ElementsMatch(t, c.expectedDeleted, s.deleted)
+		})
+	}
+}
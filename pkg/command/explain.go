@@ -0,0 +1,217 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+// explainResources maps the resource names accepted by the explain command to
+// the type used to represent them.
+var explainResources = map[string]reflect.Type{
+	"application":    reflect.TypeOf(applications.Application{}),
+	"scenario":       reflect.TypeOf(applications.Scenario{}),
+	"recommendation": reflect.TypeOf(applications.RecommendationList{}),
+}
+
+// explainDescriptions contains descriptions of the fields which cannot be
+// derived from the type information alone.
+var explainDescriptions = map[string]string{
+	"application.name":                             "The unique name of the application.",
+	"application.title":                            "The human readable name of the application.",
+	"application.resources":                        "The Kubernetes resources that make up the application.",
+	"application.resources[].kubernetes.namespace": "The namespace of the application resources.",
+	"application.resources[].kubernetes.selector":  "The label selector of the application resources.",
+	"application.createdAt":                        "The time the application was created.",
+	"scenario.name":                                "The unique name of the scenario.",
+	"scenario.title":                               "The human readable name of the scenario.",
+	"scenario.configuration":                       "The configuration used to generate the experiment.",
+	"scenario.objective":                           "The goals of the experiment.",
+	"scenario.clusters":                            "The names of the clusters the scenario can run on.",
+	"scenario.stormforgePerf":                      "The StormForge Performance test case used to generate load.",
+	"scenario.locust":                              "The Locust file used to generate load.",
+	"scenario.k6":                                  "The k6 script used to generate load.",
+	"scenario.custom":                              "The custom pod template used to generate load.",
+	"recommendation.deploy":                        "The configuration used to deploy recommendations.",
+	"recommendation.deploy.mode":                   "The recommendation mode; one of: disabled|manual|auto.",
+	"recommendation.deploy.interval":               "The interval at which recommendations are deployed.",
+	"recommendation.deploy.limits":                 "The namespace limit ranges recommendations must satisfy.",
+	"recommendation.configuration":                 "The configuration used to produce recommendations.",
+	"recommendation.recommendations":               "The recommendations produced for the application.",
+}
+
+// NewExplainCommand returns a command for describing the fields of a resource.
+func NewExplainCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		output outputOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:       "explain application|scenario|recommendation",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"application", "scenario", "recommendation"},
+	}
+
+	output.AddFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		t, ok := explainResources[strings.ToLower(args[0])]
+		if !ok {
+			return fmt.Errorf("unknown resource: %s", args[0])
+		}
+
+		result := &ExplainOutput{Resource: strings.ToLower(args[0])}
+		result.addFields(t, "", map[reflect.Type]bool{})
+		return p.Fprint(out, result)
+	}
+	return cmd
+}
+
+// ExplainRow is a table row representation of a resource field.
+type ExplainRow struct {
+	Field       string `table:"field" csv:"field" json:"field"`
+	Type        string `table:"type" csv:"type" json:"type"`
+	Description string `table:"description" csv:"description" json:"description,omitempty"`
+}
+
+func (r *ExplainRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "field":
+		return r.Field, true
+	case "type":
+		return r.Type, true
+	default:
+		return nil, false
+	}
+}
+
+// ExplainOutput wraps the fields of a resource for output.
+type ExplainOutput struct {
+	Resource string       `json:"resource"`
+	Items    []ExplainRow `json:"fields"`
+}
+
+// Len returns the number of items being output.
+func (o *ExplainOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *ExplainOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *ExplainOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *ExplainOutput) SortBy(key string) error { return SortBy(o, key) }
+
+// addFields appends a row for each JSON field of the supplied struct type,
+// recursing into nested objects.
+func (o *ExplainOutput) addFields(t reflect.Type, prefix string, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+
+		// Embedded structs without a name contribute their fields directly
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			o.addFields(f.Type, prefix, seen)
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		path := prefix + name
+		o.Items = append(o.Items, ExplainRow{
+			Field:       path,
+			Type:        explainType(f.Type),
+			Description: explainDescriptions[o.Resource+"."+path],
+		})
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			if ft.Kind() == reflect.Slice {
+				path += "[]"
+			}
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && explainType(ft) == "object" {
+			o.addFields(ft, path+".", seen)
+		}
+	}
+}
+
+// explainType returns the JSON type name of the supplied type.
+func explainType(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return "timestamp"
+	case reflect.TypeOf(api.Duration(0)):
+		return "duration"
+	case reflect.TypeOf(api.NumberOrString{}), reflect.TypeOf(applications.Tolerance{}):
+		return "number|string"
+	case reflect.TypeOf(json.Number("")):
+		return "number"
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return explainType(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return "[]" + explainType(t.Elem())
+	case reflect.Map:
+		return "map[string]" + explainType(t.Elem())
+	case reflect.Struct:
+		return "object"
+	case reflect.Interface:
+		return "any"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return t.Kind().String()
+	}
+}
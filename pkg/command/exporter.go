@@ -0,0 +1,285 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// NewExporterCommand returns a command for exposing the state of applications,
+// recommendations and experiments as Prometheus metrics.
+func NewExporterCommand(cfg Config) *cobra.Command {
+	var (
+		listen   = ":9090"
+		interval = time.Minute
+	)
+
+	cmd := &cobra.Command{
+		Use:   "exporter",
+		Short: "Expose application and experiment state as Prometheus metrics",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", listen, "the `address` to serve metrics on")
+	cmd.Flags().DurationVar(&interval, "interval", interval, "the `duration` between refreshes of the metrics")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		e := &exporter{
+			apps: applications.Lister{API: newApplicationsAPI(cfg, client)},
+			exps: experiments.Lister{API: newExperimentsAPI(cfg, client)},
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", e)
+		srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		go func() {
+			e.collect(ctx)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					e.collect(ctx)
+				}
+			}
+		}()
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Serving metrics on %s/metrics\n", listen)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+	return cmd
+}
+
+// exporter periodically collects metrics and serves the most recent values.
+type exporter struct {
+	apps applications.Lister
+	exps experiments.Lister
+
+	mu      sync.Mutex
+	metrics []byte
+}
+
+// ServeHTTP writes the most recently collected metrics.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	metrics := e.metrics
+	e.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(metrics)
+}
+
+// collect lists the current state and replaces the served metrics.
+func (e *exporter) collect(ctx context.Context) {
+	m := &metricSet{}
+	start := time.Now()
+
+	var failed bool
+	if err := e.collectApplications(ctx, m); err != nil {
+		failed = true
+	}
+	if err := e.collectExperiments(ctx, m); err != nil {
+		failed = true
+	}
+
+	success := 1.0
+	if failed {
+		success = 0
+	}
+	m.add("optimize_exporter_collect_success", "Whether the last collection succeeded.", nil, success)
+	m.add("optimize_exporter_collect_duration_seconds", "The duration of the last collection.", nil, time.Since(start).Seconds())
+
+	var buf bytes.Buffer
+	_ = m.write(&buf)
+
+	e.mu.Lock()
+	e.metrics = buf.Bytes()
+	e.mu.Unlock()
+}
+
+func (e *exporter) collectApplications(ctx context.Context, m *metricSet) error {
+	return e.apps.ForEachApplication(ctx, applications.ApplicationListQuery{}, func(item *applications.ApplicationItem) error {
+		app := item.Name.String()
+		if item.LastDeployedAt != nil {
+			m.add("optimize_application_last_deployed_timestamp_seconds", "The time recommendations were last deployed for the application.",
+				[]string{"application", app}, float64(item.LastDeployedAt.Unix()))
+		}
+
+		if item.Link(api.RelationRecommendations) == "" {
+			return nil
+		}
+
+		return e.apps.ForEachRecommendation(ctx, &item.Application, func(rec *applications.RecommendationItem) error {
+			for _, p := range rec.Parameters {
+				for _, cr := range p.ContainerResources {
+					for _, r := range recommendedRatios(cr) {
+						m.add("optimize_recommendation_ratio", "The ratio of the recommended value to the current value.",
+							[]string{"application", app, "recommendation", rec.Name, "workload", p.Target.String(), "container", r.container, "resource", r.resource},
+							r.ratio)
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (e *exporter) collectExperiments(ctx context.Context, m *metricSet) error {
+	return e.exps.ForEachExperiment(ctx, experiments.ExperimentListQuery{}, func(item *experiments.ExperimentItem) error {
+		exp := []string{"experiment", item.Name.String()}
+		m.add("optimize_experiment_observations", "The number of observations made for the experiment.", exp, float64(item.Observations))
+		if item.Budget > 0 {
+			m.add("optimize_experiment_budget", "The target number of observations for the experiment.", exp, float64(item.Budget))
+		}
+		return nil
+	})
+}
+
+type recommendedRatio struct {
+	container string
+	resource  string
+	ratio     float64
+}
+
+// recommendedRatios computes the ratio of recommended to current requests,
+// when the server reports the current requests of the container.
+func recommendedRatios(cr interface{}) []recommendedRatio {
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return nil
+	}
+
+	c := struct {
+		Name     string                     `json:"name"`
+		Requests *applications.ResourceList `json:"requests"`
+		Current  *struct {
+			Requests *applications.ResourceList `json:"requests"`
+		} `json:"current"`
+	}{}
+	if err := json.Unmarshal(data, &c); err != nil || c.Current == nil {
+		return nil
+	}
+
+	var result []recommendedRatio
+	for _, name := range []string{"cpu", "memory"} {
+		rec, cur := c.Requests.Get(name), c.Current.Requests.Get(name)
+		if rec == nil || cur == nil {
+			continue
+		}
+		if cv := quantityValue(cur); cv != 0 {
+			result = append(result, recommendedRatio{container: c.Name, resource: name, ratio: quantityValue(rec) / cv})
+		}
+	}
+	return result
+}
+
+// quantityValue returns the numeric value of a number or quantity string.
+func quantityValue(v *api.NumberOrString) float64 {
+	if v.IsString {
+		if q := v.Quantity(); q != nil {
+			f, _ := q.Float64()
+			return f
+		}
+		return 0
+	}
+	return v.Float64Value()
+}
+
+// labelEscaper escapes label values for the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricSet accumulates gauge samples in the Prometheus text format.
+type metricSet struct {
+	names   []string
+	help    map[string]string
+	samples map[string][]string
+}
+
+// add records a gauge sample, labels are alternating name/value pairs.
+func (m *metricSet) add(name, help string, labels []string, value float64) {
+	if m.help == nil {
+		m.help = make(map[string]string)
+		m.samples = make(map[string][]string)
+	}
+	if _, ok := m.help[name]; !ok {
+		m.names = append(m.names, name)
+		m.help[name] = help
+	}
+
+	var lbls []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		lbls = append(lbls, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+
+	sample := name
+	if len(lbls) > 0 {
+		sample += "{" + strings.Join(lbls, ",") + "}"
+	}
+	m.samples[name] = append(m.samples[name], sample+" "+strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+func (m *metricSet) write(w io.Writer) error {
+	names := append([]string(nil), m.names...)
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, m.help[name], name); err != nil {
+			return err
+		}
+		for _, s := range m.samples[name] {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
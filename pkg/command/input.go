@@ -0,0 +1,128 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// maxInputSize is the largest amount of data read for a single input.
+const maxInputSize = 10 << 20
+
+// isURLInput returns true if the input name should be fetched over HTTP.
+func isURLInput(name string) bool {
+	switch strings.ToLower(strings.SplitN(name, ":", 2)[0]) {
+	case "http", "https":
+		return true
+	default:
+		return false
+	}
+}
+
+// readInput returns the contents of the named input. The name may be a file
+// name, "-" to read from stdin or an HTTP(S) URL.
+func readInput(cmd *cobra.Command, name string) ([]byte, error) {
+	var r io.Reader
+	switch {
+	case name == "-":
+		r = cmd.InOrStdin()
+	case isURLInput(name):
+		req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to read %s: %s", name, resp.Status)
+		}
+		r = resp.Body
+	default:
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxInputSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInputSize {
+		return nil, fmt.Errorf("unable to read %s: input exceeds %d bytes", inputName(name), maxInputSize)
+	}
+	return data, nil
+}
+
+// readInputDocuments returns the YAML documents of the named input, see `readInput`.
+func readInputDocuments(cmd *cobra.Command, name string) ([][]byte, error) {
+	data, err := readInput(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs [][]byte
+	for _, doc := range bytes.Split(append([]byte("\n"), data...), []byte("\n---")) {
+		// Drop anything else on the separator line (e.g. "--- # comment")
+		if i := bytes.IndexByte(doc, '\n'); i >= 0 {
+			doc = doc[i+1:]
+		} else {
+			doc = nil
+		}
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// readInputDocument returns the only YAML document of the named input, see `readInput`.
+func readInputDocument(cmd *cobra.Command, name string) ([]byte, error) {
+	docs, err := readInputDocuments(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(docs) {
+	case 0:
+		return nil, fmt.Errorf("no document found in %s", inputName(name))
+	case 1:
+		return docs[0], nil
+	default:
+		return nil, fmt.Errorf("expected a single document in %s, found %d", inputName(name), len(docs))
+	}
+}
+
+// inputName returns the display name of an input.
+func inputName(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	return name
+}
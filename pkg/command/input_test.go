@@ -0,0 +1,127 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadInput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/input.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("url: true\n"))
+	}))
+	defer srv.Close()
+
+	filename := filepath.Join(t.TempDir(), "input.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("file: true\n"), 0600))
+
+	cases := []struct {
+		desc        string
+		name        string
+		expected    string
+		expectedErr string
+	}{
+		{desc: "stdin", name: "-", expected: "stdin: true\n"},
+		{desc: "file", name: filename, expected: "file: true\n"},
+		{desc: "url", name: srv.URL + "/input.yaml", expected: "url: true\n"},
+		{desc: "url not found", name: srv.URL + "/missing.yaml", expectedErr: "unable to read " + srv.URL + "/missing.yaml: 404 Not Found"},
+		{desc: "missing file", name: filepath.Join(filepath.Dir(filename), "missing.yaml"), expectedErr: "open " + filepath.Join(filepath.Dir(filename), "missing.yaml") + ": no such file or directory"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetIn(strings.NewReader("stdin: true\n"))
+			data, err := readInput(cmd, c.name)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, string(data))
+			}
+		})
+	}
+}
+
+func TestReadInput_maxInputSize(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(strings.Repeat("x", maxInputSize+1)))
+	_, err := readInput(cmd, "-")
+	assert.EqualError(t, err, "unable to read stdin: input exceeds 10485760 bytes")
+}
+
+func TestReadInputDocuments(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected []string
+	}{
+		{desc: "empty", input: ""},
+		{desc: "single", input: "a: 1\n", expected: []string{"a: 1\n"}},
+		{desc: "multiple", input: "a: 1\n---\nb: 2\n", expected: []string{"a: 1", "b: 2\n"}},
+		{desc: "leading separator", input: "---\na: 1\n", expected: []string{"a: 1\n"}},
+		{desc: "separator comment", input: "a: 1\n--- # next\nb: 2\n", expected: []string{"a: 1", "b: 2\n"}},
+		{desc: "empty documents", input: "---\n---\na: 1\n---\n\n---", expected: []string{"a: 1"}},
+		{desc: "separator in value", input: "a: |\n  x\n  ---\n", expected: []string{"a: |\n  x\n  ---\n"}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetIn(strings.NewReader(c.input))
+			docs, err := readInputDocuments(cmd, "-")
+			if assert.NoError(t, err) {
+				var actual []string
+				for _, doc := range docs {
+					actual = append(actual, string(doc))
+				}
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadInputDocument(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("---\n"))
+	_, err := readInputDocument(cmd, "-")
+	assert.EqualError(t, err, "no document found in stdin")
+
+	cmd.SetIn(strings.NewReader("a: 1\n---\nb: 2\n"))
+	_, err = readInputDocument(cmd, "-")
+	assert.EqualError(t, err, "expected a single document in stdin, found 2")
+}
+
+func TestIsURLInput(t *testing.T) {
+	assert.True(t, isURLInput("https://example.com/input.yaml"))
+	assert.True(t, isURLInput("HTTP://example.com/input.yaml"))
+	assert.False(t, isURLInput("input.yaml"))
+	assert.False(t, isURLInput("-"))
+	assert.False(t, isURLInput("file:///input.yaml"))
+}
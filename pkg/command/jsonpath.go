@@ -0,0 +1,676 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathPrinter renders the values selected by a JSONPath template. The
+// syntax matches `kubectl`: literal text is copied as-is while expressions such
+// as `{.items[*].name}` are enclosed in braces. Ranges (`{range ...}` through
+// `{end}`) and quoted strings (e.g. `{"\n"}`) are also supported. Expressions
+// are evaluated against the JSON representation of the printed object, missing
+// keys produce no output.
+type jsonPathPrinter struct {
+	nodes []jsonPathNode
+}
+
+// newJSONPathPrinter parses the supplied JSONPath template.
+func newJSONPathPrinter(text string) (*jsonPathPrinter, error) {
+	nodes, rest, err := parseJSONPathTemplate(text, false)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid JSONPath template: unexpected {end}")
+	}
+	return &jsonPathPrinter{nodes: nodes}, nil
+}
+
+// Fprint renders the supplied object using the template.
+func (p *jsonPathPrinter) Fprint(out io.Writer, obj interface{}) error {
+	root, err := jsonValue(obj)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := executeJSONPath(&buf, p.nodes, root, root); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// jsonValue returns the generic JSON representation of the supplied object.
+func jsonValue(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// jsonPathNode is a single element of a parsed JSONPath template.
+type jsonPathNode struct {
+	// Literal text, only used when the path is nil.
+	text string
+	// The expression to evaluate.
+	path []jsonPathSegment
+	// The nodes evaluated for each value selected by a range expression.
+	body []jsonPathNode
+	// Indicates the node is a range expression.
+	isRange bool
+}
+
+// parseJSONPathTemplate parses template nodes. When parsing the body of a
+// range, the text following the terminating `{end}` is also returned.
+func parseJSONPathTemplate(text string, inRange bool) ([]jsonPathNode, string, error) {
+	var nodes []jsonPathNode
+	for text != "" {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			nodes = append(nodes, jsonPathNode{text: text})
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jsonPathNode{text: text[:open]})
+		}
+
+		end, err := matchingBrace(text, open)
+		if err != nil {
+			return nil, "", err
+		}
+		expr := strings.TrimSpace(text[open+1 : end])
+		text = text[end+1:]
+
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nodes, "{end}", nil
+			}
+			return nodes, text, nil
+
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJSONPath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+			body, rest, err := parseJSONPathTemplate(text, true)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, body: body, isRange: true})
+			text = rest
+
+		case strings.HasPrefix(expr, `"`):
+			s, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid JSONPath string %s: %w", expr, err)
+			}
+			nodes = append(nodes, jsonPathNode{text: s})
+
+		default:
+			path, err := parseJSONPath(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathNode{path: path})
+		}
+	}
+
+	if inRange {
+		return nil, "", fmt.Errorf("invalid JSONPath template: missing {end}")
+	}
+	return nodes, "", nil
+}
+
+// matchingBrace returns the index of the brace closing the one at the supplied
+// index, ignoring braces in quoted strings.
+func matchingBrace(text string, open int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid JSONPath template: unclosed expression %q", text[open:])
+}
+
+// executeJSONPath writes the result of evaluating the nodes against the
+// current value.
+func executeJSONPath(w *bytes.Buffer, nodes []jsonPathNode, root, current interface{}) error {
+	for _, n := range nodes {
+		if n.path == nil {
+			w.WriteString(n.text)
+			continue
+		}
+
+		values := evalJSONPath(n.path, root, current)
+		if n.isRange {
+			for _, v := range values {
+				if err := executeJSONPath(w, n.body, root, v); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for i, v := range values {
+			if i > 0 {
+				w.WriteByte(' ')
+			}
+			s, err := formatJSONPathValue(v)
+			if err != nil {
+				return err
+			}
+			w.WriteString(s)
+		}
+	}
+	return nil
+}
+
+// formatJSONPathValue returns the text of a selected value, strings are not
+// quoted while objects and arrays are rendered as JSON.
+func formatJSONPathValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// jsonPathSegment is a single step of a JSONPath expression.
+type jsonPathSegment struct {
+	kind jsonPathSegmentKind
+	// The field name of a field segment.
+	name string
+	// The bounds of index and slice segments.
+	start, end, step *int
+	// The condition of a filter segment.
+	filter *jsonPathFilter
+}
+
+type jsonPathSegmentKind int
+
+const (
+	jsonPathRoot jsonPathSegmentKind = iota
+	jsonPathField
+	jsonPathIndex
+	jsonPathSlice
+	jsonPathWildcard
+	jsonPathRecursive
+	jsonPathFilterKind
+)
+
+// parseJSONPath parses an expression such as `.items[0].name`. Expressions are
+// relative to the current value unless they start with `$`.
+func parseJSONPath(expr string) ([]jsonPathSegment, error) {
+	path := []jsonPathSegment{}
+	s := expr
+	switch {
+	case strings.HasPrefix(s, "$"):
+		path = append(path, jsonPathSegment{kind: jsonPathRoot})
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	}
+
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			path = append(path, jsonPathSegment{kind: jsonPathRecursive})
+			s = s[1:]
+
+		case s[0] == '.':
+			s = s[1:]
+			if s == "" {
+				// A lone "." selects the current value
+				break
+			}
+			if s[0] == '[' {
+				continue
+			}
+			if s[0] == '*' {
+				path = append(path, jsonPathSegment{kind: jsonPathWildcard})
+				s = s[1:]
+				continue
+			}
+			n := strings.IndexAny(s, ".[")
+			if n < 0 {
+				n = len(s)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("invalid JSONPath expression %q: missing field name", expr)
+			}
+			path = append(path, jsonPathSegment{kind: jsonPathField, name: s[:n]})
+			s = s[n:]
+
+		case s[0] == '[':
+			end, err := matchingBracket(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expr, err)
+			}
+			seg, err := parseJSONPathSubscript(strings.TrimSpace(s[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expr, err)
+			}
+			path = append(path, seg)
+			s = s[end+1:]
+
+		default:
+			return nil, fmt.Errorf("invalid JSONPath expression %q: unexpected %q", expr, s)
+		}
+	}
+	return path, nil
+}
+
+// matchingBracket returns the index of the bracket closing the one at the start
+// of the supplied text, ignoring brackets in quoted strings.
+func matchingBracket(s string) (int, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed subscript")
+}
+
+// parseJSONPathSubscript parses the contents of a bracketed subscript.
+func parseJSONPathSubscript(sub string) (jsonPathSegment, error) {
+	switch {
+	case sub == "*":
+		return jsonPathSegment{kind: jsonPathWildcard}, nil
+
+	case strings.HasPrefix(sub, "?(") && strings.HasSuffix(sub, ")"):
+		f, err := parseJSONPathFilter(strings.TrimSpace(sub[2 : len(sub)-1]))
+		if err != nil {
+			return jsonPathSegment{}, err
+		}
+		return jsonPathSegment{kind: jsonPathFilterKind, filter: f}, nil
+
+	case strings.HasPrefix(sub, "'") || strings.HasPrefix(sub, `"`):
+		name, err := unquoteJSONPathString(sub)
+		if err != nil {
+			return jsonPathSegment{}, err
+		}
+		return jsonPathSegment{kind: jsonPathField, name: name}, nil
+
+	case strings.Contains(sub, ":"):
+		parts := strings.Split(sub, ":")
+		if len(parts) > 3 {
+			return jsonPathSegment{}, fmt.Errorf("invalid slice %q", sub)
+		}
+		seg := jsonPathSegment{kind: jsonPathSlice}
+		bounds := []**int{&seg.start, &seg.end, &seg.step}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return jsonPathSegment{}, fmt.Errorf("invalid slice %q", sub)
+			}
+			*bounds[i] = &n
+		}
+		if seg.step != nil && *seg.step <= 0 {
+			return jsonPathSegment{}, fmt.Errorf("invalid slice step %q", sub)
+		}
+		return seg, nil
+
+	default:
+		n, err := strconv.Atoi(sub)
+		if err != nil {
+			return jsonPathSegment{}, fmt.Errorf("invalid subscript %q", sub)
+		}
+		return jsonPathSegment{kind: jsonPathIndex, start: &n}, nil
+	}
+}
+
+// unquoteJSONPathString removes the single or double quotes from a string.
+func unquoteJSONPathString(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = `"` + strings.ReplaceAll(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+// evalJSONPath returns the values selected by the path.
+func evalJSONPath(path []jsonPathSegment, root, current interface{}) []interface{} {
+	values := []interface{}{current}
+	for _, seg := range path {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, seg.apply(root, v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+// apply returns the values selected by the segment from a single value.
+func (seg *jsonPathSegment) apply(root, v interface{}) []interface{} {
+	switch seg.kind {
+	case jsonPathRoot:
+		return []interface{}{root}
+
+	case jsonPathField:
+		if m, ok := v.(map[string]interface{}); ok {
+			if fv, ok := m[seg.name]; ok {
+				return []interface{}{fv}
+			}
+		}
+		return nil
+
+	case jsonPathIndex:
+		if a, ok := v.([]interface{}); ok {
+			i := *seg.start
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []interface{}{a[i]}
+			}
+		}
+		return nil
+
+	case jsonPathSlice:
+		a, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		start, end, step := 0, len(a), 1
+		if seg.start != nil {
+			start = clampIndex(*seg.start, len(a))
+		}
+		if seg.end != nil {
+			end = clampIndex(*seg.end, len(a))
+		}
+		if seg.step != nil {
+			step = *seg.step
+		}
+		var result []interface{}
+		for i := start; i < end; i += step {
+			result = append(result, a[i])
+		}
+		return result
+
+	case jsonPathWildcard:
+		return children(v)
+
+	case jsonPathRecursive:
+		result := []interface{}{v}
+		for _, c := range children(v) {
+			result = append(result, seg.apply(root, c)...)
+		}
+		return result
+
+	case jsonPathFilterKind:
+		var result []interface{}
+		for _, c := range children(v) {
+			if seg.filter.match(root, c) {
+				result = append(result, c)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// clampIndex resolves a negative index and limits it to the supplied length.
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// children returns the elements of an array or the values of an object,
+// ordered by key.
+func children(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			result = append(result, v[k])
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// jsonPathFilter is the condition of a filter expression, such as
+// `?(@.name=="foo")`. Without an operator, the filter checks for existence.
+type jsonPathFilter struct {
+	left  []jsonPathSegment
+	op    string
+	right []jsonPathSegment
+	value interface{}
+}
+
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseJSONPathFilter parses the condition of a filter expression.
+func parseJSONPathFilter(cond string) (*jsonPathFilter, error) {
+	pos, op := -1, ""
+	var quote byte
+	for i := 0; i < len(cond) && pos < 0; i++ {
+		c := cond[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		default:
+			for _, o := range jsonPathOperators {
+				if strings.HasPrefix(cond[i:], o) {
+					pos, op = i, o
+					break
+				}
+			}
+		}
+	}
+
+	f := &jsonPathFilter{op: op}
+	left := cond
+	if pos >= 0 {
+		left = strings.TrimSpace(cond[:pos])
+		right := strings.TrimSpace(cond[pos+len(op):])
+		switch {
+		case strings.HasPrefix(right, "@") || strings.HasPrefix(right, "$"):
+			path, err := parseJSONPath(right)
+			if err != nil {
+				return nil, err
+			}
+			f.right = path
+		case strings.HasPrefix(right, "'") || strings.HasPrefix(right, `"`):
+			s, err := unquoteJSONPathString(right)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter value %s", right)
+			}
+			f.value = s
+		default:
+			dec := json.NewDecoder(strings.NewReader(right))
+			dec.UseNumber()
+			if err := dec.Decode(&f.value); err != nil {
+				return nil, fmt.Errorf("invalid filter value %s", right)
+			}
+		}
+	}
+
+	if !strings.HasPrefix(left, "@") {
+		return nil, fmt.Errorf("invalid filter %q: expected a path starting with @", cond)
+	}
+	path, err := parseJSONPath(left)
+	if err != nil {
+		return nil, err
+	}
+	f.left = path
+	return f, nil
+}
+
+// match evaluates the filter against a single value.
+func (f *jsonPathFilter) match(root, v interface{}) bool {
+	left := evalJSONPath(f.left, root, v)
+	if f.op == "" {
+		return len(left) > 0
+	}
+
+	right := []interface{}{f.value}
+	if f.right != nil {
+		right = evalJSONPath(f.right, root, v)
+	}
+
+	for _, l := range left {
+		for _, r := range right {
+			if compareJSONValues(l, f.op, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareJSONValues compares two values using the supplied operator, numbers
+// are compared numerically and strings lexically.
+func compareJSONValues(l interface{}, op string, r interface{}) bool {
+	var c int
+	switch lv := l.(type) {
+	case json.Number:
+		rv, ok := r.(json.Number)
+		if !ok {
+			return op == "!="
+		}
+		lf, lerr := lv.Float64()
+		rf, rerr := rv.Float64()
+		if lerr != nil || rerr != nil {
+			return op == "!="
+		}
+		switch {
+		case lf < rf:
+			c = -1
+		case lf > rf:
+			c = 1
+		}
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return op == "!="
+		}
+		c = strings.Compare(lv, rv)
+	default:
+		eq := fmt.Sprint(l) == fmt.Sprint(r)
+		switch op {
+		case "==":
+			return eq
+		case "!=":
+			return !eq
+		default:
+			return false
+		}
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPathPrinter(t *testing.T) {
+	doc := json.RawMessage(`{
+		"kind": "List",
+		"items": [
+			{"name": "a", "value": 1, "tags": ["x", "y"]},
+			{"name": "b", "value": 5, "meta": {"name": "inner"}},
+			{"name": "c", "value": 10, "enabled": true}
+		]
+	}`)
+
+	cases := []struct {
+		desc        string
+		template    string
+		expected    string
+		expectedErr string
+	}{
+		{desc: "literal", template: "kind", expected: "kind"},
+		{desc: "field", template: "{.kind}", expected: "List"},
+		{desc: "root", template: "{$.kind}", expected: "List"},
+		{desc: "missing", template: "{.missing}", expected: ""},
+		{desc: "mixed text", template: "kind={.kind}!", expected: "kind=List!"},
+		{desc: "index", template: "{.items[0].name}", expected: "a"},
+		{desc: "negative index", template: "{.items[-1].name}", expected: "c"},
+		{desc: "index out of range", template: "{.items[5].name}", expected: ""},
+		{desc: "quoted field", template: "{.items[0]['name']}", expected: "a"},
+		{desc: "dot subscript", template: "{.items.[1].name}", expected: "b"},
+		{desc: "double quoted field", template: `{.items[0]["name"]}`, expected: "a"},
+		{desc: "wildcard", template: "{.items[*].name}", expected: "a b c"},
+		{desc: "dot wildcard", template: "{.items.*.name}", expected: "a b c"},
+		{desc: "object wildcard", template: "{.items[1].meta.*}", expected: "inner"},
+		{desc: "slice", template: "{.items[0:2].name}", expected: "a b"},
+		{desc: "slice open end", template: "{.items[1:].name}", expected: "b c"},
+		{desc: "slice negative start", template: "{.items[-2:].name}", expected: "b c"},
+		{desc: "slice step", template: "{.items[::2].name}", expected: "a c"},
+		{desc: "slice clamped", template: "{.items[1:100].name}", expected: "b c"},
+		{desc: "recursive descent", template: "{..name}", expected: "a b inner c"},
+		{desc: "recursive descent wildcard", template: "{.items[0]..[*]}", expected: `a ["x","y"] 1 x y`},
+		{desc: "filter compare number", template: "{.items[?(@.value>1)].name}", expected: "b c"},
+		{desc: "filter compare decimal", template: "{.items[?(@.value<=5.0)].name}", expected: "a b"},
+		{desc: "filter equal string", template: `{.items[?(@.name=="b")].value}`, expected: "5"},
+		{desc: "filter not equal string", template: "{.items[?(@.name!='a')].name}", expected: "b c"},
+		{desc: "filter bool", template: "{.items[?(@.enabled==true)].name}", expected: "c"},
+		{desc: "filter exists", template: "{.items[?(@.tags)].name}", expected: "a"},
+		{desc: "filter root", template: "{.items[?(@.name==$.items[2].name)].value}", expected: "10"},
+		{desc: "filter mismatched types", template: `{.items[?(@.value=="1")].name}`, expected: ""},
+		{desc: "object value", template: "{.items[1].meta}", expected: `{"name":"inner"}`},
+		{desc: "array value", template: "{.items[0].tags}", expected: `["x","y"]`},
+		{desc: "quoted string", template: `{"{"}{.kind}{"}\n"}`, expected: "{List}\n"},
+		{desc: "range", template: `{range .items[*]}{.name}={.value}{"\n"}{end}`, expected: "a=1\nb=5\nc=10\n"},
+		{desc: "range current", template: `{range .items[0].tags[*]}[{@}]{end}`, expected: "[x][y]"},
+		{desc: "nested range", template: `{range .items[*]}{range .tags[*]}{.}{end}{end}`, expected: "xy"},
+		{desc: "range root", template: `{range .items[0:2]}{$.kind}{end}`, expected: "ListList"},
+		{desc: "range empty", template: `{range .missing[*]}x{end}done`, expected: "done"},
+
+		{desc: "unclosed expression", template: "{.items", expectedErr: `invalid JSONPath template: unclosed expression "{.items"`},
+		{desc: "unexpected end", template: "{.kind}{end}", expectedErr: "invalid JSONPath template: unexpected {end}"},
+		{desc: "missing end", template: "{range .items[*]}{.name}", expectedErr: "invalid JSONPath template: missing {end}"},
+		{desc: "unclosed subscript", template: "{.items[0}", expectedErr: `invalid JSONPath expression ".items[0": unclosed subscript`},
+		{desc: "invalid subscript", template: "{.items[a]}", expectedErr: `invalid JSONPath expression ".items[a]": invalid subscript "a"`},
+		{desc: "invalid slice", template: "{.items[1:2:3:4]}", expectedErr: `invalid JSONPath expression ".items[1:2:3:4]": invalid slice "1:2:3:4"`},
+		{desc: "invalid slice step", template: "{.items[::0]}", expectedErr: `invalid JSONPath expression ".items[::0]": invalid slice step "::0"`},
+		{desc: "invalid filter", template: "{.items[?(.name)]}", expectedErr: `invalid JSONPath expression ".items[?(.name)]": invalid filter ".name": expected a path starting with @`},
+		{desc: "invalid filter value", template: "{.items[?(@.name==b)]}", expectedErr: `invalid JSONPath expression ".items[?(@.name==b)]": invalid filter value b`},
+		{desc: "unexpected text", template: "{items}", expectedErr: `invalid JSONPath expression "items": unexpected "items"`},
+		{desc: "invalid string", template: `{"\x"}`, expectedErr: `invalid JSONPath string "\x": invalid syntax`},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			p, err := newJSONPathPrinter(c.template)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var out bytes.Buffer
+			if assert.NoError(t, p.Fprint(&out, doc)) {
+				assert.Equal(t, c.expected, out.String())
+			}
+		})
+	}
+}
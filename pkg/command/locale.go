@@ -0,0 +1,134 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// outputLocale is the locale used for sorting, numbers and relative times.
+var outputLocale = language.AmericanEnglish
+
+// SetLocale changes the locale used to format command output.
+func SetLocale(tag language.Tag) {
+	outputLocale = tag
+}
+
+// ParseLocale parses a BCP 47 language tag, POSIX style locale names (e.g.
+// "de_DE.UTF-8") are also accepted.
+func ParseLocale(s string) (language.Tag, error) {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	return language.Parse(strings.ReplaceAll(s, "_", "-"))
+}
+
+// relativeTime holds the localized strings used for relative times.
+type relativeTime struct {
+	ago, fromNow string
+	magnitudes   []humanize.RelTimeMagnitude
+}
+
+// relativeTimes are the supported translations of relative times, keyed by base language.
+var relativeTimes = map[string]relativeTime{
+	"de": {ago: "vor", fromNow: "in", magnitudes: []humanize.RelTimeMagnitude{
+		{D: time.Second, Format: "jetzt", DivBy: time.Second},
+		{D: 2 * time.Second, Format: "%s 1 Sekunde", DivBy: 1},
+		{D: time.Minute, Format: "%s %d Sekunden", DivBy: time.Second},
+		{D: 2 * time.Minute, Format: "%s 1 Minute", DivBy: 1},
+		{D: time.Hour, Format: "%s %d Minuten", DivBy: time.Minute},
+		{D: 2 * time.Hour, Format: "%s 1 Stunde", DivBy: 1},
+		{D: humanize.Day, Format: "%s %d Stunden", DivBy: time.Hour},
+		{D: 2 * humanize.Day, Format: "%s 1 Tag", DivBy: 1},
+		{D: humanize.Week, Format: "%s %d Tagen", DivBy: humanize.Day},
+		{D: 2 * humanize.Week, Format: "%s 1 Woche", DivBy: 1},
+		{D: humanize.Month, Format: "%s %d Wochen", DivBy: humanize.Week},
+		{D: 2 * humanize.Month, Format: "%s 1 Monat", DivBy: 1},
+		{D: humanize.Year, Format: "%s %d Monaten", DivBy: humanize.Month},
+		{D: 2 * humanize.Year, Format: "%s 1 Jahr", DivBy: 1},
+		{D: math.MaxInt64, Format: "%s %d Jahren", DivBy: humanize.Year},
+	}},
+	"es": {ago: "hace", fromNow: "dentro de", magnitudes: []humanize.RelTimeMagnitude{
+		{D: time.Second, Format: "ahora", DivBy: time.Second},
+		{D: 2 * time.Second, Format: "%s 1 segundo", DivBy: 1},
+		{D: time.Minute, Format: "%s %d segundos", DivBy: time.Second},
+		{D: 2 * time.Minute, Format: "%s 1 minuto", DivBy: 1},
+		{D: time.Hour, Format: "%s %d minutos", DivBy: time.Minute},
+		{D: 2 * time.Hour, Format: "%s 1 hora", DivBy: 1},
+		{D: humanize.Day, Format: "%s %d horas", DivBy: time.Hour},
+		{D: 2 * humanize.Day, Format: "%s 1 día", DivBy: 1},
+		{D: humanize.Week, Format: "%s %d días", DivBy: humanize.Day},
+		{D: 2 * humanize.Week, Format: "%s 1 semana", DivBy: 1},
+		{D: humanize.Month, Format: "%s %d semanas", DivBy: humanize.Week},
+		{D: 2 * humanize.Month, Format: "%s 1 mes", DivBy: 1},
+		{D: humanize.Year, Format: "%s %d meses", DivBy: humanize.Month},
+		{D: 2 * humanize.Year, Format: "%s 1 año", DivBy: 1},
+		{D: math.MaxInt64, Format: "%s %d años", DivBy: humanize.Year},
+	}},
+	"fr": {ago: "il y a", fromNow: "dans", magnitudes: []humanize.RelTimeMagnitude{
+		{D: time.Second, Format: "maintenant", DivBy: time.Second},
+		{D: 2 * time.Second, Format: "%s 1 seconde", DivBy: 1},
+		{D: time.Minute, Format: "%s %d secondes", DivBy: time.Second},
+		{D: 2 * time.Minute, Format: "%s 1 minute", DivBy: 1},
+		{D: time.Hour, Format: "%s %d minutes", DivBy: time.Minute},
+		{D: 2 * time.Hour, Format: "%s 1 heure", DivBy: 1},
+		{D: humanize.Day, Format: "%s %d heures", DivBy: time.Hour},
+		{D: 2 * humanize.Day, Format: "%s 1 jour", DivBy: 1},
+		{D: humanize.Week, Format: "%s %d jours", DivBy: humanize.Day},
+		{D: 2 * humanize.Week, Format: "%s 1 semaine", DivBy: 1},
+		{D: humanize.Month, Format: "%s %d semaines", DivBy: humanize.Week},
+		{D: 2 * humanize.Month, Format: "%s 1 mois", DivBy: 1},
+		{D: humanize.Year, Format: "%s %d mois", DivBy: humanize.Month},
+		{D: 2 * humanize.Year, Format: "%s 1 an", DivBy: 1},
+		{D: math.MaxInt64, Format: "%s %d ans", DivBy: humanize.Year},
+	}},
+}
+
+// localRelTime formats the time relative to now using the output locale. The
+// direction labels are omitted when the time is only used as an age.
+func localRelTime(t time.Time, labels bool) string {
+	base, _ := outputLocale.Base()
+	rt, ok := relativeTimes[base.String()]
+	if !ok {
+		if labels {
+			return humanize.Time(t)
+		}
+		return strings.TrimSpace(humanize.RelTime(t, time.Now(), "", ""))
+	}
+
+	if labels {
+		return humanize.CustomRelTime(t, time.Now(), rt.ago, rt.fromNow, rt.magnitudes)
+	}
+	return strings.TrimSpace(humanize.CustomRelTime(t, time.Now(), "", "", rt.magnitudes))
+}
+
+// localDecimal replaces the decimal separator of a formatted number with the
+// one used by the output locale. Digit grouping is not applied so values remain
+// exact and can be parsed by locale aware tools.
+func localDecimal(s string) string {
+	sep := message.NewPrinter(outputLocale).Sprint(number.Decimal(1.5))
+	if len(sep) < 3 || sep[1:len(sep)-1] == "." {
+		return s
+	}
+	return strings.Replace(s, ".", sep[1:len(sep)-1], 1)
+}
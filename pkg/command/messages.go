@@ -0,0 +1,130 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// MessageKey identifies a user-facing message template.
+type MessageKey string
+
+const (
+	MessageCreatedApplication           MessageKey = "created-application"
+	MessageCreatedScenario              MessageKey = "created-scenario"
+	MessageCreatedRecommendation        MessageKey = "created-recommendation"
+	MessageCreatedTrial                 MessageKey = "created-trial"
+	MessageUpdatedApplication           MessageKey = "updated-application"
+	MessageUpdatedScenario              MessageKey = "updated-scenario"
+	MessageUpdatedTemplate              MessageKey = "updated-template"
+	MessageUpdatedExperiment            MessageKey = "updated-experiment"
+	MessageUpdatedTrial                 MessageKey = "updated-trial"
+	MessageUpdatedCluster               MessageKey = "updated-cluster"
+	MessageDeletedApplication           MessageKey = "deleted-application"
+	MessageDeletedScenario              MessageKey = "deleted-scenario"
+	MessageDeletedExperiment            MessageKey = "deleted-experiment"
+	MessageDeletedTrial                 MessageKey = "deleted-trial"
+	MessageDeletedCluster               MessageKey = "deleted-cluster"
+	MessageEnabledRecommendations       MessageKey = "enabled-recommendations"
+	MessageDisabledRecommendations      MessageKey = "disabled-recommendations"
+	MessageBackfillComplete             MessageKey = "backfill-complete"
+	MessageRecommendationRequested      MessageKey = "recommendation-requested"
+	MessageTooManyApplicationsToInspect MessageKey = "too-many-applications"
+	MessageTrialPending                 MessageKey = "trial-pending"
+	MessagePushedMetrics                MessageKey = "pushed-metrics"
+	MessageConfirmDelete                MessageKey = "confirm-delete"
+	MessageDryRunDeletedExperiment      MessageKey = "dry-run-deleted-experiment"
+)
+
+// defaultMessages are the English message templates, the arguments of each
+// message are documented by the template itself.
+var defaultMessages = map[MessageKey]string{
+	MessageCreatedApplication:           `created application %q.`,
+	MessageCreatedScenario:              `created scenario %q.`,
+	MessageCreatedRecommendation:        `created recommendation %q.`,
+	MessageCreatedTrial:                 `created trial %q.`,
+	MessageUpdatedApplication:           `updated application %q.`,
+	MessageUpdatedScenario:              `updated scenario %q.`,
+	MessageUpdatedTemplate:              `updated template for scenario %q.`,
+	MessageUpdatedExperiment:            `updated experiment %q.`,
+	MessageUpdatedTrial:                 `updated trial %q.`,
+	MessageUpdatedCluster:               `updated cluster %q.`,
+	MessageDeletedApplication:           `deleted application %q.`,
+	MessageDeletedScenario:              `deleted scenario %q.`,
+	MessageDeletedExperiment:            `deleted experiment %q.`,
+	MessageDeletedTrial:                 `deleted trial %q.`,
+	MessageDeletedCluster:               `deleted cluster %q.`,
+	MessageEnabledRecommendations:       `enabled application recommendations.`,
+	MessageDisabledRecommendations:      `disabled application recommendations.`,
+	MessageBackfillComplete:             `backfill complete for application %q.`,
+	MessageRecommendationRequested:      `Requested a recommendation for application %q, use --wait to wait for the result`,
+	MessageTooManyApplicationsToInspect: `WARNING: Too many applications to fetch recommendations (%d, limit is %d), try fetching individual applications`,
+	MessageTrialPending:                 `Trial is pending as %q, use --wait to wait for its number`,
+	MessagePushedMetrics:                `pushed %d samples.`,
+	MessageConfirmDelete:                `delete %d %s? [y/N] `,
+	MessageDryRunDeletedExperiment:      `deleted experiment %q (dry run).`,
+}
+
+var (
+	messagesMu sync.RWMutex
+	messages   = make(map[string]map[MessageKey]string)
+)
+
+// messageLanguage returns the catalog key of a language, the empty string is
+// used for language independent overrides.
+func messageLanguage(tag language.Tag) string {
+	if tag == language.Und {
+		return ""
+	}
+	base, _ := tag.Base()
+	return base.String()
+}
+
+// RegisterMessages overrides message templates for a language, use
+// `language.Und` to override the templates of every language (e.g. to change
+// the product name). Templates must accept the same arguments as the default.
+func RegisterMessages(tag language.Tag, templates map[MessageKey]string) {
+	lang := messageLanguage(tag)
+
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	m := messages[lang]
+	if m == nil {
+		m = make(map[MessageKey]string, len(templates))
+		messages[lang] = m
+	}
+	for k, v := range templates {
+		m[k] = v
+	}
+}
+
+// MessageFormat returns the template of a message for the output locale,
+// falling back to the language independent overrides and then the default.
+func MessageFormat(key MessageKey) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	for _, lang := range []string{messageLanguage(outputLocale), ""} {
+		if format, ok := messages[lang][key]; ok {
+			return format
+		}
+	}
+	return defaultMessages[key]
+}
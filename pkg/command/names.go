@@ -0,0 +1,136 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// namePrinter renders `TYPE/NAME` lines suitable for use as arguments to other commands.
+type namePrinter struct{}
+
+// Fprint renders the names of the supplied output or row.
+func (p *namePrinter) Fprint(out io.Writer, obj interface{}) error {
+	if o, ok := obj.(Output); ok {
+		for i := 0; i < o.Len(); i++ {
+			if err := p.Fprint(out, o.Item(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r, err := rowResult(obj)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, r.Type+"/"+r.Name)
+	return err
+}
+
+// rowResult returns the type, name and server metadata of a row.
+func rowResult(obj interface{}) (*Result, error) {
+	var r Result
+	var md api.Metadata
+	switch obj := obj.(type) {
+	case *ApplicationRow:
+		r.Type, r.Name, r.Title, md = "application", obj.Name, obj.DisplayName, obj.Metadata
+	case *ScenarioRow:
+		r.Type, r.Name, r.Title, md = "scenario", joinApplicationName(obj.Metadata, obj.Name), obj.DisplayName, obj.Metadata
+	case *RecommendationRow:
+		r.Type, r.Name, md = "recommendation", joinApplicationName(obj.Metadata, obj.Name), obj.Metadata
+	case *ExperimentRow:
+		r.Type, r.Name, r.Title, md = "experiment", obj.Name, obj.DisplayName, obj.Metadata
+	case *TrialRow:
+		r.Type, md = "trial", obj.Metadata
+		if obj.TrialItem.Experiment != nil && obj.TrialItem.Experiment.Name != "" {
+			r.Name = fmt.Sprintf("%s/%03d", obj.TrialItem.Experiment.Name, obj.Number)
+		} else {
+			r.Name = fmt.Sprintf("%d", obj.Number)
+		}
+	case *ClusterRow:
+		r.Type, r.Name, r.Title, md = "cluster", obj.Name, obj.DisplayName, obj.Metadata
+	default:
+		return nil, fmt.Errorf("unable to render %T as a name", obj)
+	}
+
+	r.SelfURL = md.Link(api.RelationSelf)
+	r.Location = md.Location()
+	if t := md.Title(); t != "" {
+		r.Title = t
+	}
+	return &r, nil
+}
+
+// joinApplicationName prefixes the name of an application sub-resource with the
+// application name taken from its self link.
+func joinApplicationName(md api.Metadata, name string) string {
+	u, err := url.Parse(md.Link(api.RelationSelf))
+	if err != nil {
+		return name
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := len(segments) - 3; i >= 0; i-- {
+		if segments[i] == "applications" {
+			return segments[i+1] + "/" + name
+		}
+	}
+	return name
+}
+
+// RunNamedResources runs the sub-command matching the type of each `TYPE/NAME`
+// argument (as produced by the "name" output format) with the remaining names.
+// Arguments are grouped by type, preserving their order.
+func RunNamedResources(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+
+	var types []string
+	names := make(map[string][]string)
+	for _, arg := range args {
+		t, name, ok := strings.Cut(arg, "/")
+		if !ok || name == "" {
+			return fmt.Errorf("expected TYPE/NAME, got %q", arg)
+		}
+		if _, ok := names[t]; !ok {
+			types = append(types, t)
+		}
+		names[t] = append(names[t], name)
+	}
+
+	for _, t := range types {
+		sub, _, err := cmd.Find([]string{t})
+		if err != nil || sub == cmd || sub.RunE == nil {
+			return fmt.Errorf("unknown resource type %q for %q", t, cmd.CommandPath())
+		}
+
+		sub.SetContext(cmd.Context())
+		if err := sub.RunE(sub, names[t]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
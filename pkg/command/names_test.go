@@ -0,0 +1,198 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestNamePrinter(t *testing.T) {
+	selfLink := func(u string) api.Metadata {
+		return api.Metadata{"Link": []string{"<" + u + ">;rel=self"}}
+	}
+
+	exp := &experiments.Experiment{Name: "exp1"}
+	trial := &experiments.TrialItem{Number: 7, Experiment: exp}
+	exps := &ExperimentOutput{}
+	_ = exps.Add(&experiments.ExperimentItem{Experiment: experiments.Experiment{Name: "a"}})
+	_ = exps.Add(&experiments.ExperimentItem{Experiment: experiments.Experiment{Name: "b"}})
+
+	cases := []struct {
+		desc        string
+		obj         interface{}
+		expected    string
+		expectedErr string
+	}{
+		{
+			desc:     "application",
+			obj:      NewApplicationRow(&applications.ApplicationItem{Application: applications.Application{Name: "app1"}}),
+			expected: "application/app1\n",
+		},
+		{
+			desc: "scenario",
+			obj: NewScenarioRow(&applications.ScenarioItem{Scenario: applications.Scenario{
+				Metadata: selfLink("https://example.com/v2/applications/app1/scenarios/scn1"),
+				Name:     "scn1",
+			}}),
+			expected: "scenario/app1/scn1\n",
+		},
+		{
+			desc:     "experiment",
+			obj:      NewExperimentRow(&experiments.ExperimentItem{Experiment: *exp}),
+			expected: "experiment/exp1\n",
+		},
+		{
+			desc:     "trial",
+			obj:      NewTrialRow(trial),
+			expected: "trial/exp1/007\n",
+		},
+		{
+			desc:     "trial without experiment",
+			obj:      NewTrialRow(&experiments.TrialItem{Number: 7}),
+			expected: "trial/7\n",
+		},
+		{
+			desc:     "cluster",
+			obj:      NewClusterRow(&applications.ClusterItem{Cluster: applications.Cluster{Name: "c1"}}),
+			expected: "cluster/c1\n",
+		},
+		{
+			desc:     "output",
+			obj:      exps,
+			expected: "experiment/a\nexperiment/b\n",
+		},
+		{
+			desc:        "unsupported",
+			obj:         "test",
+			expectedErr: "unable to render string as a name",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			err := (&namePrinter{}).Fprint(&out, c.obj)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestJoinApplicationName(t *testing.T) {
+	cases := []struct {
+		desc     string
+		self     string
+		name     string
+		expected string
+	}{
+		{desc: "scenario", self: "https://example.com/v2/applications/app1/scenarios/scn1", name: "scn1", expected: "app1/scn1"},
+		{desc: "relative", self: "/v2/applications/app1/recommendations/rec1", name: "rec1", expected: "app1/rec1"},
+		{desc: "application", self: "https://example.com/v2/applications/app1", name: "scn1", expected: "scn1"},
+		{desc: "missing", name: "scn1", expected: "scn1"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			md := api.Metadata{}
+			if c.self != "" {
+				md["Link"] = []string{"<" + c.self + ">;rel=self"}
+			}
+			assert.Equal(t, c.expected, joinApplicationName(md, c.name))
+		})
+	}
+}
+
+func TestRunNamedResources(t *testing.T) {
+	var calls [][]string
+	record := func(cmd *cobra.Command, args []string) error {
+		calls = append(calls, append([]string{cmd.Name()}, args...))
+		return nil
+	}
+
+	cmd := &cobra.Command{Use: "delete", RunE: RunNamedResources}
+	cmd.AddCommand(
+		&cobra.Command{Use: "experiments", Aliases: []string{"experiment"}, RunE: record},
+		&cobra.Command{Use: "trials", Aliases: []string{"trial"}, RunE: record},
+		&cobra.Command{Use: "help-only"},
+	)
+	cmd.SetContext(context.Background())
+
+	cases := []struct {
+		desc          string
+		args          []string
+		expectedCalls [][]string
+		expectedErr   string
+	}{
+		{
+			desc: "grouped by type",
+			args: []string{"experiment/a", "trial/a/001", "experiments/b"},
+			expectedCalls: [][]string{
+				{"experiments", "a"},
+				{"trials", "a/001"},
+				{"experiments", "b"},
+			},
+		},
+		{
+			desc: "aliases",
+			args: []string{"experiment/a", "experiment/b", "trial/a/001"},
+			expectedCalls: [][]string{
+				{"experiments", "a", "b"},
+				{"trials", "a/001"},
+			},
+		},
+		{
+			desc:        "missing type",
+			args:        []string{"a"},
+			expectedErr: `expected TYPE/NAME, got "a"`,
+		},
+		{
+			desc:        "missing name",
+			args:        []string{"experiment/"},
+			expectedErr: `expected TYPE/NAME, got "experiment/"`,
+		},
+		{
+			desc:        "unknown type",
+			args:        []string{"widget/a"},
+			expectedErr: `unknown resource type "widget" for "delete"`,
+		},
+		{
+			desc:        "not runnable",
+			args:        []string{"help-only/a"},
+			expectedErr: `unknown resource type "help-only" for "delete"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			calls = nil
+			err := RunNamedResources(cmd, c.args)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -18,14 +18,17 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"golang.org/x/text/cases"
@@ -39,6 +42,125 @@ type Printer interface {
 	Fprint(out io.Writer, obj interface{}) error
 }
 
+// outputOptions holds the common output flags of the get, create and edit commands.
+type outputOptions struct {
+	// The name of the output format, empty to use the default printer.
+	Format string
+	// The field delimiter used for CSV output.
+	CSVDelimiter string
+	// Additional format names handled directly by the command.
+	formats []string
+	// The operation reported by the "json" format of mutating commands.
+	operation string
+}
+
+// AddFlags registers the output flags on the supplied command. Additional
+// format names must be handled by the command itself.
+func (o *outputOptions) AddFlags(cmd *cobra.Command, formats ...string) {
+	o.formats = append([]string{"table", "wide", "json", "yaml", "csv", "name", "jsonpath", "go-template"}, formats...)
+	if o.CSVDelimiter == "" {
+		o.CSVDelimiter = ","
+	}
+
+	cmd.Flags().StringVarP(&o.Format, "output", "o", o.Format, "output `format`; one of: "+strings.Join(o.formats, "|"))
+	cmd.Flags().StringVar(&o.CSVDelimiter, "csv-delimiter", o.CSVDelimiter, "field `delimiter` used for CSV output")
+
+	_ = cmd.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return o.formats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// AddNameFlags registers an output flag which only supports the "name" format,
+// for commands which otherwise only report what they changed.
+func (o *outputOptions) AddNameFlags(cmd *cobra.Command) {
+	o.formats = []string{"name"}
+
+	cmd.Flags().StringVarP(&o.Format, "output", "o", o.Format, "output `format`; one of: name")
+
+	_ = cmd.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return o.formats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// AddResultFlags registers an output flag supporting the "name", "json",
+// template and table formats, for commands which create or delete resources.
+// The "json" and template formats render a result object describing the
+// operation for each resource.
+func (o *outputOptions) AddResultFlags(cmd *cobra.Command, operation string) {
+	o.formats = []string{"name", "json", "jsonpath", "go-template", "table", "wide"}
+	o.operation = operation
+
+	cmd.Flags().StringVarP(&o.Format, "output", "o", o.Format, "output `format`; one of: "+strings.Join(o.formats, "|"))
+
+	_ = cmd.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return o.formats, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// Printer returns the printer for the selected output format, falling back to
+// the supplied default printer. The template formats take the template as an
+// argument, e.g. `jsonpath={.items[*].name}`.
+func (o *outputOptions) Printer(cfg Config, p Printer) (Printer, error) {
+	if o.Format == "" {
+		return p, nil
+	}
+
+	format, arg, hasArg := strings.Cut(o.Format, "=")
+	for _, f := range o.formats {
+		if f != format {
+			continue
+		}
+
+		switch f {
+		case "jsonpath", "go-template":
+			if arg == "" {
+				return nil, fmt.Errorf("missing template for output format: %s", f)
+			}
+			var tp Printer
+			var err error
+			if f == "jsonpath" {
+				tp, err = newJSONPathPrinter(arg)
+			} else {
+				tp, err = newGoTemplatePrinter(arg)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if o.operation != "" {
+				return &resultPrinter{Operation: o.operation, Printer: tp}, nil
+			}
+			return tp, nil
+		}
+
+		if hasArg {
+			return nil, fmt.Errorf("unexpected argument for output format: %s", f)
+		}
+
+		switch f {
+		case "table", "wide":
+			return &tablePrinter{Wide: f == "wide", NoHeader: isNoHeaders(cfg)}, nil
+		case "yaml":
+			return &yamlPrinter{}, nil
+		case "csv":
+			comma, err := csvDelimiter(o.CSVDelimiter)
+			if err != nil {
+				return nil, err
+			}
+			return &csvPrinter{Comma: comma, NoHeader: isNoHeaders(cfg)}, nil
+		case "name":
+			return &namePrinter{}, nil
+		case "json":
+			if o.operation != "" {
+				return &resultPrinter{Operation: o.operation}, nil
+			}
+			return &jsonPrinter{}, nil
+		default:
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format: %s", o.Format)
+}
+
 // formatTime is a helper that returns empty strings for zero times and adds
 // support for a humanized format (if the layout is empty).
 func formatTime(t *time.Time, layout string) string {
@@ -46,14 +168,67 @@ func formatTime(t *time.Time, layout string) string {
 	case t == nil || t.IsZero():
 		return ""
 	case layout == "ago":
-		return humanize.Time(*t)
+		return localRelTime(*t, true)
 	case layout == "":
-		return strings.TrimSpace(humanize.RelTime(*t, time.Now(), "", ""))
+		return localRelTime(*t, false)
+	case outputTimeZone != nil:
+		return t.In(outputTimeZone).Format(layout)
 	default:
 		return t.Format(layout)
 	}
 }
 
+// NumberFormat controls the string representation of numeric values.
+type NumberFormat struct {
+	// The number of significant digits to render, zero or less to render
+	// values using the minimum number of digits necessary to be exact.
+	Precision int
+	// Flag indicating that quantity strings (e.g. "500m" or "1Gi") should be
+	// rendered as plain numbers.
+	NormalizeQuantities bool
+}
+
+// FormatFloat returns the string representation of a floating point value.
+func (f NumberFormat) FormatFloat(v float64) string {
+	if f.Precision > 0 && !math.IsInf(v, 0) && !math.IsNaN(v) {
+		// Round to the significant digits without switching to exponent notation
+		v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', f.Precision, 64), 64)
+	}
+	return localDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// FormatMetric returns the string representation of a trial value, using the
+// unit and display format of the metric if the experiment is available.
+func (f NumberFormat) FormatMetric(exp *experiments.Experiment, v *experiments.Value) string {
+	if exp != nil {
+		if m := exp.Metric(v.MetricName); m != nil {
+			return m.FormatValue(v.Value, f.FormatFloat)
+		}
+	}
+	return f.FormatFloat(v.Value)
+}
+
+// FormatValue returns the string representation of a number or string value.
+func (f NumberFormat) FormatValue(v *api.NumberOrString) string {
+	switch {
+	case v == nil:
+		return ""
+	case v.IsString:
+		if f.NormalizeQuantities {
+			if q := v.Quantity(); q != nil {
+				qf, _ := q.Float64()
+				return f.FormatFloat(qf)
+			}
+		}
+		return v.StrVal
+	case f.Precision > 0:
+		return f.FormatFloat(v.Float64Value())
+	default:
+		// Preserve the original representation of the number
+		return v.String()
+	}
+}
+
 // NOTE: All the "*Row" structs have `json:"-"` for everything EXCEPT their
 // inline "*Item" field so when the row is marshalled as JSON it appears the
 // same as what the item would have been.
@@ -65,8 +240,10 @@ type ApplicationRow struct {
 	ScenarioCount       int    `table:"scenarios" csv:"scenario_count" json:"-"`
 	RecommendationMode  string `table:"recommendations" csv:"recommendations" json:"-"`
 	DeployInterval      string `table:"deploy_interval,wide" csv:"deploy_interval" json:"-"`
+	LimitRanges         string `table:"limit_ranges,wide" csv:"limit_ranges" json:"-"`
 	LastDeployedMachine string `table:"-" csv:"last_deployed" json:"-"`
 	LastDeployedHuman   string `table:"last_deployed,wide" csv:"-" json:"-"`
+	Backfill            string `table:"backfill,wide" csv:"backfill" json:"-"`
 	Age                 string `table:"age,wide" csv:"-" json:"-"`
 
 	applications.ApplicationItem `table:"-" csv:"-"`
@@ -76,6 +253,11 @@ type ApplicationRow struct {
 	RecommendationsDeployConfig     *applications.DeployConfiguration `table:"-" csv:"-" json:"recommendationsDeployConfig,omitempty"`
 	RecommendationsConfiguration    []applications.Configuration      `table:"-" csv:"-" json:"recommendationsConfiguration,omitempty"`
 	RecommendationsBackfillProgress *applications.BackfillProgress    `table:"-" csv:"-" json:"recommendationsBackfillProgress,omitempty"`
+
+	// Optional sub-resources inlined on request
+
+	Scenarios           []applications.ScenarioItem       `table:"-" csv:"-" json:"scenarios,omitempty"`
+	RecommendationItems []applications.RecommendationItem `table:"-" csv:"-" json:"recommendationItems,omitempty"`
 }
 
 func NewApplicationRow(item *applications.ApplicationItem) *ApplicationRow {
@@ -85,7 +267,7 @@ func NewApplicationRow(item *applications.ApplicationItem) *ApplicationRow {
 		ScenarioCount:       item.ScenarioCount,
 		RecommendationMode:  "Disabled",
 		LastDeployedMachine: formatTime(item.LastDeployedAt, time.RFC3339),
-		LastDeployedHuman:   formatTime(item.LastDeployedAt, "ago"),
+		LastDeployedHuman:   formatTimeColumn("last_deployed", item.LastDeployedAt),
 		Age:                 formatTime(item.CreatedAt, ""),
 
 		ApplicationItem: *item,
@@ -130,9 +312,26 @@ func (r *ApplicationRow) SetRecommendationsDeployConfig(deploy *applications.Dep
 		r.DeployInterval = deploy.Interval.String()
 	}
 
+	r.LimitRanges = formatLimitRanges(deploy.Limits)
+
 	r.RecommendationsDeployConfig = deploy
 }
 
+// formatLimitRanges returns the "Type resource=min-max" representation of the limit ranges.
+func formatLimitRanges(limits []applications.LimitRangeItem) string {
+	var ranges []string
+	for _, l := range limits {
+		for _, resourceName := range []string{"cpu", "memory"} {
+			min, max := l.Min.Get(resourceName), l.Max.Get(resourceName)
+			if min == nil && max == nil {
+				continue
+			}
+			ranges = append(ranges, fmt.Sprintf("%s %s=%s-%s", l.Type, resourceName, NumberFormat{}.FormatValue(min), NumberFormat{}.FormatValue(max)))
+		}
+	}
+	return strings.Join(ranges, ", ")
+}
+
 func (r *ApplicationRow) SetRecommendationsConfiguration(config []applications.Configuration) {
 	for i := range config {
 		r.RecommendationsConfiguration = append(r.RecommendationsConfiguration, config[i])
@@ -144,6 +343,7 @@ func (r *ApplicationRow) SetBackfillProgress(progress *applications.BackfillProg
 		return
 	}
 
+	r.Backfill = formatTime(&progress.Timestamp, "")
 	r.RecommendationsBackfillProgress = progress
 }
 
@@ -217,11 +417,65 @@ func (o *ScenarioOutput) Item(i int) Row { return &o.Items[i] }
 // SortBy sorts the output by the named value.
 func (o *ScenarioOutput) SortBy(key string) error { return SortBy(o, key) }
 
+// TemplateRevisionRow is a table row representation of a template revision.
+type TemplateRevisionRow struct {
+	Revision         int    `table:"revision" csv:"revision" json:"-"`
+	CreatedAtMachine string `table:"-" csv:"created" json:"-"`
+	CreatedAtHuman   string `table:"created" csv:"-" json:"-"`
+	Parameters       int    `table:"parameters" csv:"parameters" json:"-"`
+	Metrics          int    `table:"metrics" csv:"metrics" json:"-"`
+	Source           string `table:"source" csv:"source" json:"source"`
+
+	applications.TemplateRevision `table:"-" csv:"-"`
+}
+
+func NewTemplateRevisionRow(rev *applications.TemplateRevision, source string) *TemplateRevisionRow {
+	return &TemplateRevisionRow{
+		Revision:         rev.Revision,
+		CreatedAtMachine: formatTime(rev.CreatedAt, time.RFC3339),
+		CreatedAtHuman:   formatTimeColumn("created", rev.CreatedAt),
+		Parameters:       len(rev.Template.Parameters),
+		Metrics:          len(rev.Template.Metrics),
+		Source:           source,
+
+		TemplateRevision: *rev,
+	}
+}
+
+func (r *TemplateRevisionRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "revision":
+		return r.Revision, true
+	case "created":
+		return r.TemplateRevision.CreatedAt, true
+	default:
+		return nil, false
+	}
+}
+
+// TemplateRevisionOutput wraps a template revision list for output.
+type TemplateRevisionOutput struct {
+	Items []TemplateRevisionRow `json:"items"`
+}
+
+// Len returns the number of items being output.
+func (o *TemplateRevisionOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *TemplateRevisionOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *TemplateRevisionOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *TemplateRevisionOutput) SortBy(key string) error { return SortBy(o, key) }
+
 // RecommendationRow is a table row representation of a recommendation.
 type RecommendationRow struct {
 	Name              string `table:"name" csv:"name" json:"-"`
 	DeployedAtMachine string `table:"-" csv:"last_deployed" json:"-"`
 	DeployedAtHuman   string `table:"last_deployed" csv:"-" json:"-"`
+	ContainerSummary  string `table:"containers,wide" csv:"containers" json:"-"`
 
 	applications.RecommendationItem `table:"-" csv:"-"`
 }
@@ -236,12 +490,35 @@ func NewRecommendationRow(item *applications.RecommendationItem) *Recommendation
 	return &RecommendationRow{
 		Name:              item.Name,
 		DeployedAtMachine: formatTime(item.DeployedAt, time.RFC3339),
-		DeployedAtHuman:   formatTime(item.DeployedAt, "ago"),
+		DeployedAtHuman:   formatTimeColumn("last_deployed", item.DeployedAt),
+		ContainerSummary:  formatContainerRequests(item.Containers(), nil, NumberFormat{}),
 
 		RecommendationItem: *item,
 	}
 }
 
+// formatContainerRequests summarizes the recommended requests of each container,
+// current values are included when they are known and different.
+func formatContainerRequests(containers []applications.RecommendationContainer, current map[string]*applications.ResourceList, format NumberFormat) string {
+	summaries := make([]string, 0, len(containers))
+	for _, c := range containers {
+		name := c.Target.String() + "/" + c.Container
+		summary := name
+		for _, resource := range []string{"cpu", "memory"} {
+			value := format.FormatValue(c.Requests.Get(resource))
+			if value == "" {
+				continue
+			}
+			if cur := format.FormatValue(current[name].Get(resource)); cur != "" && cur != value {
+				value = cur + "→" + value
+			}
+			summary += " " + resource + "=" + value
+		}
+		summaries = append(summaries, summary)
+	}
+	return strings.Join(summaries, ", ")
+}
+
 func (r *RecommendationRow) Lookup(key string) (interface{}, bool) {
 	switch SortByKey(key) {
 	case "name":
@@ -276,14 +553,262 @@ func (o *RecommendationOutput) Item(i int) Row { return &o.Items[i] }
 // SortBy sorts the output by the named value.
 func (o *RecommendationOutput) SortBy(key string) error { return SortBy(o, key) }
 
+// SetCurrentRequests compares the container requests of each recommendation to
+// those of the most recently deployed recommendation in the output.
+func (o *RecommendationOutput) SetCurrentRequests(format NumberFormat) {
+	var deployed *applications.RecommendationItem
+	for i := range o.Items {
+		item := &o.Items[i].RecommendationItem
+		if item.DeployedAt != nil && (deployed == nil || item.DeployedAt.After(*deployed.DeployedAt)) {
+			deployed = item
+		}
+	}
+	if deployed == nil {
+		return
+	}
+
+	current := make(map[string]*applications.ResourceList)
+	for _, c := range deployed.Containers() {
+		current[c.Target.String()+"/"+c.Container] = c.Requests
+	}
+
+	for i := range o.Items {
+		o.Items[i].ContainerSummary = formatContainerRequests(o.Items[i].Containers(), current, format)
+	}
+}
+
+// RecommendationPointRow is a table row representation of the recommended
+// resources of a container at a point in time.
+type RecommendationPointRow struct {
+	Workload       string `table:"workload" csv:"workload" json:"workload"`
+	Container      string `table:"container" csv:"container" json:"container"`
+	TimeMachine    string `table:"-" csv:"time" json:"time,omitempty"`
+	TimeHuman      string `table:"time" csv:"-" json:"-"`
+	Recommendation string `table:"recommendation,wide" csv:"recommendation" json:"recommendation"`
+	CPURequest     string `table:"cpu_request" csv:"cpu_request" json:"cpuRequest,omitempty"`
+	MemoryRequest  string `table:"memory_request" csv:"memory_request" json:"memoryRequest,omitempty"`
+	CPULimit       string `table:"cpu_limit,wide" csv:"cpu_limit" json:"cpuLimit,omitempty"`
+	MemoryLimit    string `table:"memory_limit,wide" csv:"memory_limit" json:"memoryLimit,omitempty"`
+
+	time time.Time
+}
+
+func NewRecommendationPointRows(series *applications.ContainerTimeSeries, format NumberFormat) []RecommendationPointRow {
+	rows := make([]RecommendationPointRow, 0, len(series.Points))
+	for i := range series.Points {
+		pt := &series.Points[i]
+		rows = append(rows, RecommendationPointRow{
+			Workload:       series.Target.String(),
+			Container:      series.Container,
+			TimeMachine:    formatTime(&pt.Time, time.RFC3339),
+			TimeHuman:      formatTimeColumn("time", &pt.Time),
+			Recommendation: pt.Recommendation,
+			CPURequest:     format.FormatValue(pt.Requests.Get("cpu")),
+			MemoryRequest:  format.FormatValue(pt.Requests.Get("memory")),
+			CPULimit:       format.FormatValue(pt.Limits.Get("cpu")),
+			MemoryLimit:    format.FormatValue(pt.Limits.Get("memory")),
+
+			time: pt.Time,
+		})
+	}
+	return rows
+}
+
+func (r *RecommendationPointRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "workload":
+		return r.Workload, true
+	case "container":
+		return r.Container, true
+	case "time":
+		return r.time, true
+	case "recommendation":
+		return r.Recommendation, true
+	default:
+		return nil, false
+	}
+}
+
+// RecommendationTimeSeriesOutput wraps the recommendation time series for output.
+type RecommendationTimeSeriesOutput struct {
+	Items []RecommendationPointRow `json:"items"`
+}
+
+// Len returns the number of items being output.
+func (o *RecommendationTimeSeriesOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *RecommendationTimeSeriesOutput) Swap(i, j int) {
+	o.Items[i], o.Items[j] = o.Items[j], o.Items[i]
+}
+
+// Item returns the specified row value.
+func (o *RecommendationTimeSeriesOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *RecommendationTimeSeriesOutput) SortBy(key string) error { return SortBy(o, key) }
+
+// RecommendationStatusRow is a table row representation of the recommendation
+// status of an application.
+type RecommendationStatusRow struct {
+	Application               string     `table:"application" csv:"application" json:"application"`
+	Mode                      string     `table:"mode" csv:"mode" json:"mode,omitempty"`
+	LastRecommendationMachine string     `table:"-" csv:"last_recommendation" json:"-"`
+	LastRecommendationHuman   string     `table:"last_recommendation" csv:"-" json:"-"`
+	LastDeployedMachine       string     `table:"-" csv:"last_deployed" json:"-"`
+	LastDeployedHuman         string     `table:"last_deployed" csv:"-" json:"-"`
+	Stale                     string     `table:"stale" csv:"stale" json:"-"`
+	IsStale                   bool       `table:"-" csv:"-" json:"stale"`
+	LastRecommendationAt      *time.Time `table:"-" csv:"-" json:"lastRecommendationAt,omitempty"`
+	LastDeployedAt            *time.Time `table:"-" csv:"-" json:"lastDeployedAt,omitempty"`
+}
+
+// NewRecommendationStatusRow returns the recommendation status of an application,
+// recommendations older than the stale duration are flagged.
+func NewRecommendationStatusRow(item *applications.ApplicationItem, rl *applications.RecommendationList, staleAfter time.Duration) *RecommendationStatusRow {
+	r := &RecommendationStatusRow{
+		Application:    item.Name.String(),
+		Mode:           string(item.Recommendations),
+		LastDeployedAt: item.LastDeployedAt,
+	}
+
+	if rl != nil {
+		if rl.DeployConfiguration != nil && rl.DeployConfiguration.Mode != "" {
+			r.Mode = string(rl.DeployConfiguration.Mode)
+		}
+
+		for i := range rl.Recommendations {
+			rec := &rl.Recommendations[i]
+			if lm := rec.LastModified(); !lm.IsZero() && (r.LastRecommendationAt == nil || lm.After(*r.LastRecommendationAt)) {
+				r.LastRecommendationAt = &lm
+			}
+			if rec.DeployedAt != nil && (r.LastDeployedAt == nil || rec.DeployedAt.After(*r.LastDeployedAt)) {
+				r.LastDeployedAt = rec.DeployedAt
+			}
+		}
+	}
+
+	if r.Mode == "" {
+		r.Mode = string(applications.RecommendationsDisabled)
+	}
+	if r.Mode != string(applications.RecommendationsDisabled) && staleAfter > 0 {
+		r.IsStale = r.LastRecommendationAt == nil || time.Since(*r.LastRecommendationAt) > staleAfter
+	}
+	if r.IsStale {
+		r.Stale = "Yes"
+	}
+
+	r.Mode = cases.Title(language.English).String(r.Mode)
+	r.LastRecommendationMachine = formatTime(r.LastRecommendationAt, time.RFC3339)
+	r.LastRecommendationHuman = formatTimeColumn("last_recommendation", r.LastRecommendationAt)
+	r.LastDeployedMachine = formatTime(r.LastDeployedAt, time.RFC3339)
+	r.LastDeployedHuman = formatTimeColumn("last_deployed", r.LastDeployedAt)
+	return r
+}
+
+func (r *RecommendationStatusRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "application", "name":
+		return r.Application, true
+	case "mode":
+		return r.Mode, true
+	case "last_recommendation":
+		return r.LastRecommendationAt, true
+	case "last_deployed":
+		return r.LastDeployedAt, true
+	case "stale":
+		return r.Stale, true
+	default:
+		return nil, false
+	}
+}
+
+// RecommendationStatusOutput wraps the recommendation status of applications for output.
+type RecommendationStatusOutput struct {
+	Items []RecommendationStatusRow `json:"items"`
+}
+
+// Len returns the number of items being output.
+func (o *RecommendationStatusOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *RecommendationStatusOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *RecommendationStatusOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *RecommendationStatusOutput) SortBy(key string) error { return SortBy(o, key) }
+
+// MergedValueRow is a table row representation of an effective configuration value.
+type MergedValueRow struct {
+	Field  string `table:"field" csv:"field" json:"field"`
+	Value  string `table:"value" csv:"value" json:"value"`
+	Source string `table:"source" csv:"source" json:"source"`
+}
+
+func NewMergedValueRow(v *applications.MergedValue) *MergedValueRow {
+	value := fmt.Sprint(v.Value)
+	if _, ok := v.Value.([]interface{}); ok {
+		data, _ := json.Marshal(v.Value)
+		value = string(data)
+	}
+
+	return &MergedValueRow{
+		Field:  v.Path,
+		Value:  value,
+		Source: v.Source,
+	}
+}
+
+func (r *MergedValueRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "field":
+		return r.Field, true
+	case "source":
+		return r.Source, true
+	default:
+		return nil, false
+	}
+}
+
+// MergedValueOutput wraps the effective configuration values for output.
+type MergedValueOutput struct {
+	Items []MergedValueRow `json:"items"`
+}
+
+// Len returns the number of items being output.
+func (o *MergedValueOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *MergedValueOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *MergedValueOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *MergedValueOutput) SortBy(key string) error { return SortBy(o, key) }
+
 // ExperimentRow is a table row representation of an experiment.
 type ExperimentRow struct {
 	Name         string            `table:"name" csv:"name" json:"-"`
 	DisplayName  string            `table:"Name,custom" json:"-"`
 	Observations int64             `table:"observations,wide" csv:"observations" json:"-"`
+	TrialBudget  string            `table:"budget,wide" csv:"experiment_budget" json:"-"`
+	Concurrency  string            `table:"parallelism,wide" csv:"parallelism" json:"-"`
 	Labels       map[string]string `table:"labels,labels" csv:"label_,labels,flatten" json:"-"`
 
+	// Trial details are only populated on request since they require additional queries
+
+	ActiveTrials    int    `table:"active,wide" csv:"active_trials" json:"activeTrials,omitempty"`
+	CompletedTrials int    `table:"completed,wide" csv:"completed_trials" json:"completedTrials,omitempty"`
+	FailedTrials    int    `table:"failed,wide" csv:"failed_trials" json:"failedTrials,omitempty"`
+	BestMetric      string `table:"best_metric,wide" csv:"best_metric" json:"bestMetric,omitempty"`
+	BestValue       string `table:"best_value,wide" csv:"best_value" json:"bestValue,omitempty"`
+
 	experiments.ExperimentItem `table:"-" csv:"-"`
+
+	best *float64
 }
 
 func NewExperimentRow(item *experiments.ExperimentItem) *ExperimentRow {
@@ -291,23 +816,79 @@ func NewExperimentRow(item *experiments.ExperimentItem) *ExperimentRow {
 		Name:         item.Name.String(),
 		DisplayName:  item.DisplayName,
 		Observations: item.Observations,
+		TrialBudget:  optimizationColumn(item.ExperimentBudget()),
+		Concurrency:  optimizationColumn(item.Parallelism()),
 		Labels:       item.Labels,
 
 		ExperimentItem: *item,
 	}
 }
 
+// optimizationColumn formats an optimization setting, unset values are blank.
+func optimizationColumn(value int) string {
+	if value <= 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
 func (r *ExperimentRow) Lookup(key string) (interface{}, bool) {
 	switch SortByKey(key) {
 	case "name":
 		return r.Name, true
 	case "observations":
 		return r.Observations, true
+	case "budget":
+		return r.ExperimentBudget(), true
+	case "parallelism":
+		return r.Parallelism(), true
+	case "active":
+		return r.ActiveTrials, true
+	case "completed":
+		return r.CompletedTrials, true
+	case "failed":
+		return r.FailedTrials, true
 	default:
 		return nil, false
 	}
 }
 
+// AddTrial updates the trial details of the row using the supplied trial.
+func (r *ExperimentRow) AddTrial(item *experiments.TrialItem) error {
+	switch item.Status {
+	case experiments.TrialActive:
+		r.ActiveTrials++
+	case experiments.TrialFailed:
+		r.FailedTrials++
+	case experiments.TrialCompleted:
+		r.CompletedTrials++
+	default:
+		return nil
+	}
+
+	// The best value is reported for the first optimized metric
+	for _, m := range r.Metrics {
+		if m.Optimize != nil && !*m.Optimize {
+			continue
+		}
+
+		for _, v := range item.Values {
+			if v.MetricName != m.Name || item.Status != experiments.TrialCompleted {
+				continue
+			}
+
+			if r.best == nil || (m.Minimize && v.Value < *r.best) || (!m.Minimize && v.Value > *r.best) {
+				value := v.Value
+				r.best = &value
+				r.BestMetric = m.Name
+				r.BestValue = m.FormatValue(value, NumberFormat{}.FormatFloat)
+			}
+		}
+		break
+	}
+	return nil
+}
+
 // ExperimentOutput wraps an experiment list for output.
 type ExperimentOutput struct {
 	Items []ExperimentRow `json:"items"`
@@ -333,20 +914,25 @@ func (o *ExperimentOutput) SortBy(key string) error { return SortBy(o, key) }
 
 // TrialRow is a table row representation of a trial.
 type TrialRow struct {
-	Experiment     string            `table:"experiment,custom" csv:"experiment" json:"-"`
-	Name           string            `table:"name" json:"-"`
-	Number         int64             `table:"number,custom" csv:"number" json:"-"`
-	Status         string            `table:"status" csv:"status" json:"-"`
-	Assignments    map[string]string `csv:"parameter_,flatten" json:"-"`
-	Values         map[string]string `csv:"metric_,flatten" json:"-"`
-	FailureReason  string            `table:"failure_reason,wide" csv:"failure_reason" json:"-"`
-	FailureMessage string            `table:"failure_message,wide" csv:"failure_message" json:"-"`
-	Labels         map[string]string `table:"labels,labels" csv:"label_,labels,flatten" json:"-"`
+	Experiment      string            `table:"experiment,custom" csv:"experiment" json:"-"`
+	Name            string            `table:"name" json:"-"`
+	Number          int64             `table:"number,custom" csv:"number" json:"-"`
+	Status          string            `table:"status" csv:"status" json:"-"`
+	Assignments     map[string]string `csv:"parameter_,flatten" json:"-"`
+	Values          map[string]string `csv:"metric_,flatten" json:"-"`
+	FailureReason   string            `table:"failure_reason,wide" csv:"failure_reason" json:"-"`
+	FailureMessage  string            `table:"failure_message,wide" csv:"failure_message" json:"-"`
+	AbandonedReason string            `table:"abandoned_reason,wide" csv:"abandoned_reason" json:"-"`
+	Labels          map[string]string `table:"labels,labels" csv:"label_,labels,flatten" json:"-"`
 
 	experiments.TrialItem `table:"-" csv:"-"`
 }
 
 func NewTrialRow(item *experiments.TrialItem) *TrialRow {
+	return newTrialRow(item, NumberFormat{})
+}
+
+func newTrialRow(item *experiments.TrialItem, format NumberFormat) *TrialRow {
 	var experiment string
 	if item.Experiment != nil {
 		experiment = item.Experiment.DisplayName
@@ -361,24 +947,25 @@ func NewTrialRow(item *experiments.TrialItem) *TrialRow {
 
 	assignments := make(map[string]string, len(item.Assignments))
 	for i := range item.Assignments {
-		assignments[item.Assignments[i].ParameterName] = item.Assignments[i].Value.String()
+		assignments[item.Assignments[i].ParameterName] = format.FormatValue(&item.Assignments[i].Value)
 	}
 
 	values := make(map[string]string, len(item.Values))
 	for i := range item.Values {
-		values[item.Values[i].MetricName] = strconv.FormatFloat(item.Values[i].Value, 'f', -1, 64)
+		values[item.Values[i].MetricName] = format.FormatMetric(item.Experiment, &item.Values[i])
 	}
 
 	return &TrialRow{
-		Experiment:     experiment,
-		Name:           name,
-		Number:         item.Number,
-		Status:         cases.Title(language.English).String(string(item.Status)),
-		FailureReason:  item.FailureReason,
-		FailureMessage: item.FailureMessage,
-		Assignments:    assignments,
-		Values:         values,
-		Labels:         item.Labels,
+		Experiment:      experiment,
+		Name:            name,
+		Number:          item.Number,
+		Status:          cases.Title(language.English).String(string(item.Status)),
+		FailureReason:   item.FailureReason,
+		FailureMessage:  item.FailureMessage,
+		AbandonedReason: item.AbandonedReason,
+		Assignments:     assignments,
+		Values:          values,
+		Labels:          item.Labels,
 
 		TrialItem: *item,
 	}
@@ -400,11 +987,14 @@ func (r *TrialRow) Lookup(key string) (interface{}, bool) {
 // TrialOutput wraps a trial list for output.
 type TrialOutput struct {
 	Items []TrialRow `json:"items"`
+
+	// Format is used to render the assignment and metric values of added items.
+	Format NumberFormat `json:"-"`
 }
 
 // Add a trial item to the output.
 func (o *TrialOutput) Add(item *experiments.TrialItem) error {
-	o.Items = append(o.Items, *NewTrialRow(item))
+	o.Items = append(o.Items, *newTrialRow(item, o.Format))
 	return nil
 }
 
@@ -444,7 +1034,7 @@ func NewClusterRow(item *applications.ClusterItem) *ClusterRow {
 		PerformanceTestVersion: item.PerformanceTestVersion,
 		KubernetesVersion:      item.KubernetesVersion,
 		LastSeenMachine:        formatTime(item.LastSeen, time.RFC3339),
-		LastSeenHuman:          formatTime(item.LastSeen, "ago"),
+		LastSeenHuman:          formatTimeColumn("last_seen", item.LastSeen),
 		Age:                    formatTime(item.CreatedAt, ""),
 
 		ClusterItem: *item,
@@ -501,6 +1091,7 @@ type ActivityRow struct {
 	Tags             string `table:"tags" csv:"tags" json:"-"`
 	ExternalURL      string `table:"reference" csv:"external_url" json:"-"`
 	URL              string `table:"url,wide" csv:"url" json:"-"`
+	Cluster          string `table:"cluster,wide" csv:"cluster" json:"-"`
 	FailureReason    string `table:"reason,wide" csv:"failure_reason" json:"-"`
 	PublishedHuman   string `table:"published" csv:"-" json:"-"`
 	PublishedMachine string `table:"-" csv:"published" json:"-"`
@@ -520,14 +1111,32 @@ func NewActivityRow(item *applications.ActivityItem) *ActivityRow {
 		Tags:             strings.Join(item.Tags, ", "),
 		ExternalURL:      item.ExternalURL,
 		URL:              item.URL,
+		Cluster:          item.ClusterName().String(),
 		FailureReason:    fr,
 		PublishedMachine: formatTime(&item.DatePublished, time.RFC3339),
-		PublishedHuman:   formatTime(&item.DatePublished, "ago"),
+		PublishedHuman:   formatTimeColumn("published", &item.DatePublished),
 
 		ActivityItem: *item,
 	}
 }
 
+func (r *ActivityRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "id":
+		return r.ID, true
+	case "title":
+		return r.Title, true
+	case "tags":
+		return r.Tags, true
+	case "cluster":
+		return r.Cluster, true
+	case "published":
+		return r.ActivityItem.DatePublished, true
+	default:
+		return nil, false
+	}
+}
+
 type ActivityOutput struct {
 	Items []ActivityRow `json:"-"`
 
@@ -538,6 +1147,15 @@ func (o *ActivityOutput) Add(item *applications.ActivityItem) {
 	o.Items = append(o.Items, *NewActivityRow(item))
 }
 
+// Len returns the number of items being output.
+func (o *ActivityOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *ActivityOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *ActivityOutput) Item(i int) Row { return &o.Items[i] }
+
 // Row represents a single row in the output.
 type Row interface {
 	// Lookup returns a named value on the row.
@@ -556,6 +1174,25 @@ type Output interface {
 	// NOTE: there should also be an `Add(*item) error`-ish function
 }
 
+// rowOutput adapts a single row for printers which only render outputs.
+type rowOutput struct{ Row }
+
+func (o rowOutput) Len() int      { return 1 }
+func (o rowOutput) Swap(int, int) {}
+func (o rowOutput) Item(int) Row  { return o.Row }
+
+// asOutput returns the supplied object as an output, if possible.
+func asOutput(obj interface{}) (Output, bool) {
+	switch obj := obj.(type) {
+	case Output:
+		return obj, true
+	case Row:
+		return rowOutput{Row: obj}, true
+	default:
+		return nil, false
+	}
+}
+
 // SortBy sorts the supplied output using the named value on each row.
 func SortBy(o Output, name string) error {
 	if name == "" {
@@ -568,7 +1205,7 @@ func SortBy(o Output, name string) error {
 		keys:   make([][]byte, n),
 	}
 
-	c := collate.New(language.AmericanEnglish, collate.Loose, collate.Numeric)
+	c := collate.New(outputLocale, collate.Loose, collate.Numeric)
 	buf := &collate.Buffer{}
 	var reverse bool
 	for i := 0; i < n; i++ {
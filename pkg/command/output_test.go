@@ -0,0 +1,124 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestNumberFormat_FormatFloat(t *testing.T) {
+	tenth := 0.1
+	cases := []struct {
+		desc     string
+		format   NumberFormat
+		value    float64
+		expected string
+	}{
+		{desc: "exact", value: tenth + 0.2, expected: "0.30000000000000004"},
+		{desc: "no exponent", value: 1e21, expected: "1000000000000000000000"},
+		{desc: "precision", format: NumberFormat{Precision: 3}, value: 1234.5678, expected: "1230"},
+		{desc: "precision fraction", format: NumberFormat{Precision: 3}, value: 0.000123456, expected: "0.000123"},
+		{desc: "precision exact", format: NumberFormat{Precision: 3}, value: 0.5, expected: "0.5"},
+		{desc: "infinity", format: NumberFormat{Precision: 3}, value: math.Inf(1), expected: "+Inf"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.format.FormatFloat(c.value))
+		})
+	}
+}
+
+func TestNumberFormat_FormatValue(t *testing.T) {
+	value := func(v api.NumberOrString) *api.NumberOrString { return &v }
+	cases := []struct {
+		desc     string
+		format   NumberFormat
+		value    *api.NumberOrString
+		expected string
+	}{
+		{desc: "nil"},
+		{desc: "number", value: value(api.FromNumber("1.250")), expected: "1.250"},
+		{desc: "large integer", value: value(api.FromNumber("9007199254740993")), expected: "9007199254740993"},
+		{desc: "string", value: value(api.FromString("500m")), expected: "500m"},
+		{desc: "precision", format: NumberFormat{Precision: 2}, value: value(api.FromNumber("1.250")), expected: "1.2"},
+		{desc: "precision string", format: NumberFormat{Precision: 2}, value: value(api.FromString("1.250")), expected: "1.250"},
+		{desc: "normalize milli", format: NumberFormat{NormalizeQuantities: true}, value: value(api.FromString("500m")), expected: "0.5"},
+		{desc: "normalize binary", format: NumberFormat{NormalizeQuantities: true}, value: value(api.FromString("1Gi")), expected: "1073741824"},
+		{desc: "normalize invalid", format: NumberFormat{NormalizeQuantities: true}, value: value(api.FromString("fast")), expected: "fast"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.format.FormatValue(c.value))
+		})
+	}
+}
+
+func TestOutputOptions_Printer_csv(t *testing.T) {
+	o := outputOptions{Format: "csv", CSVDelimiter: `\t`, formats: []string{"csv"}}
+	p, err := o.Printer(nil, nil)
+	if assert.NoError(t, err) && assert.IsType(t, &csvPrinter{}, p) {
+		assert.Equal(t, '\t', p.(*csvPrinter).Comma)
+	}
+
+	o.CSVDelimiter = "::"
+	_, err = o.Printer(nil, nil)
+	assert.EqualError(t, err, `invalid CSV delimiter: "::"`)
+}
+
+// noHeadersConfig is a command configuration which omits table headers.
+type noHeadersConfig struct{ testConfig }
+
+func (noHeadersConfig) IsQuiet() bool     { return false }
+func (noHeadersConfig) IsNoHeaders() bool { return true }
+
+func TestOutputOptions_Printer(t *testing.T) {
+	defaultPrinter := &jsonPrinter{}
+	cases := []struct {
+		desc      string
+		cfg       Config
+		format    string
+		operation string
+		expected  Printer
+		err       string
+	}{
+		{desc: "default", expected: defaultPrinter},
+		{desc: "table", format: "table", expected: &tablePrinter{}},
+		{desc: "wide", format: "wide", expected: &tablePrinter{Wide: true}},
+		{desc: "no headers", cfg: noHeadersConfig{}, format: "table", expected: &tablePrinter{NoHeader: true}},
+		{desc: "json", format: "json", expected: &jsonPrinter{}},
+		{desc: "yaml", format: "yaml", expected: &yamlPrinter{}},
+		{desc: "name", format: "name", expected: &namePrinter{}},
+		{desc: "result json", format: "json", operation: "deleted", expected: &resultPrinter{Operation: "deleted"}},
+		{desc: "unknown", format: "xml", err: "unknown output format: xml"},
+		{desc: "unexpected argument", format: "yaml=x", err: "unexpected argument for output format: yaml"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := outputOptions{Format: c.format, formats: []string{"table", "wide", "json", "yaml", "name"}, operation: c.operation}
+			p, err := o.Printer(c.cfg, defaultPrinter)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// readPodTemplate reads and validates a pod template from the named input. The
+// template is strictly decoded as a Kubernetes `PodTemplateSpec`, unknown fields
+// and values of the wrong type are rejected, other values are left for the
+// cluster to validate. The template is returned as it was read so defaults are
+// not added to it.
+func readPodTemplate(cmd *cobra.Command, name string) (map[string]interface{}, error) {
+	data, err := readInputDocument(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+
+	name = inputName(name)
+	var podTemplate map[string]interface{}
+	if err := yaml.Unmarshal(data, &podTemplate); err != nil {
+		return nil, fmt.Errorf("invalid pod template %s: %w", name, err)
+	}
+
+	var spec corev1.PodTemplateSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid pod template %s: %s", name, strings.TrimPrefix(err.Error(), "error unmarshaling JSON: while decoding JSON: "))
+	}
+
+	var errs []string
+	if _, ok := podTemplate["spec"]; !ok {
+		errs = append(errs, "spec: required field is missing")
+	} else if len(spec.Spec.Containers) == 0 {
+		errs = append(errs, "spec.containers: at least one container is required")
+	}
+	for i, c := range spec.Spec.Containers {
+		if c.Name == "" {
+			errs = append(errs, fmt.Sprintf("spec.containers[%d].name: required field is missing", i))
+		}
+	}
+	switch spec.Spec.RestartPolicy {
+	case "", corev1.RestartPolicyAlways, corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+	default:
+		errs = append(errs, fmt.Sprintf(`spec.restartPolicy: expected one of "Always", "OnFailure" or "Never", got %s`, spec.Spec.RestartPolicy))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid pod template %s:\n  %s", name, strings.Join(errs, "\n  "))
+	}
+	return podTemplate, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPodTemplate(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		expectedErr string
+	}{
+		{
+			desc: "valid",
+			input: `
+metadata:
+  labels:
+    app: test
+spec:
+  restartPolicy: Never
+  containers:
+  - name: main
+    image: busybox
+    args: ["sleep", "1"]
+    env:
+    - name: FOO
+      value: bar
+    resources:
+      limits:
+        cpu: 500m
+`,
+		},
+		{
+			desc:        "spec not an object",
+			input:       `spec: [main]`,
+			expectedErr: "invalid pod template stdin: json: cannot unmarshal array into Go struct field .spec of type v1.PodSpec",
+		},
+		{
+			desc:        "missing spec",
+			input:       `metadata: {}`,
+			expectedErr: "invalid pod template stdin:\n  spec: required field is missing",
+		},
+		{
+			desc:        "unknown field",
+			input:       `{spec: {containers: [{name: main}]}, kind: Pod}`,
+			expectedErr: `invalid pod template stdin: json: unknown field "kind"`,
+		},
+		{
+			desc:        "misspelled field",
+			input:       `{spec: {containers: [{name: main, resources: {limit: {cpu: 1}}}]}}`,
+			expectedErr: `invalid pod template stdin: json: unknown field "limit"`,
+		},
+		{
+			desc:        "containers not a list",
+			input:       `spec: {containers: {}}`,
+			expectedErr: "invalid pod template stdin: json: cannot unmarshal object into Go struct field .spec.containers of type []v1.Container",
+		},
+		{
+			desc:        "wrong value type",
+			input:       `{spec: {containers: [{name: main, args: sleep}]}}`,
+			expectedErr: "invalid pod template stdin: json: cannot unmarshal string into Go struct field .spec.containers.0.args of type []string",
+		},
+		{
+			desc:        "invalid quantity",
+			input:       `{spec: {containers: [{name: main, resources: {limits: {cpu: lots}}}]}}`,
+			expectedErr: "invalid pod template stdin: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+		{
+			desc:        "no containers",
+			input:       `spec: {containers: []}`,
+			expectedErr: "invalid pod template stdin:\n  spec.containers: at least one container is required",
+		},
+		{
+			desc: "invalid container",
+			input: `
+spec:
+  restartPolicy: Sometimes
+  containers:
+  - image: busybox
+`,
+			expectedErr: "invalid pod template stdin:\n" +
+				"  spec.containers[0].name: required field is missing\n" +
+				`  spec.restartPolicy: expected one of "Always", "OnFailure" or "Never", got Sometimes`,
+		},
+		{
+			desc:        "invalid YAML",
+			input:       `spec: [`,
+			expectedErr: "invalid pod template stdin: error converting YAML to JSON: yaml: line 1: did not find expected node content",
+		},
+		{
+			desc:        "multiple documents",
+			input:       "spec: {}\n---\nspec: {}\n",
+			expectedErr: "expected a single document in stdin, found 2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetIn(strings.NewReader(c.input))
+			podTemplate, err := readPodTemplate(cmd, "-")
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else if assert.NoError(t, err) {
+				assert.Contains(t, podTemplate, "spec")
+			}
+		})
+	}
+}
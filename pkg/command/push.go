@@ -0,0 +1,72 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	metrics "github.com/thestormforge/optimize-go/pkg/api/metrics/v1"
+)
+
+// NewPushMetricsCommand returns a command for manually writing metric samples
+// to the remote write endpoint.
+func NewPushMetricsCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		filename string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Write metric samples",
+		Long:  "Write metric samples to the recommendations data pipeline, for example to backfill or test data collection without running the agent. Samples may be JSON or the Prometheus text exposition format.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.Flags().StringVarP(&filename, "file", "f", filename, "`file` containing the samples to write")
+	_ = cmd.MarkFlagRequired("file")
+	_ = cmd.MarkFlagFilename("file", "json", "txt", "prom")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		data, err := readInput(cmd, filename)
+		if err != nil {
+			return err
+		}
+
+		wr, err := metrics.ParseWriteRequest(data, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid samples in %s: %w", inputName(filename), err)
+		}
+		if len(wr.TimeSeries) == 0 {
+			return fmt.Errorf("no samples found in %s", inputName(filename))
+		}
+
+		if err := newMetricsAPI(cfg, client).Write(ctx, wr); err != nil {
+			return err
+		}
+
+		return p.Fprint(out, &wr)
+	}
+	return cmd
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+// ValueParser converts a flag argument into a number or string.
+type ValueParser func(string) (api.NumberOrString, error)
+
+// ParseQuantity parses a Kubernetes style quantity (e.g. "100m" or "1Gi").
+func ParseQuantity(s string) (api.NumberOrString, error) {
+	v := api.FromValue(s)
+	if v.Quantity() == nil {
+		return v, fmt.Errorf("invalid quantity %q", s)
+	}
+	return v, nil
+}
+
+// ParseRatio parses a ratio, the value is always kept as a string.
+func ParseRatio(s string) (api.NumberOrString, error) {
+	v := api.FromString(s)
+	if v.Quantity() == nil {
+		return v, fmt.Errorf("invalid ratio %q", s)
+	}
+	return v, nil
+}
+
+// ParseTolerance parses a tolerance level (e.g. "low", "medium" or "high").
+func ParseTolerance(s string) (api.NumberOrString, error) {
+	v := applications.ToleranceFrom(s)
+	switch v.StrVal {
+	case "low", "medium", "high":
+		return api.NumberOrString(v), nil
+	default:
+		return api.NumberOrString(v), fmt.Errorf("invalid tolerance %q, must be one of: low|medium|high", s)
+	}
+}
+
+// QuantityValue is a flag value which only accepts quantities.
+type QuantityValue api.NumberOrString
+
+var _ pflag.Value = &QuantityValue{}
+
+// String returns the quantity.
+func (v *QuantityValue) String() string {
+	if *v == (QuantityValue{}) {
+		return ""
+	}
+	return (*api.NumberOrString)(v).String()
+}
+
+// Set parses the quantity.
+func (v *QuantityValue) Set(s string) error {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*v = QuantityValue(q)
+	return nil
+}
+
+// Type returns the name of the flag type.
+func (v *QuantityValue) Type() string {
+	return "quantity"
+}
+
+// ResourceListValue is a flag value which accepts `resource=value` pairs.
+type ResourceListValue struct {
+	list  **applications.ResourceList
+	parse ValueParser
+}
+
+var _ pflag.Value = &ResourceListValue{}
+
+// NewResourceListValue returns a flag value which populates the supplied resource
+// list, the values are validated using the supplied parser.
+func NewResourceListValue(p **applications.ResourceList, parse ValueParser) *ResourceListValue {
+	return &ResourceListValue{list: p, parse: parse}
+}
+
+// String returns the resource list as comma separated `resource=value` pairs.
+func (v *ResourceListValue) String() string {
+	if v.list == nil || *v.list == nil {
+		return ""
+	}
+
+	var pairs []string
+	for _, name := range []string{"cpu", "memory"} {
+		if val := (*v.list).Get(name); val != nil {
+			pairs = append(pairs, name+"="+val.String())
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set parses comma separated `resource=value` pairs into the resource list,
+// a value of "-" clears the resource.
+func (v *ResourceListValue) Set(s string) error {
+	values := make(map[string]api.NumberOrString)
+	var cleared []string
+	for _, pair := range strings.Split(s, ",") {
+		k, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q must be formatted as resource=value", pair)
+		}
+
+		name, err := resourceName(k)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(val) == clearValue {
+			cleared = append(cleared, name)
+			continue
+		}
+
+		parse := v.parse
+		if parse == nil {
+			parse = ParseQuantity
+		}
+		values[name], err = parse(strings.TrimSpace(val))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if *v.list == nil {
+		*v.list = &applications.ResourceList{}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		(*v.list).Set(name, values[name])
+	}
+	for _, name := range cleared {
+		(*v.list).Clear(name)
+	}
+	return nil
+}
+
+// clearValue is the flag value used to remove a resource.
+const clearValue = "-"
+
+// Type returns the name of the flag type.
+func (v *ResourceListValue) Type() string {
+	return "resourceList"
+}
+
+// resourceName returns the canonical name of a resource.
+func resourceName(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "cpu", "c":
+		return "cpu", nil
+	case "memory", "mem", "m":
+		return "memory", nil
+	default:
+		return "", fmt.Errorf("invalid resource %q, must be one of: cpu|memory", s)
+	}
+}
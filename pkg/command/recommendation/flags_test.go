@@ -0,0 +1,175 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+func TestResourceListValue(t *testing.T) {
+	cases := []struct {
+		desc           string
+		parse          ValueParser
+		args           []string
+		expectedCPU    string
+		expectedMemory string
+		expectedString string
+		expectedErr    string
+	}{
+		{
+			desc:           "quantities",
+			args:           []string{"--values=cpu=500m,memory=1Gi"},
+			expectedCPU:    "500m",
+			expectedMemory: "1Gi",
+			expectedString: "cpu=500m,memory=1Gi",
+		},
+		{
+			desc:           "aliases",
+			args:           []string{"--values= C = 2 , mem=512Mi"},
+			expectedCPU:    "2",
+			expectedMemory: "512Mi",
+			expectedString: "cpu=2,memory=512Mi",
+		},
+		{
+			desc:           "repeated",
+			args:           []string{"--values=cpu=1", "--values=memory=1G", "--values=cpu=2"},
+			expectedCPU:    "2",
+			expectedMemory: "1G",
+			expectedString: "cpu=2,memory=1G",
+		},
+		{
+			desc:           "ratios",
+			parse:          ParseRatio,
+			args:           []string{"--values=cpu=1.5"},
+			expectedCPU:    "1.5",
+			expectedString: "cpu=1.5",
+		},
+		{
+			desc:           "tolerances",
+			parse:          ParseTolerance,
+			args:           []string{"--values=cpu=l,memory=HIGH"},
+			expectedCPU:    "low",
+			expectedMemory: "high",
+			expectedString: "cpu=low,memory=high",
+		},
+		{
+			desc:        "missing value",
+			args:        []string{"--values=cpu"},
+			expectedErr: `invalid argument "cpu" for "--values" flag: "cpu" must be formatted as resource=value`,
+		},
+		{
+			desc:        "invalid resource",
+			args:        []string{"--values=disk=1Gi"},
+			expectedErr: `invalid argument "disk=1Gi" for "--values" flag: invalid resource "disk", must be one of: cpu|memory`,
+		},
+		{
+			desc:        "invalid quantity",
+			args:        []string{"--values=cpu=fast"},
+			expectedErr: `invalid argument "cpu=fast" for "--values" flag: cpu: invalid quantity "fast"`,
+		},
+		{
+			desc:        "invalid tolerance",
+			parse:       ParseTolerance,
+			args:        []string{"--values=cpu=extreme"},
+			expectedErr: `invalid argument "cpu=extreme" for "--values" flag: cpu: invalid tolerance "extreme", must be one of: low|medium|high`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var rl *applications.ResourceList
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.Var(NewResourceListValue(&rl, c.parse), "values", "")
+
+			err := fs.Parse(c.args)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			if !assert.NoError(t, err) || !assert.NotNil(t, rl) {
+				return
+			}
+
+			str := func(v *api.NumberOrString) string {
+				if v == nil {
+					return ""
+				}
+				return v.String()
+			}
+			assert.Equal(t, c.expectedCPU, str(rl.CPU))
+			assert.Equal(t, c.expectedMemory, str(rl.Memory))
+			assert.Equal(t, c.expectedString, fs.Lookup("values").Value.String())
+		})
+	}
+}
+
+func TestQuantityValue(t *testing.T) {
+	var v QuantityValue
+	assert.Equal(t, "", v.String())
+	assert.NoError(t, v.Set("250m"))
+	assert.Equal(t, "250m", v.String())
+	assert.NoError(t, v.Set("2"))
+	assert.Equal(t, api.FromInt64(2), api.NumberOrString(v))
+	assert.EqualError(t, v.Set("1.2.3"), `invalid quantity "1.2.3"`)
+}
+
+func TestResourceListValue_clear(t *testing.T) {
+	cases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc:     "clear",
+			args:     []string{"--values=cpu=-"},
+			expected: `{"cpu":null}`,
+		},
+		{
+			desc:     "clear and set",
+			args:     []string{"--values=cpu=-,memory=1Gi"},
+			expected: `{"cpu":null,"memory":"1Gi"}`,
+		},
+		{
+			desc:     "set after clear",
+			args:     []string{"--values=cpu=-", "--values=cpu=500m"},
+			expected: `{"cpu":"500m"}`,
+		},
+		{
+			desc:     "clear after set",
+			args:     []string{"--values=cpu=500m,memory=1Gi", "--values=memory=-"},
+			expected: `{"cpu":"500m","memory":null}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var rl *applications.ResourceList
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.Var(NewResourceListValue(&rl, ParseQuantity), "values", "")
+			if assert.NoError(t, fs.Parse(c.args)) {
+				data, err := json.Marshal(rl)
+				if assert.NoError(t, err) {
+					assert.JSONEq(t, c.expected, string(data))
+				}
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package recommendation
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,8 +47,13 @@ const (
 	flagDeployInterval               = "interval"
 	flagDeployMaxRecommendationRatio = "deploy-max-ratio"
 	flagDeployCluster                = "cluster"
+	flagDeployLimitRangeMax          = "limit-range-max"
+	flagDeployLimitRangeMin          = "limit-range-min"
 )
 
+// limitRangeTypeContainer is the limit range type for per-container constraints.
+const limitRangeTypeContainer = "Container"
+
 var (
 	defaultDeployInterval = api.Duration(1 * time.Hour)
 	validDeployModes      = []string{
@@ -61,29 +68,29 @@ var (
 type ContainerResourcesOptions struct {
 	Selector                   string
 	Interval                   time.Duration
-	TargetUtilization          map[string]string
-	Tolerance                  map[string]string
-	BoundsLimitsMax            map[string]string
-	BoundsLimitsMin            map[string]string
-	BoundsRequestsMax          map[string]string
-	BoundsRequestsMin          map[string]string
+	TargetUtilization          *applications.ResourceList
+	Tolerance                  *applications.ResourceList
+	BoundsLimitsMax            *applications.ResourceList
+	BoundsLimitsMin            *applications.ResourceList
+	BoundsRequestsMax          *applications.ResourceList
+	BoundsRequestsMin          *applications.ResourceList
 	BoundsTargetUtilizationMax map[string]int64
 	BoundsTargetUtilizationMin map[string]int64
-	LimitRequestRatio          map[string]string
+	LimitRequestRatio          *applications.ResourceList
 }
 
 func (opts *ContainerResourcesOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opts.Selector, flagContainerResourcesSelector, opts.Selector, "`selector` for application resources which should have container resource optimization applied")
 	cmd.Flags().DurationVar(&opts.Interval, flagContainerResourcesInterval, opts.Interval, "amount of `time` between container resource recommendation computations")
-	cmd.Flags().StringToStringVar(&opts.TargetUtilization, flagContainerResourcesTargetUtilization, opts.TargetUtilization, "container resource target utilization as `resource=value`; resource is one of: cpu|memory")
-	cmd.Flags().StringToStringVar(&opts.Tolerance, flagContainerResourcesTolerance, opts.Tolerance, "container resource tolerance as `resource=tolerance`; resource is one of: cpu|memory; tolerance is one of: low|medium|high")
-	cmd.Flags().StringToStringVar(&opts.BoundsLimitsMax, flagContainerResourcesBoundsLimitsMax, opts.BoundsLimitsMax, "per-container resource max limits as `resource=quantity`; resource is one of: cpu|memory")
-	cmd.Flags().StringToStringVar(&opts.BoundsLimitsMin, flagContainerResourcesBoundsLimitsMin, opts.BoundsLimitsMin, "per-container resource min limits as `resource=quantity`; resource is one of: cpu|memory")
-	cmd.Flags().StringToStringVar(&opts.BoundsRequestsMax, flagContainerResourcesRequestsMax, opts.BoundsRequestsMax, "per-container resource max requests as `resource=quantity`; resource is one of: cpu|memory")
-	cmd.Flags().StringToStringVar(&opts.BoundsRequestsMin, flagContainerResourcesRequestsMin, opts.BoundsRequestsMin, "per-container resource min requests as `resource=quantity`; resource is one of: cpu|memory")
+	cmd.Flags().Var(NewResourceListValue(&opts.TargetUtilization, ParseQuantity), flagContainerResourcesTargetUtilization, "container resource target utilization as `resource=value`; resource is one of: cpu|memory")
+	cmd.Flags().Var(NewResourceListValue(&opts.Tolerance, ParseTolerance), flagContainerResourcesTolerance, "container resource tolerance as `resource=tolerance`; resource is one of: cpu|memory; tolerance is one of: low|medium|high")
+	cmd.Flags().Var(NewResourceListValue(&opts.BoundsLimitsMax, ParseQuantity), flagContainerResourcesBoundsLimitsMax, "per-container resource max limits as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
+	cmd.Flags().Var(NewResourceListValue(&opts.BoundsLimitsMin, ParseQuantity), flagContainerResourcesBoundsLimitsMin, "per-container resource min limits as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
+	cmd.Flags().Var(NewResourceListValue(&opts.BoundsRequestsMax, ParseQuantity), flagContainerResourcesRequestsMax, "per-container resource max requests as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
+	cmd.Flags().Var(NewResourceListValue(&opts.BoundsRequestsMin, ParseQuantity), flagContainerResourcesRequestsMin, "per-container resource min requests as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
 	cmd.Flags().StringToInt64Var(&opts.BoundsTargetUtilizationMax, flagContainerResourcesTargetUtilizationMax, opts.BoundsTargetUtilizationMax, "per-container resource max target utilization as `resource=quantity`; resource is one of: cpu")
 	cmd.Flags().StringToInt64Var(&opts.BoundsTargetUtilizationMin, flagContainerResourcesTargetUtilizationMin, opts.BoundsTargetUtilizationMin, "per-container resource min target utilization as `resource=quantity`; resource is one of: cpu")
-	cmd.Flags().StringToStringVar(&opts.LimitRequestRatio, flagContainerResourcesLimitRequestRatio, opts.LimitRequestRatio, "per-container limit:request ratio as `resource=quantity`; resource is one of: cpu|memory")
+	cmd.Flags().Var(NewResourceListValue(&opts.LimitRequestRatio, ParseQuantity), flagContainerResourcesLimitRequestRatio, "per-container limit:request ratio as `resource=quantity`; resource is one of: cpu|memory")
 
 	cmd.Flag(flagContainerResourcesInterval).Hidden = true
 	cmd.Flag(flagContainerResourcesTargetUtilization).Hidden = true
@@ -109,28 +116,16 @@ func (opts *ContainerResourcesOptions) Apply(configuration *[]applications.Confi
 		lazyContainerResources().Interval = api.Duration(opts.Interval)
 	}
 
-	if size := len(opts.TargetUtilization); size > 0 {
-		targetUtilization := &applications.ResourceList{}
-		for k, v := range opts.TargetUtilization {
-			targetUtilization.Set(strings.ToLower(k), api.FromValue(v))
-		}
-		lazyContainerResources().TargetUtilization = targetUtilization
+	if opts.TargetUtilization != nil {
+		lazyContainerResources().TargetUtilization = opts.TargetUtilization
 	}
 
-	if size := len(opts.Tolerance); size > 0 {
-		tolerance := &applications.ResourceList{}
-		for k, v := range opts.Tolerance {
-			tolerance.Set(strings.ToLower(k), api.NumberOrString(applications.ToleranceFrom(v)))
-		}
-		lazyContainerResources().Tolerance = tolerance
+	if opts.Tolerance != nil {
+		lazyContainerResources().Tolerance = opts.Tolerance
 	}
 
-	if size := len(opts.LimitRequestRatio); size > 0 {
-		limitRequestRatio := &applications.ResourceList{}
-		for k, v := range opts.LimitRequestRatio {
-			limitRequestRatio.Set(strings.ToLower(k), api.FromValue(v))
-		}
-		lazyContainerResources().LimitRequestRatio = limitRequestRatio
+	if opts.LimitRequestRatio != nil {
+		lazyContainerResources().LimitRequestRatio = opts.LimitRequestRatio
 	}
 
 	bounds := &applications.Bounds{}
@@ -140,23 +135,11 @@ func (opts *ContainerResourcesOptions) Apply(configuration *[]applications.Confi
 		}
 		return bounds.Limits
 	}
-	if len(opts.BoundsLimitsMax) > 0 {
-		limits := lazyLimits()
-		if limits.Max == nil {
-			limits.Max = &applications.ResourceList{}
-		}
-		for k, v := range opts.BoundsLimitsMax {
-			limits.Max.Set(strings.ToLower(k), api.FromValue(v))
-		}
+	if opts.BoundsLimitsMax != nil {
+		lazyLimits().Max = opts.BoundsLimitsMax
 	}
-	if len(opts.BoundsLimitsMin) > 0 {
-		limits := lazyLimits()
-		if limits.Min == nil {
-			limits.Min = &applications.ResourceList{}
-		}
-		for k, v := range opts.BoundsLimitsMin {
-			limits.Min.Set(strings.ToLower(k), api.FromValue(v))
-		}
+	if opts.BoundsLimitsMin != nil {
+		lazyLimits().Min = opts.BoundsLimitsMin
 	}
 
 	lazyRequests := func() *applications.BoundsRange {
@@ -165,23 +148,11 @@ func (opts *ContainerResourcesOptions) Apply(configuration *[]applications.Confi
 		}
 		return bounds.Requests
 	}
-	if len(opts.BoundsRequestsMax) > 0 {
-		requests := lazyRequests()
-		if requests.Max == nil {
-			requests.Max = &applications.ResourceList{}
-		}
-		for k, v := range opts.BoundsRequestsMax {
-			requests.Max.Set(strings.ToLower(k), api.FromValue(v))
-		}
+	if opts.BoundsRequestsMax != nil {
+		lazyRequests().Max = opts.BoundsRequestsMax
 	}
-	if len(opts.BoundsRequestsMin) > 0 {
-		requests := lazyRequests()
-		if requests.Min == nil {
-			requests.Min = &applications.ResourceList{}
-		}
-		for k, v := range opts.BoundsRequestsMin {
-			requests.Min.Set(strings.ToLower(k), api.FromValue(v))
-		}
+	if opts.BoundsRequestsMin != nil {
+		lazyRequests().Min = opts.BoundsRequestsMin
 	}
 
 	lazyTargetUtilization := func() *applications.BoundsRange {
@@ -217,15 +188,19 @@ func (opts *ContainerResourcesOptions) Apply(configuration *[]applications.Confi
 type DeployConfigurationOptions struct {
 	Mode                   string
 	Interval               time.Duration
-	MaxRecommendationRatio map[string]string
+	MaxRecommendationRatio *applications.ResourceList
 	Clusters               []string
+	LimitRangeMax          *applications.ResourceList
+	LimitRangeMin          *applications.ResourceList
 }
 
 func (opts *DeployConfigurationOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opts.Mode, flagDeployMode, opts.Mode, "deployment `mode`; one of: manual|auto|disabled")
 	cmd.Flags().DurationVar(&opts.Interval, flagDeployInterval, opts.Interval, "desired amount of `time` between deployments")
-	cmd.Flags().StringToStringVar(&opts.MaxRecommendationRatio, flagDeployMaxRecommendationRatio, opts.MaxRecommendationRatio, "limit the recommended/current value ratio as `resource=ratio`")
+	cmd.Flags().Var(NewResourceListValue(&opts.MaxRecommendationRatio, ParseRatio), flagDeployMaxRecommendationRatio, "limit the recommended/current value ratio as `resource=ratio`")
 	cmd.Flags().StringArrayVar(&opts.Clusters, flagDeployCluster, opts.Clusters, "cluster `name` used for recommendations")
+	cmd.Flags().Var(NewResourceListValue(&opts.LimitRangeMax, ParseQuantity), flagDeployLimitRangeMax, "namespace limit range per-container max as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
+	cmd.Flags().Var(NewResourceListValue(&opts.LimitRangeMin, ParseQuantity), flagDeployLimitRangeMin, "namespace limit range per-container min as `resource=quantity`; resource is one of: cpu|memory; use resource=- to clear")
 
 	cmd.Flag(flagDeployMaxRecommendationRatio).Hidden = true
 
@@ -250,17 +225,21 @@ func (opts *DeployConfigurationOptions) Apply(deployConfiguration **applications
 		lazyDeployConfig().Interval = api.Duration(opts.Interval)
 	}
 
-	if len(opts.MaxRecommendationRatio) > 0 {
-		ratios := &applications.ResourceList{}
-		for k, v := range opts.MaxRecommendationRatio {
-			ratios.Set(strings.ToLower(k), api.FromString(v))
-		}
-		lazyDeployConfig().MaxRecommendationRatio = ratios
+	if opts.MaxRecommendationRatio != nil {
+		lazyDeployConfig().MaxRecommendationRatio = opts.MaxRecommendationRatio
 	}
 
 	if len(opts.Clusters) > 0 {
 		lazyDeployConfig().Clusters = opts.Clusters
 	}
+
+	if opts.LimitRangeMax != nil || opts.LimitRangeMin != nil {
+		lazyDeployConfig().Limits = []applications.LimitRangeItem{{
+			Type: limitRangeTypeContainer,
+			Max:  opts.LimitRangeMax,
+			Min:  opts.LimitRangeMin,
+		}}
+	}
 }
 
 // Finish attempts to validate the requested changes.
@@ -413,6 +392,23 @@ func Finish(cmd *cobra.Command, appAPI applications.API, app applications.Applic
 		)...)
 	}
 
+	// NOTE: The limit ranges are replaced as a whole; keep the existing types which are not being changed
+	limitRanges := recs.DeployConfiguration.Limits
+	if patch.DeployConfiguration != nil && len(patch.DeployConfiguration.Limits) > 0 {
+		patch.DeployConfiguration.Limits = mergeLimitRanges(recs.DeployConfiguration.Limits, patch.DeployConfiguration.Limits)
+		limitRanges = patch.DeployConfiguration.Limits
+	}
+
+	// Validate the limit ranges against the effective bounds
+	var bounds *applications.Bounds
+	switch {
+	case len(patch.Configuration) > 0 && patch.Configuration[0].ContainerResources != nil:
+		bounds = patch.Configuration[0].ContainerResources.Bounds
+	case len(recs.Configuration) > 0 && recs.Configuration[0].ContainerResources != nil:
+		bounds = recs.Configuration[0].ContainerResources.Bounds
+	}
+	errs = append(errs, checkLimitRanges(mode, limitRanges, bounds, cmd.CommandPath())...)
+
 	// Application resources are required to enable recommendations
 	if mode.Enabled() && len(app.Resources) == 0 {
 		errs = append(errs, &Error{
@@ -505,6 +501,76 @@ func checkResourceList(mode applications.RecommendationsMode, name string, minLi
 	return errs
 }
 
+// mergeLimitRanges replaces the current limit ranges with the desired limit
+// ranges of the same type.
+func mergeLimitRanges(current, desired []applications.LimitRangeItem) []applications.LimitRangeItem {
+	result := make([]applications.LimitRangeItem, 0, len(current)+len(desired))
+	replaced := make(map[string]bool, len(desired))
+	for i := range desired {
+		replaced[desired[i].Type] = true
+	}
+	for i := range current {
+		if !replaced[current[i].Type] {
+			result = append(result, current[i])
+		}
+	}
+	return append(result, desired...)
+}
+
+// checkLimitRanges validates the container limit ranges and ensures the bounds
+// do not fall outside them.
+func checkLimitRanges(mode applications.RecommendationsMode, limitRanges []applications.LimitRangeItem, bounds *applications.Bounds, fixCommand string) ErrorList {
+	var errs ErrorList
+
+	for _, lr := range limitRanges {
+		if lr.Type != limitRangeTypeContainer {
+			continue
+		}
+
+		rangeErrs := checkResourceList(
+			mode, "limit range",
+			lr.Min, lr.Max,
+			fixCommand, flagDeployLimitRangeMin, flagDeployLimitRangeMax,
+		)
+		errs = append(errs, rangeErrs...)
+		if len(rangeErrs) > 0 || bounds == nil {
+			continue
+		}
+
+		for _, b := range []struct {
+			name           string
+			bounds         *applications.BoundsRange
+			fixMin, fixMax string
+		}{
+			{"limit", bounds.Limits, flagContainerResourcesBoundsLimitsMin, flagContainerResourcesBoundsLimitsMax},
+			{"request", bounds.Requests, flagContainerResourcesRequestsMin, flagContainerResourcesRequestsMax},
+		} {
+			if b.bounds == nil {
+				continue
+			}
+
+			for _, resourceName := range []string{"cpu", "memory"} {
+				if min, v := lr.Min.Get(resourceName), b.bounds.Min.Get(resourceName); min != nil && v != nil && QuantityLess(v, min) {
+					errs = append(errs, &Error{
+						Message:    fmt.Sprintf("invalid minimum container %s for %s: %s is less than the limit range minimum %s", b.name, resourceName, v, min),
+						FixCommand: fixCommand,
+						FixFlag:    b.fixMin,
+					})
+				}
+				if max, v := lr.Max.Get(resourceName), b.bounds.Max.Get(resourceName); max != nil && v != nil && QuantityLess(max, v) {
+					errs = append(errs, &Error{
+						Message:    fmt.Sprintf("invalid maximum container %s for %s: %s is greater than the limit range maximum %s", b.name, resourceName, v, max),
+						FixCommand: fixCommand,
+						FixFlag:    b.fixMax,
+					})
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
 // checkLimitRequestRatio ensures the ratio does not produce invalid results.
 func checkLimitRequestRatio(list *applications.ResourceList, fixCommand, fixFlag string) ErrorList {
 	var errs ErrorList
@@ -527,17 +593,29 @@ func checkLimitRequestRatio(list *applications.ResourceList, fixCommand, fixFlag
 	return errs
 }
 
+// Error is a validation error with an optional suggested fix.
 type Error struct {
-	Message        string
-	FixCommand     string
-	FixFlag        string
-	FixValidValues []string
+	Message        string   `json:"message"`
+	FixCommand     string   `json:"fixCommand,omitempty"`
+	FixFlag        string   `json:"fixFlag,omitempty"`
+	FixValidValues []string `json:"fixValidValues,omitempty"`
 }
 
 func (e *Error) Error() string {
 	return e.Message
 }
 
+// Suggestion is a command invocation which may fix one or more errors.
+type Suggestion struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// String returns the suggested command line.
+func (s Suggestion) String() string {
+	return strings.Join(append([]string{s.Command}, s.Args...), " ")
+}
+
 type ErrorList []*Error
 
 func (el ErrorList) Err() error {
@@ -552,32 +630,120 @@ func (el ErrorList) Error() string {
 		panic("use ErrorList.Err() to ignore an empty error list")
 	}
 
-	var msgs []string
-	suggestions := make(map[string][]string)
+	msgs := make([]string, 0, len(el))
 	for _, err := range el {
 		msgs = append(msgs, err.Error())
+	}
+
+	msg := strings.Join(msgs, "\n")
+
+	if suggestions := el.Suggestions(); len(suggestions) > 0 {
+		msg += "\n\nTry running:"
+		for _, s := range suggestions {
+			msg += "\n  " + s.String()
+		}
+	}
+
+	return msg
+}
+
+// MarshalJSON returns the machine-readable form of the errors and their suggested fixes.
+func (el ErrorList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Errors      []*Error     `json:"errors"`
+		Suggestions []Suggestion `json:"suggestions,omitempty"`
+	}{
+		Errors:      el,
+		Suggestions: el.Suggestions(),
+	})
+}
+
+// Suggestions returns the suggested fixes sorted by command. Repeated flags are
+// de-duplicated and `resource=value` style values for the same flag are
+// combined into a single comma separated argument.
+func (el ErrorList) Suggestions() []Suggestion {
+	type flagValues struct {
+		name   string
+		groups [][]string
+	}
+
+	flags := make(map[string][]*flagValues)
+	for _, err := range el {
+		if err.FixCommand == "" || err.FixFlag == "" {
+			continue
+		}
 
-		if err.FixCommand != "" && err.FixFlag != "" {
-			// TODO Can we batch up flags that can be combined, e.g. "--max-request x=y --max-request a=b" => "--max-request x=y,a=b"?
-			suggestions[err.FixCommand] = append(suggestions[err.FixCommand], "--"+err.FixFlag)
-			if len(err.FixValidValues) > 0 {
-				suggestions[err.FixCommand] = append(suggestions[err.FixCommand], strings.Join(err.FixValidValues, "|"))
+		var fv *flagValues
+		for _, f := range flags[err.FixCommand] {
+			if f.name == err.FixFlag {
+				fv = f
+				break
 			}
 		}
+		if fv == nil {
+			fv = &flagValues{name: err.FixFlag}
+			flags[err.FixCommand] = append(flags[err.FixCommand], fv)
+		}
+
+		if len(err.FixValidValues) == 0 {
+			continue
+		}
+		group := strings.Join(err.FixValidValues, "|")
+		duplicate := false
+		for _, g := range fv.groups {
+			duplicate = duplicate || strings.Join(g, "|") == group
+		}
+		if !duplicate {
+			fv.groups = append(fv.groups, err.FixValidValues)
+		}
 	}
 
-	msg := strings.Join(msgs, "\n")
+	commands := make([]string, 0, len(flags))
+	for cmd := range flags {
+		commands = append(commands, cmd)
+	}
+	sort.Strings(commands)
 
-	if len(suggestions) > 0 {
-		msg += "\n\nTry running:"
-		// TODO Do a sorted iteration instead?
-		for cmd, args := range suggestions {
-			msg += "\n  " + cmd
-			if len(args) > 0 {
-				msg += " " + strings.Join(args, " ")
+	suggestions := make([]Suggestion, 0, len(commands))
+	for _, cmd := range commands {
+		s := Suggestion{Command: cmd}
+		for _, fv := range flags[cmd] {
+			if len(fv.groups) == 0 {
+				s.Args = append(s.Args, "--"+fv.name)
+				continue
+			}
+
+			if combinable(fv.groups) {
+				values := make([]string, 0, len(fv.groups))
+				for _, g := range fv.groups {
+					values = append(values, g[0])
+				}
+				s.Args = append(s.Args, "--"+fv.name, strings.Join(values, ","))
+				continue
+			}
+
+			for _, g := range fv.groups {
+				s.Args = append(s.Args, "--"+fv.name, strings.Join(g, "|"))
 			}
 		}
+		suggestions = append(suggestions, s)
 	}
+	return suggestions
+}
 
-	return msg
+// combinable checks if the value groups of a flag can be joined into a single
+// comma separated `key=value` list.
+func combinable(groups [][]string) bool {
+	keys := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		if len(g) != 1 {
+			return false
+		}
+		k, _, ok := strings.Cut(g[0], "=")
+		if !ok || keys[k] {
+			return false
+		}
+		keys[k] = true
+	}
+	return true
 }
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorList_Suggestions(t *testing.T) {
+	cases := []struct {
+		desc     string
+		errs     ErrorList
+		expected []Suggestion
+	}{
+		{
+			desc:     "no fixes",
+			errs:     ErrorList{{Message: "bad"}},
+			expected: []Suggestion{},
+		},
+		{
+			desc: "sorted by command",
+			errs: ErrorList{
+				{Message: "a", FixCommand: "optimize edit recommendations", FixFlag: "interval", FixValidValues: []string{"1h"}},
+				{Message: "b", FixCommand: "optimize edit application", FixFlag: "namespace", FixValidValues: []string{"default"}},
+			},
+			expected: []Suggestion{
+				{Command: "optimize edit application", Args: []string{"--namespace", "default"}},
+				{Command: "optimize edit recommendations", Args: []string{"--interval", "1h"}},
+			},
+		},
+		{
+			desc: "combine resource values",
+			errs: ErrorList{
+				{Message: "a", FixCommand: "optimize edit recommendations", FixFlag: "max-request", FixValidValues: []string{"cpu=1"}},
+				{Message: "b", FixCommand: "optimize edit recommendations", FixFlag: "max-request", FixValidValues: []string{"memory=1Gi"}},
+			},
+			expected: []Suggestion{
+				{Command: "optimize edit recommendations", Args: []string{"--max-request", "cpu=1,memory=1Gi"}},
+			},
+		},
+		{
+			desc: "duplicate flags",
+			errs: ErrorList{
+				{Message: "a", FixCommand: "optimize edit recommendations", FixFlag: "reset"},
+				{Message: "b", FixCommand: "optimize edit recommendations", FixFlag: "reset"},
+				{Message: "c", FixCommand: "optimize edit recommendations", FixFlag: "mode", FixValidValues: []string{"auto", "manual"}},
+				{Message: "d", FixCommand: "optimize edit recommendations", FixFlag: "mode", FixValidValues: []string{"auto", "manual"}},
+			},
+			expected: []Suggestion{
+				{Command: "optimize edit recommendations", Args: []string{"--reset", "--mode", "auto|manual"}},
+			},
+		},
+		{
+			desc: "conflicting resource values",
+			errs: ErrorList{
+				{Message: "a", FixCommand: "optimize edit recommendations", FixFlag: "max-request", FixValidValues: []string{"cpu=1"}},
+				{Message: "b", FixCommand: "optimize edit recommendations", FixFlag: "max-request", FixValidValues: []string{"cpu=2"}},
+			},
+			expected: []Suggestion{
+				{Command: "optimize edit recommendations", Args: []string{"--max-request", "cpu=1", "--max-request", "cpu=2"}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.errs.Suggestions())
+		})
+	}
+}
+
+func TestErrorList_Error(t *testing.T) {
+	errs := ErrorList{
+		{Message: "cpu is too low", FixCommand: "optimize edit recommendations", FixFlag: "min-request", FixValidValues: []string{"cpu=100m"}},
+		{Message: "memory is too low", FixCommand: "optimize edit recommendations", FixFlag: "min-request", FixValidValues: []string{"memory=1Gi"}},
+	}
+	assert.Equal(t, "cpu is too low\nmemory is too low\n\nTry running:\n  optimize edit recommendations --min-request cpu=100m,memory=1Gi", errs.Error())
+	assert.EqualError(t, errs.Err(), errs.Error())
+	assert.NoError(t, ErrorList{}.Err())
+}
+
+func TestErrorList_MarshalJSON(t *testing.T) {
+	errs := ErrorList{
+		{Message: "bad interval", FixCommand: "optimize edit recommendations", FixFlag: "interval", FixValidValues: []string{"1h"}},
+		{Message: "unknown"},
+	}
+	data, err := json.Marshal(errs)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{
+			"errors": [
+				{"message": "bad interval", "fixCommand": "optimize edit recommendations", "fixFlag": "interval", "fixValidValues": ["1h"]},
+				{"message": "unknown"}
+			],
+			"suggestions": [
+				{"command": "optimize edit recommendations", "args": ["--interval", "1h"]}
+			]
+		}`, string(data))
+	}
+}
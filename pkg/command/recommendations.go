@@ -17,17 +17,140 @@ limitations under the License.
 package command
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 )
 
+// NewCreateRecommendationCommand returns a command for requesting an on-demand
+// recommendation for an application.
+func NewCreateRecommendationCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		wait    bool
+		timeout = 5 * time.Minute
+		output  outputOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:               "recommendation APP_NAME",
+		Aliases:           []string{"rec"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validApplicationArgs(cfg),
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", wait, "wait for the new recommendation to appear")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "maximum `duration` to wait for the recommendation")
+	output.AddResultFlags(cmd, "create")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		l := applications.Lister{
+			API: newApplicationsAPI(cfg, client),
+		}
+
+		return l.ForEachNamedApplication(ctx, args, false, func(item *applications.ApplicationItem) error {
+			u := item.Link(api.RelationRecommendations)
+			if u == "" {
+				return fmt.Errorf("malformed response, missing recommendations link")
+			}
+
+			// Record the existing recommendations so a new one can be identified
+			existing := make(map[string]bool)
+			if wait {
+				rl, err := l.API.ListRecommendations(ctx, u)
+				if err != nil {
+					return err
+				}
+				for i := range rl.Recommendations {
+					existing[rl.Recommendations[i].Name] = true
+				}
+			}
+
+			md, err := l.API.CreateRecommendation(ctx, u)
+			if err != nil {
+				return err
+			}
+
+			var rec *applications.RecommendationItem
+			switch {
+			case md.Location() != "":
+				r, err := l.API.GetRecommendation(ctx, md.Location())
+				if err != nil {
+					return err
+				}
+				rec = &applications.RecommendationItem{Recommendation: r}
+			case wait:
+				rec, err = waitForRecommendation(ctx, l.API, u, existing, timeout)
+				if err != nil {
+					return fmt.Errorf("waiting for recommendation of application %q: %w", item.Name, err)
+				}
+			default:
+				if !isQuiet(cfg) {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), MessageFormat(MessageRecommendationRequested)+"\n", item.Name)
+				}
+				return nil
+			}
+
+			return p.Fprint(out, NewRecommendationRow(rec))
+		})
+	}
+	return cmd
+}
+
+// waitForRecommendation polls the recommendations until one which is not in
+// the existing set appears.
+func waitForRecommendation(ctx context.Context, appAPI applications.API, u string, existing map[string]bool, timeout time.Duration) (*applications.RecommendationItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		rl, err := appAPI.ListRecommendations(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rl.Recommendations {
+			if !existing[rl.Recommendations[i].Name] {
+				return &rl.Recommendations[i], nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 // NewGetRecommendationsCommand returns a command for getting recommendations.
 func NewGetRecommendationsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		sortBy string
+		sortBy         string
+		output         outputOptions
+		watch          watchOptions
+		timeSeries     bool
+		workload       string
+		ignoreNotFound bool
+
+		continueOnError bool
 	)
 
 	cmd := &cobra.Command{
@@ -38,32 +161,93 @@ func NewGetRecommendationsCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	cmd.Flags().BoolVar(&timeSeries, "timeseries", timeSeries, "output the recommended values of each container over time")
+	cmd.Flags().StringVar(&workload, "workload", workload, "only include the `kind/name` workload in the time series")
+	output.AddFlags(cmd)
+	watch.AddFlags(cmd)
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		l := applications.Lister{
-			API: applications.NewAPI(client),
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
 		}
 
-		result := &RecommendationOutput{Items: make([]RecommendationRow, 0, len(args))}
-		if err := l.ForEachNamedRecommendation(ctx, args, false, result.Add); err != nil {
-			return err
+		l := applications.Lister{
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
-		if err := result.SortBy(sortBy); err != nil {
-			return err
+		if timeSeries {
+			return watch.Run(ctx, out, p, func(ctx context.Context) (Output, error) {
+				var partial partialResult
+				var items []applications.RecommendationItem
+				if err := partial.Check(l.ForEachNamedRecommendation(ctx, args, false, func(item *applications.RecommendationItem) error {
+					items = append(items, NewRecommendationRow(item).RecommendationItem)
+					return nil
+				})); err != nil {
+					return nil, err
+				}
+
+				result := &RecommendationTimeSeriesOutput{}
+				for _, series := range applications.NewContainerTimeSeries(items, workloadFilter(workload)) {
+					result.Items = append(result.Items, NewRecommendationPointRows(&series, NumberFormat{})...)
+				}
+
+				if err := result.SortBy(sortBy); err != nil {
+					return nil, err
+				}
+				return result, partial.Err()
+			})
 		}
 
-		return p.Fprint(out, result)
+		return watch.Run(ctx, out, p, func(ctx context.Context) (Output, error) {
+			var partial partialResult
+			result := &RecommendationOutput{Items: make([]RecommendationRow, 0, len(args))}
+			if err := partial.Check(l.ForEachNamedRecommendation(ctx, args, false, result.Add)); err != nil {
+				return nil, err
+			}
+			result.SetCurrentRequests(NumberFormat{})
+
+			if err := result.SortBy(sortBy); err != nil {
+				return nil, err
+			}
+			return result, partial.Err()
+		})
 	}
 	return cmd
 }
 
+// workloadFilter returns a filter matching a "[namespace/]kind/name" or "name" workload.
+func workloadFilter(workload string) func(applications.TargetRef) bool {
+	if workload == "" {
+		return nil
+	}
+
+	parts := strings.Split(workload, "/")
+	return func(ref applications.TargetRef) bool {
+		name, kind, namespace := parts[len(parts)-1], "", ""
+		if len(parts) > 1 {
+			kind = parts[len(parts)-2]
+		}
+		if len(parts) > 2 {
+			namespace = parts[len(parts)-3]
+		}
+
+		return ref.Workload == name &&
+			(kind == "" || strings.EqualFold(ref.Kind, kind)) &&
+			(namespace == "" || ref.Namespace == namespace)
+	}
+}
+
 func validRecommendationArgs(cfg Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return validArgs(cfg, func(l *completionLister, toComplete string) (completions []string, directive cobra.ShellCompDirective) {
 		directive |= cobra.ShellCompDirectiveNoFileComp
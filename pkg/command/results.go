@@ -0,0 +1,70 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Result is the structured outcome of a mutating command.
+type Result struct {
+	// The operation performed, e.g. "create" or "delete".
+	Operation string `json:"operation"`
+	// The type of the resource, e.g. "application".
+	Type string `json:"type"`
+	// The name of the resource, as accepted by other commands.
+	Name string `json:"name"`
+	// The URL of the resource.
+	SelfURL string `json:"selfURL,omitempty"`
+	// The location reported by the server.
+	Location string `json:"location,omitempty"`
+	// The human readable title of the resource.
+	Title string `json:"title,omitempty"`
+}
+
+// resultPrinter renders one JSON result per line for each row it is given.
+type resultPrinter struct {
+	Operation string
+	// Optional printer used to render each result instead of JSON.
+	Printer Printer
+}
+
+// Fprint renders the results of the supplied output or row.
+func (p *resultPrinter) Fprint(out io.Writer, obj interface{}) error {
+	if o, ok := obj.(Output); ok {
+		for i := 0; i < o.Len(); i++ {
+			if err := p.Fprint(out, o.Item(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r, err := rowResult(obj)
+	if err != nil {
+		return err
+	}
+	r.Operation = p.Operation
+	if p.Printer != nil {
+		return p.Printer.Fprint(out, r)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(r)
+}
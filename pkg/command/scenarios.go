@@ -17,15 +17,15 @@ limitations under the License.
 package command
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
-	"sigs.k8s.io/yaml"
+	"github.com/thestormforge/optimize-go/pkg/command/recommendation"
 )
 
 // NewCreateScenarioCommand returns a command for creating scenarios.
@@ -45,6 +45,11 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 			spawnRate  int
 			runTime    time.Duration
 		}
+		k6Scenario struct {
+			script   string
+			vus      int
+			duration time.Duration
+		}
 		customScenario struct {
 			usePushGateway     bool
 			podTemplateFile    string
@@ -52,6 +57,7 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 			approximateRuntime time.Duration
 			image              string
 		}
+		output outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -70,26 +76,32 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().IntVar(&locustScenario.users, "locust-users", 0, "`num`ber of concurrent Locust users")
 	cmd.Flags().IntVar(&locustScenario.spawnRate, "locust-spawn-rate", 0, "`rate` per second in which users are spawned")
 	cmd.Flags().DurationVar(&locustScenario.runTime, "locust-run-time", 0, "stop after the specified amount of `time`")
+	cmd.Flags().StringVar(&k6Scenario.script, "k6-script", "", "`file` containing the k6 test script to run")
+	cmd.Flags().IntVar(&k6Scenario.vus, "k6-vus", 0, "`num`ber of concurrent k6 virtual users")
+	cmd.Flags().DurationVar(&k6Scenario.duration, "k6-duration", 0, "stop after the specified amount of `time`")
 	cmd.Flags().BoolVar(&customScenario.usePushGateway, "custom-use-push-gateway", false, "enables the Prometheus Push Gateway")
 	cmd.Flags().StringVar(&customScenario.podTemplateFile, "custom-pod-template", "", "`file` containing the custom trial job pod template")
 	cmd.Flags().DurationVar(&customScenario.initialDelay, "custom-initial-delay", 0, "additional `delay` before starting the trial job pod")
 	cmd.Flags().DurationVar(&customScenario.approximateRuntime, "custom-approximate-runtime", 0, "the estimated amount of `time` the trial should last")
 	cmd.Flags().StringVar(&customScenario.image, "custom-image", "", "override the image `name` of the first container in the trial job pod")
+	output.AddResultFlags(cmd, "create")
 
-	// TODO The application service will not persist these values
-	cmd.Flag("locustfile").Hidden = true
-	cmd.Flag("locust-users").Hidden = true
-	cmd.Flag("locust-spawn-rate").Hidden = true
-	cmd.Flag("locust-run-time").Hidden = true
+	_ = cmd.RegisterFlagCompletionFunc("cluster", validClusterArgs(cfg, applications.ClusterScenarios))
+	_ = cmd.RegisterFlagCompletionFunc("test-case", validTestCaseArgs(cfg))
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
-		appAPI := applications.NewAPI(client)
+		appAPI := newApplicationsAPI(cfg, client)
 
 		appName, scnName := applications.SplitScenarioName(args[0])
 		app, err := appAPI.GetApplicationByName(ctx, appName)
@@ -102,6 +114,10 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 			return fmt.Errorf("malformed response, missing scenarios link")
 		}
 
+		if err := checkScenarioClusters(cmd, appAPI, clusters); err != nil {
+			return err
+		}
+
 		scn := applications.Scenario{
 			DisplayName:   title,
 			Configuration: []interface{}{},
@@ -109,119 +125,88 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 			Clusters:      clusters,
 		}
 
-		// Only support setting the label selector on parameter configurations
 		if containerResourceSelector != "" {
-			scn.Configuration = append(scn.Configuration, map[string]interface{}{
-				"containerResources": map[string]interface{}{
-					// TODO This should be "labelSelector" but then the UI wouldn't recognize it
-					"selector": containerResourceSelector,
-				},
-			})
+			scn.Configuration = setScenarioSelector(scn.Configuration, "containerResources", containerResourceSelector)
 		}
 		if replicaSelector != "" {
-			scn.Configuration = append(scn.Configuration, map[string]interface{}{
-				"replicas": map[string]interface{}{
-					// TODO This should be "labelSelector" but then the UI wouldn't recognize it
-					"selector": replicaSelector,
-				},
-			})
+			scn.Configuration = setScenarioSelector(scn.Configuration, "replicas", replicaSelector)
 		}
-
-		// Only support generating named based goals
-		var namedGoals []interface{}
-		for _, goal := range goals {
-			namedGoals = append(namedGoals, map[string]interface{}{"name": goal})
-		}
-		if len(namedGoals) > 0 {
-			scn.Objective = append(scn.Objective, map[string]interface{}{"goals": namedGoals})
+		if len(goals) > 0 {
+			scn.Objective = setScenarioGoals(scn.Objective, goals)
 		}
 
 		// Scenario settings
-		settings := make(map[string]interface{})
+		settings := make(applications.Settings)
 		switch {
 		case perftestScenario.testCase != "":
-			settings["testCase"] = perftestScenario.testCase
+			if err := checkScenarioTestCase(cmd, appAPI, perftestScenario.testCase); err != nil {
+				return err
+			}
+			settings.Set("testCase", perftestScenario.testCase)
 			scn.StormForgePerformance = settings
 
 		case locustScenario.locustfile != "":
-			switch strings.ToLower(strings.SplitN(locustScenario.locustfile, ":", 2)[0]) {
-			case "http", "https":
-				//	The file name field can be a URL
-				settings["locustfile"] = locustScenario.locustfile
-			default:
-				// The file contents can be inlined in the file name field
-				data, err := os.ReadFile(locustScenario.locustfile)
-				if err != nil {
-					return err
-				}
-				settings["locustfile"] = string(data)
+			locustfile, err := readScenarioFile(cmd, locustScenario.locustfile)
+			if err != nil {
+				return err
 			}
-			if locustScenario.users > 0 {
-				settings["users"] = locustScenario.users
+			scn.Locust = &applications.LocustScenario{
+				Locustfile: locustfile,
+				Users:      locustScenario.users,
+				SpawnRate:  locustScenario.spawnRate,
+				RunTime:    api.Duration(locustScenario.runTime),
 			}
-			if locustScenario.spawnRate > 0 {
-				settings["spawnRate"] = locustScenario.spawnRate
+
+		case k6Scenario.script != "":
+			script, err := readScenarioFile(cmd, k6Scenario.script)
+			if err != nil {
+				return err
 			}
-			if locustScenario.runTime > 0 {
-				settings["runTime"] = locustScenario.runTime.String()
+			scn.K6 = &applications.K6Scenario{
+				Script:   script,
+				VUs:      k6Scenario.vus,
+				Duration: api.Duration(k6Scenario.duration),
 			}
-			scn.Locust = settings
 
 		default:
 			if customScenario.podTemplateFile != "" {
-				data, err := os.ReadFile(customScenario.podTemplateFile)
+				podTemplate, err := readPodTemplate(cmd, customScenario.podTemplateFile)
 				if err != nil {
 					return err
 				}
-
-				var podTemplate map[string]interface{}
-				if err := yaml.Unmarshal(data, &podTemplate); err != nil {
-					return err
-				}
-
-				if _, ok := podTemplate["spec"]; !ok {
-					return fmt.Errorf("pod template should have a 'spec' field")
-				}
-
-				settings["podTemplate"] = podTemplate
+				settings.Set("podTemplate", podTemplate)
 			}
 			if customScenario.usePushGateway {
-				settings["pushGateway"] = customScenario.usePushGateway
+				settings.Set("pushGateway", customScenario.usePushGateway)
 			}
 			if customScenario.initialDelay > 0 {
-				settings["initialDelaySeconds"] = int(customScenario.initialDelay.Round(time.Second).Seconds())
+				settings.Set("initialDelaySeconds", int(customScenario.initialDelay.Round(time.Second).Seconds()))
 			}
 			if customScenario.approximateRuntime > 0 {
-				settings["approximateRuntimeSeconds"] = int(customScenario.approximateRuntime.Round(time.Second).Seconds())
+				settings.Set("approximateRuntimeSeconds", int(customScenario.approximateRuntime.Round(time.Second).Seconds()))
 			}
 			if customScenario.image != "" {
-				settings["image"] = customScenario.image
+				settings.Set("image", customScenario.image)
 			}
 			if len(settings) > 0 {
 				scn.Custom = settings
 			}
 		}
 
-		var selfURL string
-		if scnName != "" {
-			md, err := appAPI.CreateScenarioByName(ctx, scenariosURL, scnName, scn)
+		// Older servers silently drop settings for scenario types they do not know
+		if scn.Locust != nil || scn.K6 != nil {
+			md, err := appAPI.CheckEndpoint(ctx)
 			if err != nil {
 				return err
 			}
-			selfURL = md.Link(api.RelationSelf)
-		} else {
-			md, err := appAPI.CreateScenario(ctx, scenariosURL, scn)
-			if err != nil {
+			if err := applications.CheckScenarioSupport(md, &scn); err != nil {
 				return err
 			}
-			selfURL = md.Location()
 		}
 
-		// Fetch the scenario back for display
-		if selfURL != "" {
-			if s, err := appAPI.GetScenario(ctx, selfURL); err == nil {
-				scn = s
-			}
+		scn, err = applications.CreateAndGetScenario(ctx, appAPI, scenariosURL, scnName, scn)
+		if err != nil {
+			return err
 		}
 
 		return p.Fprint(out, NewScenarioRow(&applications.ScenarioItem{Scenario: scn}))
@@ -232,8 +217,15 @@ func NewCreateScenarioCommand(cfg Config, p Printer) *cobra.Command {
 // NewEditScenarioCommand returns a command for editing a scenario.
 func NewEditScenarioCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		title    string
-		clusters []string
+		title                     string
+		clusters                  []string
+		containerResourceSelector string
+		replicaSelector           string
+		goals                     []string
+		testCase                  string
+		preconditions             preconditionOptions
+		ignoreNotFound            bool
+		output                    outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -244,16 +236,42 @@ func NewEditScenarioCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.Flags().StringVar(&title, "title", "", "human readable `name` for the scenario")
 	cmd.Flags().StringArrayVar(&clusters, "cluster", nil, "cluster `name` used for experimentation")
+	cmd.Flags().StringVar(&containerResourceSelector, "container-resource-selector", "", "`selector` for application resources which should have container resource optimization applied")
+	cmd.Flags().StringVar(&replicaSelector, "replica-selector", "", "`selector` for application resources which should have replica optimization applied")
+	cmd.Flags().StringSliceVar(&goals, "goals", nil, "replace the application optimization `objectives`")
+	cmd.Flags().StringVar(&testCase, "test-case", "", "`name` of the StormForge Performance test case to use")
+	preconditions.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	output.AddFlags(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("cluster", validClusterArgs(cfg, applications.ClusterScenarios))
+	_ = cmd.RegisterFlagCompletionFunc("test-case", validTestCaseArgs(cfg))
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:            newApplicationsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
+		}
+
+		if err := checkScenarioClusters(cmd, l.API, clusters); err != nil {
+			return err
+		}
+
+		if testCase != "" {
+			if err := checkScenarioTestCase(cmd, l.API, testCase); err != nil {
+				return err
+			}
 		}
 
 		return l.ForEachNamedScenario(ctx, args, false, func(item *applications.ScenarioItem) error {
@@ -263,16 +281,40 @@ func NewEditScenarioCommand(cfg Config, p Printer) *cobra.Command {
 			}
 
 			scn := applications.Scenario{
+				Metadata:    preconditions.IfMatch(item.Metadata),
 				DisplayName: title,
-				Clusters:    nil,
+				Clusters:    clusters,
+			}
+
+			// Arrays are replaced by the patch, so changes must include the unmodified entries
+			configuration := item.Configuration
+			if cmd.Flags().Changed("container-resource-selector") {
+				configuration = setScenarioSelector(configuration, "containerResources", containerResourceSelector)
+				scn.Configuration = configuration
+			}
+			if cmd.Flags().Changed("replica-selector") {
+				configuration = setScenarioSelector(configuration, "replicas", replicaSelector)
+				scn.Configuration = configuration
+			}
+			if cmd.Flags().Changed("goals") {
+				scn.Objective = setScenarioGoals(item.Objective, goals)
 			}
 
-			if scn.DisplayName == "" {
+			if testCase != "" {
+				settings, err := item.StormForgePerformanceSettings()
+				if err != nil {
+					return err
+				}
+				settings.Set("testCase", testCase)
+				scn.StormForgePerformance = settings
+			}
+
+			if scn.DisplayName == "" && len(scn.Clusters) == 0 && scn.Configuration == nil && scn.Objective == nil && scn.StormForgePerformance == nil {
 				return nil
 			}
 
 			if err := l.API.PatchScenario(ctx, selfURL, scn); err != nil {
-				return err
+				return preconditions.Check(err)
 			}
 			return p.Fprint(out, NewScenarioRow(item))
 		})
@@ -280,10 +322,163 @@ func NewEditScenarioCommand(cfg Config, p Printer) *cobra.Command {
 	return cmd
 }
 
+// setScenarioSelector returns the scenario configuration with the selector of
+// the specified parameter configuration (e.g. "replicas") changed. Only setting
+// the label selector on parameter configurations is supported.
+func setScenarioSelector(configuration []interface{}, key, selector string) []interface{} {
+	result := make([]interface{}, 0, len(configuration)+1)
+	found := false
+	for _, c := range configuration {
+		if m, ok := c.(map[string]interface{}); ok {
+			if pc, ok := m[key].(map[string]interface{}); ok && !found {
+				// TODO This should be "labelSelector" but then the UI wouldn't recognize it
+				pc["selector"] = selector
+				found = true
+			}
+		}
+		result = append(result, c)
+	}
+
+	if !found {
+		result = append(result, map[string]interface{}{
+			key: map[string]interface{}{
+				// TODO This should be "labelSelector" but then the UI wouldn't recognize it
+				"selector": selector,
+			},
+		})
+	}
+	return result
+}
+
+// setScenarioGoals returns the scenario objective with the goals replaced by
+// the supplied names. Only generating name based goals is supported.
+func setScenarioGoals(objective []interface{}, goals []string) []interface{} {
+	namedGoals := make([]interface{}, 0, len(goals))
+	for _, goal := range goals {
+		namedGoals = append(namedGoals, map[string]interface{}{"name": goal})
+	}
+
+	result := make([]interface{}, 0, len(objective)+1)
+	found := false
+	for _, o := range objective {
+		if m, ok := o.(map[string]interface{}); ok {
+			if _, ok := m["goals"]; ok {
+				if found || len(namedGoals) == 0 {
+					continue
+				}
+				m["goals"] = namedGoals
+				found = true
+			}
+		}
+		result = append(result, o)
+	}
+
+	if !found && len(namedGoals) > 0 {
+		result = append(result, map[string]interface{}{"goals": namedGoals})
+	}
+	return result
+}
+
+// readScenarioFile returns the contents of the named input, URLs are returned
+// as-is for the server to fetch.
+func readScenarioFile(cmd *cobra.Command, name string) (string, error) {
+	if isURLInput(name) {
+		return name, nil
+	}
+
+	data, err := readInput(cmd, name)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// checkScenarioClusters verifies the supplied cluster names refer to clusters
+// which support scenarios, suggesting valid names if they do not.
+func checkScenarioClusters(cmd *cobra.Command, appAPI applications.API, clusters []string) error {
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	q := applications.ClusterListQuery{}
+	q.SetModules(applications.ClusterScenarios)
+	list, err := appAPI.ListClusters(cmd.Context(), q)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	valid := make(map[string]bool, len(list.Items))
+	for i := range list.Items {
+		names = append(names, list.Items[i].Name.String())
+		valid[list.Items[i].Name.String()] = true
+	}
+
+	var errs recommendation.ErrorList
+	for _, c := range clusters {
+		if valid[c] {
+			continue
+		}
+
+		errs = append(errs, &recommendation.Error{
+			Message:        fmt.Sprintf("invalid cluster: %s", c),
+			FixCommand:     cmd.CommandPath(),
+			FixFlag:        "cluster",
+			FixValidValues: names,
+		})
+	}
+	return errs.Err()
+}
+
+// checkScenarioTestCase verifies the StormForge Performance test case exists,
+// the check is skipped if the server does not expose test cases.
+func checkScenarioTestCase(cmd *cobra.Command, appAPI applications.API, testCase string) error {
+	list, err := appAPI.ListTestCases(cmd.Context(), applications.TestCaseListQuery{})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.Type == applications.ErrTestCasesNotFound {
+			return nil
+		}
+		return err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for i := range list.Items {
+		if list.Items[i].Name == testCase {
+			return nil
+		}
+		names = append(names, list.Items[i].Name)
+	}
+
+	return &recommendation.Error{
+		Message:        fmt.Sprintf("invalid test case: %s", testCase),
+		FixCommand:     cmd.CommandPath(),
+		FixFlag:        "test-case",
+		FixValidValues: names,
+	}
+}
+
+// validTestCaseArgs returns shell completions for StormForge Performance test case names.
+func validTestCaseArgs(cfg Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return validArgs(cfg, func(l *completionLister, toComplete string) (completions []string, directive cobra.ShellCompDirective) {
+		directive |= cobra.ShellCompDirectiveNoFileComp
+		l.forAllTestCases(func(item *applications.TestCaseItem) {
+			if strings.HasPrefix(item.Name, toComplete) {
+				completions = append(completions, item.Name+"\t"+item.DisplayName)
+			}
+		})
+		return
+	})
+}
+
 // NewGetScenariosCommand returns a command for getting scenarios.
 func NewGetScenariosCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		sortBy string
+		sortBy         string
+		output         outputOptions
+		ignoreNotFound bool
+
+		continueOnError bool
 	)
 
 	cmd := &cobra.Command{
@@ -293,20 +488,31 @@ func NewGetScenariosCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd)
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
+		var partial partialResult
 		result := &ScenarioOutput{Items: make([]ScenarioRow, 0, len(args))}
-		if err := l.ForEachNamedScenario(ctx, args, false, result.Add); err != nil {
+		if err := partial.Check(l.ForEachNamedScenario(ctx, args, false, result.Add)); err != nil {
 			return err
 		}
 
@@ -314,7 +520,7 @@ func NewGetScenariosCommand(cfg Config, p Printer) *cobra.Command {
 			return err
 		}
 
-		return p.Fprint(out, result)
+		return partial.Print(out, p, result)
 	}
 	return cmd
 }
@@ -323,6 +529,9 @@ func NewGetScenariosCommand(cfg Config, p Printer) *cobra.Command {
 func NewDeleteScenariosCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		ignoreNotFound bool
+
+		continueOnError bool
+		output          outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -332,16 +541,24 @@ func NewDeleteScenariosCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "treat not found errors as successful deletes")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	output.AddResultFlags(cmd, "delete")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := applications.Lister{
-			API: applications.NewAPI(client),
+			API:             newApplicationsAPI(cfg, client),
+			ContinueOnError: continueOnError,
 		}
 
 		return l.ForEachNamedScenario(ctx, args, ignoreNotFound, func(item *applications.ScenarioItem) error {
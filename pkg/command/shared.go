@@ -17,21 +17,315 @@ limitations under the License.
 package command
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	metrics "github.com/thestormforge/optimize-go/pkg/api/metrics/v1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	"golang.org/x/oauth2"
 )
 
 // Config represents the configuration necessary to run a command.
+//
+// Applications embedding these commands may supply their own implementation;
+// the configuration may optionally implement any of the `TokenSourceConfig`,
+// `TransportConfig`, `UserAgentConfig`, `ReadOnlyConfig`, `ResponseCacheConfig`
+// or `EndpointsConfig` interfaces to control how commands communicate with the API.
 type Config interface {
 	// Address returns the base address for the API endpoints.
 	Address() string
 }
 
+// TokenSourceConfig is implemented by configurations which can obtain access tokens.
+type TokenSourceConfig interface {
+	// TokenSource returns the source of access tokens for the API.
+	TokenSource(ctx context.Context) oauth2.TokenSource
+}
+
+// TransportConfig is implemented by configurations which customize the HTTP
+// transport used to make API requests (e.g. to authorize requests).
+type TransportConfig interface {
+	// Transport wraps the base round tripper, the token source may be nil.
+	Transport(tokenSource oauth2.TokenSource, base http.RoundTripper) http.RoundTripper
+}
+
+// UserAgentConfig is implemented by configurations which identify the host
+// application to the API.
+type UserAgentConfig interface {
+	// UserAgent returns the value of the User-Agent header sent with API requests.
+	UserAgent() string
+}
+
+// ReadOnlyConfig is implemented by configurations which may prevent the API
+// client from making changes.
+type ReadOnlyConfig interface {
+	// IsReadOnly returns true if only GET and HEAD requests should be allowed.
+	IsReadOnly() bool
+}
+
+// ResponseCacheConfig is implemented by configurations which persist API
+// responses between invocations (e.g. so completions can revalidate them).
+type ResponseCacheConfig interface {
+	// ResponseCacheFile returns the file used to store the responses.
+	ResponseCacheFile() string
+}
+
+// EndpointsConfig is implemented by configurations which expose endpoints
+// other than the API server.
+type EndpointsConfig interface {
+	// IssuerAddress returns the address of the authorization server.
+	IssuerAddress() string
+}
+
+// APIEndpointsConfig is implemented by configurations which override the
+// locations of individual API services.
+type APIEndpointsConfig interface {
+	// APIEndpoints returns the overridden API service locations.
+	APIEndpoints() config.Endpoints
+}
+
+// WarningConfig is implemented by configurations which report the warnings
+// returned by the server.
+type WarningConfig interface {
+	// WarningOutput returns the writer which receives server warnings, nil to ignore them.
+	WarningOutput() io.Writer
+}
+
+// OutputConfig is implemented by configurations which adjust the output of
+// the commands (e.g. from global flags).
+type OutputConfig interface {
+	// IsQuiet returns true if informational messages should be suppressed.
+	IsQuiet() bool
+	// IsNoHeaders returns true if tabular output should omit the header row.
+	IsNoHeaders() bool
+}
+
+// isQuiet returns true if the configuration suppresses informational messages.
+func isQuiet(cfg Config) bool {
+	ocfg, ok := cfg.(OutputConfig)
+	return ok && ocfg.IsQuiet()
+}
+
+// isNoHeaders returns true if the configuration omits the header row of tabular output.
+func isNoHeaders(cfg Config) bool {
+	ocfg, ok := cfg.(OutputConfig)
+	return ok && ocfg.IsNoHeaders()
+}
+
+// apiTransport is the base transport used by API clients.
+var apiTransport http.RoundTripper = api.NewTransport(api.DefaultTransportOptions)
+
+// SetTransport replaces the base transport used to communicate with the API,
+// e.g. to inspect the responses of the server. Other HTTP requests made by the
+// commands (such as reading remote input) are not affected.
+func SetTransport(t http.RoundTripper) {
+	apiTransport = t
+}
+
+// newClient returns an API client using the optional capabilities of the configuration.
+func newClient(ctx context.Context, cfg Config) (api.Client, error) {
+	transport := apiTransport
+	cache := &api.ResponseCache{}
+
+	if tcfg, ok := cfg.(TransportConfig); ok {
+		// The transport may authorize requests without the cache seeing it
+		cache.Authorized = true
+		var ts oauth2.TokenSource
+		if tscfg, ok := cfg.(TokenSourceConfig); ok {
+			ts = tscfg.TokenSource(ctx)
+		}
+		transport = tcfg.Transport(ts, transport)
+	}
+
+	if uacfg, ok := cfg.(UserAgentConfig); ok {
+		if ua := uacfg.UserAgent(); ua != "" {
+			transport = &userAgentTransport{Transport: transport, UserAgent: ua}
+		}
+	}
+
+	if rccfg, ok := cfg.(ResponseCacheConfig); ok {
+		cache.Filename = rccfg.ResponseCacheFile()
+	}
+
+	opts := []api.ClientOption{
+		api.WithRetryPolicy(api.DefaultRetryPolicy),
+		api.WithResponseCache(cache),
+	}
+	if rocfg, ok := cfg.(ReadOnlyConfig); ok && rocfg.IsReadOnly() {
+		opts = append(opts, api.WithReadOnly())
+	}
+	if wcfg, ok := cfg.(WarningConfig); ok {
+		if w := wcfg.WarningOutput(); w != nil {
+			opts = append(opts, api.WithWarningFunc(printWarning(w)))
+		}
+	}
+
+	return api.NewClient(cfg.Address(), transport, opts...)
+}
+
+// printWarning returns a function which prints each distinct server warning once.
+func printWarning(w io.Writer) func(string) {
+	var mu sync.Mutex
+	seen := make(map[string]struct{})
+	return func(warning string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := seen[warning]; ok {
+			return
+		}
+		seen[warning] = struct{}{}
+		_, _ = fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+// newApplicationsAPI returns an applications API using any endpoints overridden by the configuration.
+func newApplicationsAPI(cfg Config, client api.Client) applications.API {
+	ecfg, ok := cfg.(APIEndpointsConfig)
+	if !ok {
+		return applications.NewAPI(client)
+	}
+
+	ep := ecfg.APIEndpoints()
+	return applications.NewAPIWithEndpoints(client, applications.Endpoints{
+		Applications: ep.Applications,
+		Clusters:     ep.Clusters,
+		Activity:     ep.Activity,
+		TestCases:    ep.TestCases,
+	})
+}
+
+// newExperimentsAPI returns an experiments API using any endpoint overridden by the configuration.
+func newExperimentsAPI(cfg Config, client api.Client) experiments.API {
+	if ecfg, ok := cfg.(APIEndpointsConfig); ok {
+		if endpoint := ecfg.APIEndpoints().Experiments; endpoint != "" {
+			// Invalid endpoints fall back to the default, they should be rejected when the configuration is loaded
+			expAPI, err := experiments.NewAPIWithEndpoint(client, strings.TrimRight(endpoint, "/")+"/")
+			if err == nil {
+				return expAPI
+			}
+		}
+	}
+	return experiments.NewAPI(client)
+}
+
+// newMetricsAPI returns a metrics API using the remote write endpoint overridden by the configuration.
+func newMetricsAPI(cfg Config, client api.Client) metrics.API {
+	if ecfg, ok := cfg.(APIEndpointsConfig); ok {
+		if endpoint := ecfg.APIEndpoints().RemoteWrite; endpoint != "" {
+			// Invalid endpoints fall back to the default, they should be rejected when the configuration is loaded
+			metricsAPI, err := metrics.NewAPIWithEndpoint(client, endpoint)
+			if err == nil {
+				return metricsAPI
+			}
+		}
+	}
+	return metrics.NewAPI(client)
+}
+
+// userAgentTransport sets the User-Agent header on requests which do not already have one.
+type userAgentTransport struct {
+	Transport http.RoundTripper
+	UserAgent string
+}
+
+// RoundTrip adds the User-Agent header before delegating to the wrapped transport.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	return t.Transport.RoundTrip(req)
+}
+
+// scopeOptions are the flags used to scope lists for tokens which grant access
+// to multiple organizations, teams or workspaces.
+type scopeOptions struct {
+	Organization string
+	Team         string
+	Workspace    string
+}
+
+// AddFlags registers the scope flags on the supplied command.
+func (o *scopeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Organization, "organization", o.Organization, "limit the list to the specified `organization`")
+	cmd.Flags().StringVar(&o.Team, "team", o.Team, "limit the list to the specified `team`")
+	cmd.Flags().StringVar(&o.Workspace, "workspace", o.Workspace, "limit the list to the specified `workspace`")
+}
+
+// Apply sets the scope parameters on the supplied query.
+func (o *scopeOptions) Apply(q *api.IndexQuery) {
+	if o.Organization != "" {
+		q.SetOrganization(o.Organization)
+	}
+	if o.Team != "" {
+		q.SetTeam(o.Team)
+	}
+	if o.Workspace != "" {
+		q.SetWorkspace(o.Workspace)
+	}
+}
+
+// preconditionOptions are the flags used to prevent edits from overwriting
+// changes made since the resource was read.
+type preconditionOptions struct {
+	ResourceVersion string
+	Force           bool
+}
+
+// AddFlags registers the precondition flags on the supplied command.
+func (o *preconditionOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.ResourceVersion, "resource-version", o.ResourceVersion, "only update if the current resource `version` (ETag) matches")
+	cmd.Flags().BoolVar(&o.Force, "force", o.Force, "update even if the resource was changed since it was read")
+	cmd.MarkFlagsMutuallyExclusive("resource-version", "force")
+}
+
+// IfMatch returns the metadata used to conditionally update a resource which
+// was read with the supplied metadata.
+func (o *preconditionOptions) IfMatch(md api.Metadata) api.Metadata {
+	etag := md.ETag()
+	switch {
+	case o.Force:
+		etag = ""
+	case o.ResourceVersion != "":
+		etag = o.ResourceVersion
+		if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+			etag = `"` + etag + `"`
+		}
+	}
+
+	result := api.Metadata{}
+	if etag != "" {
+		http.Header(result).Set("ETag", etag)
+	}
+	return result
+}
+
+// Check adds a hint to conflict errors returned when a precondition fails.
+func (o *preconditionOptions) Check(err error) error {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) && apiErr.Hint == "" {
+		switch apiErr.Type {
+		case applications.ErrApplicationConflict,
+			applications.ErrScenarioConflict,
+			applications.ErrClusterConflict,
+			applications.ErrTemplateConflict:
+			apiErr.Hint = "the resource was changed since it was read, try again or use --force to overwrite the changes"
+		}
+	}
+	return err
+}
+
 // parseLabelSelector returns a map of simple equality based label selectors.
 func parseLabelSelector(s string) map[string]string {
 	if s == "" {
@@ -53,25 +347,64 @@ func parseLabelSelector(s string) map[string]string {
 	return selector
 }
 
+// matchLabelSelector returns true if the labels satisfy every expression of a
+// selector returned by `parseLabelSelector`; an expression without a value
+// only requires the label to be present.
+func matchLabelSelector(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := labels[k]; !ok || (v != "" && lv != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// confirmDelete lists the names about to be deleted and prompts for
+// confirmation. Without a terminal to prompt on, the deletion is refused.
+func confirmDelete(cmd *cobra.Command, kind string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	in, ok := cmd.InOrStdin().(*os.File)
+	if fi, err := in.Stat(); !ok || err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("refusing to delete %d %s without confirmation, use --yes to proceed", len(names), kind)
+	}
+
+	errOut := cmd.ErrOrStderr()
+	for _, name := range names {
+		_, _ = fmt.Fprintln(errOut, name)
+	}
+	_, _ = fmt.Fprintf(errOut, MessageFormat(MessageConfirmDelete), len(names), kind)
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("delete canceled")
+}
+
 func validArgs(cfg Config, f func(*completionLister, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
-		return f(&completionLister{ctx: cmd.Context(), client: client}, toComplete)
+		return f(&completionLister{ctx: cmd.Context(), cfg: cfg, client: client}, toComplete)
 	}
 }
 
 // completionLister is a helper for creating lists used for completions.
 type completionLister struct {
 	ctx    context.Context
+	cfg    Config
 	client api.Client
 }
 
 // forEachApplication lists all applications, ignoring errors.
 func (c *completionLister) forAllApplications(f func(item *applications.ApplicationItem)) {
-	l := applications.Lister{API: applications.NewAPI(c.client)}
+	l := applications.Lister{API: newApplicationsAPI(c.cfg, c.client)}
 	q := applications.ApplicationListQuery{}
 	_ = l.ForEachApplication(c.ctx, q, func(item *applications.ApplicationItem) error {
 		f(item)
@@ -81,7 +414,7 @@ func (c *completionLister) forAllApplications(f func(item *applications.Applicat
 
 // forEachExperiment lists all experiments, ignoring errors.
 func (c *completionLister) forAllExperiments(f func(item *experiments.ExperimentItem)) {
-	l := experiments.Lister{API: experiments.NewAPI(c.client)}
+	l := experiments.Lister{API: newExperimentsAPI(c.cfg, c.client)}
 	q := experiments.ExperimentListQuery{}
 	_ = l.ForEachExperiment(c.ctx, q, func(item *experiments.ExperimentItem) error {
 		f(item)
@@ -89,9 +422,21 @@ func (c *completionLister) forAllExperiments(f func(item *experiments.Experiment
 	})
 }
 
+// forAllTestCases lists all performance test cases, ignoring errors.
+func (c *completionLister) forAllTestCases(f func(item *applications.TestCaseItem)) {
+	appAPI := newApplicationsAPI(c.cfg, c.client)
+	list, err := appAPI.ListTestCases(c.ctx, applications.TestCaseListQuery{})
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		f(&list.Items[i])
+	}
+}
+
 // forEachCluster lists all cluster, ignoring errors.
 func (c *completionLister) forAllClusters(f func(item *applications.ClusterItem), m ...applications.ClusterModule) {
-	l := applications.Lister{API: applications.NewAPI(c.client)}
+	l := applications.Lister{API: newApplicationsAPI(c.cfg, c.client)}
 	q := applications.ClusterListQuery{}
 	q.SetModules(m...)
 	_ = l.ForEachCluster(c.ctx, q, func(item *applications.ClusterItem) error {
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+// NewStatusRecommendationsCommand returns a command for summarizing the
+// recommendation status of all applications.
+func NewStatusRecommendationsCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		staleAfter  = 48 * time.Hour
+		sortBy      string
+		output      outputOptions
+		scope       scopeOptions
+		concurrency = concurrencyOptions{Concurrency: 4}
+	)
+
+	cmd := &cobra.Command{
+		Use:     "recommendations",
+		Aliases: []string{"recommendation", "recs"},
+		Args:    cobra.NoArgs,
+	}
+
+	cmd.Flags().DurationVar(&staleAfter, "stale-after", staleAfter, "flag applications without a recommendation in the specified `duration`")
+	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd)
+	scope.AddFlags(cmd)
+	concurrency.AddFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		l := applications.Lister{
+			API: newApplicationsAPI(cfg, client),
+		}
+
+		var items []applications.ApplicationItem
+		q := applications.ApplicationListQuery{}
+		scope.Apply(&q.IndexQuery)
+		if err := l.ForEachApplication(ctx, q, func(item *applications.ApplicationItem) error {
+			items = append(items, *item)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// Fetch the recommendations of each application concurrently
+		result := &RecommendationStatusOutput{Items: make([]RecommendationStatusRow, len(items))}
+		errs := concurrency.forEach(ctx, len(items), func(ctx context.Context, i int) error {
+			var rl *applications.RecommendationList
+			if u := items[i].Link(api.RelationRecommendations); u != "" && items[i].Recommendations != applications.RecommendationsDisabled {
+				lst, err := l.API.ListRecommendations(ctx, u)
+				if err != nil {
+					return err
+				}
+				rl = &lst
+			}
+
+			result.Items[i] = *NewRecommendationStatusRow(&items[i], rl, staleAfter)
+			return nil
+		})
+
+		var statusErr api.MultiError
+		for i, err := range errs {
+			if err != nil && !errors.Is(err, errNotAttempted) {
+				statusErr.Add(items[i].Name.String(), err)
+			}
+		}
+		if err := statusErr.ErrorOrNil(); err != nil {
+			return err
+		}
+
+		if err := result.SortBy(sortBy); err != nil {
+			return err
+		}
+
+		return p.Fprint(out, result)
+	}
+	return cmd
+}
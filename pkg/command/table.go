@@ -0,0 +1,143 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// tableColumn describes a single column of table output.
+type tableColumn struct {
+	// The name of the column, rendered in upper case as the header.
+	name string
+	// The index of the row field used to populate the column.
+	field int
+}
+
+// tablePrinter renders the rows of the supplied output (or a single row) using
+// the `table` struct tags. Columns with the "wide" option are only included in
+// wide output and columns with the "custom" option are never included, they
+// duplicate information already present in other columns. Map values are
+// rendered as sorted `key=value` pairs. The header is only rendered once, so
+// commands may print one row at a time.
+type tablePrinter struct {
+	// Include the columns with the "wide" option.
+	Wide bool
+	// Omit the header row.
+	NoHeader bool
+
+	headerDone bool
+}
+
+// Fprint renders the supplied output as aligned columns.
+func (p *tablePrinter) Fprint(out io.Writer, obj interface{}) error {
+	o, ok := asOutput(obj)
+	if !ok {
+		return fmt.Errorf("unable to render %T as a table", obj)
+	}
+	if o.Len() == 0 {
+		return nil
+	}
+
+	columns := p.columns(o)
+	w := tabwriter.NewWriter(out, 6, 4, 3, ' ', 0)
+
+	if !p.NoHeader && !p.headerDone {
+		p.headerDone = true
+		header := make([]string, 0, len(columns))
+		for _, c := range columns {
+			header = append(header, strings.ToUpper(strings.ReplaceAll(c.name, "_", " ")))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(header, "\t")); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < o.Len(); i++ {
+		rv := reflect.Indirect(reflect.ValueOf(o.Item(i)))
+		record := make([]string, 0, len(columns))
+		for _, c := range columns {
+			record = append(record, formatTableValue(rv.Field(c.field)))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(record, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// columns returns the columns to render for the supplied output.
+func (p *tablePrinter) columns(o Output) []tableColumn {
+	rt := reflect.Indirect(reflect.ValueOf(o.Item(0))).Type()
+	var columns []tableColumn
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("table")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		include := true
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "wide":
+				include = include && p.Wide
+			case "custom":
+				include = false
+			}
+		}
+
+		if include {
+			columns = append(columns, tableColumn{name: opts[0], field: i})
+		}
+	}
+	return columns
+}
+
+// formatTableValue returns the cell contents for a row field. Tabs and line
+// breaks are replaced so they do not disturb the alignment of the table.
+func formatTableValue(fv reflect.Value) string {
+	var value string
+	switch fv.Kind() {
+	case reflect.Map:
+		pairs := make([]string, 0, fv.Len())
+		for _, k := range fv.MapKeys() {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", k.Interface(), fv.MapIndex(k).Interface()))
+		}
+		sort.Strings(pairs)
+		value = strings.Join(pairs, ",")
+	case reflect.Slice:
+		items := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			items = append(items, fmt.Sprint(fv.Index(i).Interface()))
+		}
+		value = strings.Join(items, ",")
+	case reflect.Ptr:
+		if !fv.IsNil() {
+			value = fmt.Sprint(fv.Elem().Interface())
+		}
+	default:
+		value = fmt.Sprint(fv.Interface())
+	}
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+}
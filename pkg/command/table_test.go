@@ -0,0 +1,109 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+func TestTablePrinter(t *testing.T) {
+	clusters := &ClusterOutput{Items: []ClusterRow{
+		{Name: "east", DisplayName: "East", OptimizeLiveVersion: "1.0.0", KubernetesVersion: "1.27"},
+		{Name: "west", DisplayName: "West\tCoast", OptimizeProVersion: "2.0.0", KubernetesVersion: "1.28"},
+	}}
+
+	cases := []struct {
+		desc     string
+		printer  tablePrinter
+		obj      interface{}
+		expected []string
+	}{
+		{
+			desc: "default",
+			obj:  clusters,
+			expected: []string{
+				"NAME   TITLE        OPTIMIZE PRO   OPTIMIZE LIVE   LAST SEEN",
+				"east   East                        1.0.0           ",
+				"west   West Coast   2.0.0                          ",
+			},
+		},
+		{
+			desc:    "wide",
+			printer: tablePrinter{Wide: true},
+			obj:     clusters,
+			expected: []string{
+				"NAME   TITLE        OPTIMIZE PRO   OPTIMIZE LIVE   PERFORMANCE TEST   KUBERNETES   LAST SEEN   AGE",
+				"east   East                        1.0.0                              1.27                     ",
+				"west   West Coast   2.0.0                                             1.28                     ",
+			},
+		},
+		{
+			desc:    "no header",
+			printer: tablePrinter{NoHeader: true},
+			obj:     clusters,
+			expected: []string{
+				"east   East                 1.0.0   ",
+				"west   West Coast   2.0.0           ",
+			},
+		},
+		{
+			desc: "single row",
+			obj:  &clusters.Items[0],
+			expected: []string{
+				"NAME   TITLE   OPTIMIZE PRO   OPTIMIZE LIVE   LAST SEEN",
+				"east   East                   1.0.0           ",
+			},
+		},
+		{
+			desc: "empty",
+			obj:  &ClusterOutput{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out strings.Builder
+			if assert.NoError(t, c.printer.Fprint(&out, c.obj)) {
+				assert.Equal(t, c.expected, lines(out.String()))
+			}
+		})
+	}
+}
+
+func TestTablePrinter_headerOnce(t *testing.T) {
+	p := &tablePrinter{}
+	var out strings.Builder
+	assert.NoError(t, p.Fprint(&out, NewClusterRow(&applications.ClusterItem{Cluster: applications.Cluster{Name: "east"}})))
+	assert.NoError(t, p.Fprint(&out, NewClusterRow(&applications.ClusterItem{Cluster: applications.Cluster{Name: "west"}})))
+	assert.Len(t, lines(out.String()), 3)
+}
+
+func TestTablePrinter_unsupported(t *testing.T) {
+	p := &tablePrinter{}
+	assert.EqualError(t, p.Fprint(&strings.Builder{}, "text"), "unable to render string as a table")
+}
+
+// lines splits output into lines, without the trailing line break.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
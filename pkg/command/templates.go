@@ -0,0 +1,276 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// NewGetTemplateCommand returns a command for getting a scenario template.
+func NewGetTemplateCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		revision       int
+		history        bool
+		output         outputOptions
+		ignoreNotFound bool
+	)
+
+	cmd := &cobra.Command{
+		Use:               "template APP_NAME/SCENARIO_NAME",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validApplicationArgs(cfg),
+	}
+
+	cmd.Flags().IntVar(&revision, "revision", revision, "get a previous `revision` of the template")
+	cmd.Flags().BoolVar(&history, "history", history, "list the previous revisions of the template")
+	output.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		l := applications.Lister{
+			API:            newApplicationsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
+		}
+
+		return l.ForEachNamedScenario(ctx, args, false, func(item *applications.ScenarioItem) error {
+			templateURL := item.Link(api.RelationTemplate)
+			if templateURL == "" {
+				return fmt.Errorf("malformed response, missing template link")
+			}
+
+			if !history && revision <= 0 {
+				template, err := l.API.GetTemplate(ctx, templateURL)
+				if err != nil {
+					return err
+				}
+				return p.Fprint(out, &template)
+			}
+
+			result, err := templateRevisions(ctx, l.API, templateURL, args[0])
+			if err != nil {
+				return err
+			}
+
+			if history {
+				return p.Fprint(out, result)
+			}
+
+			for i := range result.Items {
+				if result.Items[i].Revision == revision {
+					return p.Fprint(out, &result.Items[i].Template)
+				}
+			}
+			return fmt.Errorf("template revision not found: %d", revision)
+		})
+	}
+	return cmd
+}
+
+// NewEditTemplateCommand returns a command for replacing a scenario template.
+func NewEditTemplateCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		filename       string
+		preconditions  preconditionOptions
+		ignoreNotFound bool
+	)
+
+	cmd := &cobra.Command{
+		Use:               "template APP_NAME/SCENARIO_NAME",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validApplicationArgs(cfg),
+	}
+
+	cmd.Flags().StringVarP(&filename, "file", "f", filename, "`file` containing the new template")
+	preconditions.AddFlags(cmd)
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	_ = cmd.MarkFlagRequired("file")
+	_ = cmd.MarkFlagFilename("file", "yaml", "yml", "json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		data, err := readInputDocument(cmd, filename)
+		if err != nil {
+			return err
+		}
+
+		var template applications.Template
+		if err := yaml.Unmarshal(data, &template); err != nil {
+			return err
+		}
+
+		l := applications.Lister{
+			API:            newApplicationsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
+		}
+
+		return l.ForEachNamedScenario(ctx, args, false, func(item *applications.ScenarioItem) error {
+			templateURL := item.Link(api.RelationTemplate)
+			if templateURL == "" {
+				return fmt.Errorf("malformed response, missing template link")
+			}
+
+			// Keep a local copy of the current template in case the server does not track revisions
+			current, err := l.API.GetTemplate(ctx, templateURL)
+			if err != nil {
+				return err
+			}
+			if err := saveTemplateSnapshot(args[0], &current); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: Unable to save a snapshot of the current template: %v\n", err)
+			}
+
+			// Only replace the template we just saved
+			template.Metadata = preconditions.IfMatch(current.Metadata)
+			if err := l.API.UpdateTemplate(ctx, templateURL, template); err != nil {
+				return preconditions.Check(err)
+			}
+
+			return p.Fprint(out, item)
+		})
+	}
+	return cmd
+}
+
+// templateRevisions returns the revisions of a template, falling back to the
+// locally stored snapshots if the server does not expose revisions.
+func templateRevisions(ctx context.Context, appAPI applications.API, templateURL, name string) (*TemplateRevisionOutput, error) {
+	result := &TemplateRevisionOutput{}
+
+	lst, err := appAPI.ListTemplateRevisions(ctx, templateURL)
+	if err == nil {
+		for i := range lst.Revisions {
+			result.Items = append(result.Items, *NewTemplateRevisionRow(&lst.Revisions[i], "server"))
+		}
+		return result, nil
+	}
+
+	var notFoundErr *api.Error
+	if !errors.As(err, &notFoundErr) || notFoundErr.Type != applications.ErrTemplateRevisionsNotFound {
+		return nil, err
+	}
+
+	revs, err := loadTemplateSnapshots(name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revs {
+		result.Items = append(result.Items, *NewTemplateRevisionRow(&revs[i], "local"))
+	}
+	return result, nil
+}
+
+// templateSnapshotDir returns the directory used to store local snapshots of
+// the named scenario's template.
+func templateSnapshotDir(name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	appName, scnName := applications.SplitScenarioName(name)
+	return filepath.Join(dir, "stormforge", "templates", appName.String(), scnName.String()), nil
+}
+
+// loadTemplateSnapshots returns the locally stored snapshots ordered by revision.
+func loadTemplateSnapshots(name string) ([]applications.TemplateRevision, error) {
+	dir, err := templateSnapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var result []applications.TemplateRevision
+	for _, e := range entries {
+		if _, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json")); err != nil || e.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		rev := applications.TemplateRevision{}
+		if err := json.Unmarshal(data, &rev); err != nil {
+			return nil, err
+		}
+		result = append(result, rev)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Revision < result[j].Revision })
+	return result, nil
+}
+
+// saveTemplateSnapshot stores the template as the next local revision.
+func saveTemplateSnapshot(name string, template *applications.Template) error {
+	revs, err := loadTemplateSnapshots(name)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	rev := applications.TemplateRevision{Revision: 1, CreatedAt: &now, Template: *template}
+	if len(revs) > 0 {
+		rev.Revision = revs[len(revs)-1].Revision + 1
+	}
+
+	dir, err := templateSnapshotDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(&rev, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, strconv.Itoa(rev.Revision)+".json"), data, 0600)
+}
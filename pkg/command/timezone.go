@@ -0,0 +1,53 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	// outputTimeZone is the location used to render absolute times, nil to
+	// render times in the location they were received in.
+	outputTimeZone *time.Location
+	// absoluteTimeColumns are the names of the columns which should render an
+	// absolute time instead of a relative time.
+	absoluteTimeColumns = map[string]bool{}
+)
+
+// SetTimeZone changes the location used to render absolute times.
+func SetTimeZone(loc *time.Location) {
+	outputTimeZone = loc
+}
+
+// SetAbsoluteTimeColumns changes the timestamp columns (e.g. "last_deployed")
+// that are rendered as absolute times in tables, "all" applies to every column.
+func SetAbsoluteTimeColumns(columns ...string) {
+	absoluteTimeColumns = make(map[string]bool, len(columns))
+	for _, c := range columns {
+		absoluteTimeColumns[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(c)), " ", "_")] = true
+	}
+}
+
+// formatTimeColumn formats the time for display in the named table column.
+func formatTimeColumn(column string, t *time.Time) string {
+	if absoluteTimeColumns[column] || absoluteTimeColumns["all"] {
+		return formatTime(t, time.RFC3339)
+	}
+	return formatTime(t, "ago")
+}
@@ -17,19 +17,29 @@ limitations under the License.
 package command
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"sigs.k8s.io/yaml"
 )
 
 // NewCreateTrialCommand returns a command for creating a trial.
 func NewCreateTrialCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		assignments     map[string]string
+		assignmentsFile string
 		defaultBehavior string
+		labels          map[string]string
+		wait            bool
+		timeout         = 5 * time.Minute
+		output          outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -38,16 +48,31 @@ func NewCreateTrialCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringToStringVarP(&assignments, "assign", "A", nil, "assign an explicit `key=value` to a parameter")
-	cmd.Flags().StringVar(&defaultBehavior, "default", "", "select the `behavior` for default values; one of: none|min|max|rand")
+	cmd.Flags().StringVar(&assignmentsFile, "assignments-file", "", "`file` containing a JSON or YAML map of parameter assignments")
+	cmd.Flags().StringVar(&defaultBehavior, "default", "", "select the `behavior` for default values; one of: "+strings.Join(experiments.DefaultBehaviors, "|"))
+	cmd.Flags().StringToStringVar(&labels, "set-label", nil, "label `key=value` pairs to assign")
+	cmd.Flags().BoolVar(&wait, "wait", wait, "wait for the trial to be assigned a number")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "maximum `duration` to wait for the trial")
+	output.AddResultFlags(cmd, "create")
+	_ = cmd.MarkFlagFilename("assignments-file", "yaml", "yml", "json")
+
+	_ = cmd.RegisterFlagCompletionFunc("default", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return experiments.DefaultBehaviors, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
-		expAPI := experiments.NewAPI(client)
+		expAPI := newExperimentsAPI(cfg, client)
 
 		exp, err := expAPI.GetExperimentByName(ctx, experiments.ExperimentName(args[0]))
 		if err != nil {
@@ -59,25 +84,142 @@ func NewCreateTrialCommand(cfg Config, p Printer) *cobra.Command {
 			return fmt.Errorf("malformed response, missing trials link")
 		}
 
-		ta, err := experiments.NewTrialAssignments(&exp, assignments, nil, defaultBehavior)
+		if assignmentsFile != "" {
+			fileAssignments, err := readAssignmentsFile(cmd, &exp, assignmentsFile)
+			if err != nil {
+				return err
+			}
+
+			// Explicit assignments take precedence over the file
+			for k, v := range assignments {
+				fileAssignments[k] = v
+			}
+			assignments = fileAssignments
+		}
+
+		// The existing trials provide the baseline values and let us recognize the new trial
+		useBaselines := defaultBehavior == "base" || defaultBehavior == "baseline"
+		var baselines map[string]*api.NumberOrString
+		existing := make(map[int64]bool)
+		if wait || useBaselines {
+			tl, err := expAPI.GetAllTrials(ctx, trialsURL, experiments.TrialListQuery{})
+			if err != nil {
+				return err
+			}
+			for i := range tl.Trials {
+				existing[tl.Trials[i].Number] = true
+			}
+			baselines = tl.Baselines()
+			if useBaselines && baselines == nil {
+				return fmt.Errorf("experiment %q does not have a baseline trial", exp.Name)
+			}
+		}
+
+		ta, err := experiments.NewTrialAssignments(&exp, assignments, baselines, defaultBehavior)
 		if err != nil {
 			return err
 		}
+		if len(labels) > 0 {
+			ta.Labels = labels
+		}
 
-		if _, err := expAPI.CreateTrial(ctx, trialsURL, *ta); err != nil {
+		created, err := expAPI.CreateTrial(ctx, trialsURL, *ta)
+		if err != nil {
 			return err
 		}
 
 		// NOTE: The trial number will not exist until the assignments have been pulled from the queue
-		return p.Fprint(out, NewTrialRow(&experiments.TrialItem{Experiment: &exp, TrialAssignments: *ta}))
+		item := &experiments.TrialItem{Experiment: &exp, TrialAssignments: *ta}
+		item.TrialAssignments.Metadata = created.Metadata
+		switch num := created.PendingTrialNumber(); {
+		case num >= 0:
+			item.Number = num
+		case wait:
+			item, err = waitForTrial(ctx, expAPI, trialsURL, &created, ta, existing, timeout)
+			if err != nil {
+				return err
+			}
+			item.Experiment = &exp
+		case created.PendingTrial() != "" && !isQuiet(cfg):
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), MessageFormat(MessageTrialPending)+"\n", created.PendingTrial())
+		}
+
+		return p.Fprint(out, NewTrialRow(item))
 	}
 	return cmd
 }
 
+// waitForTrial polls the trials of an experiment until the created trial has
+// been assigned a number. The trial is recognized by the location returned when
+// it was created or as a new trial with the same assignments.
+func waitForTrial(ctx context.Context, expAPI experiments.API, u string, created, ta *experiments.TrialAssignments, existing map[int64]bool, timeout time.Duration) (*experiments.TrialItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	location := created.Location()
+	for {
+		tl, err := expAPI.GetAllTrials(ctx, u, experiments.TrialListQuery{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range tl.Trials {
+			t := &tl.Trials[i]
+			if t.Number <= 0 {
+				continue
+			}
+			if location != "" && t.Link(api.RelationSelf) == location {
+				return t, nil
+			}
+			if !existing[t.Number] && t.SameAssignments(ta) {
+				return t, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// readAssignmentsFile returns the parameter assignments from the named input,
+// names which do not match a parameter of the experiment are rejected.
+func readAssignmentsFile(cmd *cobra.Command, exp *experiments.Experiment, name string) (map[string]string, error) {
+	data, err := readInputDocument(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]api.NumberOrString)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid assignments in %s: %w", inputName(name), err)
+	}
+
+	known := make(map[string]bool, len(exp.Parameters))
+	for _, p := range exp.Parameters {
+		known[p.Name] = true
+	}
+
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if !known[k] {
+			return nil, fmt.Errorf("invalid assignments in %s: unknown parameter %q", inputName(name), k)
+		}
+		result[k] = v.String()
+	}
+	return result, nil
+}
+
 // NewEditTrialCommand returns a command for editing a trial.
 func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		labels map[string]string
+		labels         map[string]string
+		ignoreNotFound bool
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -87,16 +229,24 @@ func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().StringToStringVar(&labels, "set-label", nil, "label `key=value` pairs to assign")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	output.AddFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API:            newExperimentsAPI(cfg, client),
+			IgnoreNotFound: ignoreNotFound,
 		}
 
 		q := experiments.TrialListQuery{}
@@ -121,12 +271,65 @@ func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 	return cmd
 }
 
+// NewRetryTrialCommand returns a command for retrying a failed trial.
+func NewRetryTrialCommand(cfg Config, p Printer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "trial EXP_NAME/TRIAL_NUM",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validTrialArgs(cfg),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		if _, num := experiments.SplitTrialName(args[0]); num < 0 {
+			return fmt.Errorf("missing trial number: %s", args[0])
+		}
+
+		l := experiments.Lister{
+			API: newExperimentsAPI(cfg, client),
+		}
+
+		q := experiments.TrialListQuery{}
+		q.SetStatus(experiments.TrialFailed)
+		return l.ForEachNamedTrial(ctx, args, q, false, func(item *experiments.TrialItem) error {
+			trialsURL := item.Experiment.Link(api.RelationTrials)
+			if trialsURL == "" {
+				return fmt.Errorf("malformed response, missing trials link")
+			}
+
+			ta := experiments.TrialAssignments{
+				Assignments: item.Assignments,
+				Labels:      map[string]string{"retry-of": strconv.FormatInt(item.Number, 10)},
+			}
+
+			if _, err := l.API.CreateTrial(ctx, trialsURL, ta); err != nil {
+				return err
+			}
+
+			// NOTE: The trial number will not exist until the assignments have been pulled from the queue
+			return p.Fprint(out, NewTrialRow(&experiments.TrialItem{Experiment: item.Experiment, TrialAssignments: ta}))
+		})
+	}
+	return cmd
+}
+
 // NewGetTrialsCommand returns a command for getting trials.
 func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		selector string
 		all      bool
 		sortBy   string
+		output   outputOptions
+		watch    watchOptions
+		format   NumberFormat
+
+		continueOnError bool
+		ignoreNotFound  bool
 	)
 
 	cmd := &cobra.Command{
@@ -139,19 +342,34 @@ func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "selector (label `query`) to filter on")
 	cmd.Flags().BoolVarP(&all, "all", "A", all, "include all resources")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	output.AddFlags(cmd, "manifests")
+	watch.AddFlags(cmd)
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", continueOnError, "process all names before reporting errors")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "skip names which do not exist instead of failing")
+	cmd.Flags().IntVar(&format.Precision, "precision", format.Precision, "round numeric values to the specified number of significant `digits`")
+	cmd.Flags().BoolVar(&format.NormalizeQuantities, "normalize-units", format.NormalizeQuantities, "render quantity values (e.g. 500m or 1Gi) as plain numbers")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API:             newExperimentsAPI(cfg, client),
+			ContinueOnError: continueOnError,
+			IgnoreNotFound:  ignoreNotFound,
 		}
 
-		result := &TrialOutput{Items: make([]TrialRow, 0, len(args))}
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		if watch.Watch && output.Format == "manifests" {
+			return fmt.Errorf("--watch is not supported with manifests output")
+		}
 
 		q := experiments.TrialListQuery{}
 		q.SetLabelSelector(parseLabelSelector(selector))
@@ -160,23 +378,71 @@ func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 			q.AddStatus(experiments.TrialStaged)
 		}
 
-		if err := l.ForEachNamedTrial(ctx, args, q, false, result.Add); err != nil {
-			return err
+		list := func(ctx context.Context) (*TrialOutput, error) {
+			result := &TrialOutput{Items: make([]TrialRow, 0, len(args)), Format: format}
+
+			var partial partialResult
+			if err := partial.Check(l.ForEachNamedTrial(ctx, args, q, false, result.Add)); err != nil {
+				return nil, err
+			}
+
+			if err := result.SortBy(sortBy); err != nil {
+				return nil, err
+			}
+			return result, partial.Err()
 		}
 
-		if err := result.SortBy(sortBy); err != nil {
-			return err
+		if output.Format == "manifests" {
+			result, namedErr := list(ctx)
+			if result == nil {
+				return namedErr
+			}
+			if err := printTrialManifests(out, cmd.ErrOrStderr(), result); err != nil {
+				return err
+			}
+			return namedErr
 		}
 
-		return p.Fprint(out, result)
+		return watch.Run(ctx, out, p, func(ctx context.Context) (Output, error) {
+			result, err := list(ctx)
+			if result == nil {
+				return nil, err
+			}
+			return result, err
+		})
 	}
 	return cmd
 }
 
+// printTrialManifests renders the assignments of each trial as a stream of
+// Kubernetes patch documents which can be used to manually reproduce the trial.
+func printTrialManifests(out, errOut io.Writer, result *TrialOutput) error {
+	for i := range result.Items {
+		patches, unmatched := experiments.KubernetesPatches(&result.Items[i].TrialAssignments)
+		for _, name := range unmatched {
+			_, _ = fmt.Fprintf(errOut, "WARNING: Unable to generate a patch for parameter %q of trial %s\n", name, result.Items[i].Name)
+		}
+
+		for _, patch := range patches {
+			data, err := yaml.Marshal(patch)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(out, "# Trial %s\n%s---\n", result.Items[i].Name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // NewDeleteTrialsCommand returns a command for deleting ("abandoning") trials.
 func NewDeleteTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		ignoreNotFound bool
+		reason         string
+		concurrency    concurrencyOptions
+		output         outputOptions
 	)
 
 	cmd := &cobra.Command{
@@ -186,32 +452,60 @@ func NewDeleteTrialsCommand(cfg Config, p Printer) *cobra.Command {
 		ValidArgsFunction: validTrialArgs(cfg),
 	}
 
+	cmd.Flags().StringVar(&reason, "reason", reason, "the `message` explaining why the trial was abandoned")
+	concurrency.AddFlags(cmd)
+	output.AddResultFlags(cmd, "delete")
+
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API:             newExperimentsAPI(cfg, client),
+			ContinueOnError: concurrency.ContinueOnError,
 		}
 
+		// Resolve the trials up front, the lister loads all trials of each experiment at once
+		var items []*experiments.TrialItem
+		var names []string
 		q := experiments.TrialListQuery{}
 		q.SetStatus(experiments.TrialActive)
-		return l.ForEachNamedTrial(ctx, args, q, ignoreNotFound, func(item *experiments.TrialItem) error {
+		var partial partialResult
+		if err := partial.Check(l.ForEachNamedTrial(ctx, args, q, ignoreNotFound, func(item *experiments.TrialItem) error {
+			items = append(items, item)
+			names = append(names, experiments.JoinTrialName(item.Experiment, item.Number))
+			return nil
+		})); err != nil {
+			return err
+		}
+
+		p = &syncPrinter{p: p}
+		concurrency.Quiet = isQuiet(cfg)
+		if err := concurrency.Run(ctx, cmd.ErrOrStderr(), names, func(ctx context.Context, i int) error {
+			item := items[i]
 			selfURL := item.Link(api.RelationSelf)
 			if selfURL == "" {
 				return fmt.Errorf("malformed response, missing self link")
 			}
 
-			err = l.API.AbandonRunningTrial(ctx, selfURL)
-			if err != nil {
+			if err := l.API.AbandonRunningTrialWithReason(ctx, selfURL, reason); err != nil {
 				return err
 			}
+			item.AbandonedReason = reason
 
 			return p.Fprint(out, NewTrialRow(item))
-		})
+		}); err != nil {
+			return err
+		}
+		return partial.Err()
 	}
 	return cmd
 }
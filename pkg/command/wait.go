@@ -0,0 +1,152 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+// NewWaitBackfillCommand returns a command for waiting until the recommendation
+// backfill of an application covers the requested amount of history.
+func NewWaitBackfillCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		until    = lookbackDuration(7 * 24 * time.Hour)
+		interval = time.Minute
+		timeout  time.Duration
+		output   outputOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:               "backfill APP",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validApplicationArgs(cfg),
+	}
+
+	cmd.Flags().Var(&until, "until", "the `duration` of backfilled history to wait for (e.g. \"7d\")")
+	cmd.Flags().DurationVar(&interval, "interval", interval, "the `duration` between checks of the backfill progress")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "maximum `duration` to wait, zero to wait indefinitely")
+	output.AddNameFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		p, err := output.Printer(cfg, p)
+		if err != nil {
+			return err
+		}
+
+		l := applications.Lister{
+			API: newApplicationsAPI(cfg, client),
+		}
+
+		return l.ForEachNamedApplication(ctx, args, false, func(item *applications.ApplicationItem) error {
+			u := item.Link(api.RelationRecommendations)
+			if u == "" || item.Recommendations == applications.RecommendationsDisabled {
+				return fmt.Errorf("application %q does not have recommendations enabled", item.Name)
+			}
+
+			window, err := waitForBackfill(ctx, l.API, u, time.Duration(until), interval, timeout)
+			if err != nil {
+				return fmt.Errorf("waiting for backfill of application %q (%s of %s complete): %w", item.Name, window.Truncate(time.Minute), until.String(), err)
+			}
+
+			return p.Fprint(out, NewApplicationRow(item))
+		})
+	}
+	return cmd
+}
+
+// waitForBackfill polls the recommendations until the backfill progress covers
+// the requested window, the last observed window is returned.
+func waitForBackfill(ctx context.Context, appAPI applications.API, u string, until, interval, timeout time.Duration) (time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rl, err := appAPI.ListRecommendations(ctx, u)
+		if err != nil {
+			return 0, err
+		}
+
+		window := rl.BackfillProgress.Window(time.Now())
+		if window >= until {
+			return window, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return window, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lookbackDuration is a flag value for durations which also accepts day ("d")
+// and week ("w") units.
+type lookbackDuration time.Duration
+
+var _ pflag.Value = new(lookbackDuration)
+
+// String returns the duration.
+func (d *lookbackDuration) String() string {
+	return time.Duration(*d).String()
+}
+
+// Set parses the duration.
+func (d *lookbackDuration) Set(s string) error {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", s)
+			}
+			*d = lookbackDuration(v * float64(unit))
+			return nil
+		}
+	}
+
+	td, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = lookbackDuration(td)
+	return nil
+}
+
+// Type returns the name of the flag type.
+func (d *lookbackDuration) Type() string {
+	return "duration"
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// watchOptions holds the flags used to watch the output of a get command.
+type watchOptions struct {
+	// Keep polling for changes after the initial output.
+	Watch bool
+	// The amount of time to wait between polls.
+	Interval time.Duration
+}
+
+// AddFlags registers the watch flags on the supplied command.
+func (o *watchOptions) AddFlags(cmd *cobra.Command) {
+	if o.Interval == 0 {
+		o.Interval = 10 * time.Second
+	}
+
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", o.Watch, "after the initial output, watch for changes")
+	cmd.Flags().DurationVar(&o.Interval, "watch-interval", o.Interval, "polling `interval` used to watch for changes")
+}
+
+// Run prints the output produced by the list function. When watching, the list
+// is polled until the context is done and only new or changed rows are printed.
+// The list function may return an output along with an error, in which case
+// the output is printed before the error is returned.
+func (o *watchOptions) Run(ctx context.Context, out io.Writer, p Printer, list func(context.Context) (Output, error)) error {
+	result, err := list(ctx)
+	if result != nil {
+		if err := p.Fprint(out, result); err != nil {
+			return err
+		}
+	}
+	if err != nil || !o.Watch {
+		return err
+	}
+
+	seen := make(map[string]string)
+	changedRows(seen, result)
+
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		result, err := list(ctx)
+		if result != nil {
+			if changed := changedRows(seen, result); len(changed) > 0 {
+				if err := p.Fprint(out, changed); err != nil {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// changedRows returns the rows of the output which are not present in the
+// supplied snapshot (or have changed since), the snapshot is updated to
+// include every row of the output.
+func changedRows(seen map[string]string, o Output) rowsOutput {
+	var changed rowsOutput
+	for i := 0; i < o.Len(); i++ {
+		row := o.Item(i)
+		data, err := json.Marshal(row)
+		if err != nil {
+			// Rows that cannot be compared are always considered changed
+			changed = append(changed, row)
+			continue
+		}
+
+		key := rowKey(row, string(data))
+		if seen[key] != string(data) {
+			seen[key] = string(data)
+			changed = append(changed, row)
+		}
+	}
+	return changed
+}
+
+// rowKey returns the identity of a row, falling back to the supplied
+// representation of the row if it does not have a name.
+func rowKey(row Row, fallback string) string {
+	if r, err := rowResult(row); err == nil {
+		return r.Type + "/" + r.Name
+	}
+	if r, ok := row.(*ActivityRow); ok && r.ID != "" {
+		return "activity/" + r.ID
+	}
+	return fallback
+}
+
+// rowsOutput is an output consisting of a subset of rows from another output.
+type rowsOutput []Row
+
+// Len returns the number of items being output.
+func (o rowsOutput) Len() int { return len(o) }
+
+// Swap exchanges the order of the two specified items.
+func (o rowsOutput) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+
+// Item returns the specified row value.
+func (o rowsOutput) Item(i int) Row { return o[i] }
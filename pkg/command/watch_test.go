@@ -0,0 +1,101 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+func TestChangedRows(t *testing.T) {
+	seen := make(map[string]string)
+
+	changed := changedRows(seen, &ClusterOutput{Items: []ClusterRow{
+		{Name: "east", DisplayName: "East"},
+		{Name: "west", DisplayName: "West"},
+	}})
+	assert.Len(t, changed, 2)
+
+	changed = changedRows(seen, &ClusterOutput{Items: []ClusterRow{
+		{Name: "east", DisplayName: "East"},
+		{Name: "west", DisplayName: "West Coast"},
+		{Name: "north", DisplayName: "North"},
+	}})
+	if assert.Len(t, changed, 2) {
+		assert.Equal(t, "West Coast", changed.Item(0).(*ClusterRow).DisplayName)
+		assert.Equal(t, "north", changed.Item(1).(*ClusterRow).Name)
+	}
+
+	changed = changedRows(seen, &ClusterOutput{Items: []ClusterRow{
+		{Name: "east", DisplayName: "East"},
+	}})
+	assert.Empty(t, changed)
+}
+
+func TestRowKey(t *testing.T) {
+	assert.Equal(t, "cluster/east", rowKey(&ClusterRow{Name: "east"}, "{}"))
+	assert.Equal(t, "activity/1", rowKey(&ActivityRow{ID: "1"}, "{}"))
+	assert.Equal(t, "{}", rowKey(&ActivityRow{}, "{}"))
+}
+
+func TestWatchOptions_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polls := [][]string{
+		{"east"},
+		{"east"},
+		{"east", "west"},
+	}
+	list := func(context.Context) (Output, error) {
+		names := polls[0]
+		if len(polls) > 1 {
+			polls = polls[1:]
+		} else {
+			cancel()
+		}
+
+		o := &ClusterOutput{}
+		for _, name := range names {
+			_ = o.Add(&applications.ClusterItem{Cluster: applications.Cluster{Name: applications.ClusterName(name)}})
+		}
+		return o, nil
+	}
+
+	o := watchOptions{Watch: true, Interval: time.Millisecond}
+	var out strings.Builder
+	if assert.NoError(t, o.Run(ctx, &out, &namePrinter{}, list)) {
+		assert.Equal(t, "cluster/east\ncluster/west\n", out.String())
+	}
+}
+
+func TestWatchOptions_Run_error(t *testing.T) {
+	list := func(context.Context) (Output, error) {
+		return &ClusterOutput{Items: []ClusterRow{{Name: "east"}}}, errors.New("partial")
+	}
+
+	o := watchOptions{Watch: true, Interval: time.Millisecond}
+	var out strings.Builder
+	assert.EqualError(t, o.Run(context.Background(), &out, &namePrinter{}, list), "partial")
+	assert.Equal(t, "cluster/east\n", out.String())
+}
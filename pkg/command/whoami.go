@@ -18,15 +18,12 @@ package command
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 	"text/template"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 	"gopkg.in/go-jose/go-jose.v2/jwt"
-	"sigs.k8s.io/yaml"
 )
 
 // NewWhoAmICommand returns a command for determining the current identity associated
@@ -78,21 +75,7 @@ func NewWhoAmICommand(cfg Config) *cobra.Command {
 		}
 
 		// Send it through a template
-		tmpl, err := template.New("out").
-			Funcs(map[string]interface{}{
-				"toJson": func(v interface{}) (string, error) {
-					var buf strings.Builder
-					enc := json.NewEncoder(&buf)
-					enc.SetIndent("", "  ")
-					err := enc.Encode(v)
-					return buf.String(), err
-				},
-				"toYaml": func(v interface{}) (string, error) {
-					data, err := yaml.Marshal(v)
-					return string(data), err
-				},
-			}).
-			Parse(pattern)
+		tmpl, err := template.New("out").Funcs(templateFuncs).Parse(pattern)
 		if err != nil {
 			return err
 		}
@@ -104,9 +87,7 @@ func NewWhoAmICommand(cfg Config) *cobra.Command {
 // token returns an access token obtained using the supplied configuration.
 func token(ctx context.Context, cfg Config) (*oauth2.Token, error) {
 	// Check that the configuration can produce a token source
-	tcfg, ok := cfg.(interface {
-		TokenSource(ctx2 context.Context) oauth2.TokenSource
-	})
+	tcfg, ok := cfg.(TokenSourceConfig)
 	if !ok {
 		return nil, fmt.Errorf("unable to obtain token to ascertain identity")
 	}
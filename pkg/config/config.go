@@ -18,14 +18,23 @@ package config
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/thestormforge/optimize-go/pkg/oauth2/cloudidentity"
+	"github.com/thestormforge/optimize-go/pkg/oauth2/tokencache"
+	"github.com/thestormforge/optimize-go/pkg/oauth2/tokenexchange"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
@@ -42,16 +51,50 @@ type Config struct {
 	ClientID string `json:"client_id,omitempty" yaml:"client_id,omitempty" env:"STORMFORGE_CLIENT_ID"`
 	// The client secret used to obtain tokens via a client credentials grant.
 	ClientSecret string `json:"client_secret,omitempty" yaml:"client_secret,omitempty" env:"STORMFORGE_CLIENT_SECRET"`
+	// The path to a file containing an externally issued token (e.g. a projected
+	// Kubernetes service account token) which is exchanged for an access token.
+	// The client ID, if specified, is included with the exchange.
+	FederatedTokenFile string `json:"federated_token_file,omitempty" yaml:"federated_token_file,omitempty" env:"STORMFORGE_FEDERATED_TOKEN_FILE"`
+	// The cloud provider whose instance identity is exchanged for an access
+	// token; one of: aws|gcp. The client ID, if specified, is included with the exchange.
+	CloudIdentity string `json:"cloud_identity,omitempty" yaml:"cloud_identity,omitempty" env:"STORMFORGE_CLOUD_IDENTITY"`
 	// The list of scopes to request during token exchanges.
 	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
 	// Additional parameters to be included with the token request.
 	AuthorizationParams url.Values `json:"params,omitempty" yaml:"params,omitempty"`
+	// Additional token audiences keyed by the URL prefix of the requests they
+	// authorize. Requests matching a prefix are sent with a token obtained for
+	// that audience instead of the server audience. The audiences of overridden
+	// endpoints may be set using the corresponding `STORMFORGE_APPLICATIONS_AUDIENCE`
+	// or `STORMFORGE_EXPERIMENTS_AUDIENCE`.
+	Audiences map[string]string `json:"audiences,omitempty" yaml:"audiences,omitempty"`
+	// Alternate locations of individual API services, requests to overridden
+	// endpoints are authorized the same as requests to the server.
+	Endpoints Endpoints `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
 	// A hard-coded bearer token for debugging, the token will not be refreshed
 	// so the caller is responsible for providing a valid token.
 	Token string `json:"token,omitempty" yaml:"token,omitempty" env:"STORMFORGE_TOKEN"`
+	// The directory used to cache tokens obtained via a client credentials grant
+	// between invocations, tokens are encrypted using a key derived from the
+	// client secret. Caching is disabled if the directory is not specified.
+	TokenCacheDir string `json:"token_cache_dir,omitempty" yaml:"token_cache_dir,omitempty" env:"STORMFORGE_TOKEN_CACHE_DIR"`
+	// The amount of time before expiry that tokens are refreshed, increase this
+	// to tolerate a local clock which is behind the authorization server.
+	TokenLeeway time.Duration `json:"token_leeway,omitempty" yaml:"token_leeway,omitempty" env:"STORMFORGE_TOKEN_LEEWAY"`
+	// The identifier (e.g. email address) of a user to act on behalf of. The
+	// configured credentials are used as the actor of a delegation exchange
+	// whose subject is the specified user.
+	Impersonate string `json:"impersonate,omitempty" yaml:"impersonate,omitempty" env:"STORMFORGE_IMPERSONATE"`
+	// Reject any API request which could change state (i.e. anything other than
+	// a GET or HEAD) before it is sent, useful when credentials are shared with
+	// dashboards or reporting jobs.
+	ReadOnly bool `json:"read_only,omitempty" yaml:"read_only,omitempty" env:"STORMFORGE_READ_ONLY"`
 	// Hook invoked when an authorized error occurs retrieving a token. May only
 	// be invoked on a sample of errors if they are occurring rapidly.
 	UnauthorizedFunc func(error) `json:"-" yaml:"-"`
+
+	// The file the configuration was loaded from, if any.
+	file *configFile
 }
 
 // Address returns the API server address. The canonical value will be slash-terminated,
@@ -60,6 +103,108 @@ func (cfg *Config) Address() string {
 	return cfg.Server
 }
 
+// IssuerAddress returns the API authorization server address.
+func (cfg *Config) IssuerAddress() string {
+	return cfg.Issuer
+}
+
+// APIEndpoints returns the overridden API service locations.
+func (cfg *Config) APIEndpoints() Endpoints {
+	return cfg.Endpoints
+}
+
+// IsReadOnly returns true if the API client should not make changes.
+func (cfg *Config) IsReadOnly() bool {
+	return cfg.ReadOnly
+}
+
+// ResponseCacheFile returns the file used to persist API responses between
+// invocations. Each combination of server, audiences and credentials uses a
+// separate file; the empty string is returned if there is no cache directory.
+func (cfg *Config) ResponseCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	audiences := cfg.audiences()
+	prefixes := make([]string, 0, len(audiences))
+	for prefix := range audiences {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	h := sha256.New()
+	for _, s := range []string{cfg.Server, cfg.ClientID, cfg.Token, cfg.Impersonate} {
+		_, _ = h.Write([]byte(s + "\x00"))
+	}
+	for _, prefix := range prefixes {
+		_, _ = h.Write([]byte(prefix + "\x00" + audiences[prefix] + "\x00"))
+	}
+	return filepath.Join(dir, "stormforge", "responses", hex.EncodeToString(h.Sum(nil)[:16])+".json")
+}
+
+// Endpoints are the alternate locations of individual API services, typically
+// used when testing services which are not deployed behind the API server.
+// Empty values use the default location relative to the server address.
+type Endpoints struct {
+	// The applications endpoint.
+	Applications string `json:"applications,omitempty" yaml:"applications,omitempty" env:"STORMFORGE_APPLICATIONS_ENDPOINT"`
+	// The experiments endpoint.
+	Experiments string `json:"experiments,omitempty" yaml:"experiments,omitempty" env:"STORMFORGE_EXPERIMENTS_ENDPOINT"`
+	// The clusters endpoint, defaults to the location relative to the applications endpoint.
+	Clusters string `json:"clusters,omitempty" yaml:"clusters,omitempty" env:"STORMFORGE_CLUSTERS_ENDPOINT"`
+	// The application activity feed, defaults to the feed advertised by the applications endpoint.
+	Activity string `json:"activity,omitempty" yaml:"activity,omitempty" env:"STORMFORGE_ACTIVITY_ENDPOINT"`
+	// The performance test cases endpoint, defaults to the location relative to the applications endpoint.
+	TestCases string `json:"test_cases,omitempty" yaml:"test_cases,omitempty" env:"STORMFORGE_TEST_CASES_ENDPOINT"`
+	// The metrics remote write endpoint.
+	RemoteWrite string `json:"remote_write,omitempty" yaml:"remote_write,omitempty" env:"STORMFORGE_REMOTE_WRITE_ENDPOINT"`
+}
+
+// Validate checks that the overridden endpoints are valid URLs.
+func (ep *Endpoints) Validate() error {
+	for name, endpoint := range map[string]string{
+		"applications": ep.Applications,
+		"experiments":  ep.Experiments,
+		"clusters":     ep.Clusters,
+		"activity":     ep.Activity,
+		"test cases":   ep.TestCases,
+		"remote write": ep.RemoteWrite,
+	} {
+		if _, err := url.Parse(endpoint); err != nil {
+			return fmt.Errorf("invalid %s endpoint: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applicationsPrefixes returns the URL prefixes served by the application
+// service, which may be overridden independently of the server address.
+func (ep *Endpoints) applicationsPrefixes() []string {
+	var prefixes []string
+	if ep.Applications != "" {
+		prefixes = applicationsPrefixes(ep.Applications)
+	}
+	for _, endpoint := range []string{ep.Clusters, ep.Activity, ep.TestCases} {
+		if endpoint != "" {
+			prefixes = append(prefixes, endpoint)
+		}
+	}
+	return prefixes
+}
+
+// prefixes returns the URL prefixes of all the overridden endpoints.
+func (ep *Endpoints) prefixes() []string {
+	prefixes := ep.applicationsPrefixes()
+	for _, endpoint := range []string{ep.Experiments, ep.RemoteWrite} {
+		if endpoint != "" {
+			prefixes = append(prefixes, endpoint)
+		}
+	}
+	return prefixes
+}
+
 // tokenURL computes a token endpoint URL based on the configured issuer. This
 // assumes "oauth/token" as opposed to the sometimes seen "oauth2/token" path
 // convention.
@@ -85,8 +230,33 @@ func (cfg *Config) Transport(tokenSource oauth2.TokenSource, base http.RoundTrip
 			Source: tokenSource,
 			Base:   base,
 		},
-		Audience: cfg.Server,
+		Audience:    cfg.Server,
+		Prefixes:    cfg.Endpoints.prefixes(),
+		Audiences:   cfg.audiences(),
+		TokenSource: cfg.AudienceTokenSource,
+	}
+}
+
+// audiences returns the effective mapping of URL prefixes to audiences.
+func (cfg *Config) audiences() map[string]string {
+	result := make(map[string]string, len(cfg.Audiences))
+	for prefix, audience := range cfg.Audiences {
+		result[prefix] = audience
+	}
+
+	if audience := os.Getenv("STORMFORGE_APPLICATIONS_AUDIENCE"); audience != "" {
+		for _, prefix := range cfg.Endpoints.applicationsPrefixes() {
+			result[prefix] = audience
+		}
+	}
+
+	if audience := os.Getenv("STORMFORGE_EXPERIMENTS_AUDIENCE"); audience != "" {
+		if endpoint := cfg.Endpoints.Experiments; endpoint != "" {
+			result[endpoint] = audience
+		}
 	}
+
+	return result
 }
 
 // TokenSource returns a new source for obtaining tokens. The token source may be
@@ -97,6 +267,12 @@ func (cfg *Config) Transport(tokenSource oauth2.TokenSource, base http.RoundTrip
 // concurrent use; therefore it is STRONGLY recommended that this function only
 // be called once during the lifetime of a program.
 func (cfg *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return cfg.AudienceTokenSource(ctx, cfg.Server)
+}
+
+// AudienceTokenSource returns a new source for obtaining tokens for a specific
+// audience. The same caveats as `TokenSource` apply.
+func (cfg *Config) AudienceTokenSource(ctx context.Context, audience string) oauth2.TokenSource {
 	var result oauth2.TokenSource
 	switch {
 
@@ -105,6 +281,52 @@ func (cfg *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 			AccessToken: cfg.Token,
 		})
 
+	case cfg.FederatedTokenFile != "":
+		tokenURL, err := cfg.tokenURL()
+		if err != nil {
+			return &errorTokenSource{err: err}
+		}
+
+		tx := tokenexchange.Config{
+			TokenURL:       tokenURL,
+			ClientID:       cfg.ClientID,
+			Audience:       audience,
+			Scopes:         cfg.Scopes,
+			EndpointParams: cfg.AuthorizationParams,
+			ExpiryDelta:    cfg.TokenLeeway,
+		}
+
+		result = tx.TokenSource(ctx, tokenexchange.FileSubjectToken(cfg.FederatedTokenFile))
+
+	case cfg.CloudIdentity != "":
+		tokenURL, err := cfg.tokenURL()
+		if err != nil {
+			return &errorTokenSource{err: err}
+		}
+
+		tx := tokenexchange.Config{
+			TokenURL:       tokenURL,
+			ClientID:       cfg.ClientID,
+			Audience:       audience,
+			Scopes:         cfg.Scopes,
+			EndpointParams: cfg.AuthorizationParams,
+			ExpiryDelta:    cfg.TokenLeeway,
+		}
+
+		var subject tokenexchange.SubjectTokenSource
+		switch strings.ToLower(cfg.CloudIdentity) {
+		case "aws":
+			tx.SubjectTokenType = cloudidentity.AWSSubjectTokenType
+			subject = &cloudidentity.AWS{Audience: audience}
+		case "gcp":
+			tx.SubjectTokenType = cloudidentity.GCPSubjectTokenType
+			subject = &cloudidentity.GCP{Audience: audience}
+		default:
+			return &errorTokenSource{err: fmt.Errorf("unknown cloud identity provider: %s", cfg.CloudIdentity)}
+		}
+
+		result = tx.TokenSource(ctx, subject)
+
 	case cfg.ClientID != "":
 		tokenURL, err := cfg.tokenURL()
 		if err != nil {
@@ -123,10 +345,24 @@ func (cfg *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 		if cc.EndpointParams == nil {
 			cc.EndpointParams = url.Values{}
 		}
-		cc.EndpointParams.Set("audience", cfg.Server)
+		cc.EndpointParams.Set("audience", audience)
+
+		// Reuse tokens ourselves so they are refreshed early to tolerate clock skew
+		src := tokenSourceFunc(func() (*oauth2.Token, error) { return cc.Token(ctx) })
+		result = oauth2.ReuseTokenSourceWithExpiry(nil, src, cfg.TokenLeeway)
+		if cfg.TokenCacheDir != "" {
+			// The cache takes over reuse of tokens so they can be invalidated
+			cache := tokencache.NewFileCache(cfg.TokenCacheDir, audience, cfg.ClientID, cfg.ClientSecret)
+			cts := tokencache.TokenSource(cache, src)
+			cts.Leeway = cfg.TokenLeeway
+			result = cts
+		}
 
-		result = cc.TokenSource(ctx)
+	}
 
+	// Exchange the credentials for a token issued on behalf of another user
+	if result != nil && cfg.Impersonate != "" {
+		result = cfg.impersonationTokenSource(ctx, audience, result)
 	}
 
 	// Allow consumers to hook unauthorized errors occurring during authorization
@@ -137,6 +373,33 @@ func (cfg *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 	return result
 }
 
+// impersonationSubjectTokenType is the token type used when the subject of a
+// delegation exchange is identified by the user's email address.
+const impersonationSubjectTokenType = "urn:stormforge:params:oauth:token-type:email"
+
+// impersonationTokenSource returns a token source which performs a delegation
+// exchange using tokens from the supplied actor as the actor token and the
+// impersonated user as the subject.
+func (cfg *Config) impersonationTokenSource(ctx context.Context, audience string, actor oauth2.TokenSource) oauth2.TokenSource {
+	tokenURL, err := cfg.tokenURL()
+	if err != nil {
+		return &errorTokenSource{err: err}
+	}
+
+	tx := tokenexchange.Config{
+		TokenURL:         tokenURL,
+		ClientID:         cfg.ClientID,
+		Audience:         audience,
+		Scopes:           cfg.Scopes,
+		SubjectTokenType: impersonationSubjectTokenType,
+		Actor:            tokenexchange.AccessTokenSubject(actor),
+		EndpointParams:   cfg.AuthorizationParams,
+		ExpiryDelta:      cfg.TokenLeeway,
+	}
+
+	return tx.TokenSource(ctx, tokenexchange.StaticSubjectToken(cfg.Impersonate))
+}
+
 // unauthorizedHookTokenSource is a token source that allows a hook function to
 // invoked if retrieving a token fails with an unauthorized error. This is
 // intended to give consumers an avenue for gracefully shutting down: because
@@ -147,6 +410,11 @@ type unauthorizedHookTokenSource struct {
 	hook func(error)
 }
 
+// Invalidate discards any cached token held by the wrapped source.
+func (ts *unauthorizedHookTokenSource) Invalidate() {
+	invalidate(ts.src)
+}
+
 // Token retrieves a token from the wrapped source. If an OAuth2 error is returned
 // with a 401 status, the program exits with a status of 77 (EX_NOPERM).
 func (ts *unauthorizedHookTokenSource) Token() (*oauth2.Token, error) {
@@ -170,12 +438,36 @@ type transport struct {
 	oauth2.Transport
 	// The audience used to filter request URLs.
 	Audience string
+	// Additional URL prefixes which are authorized using the audience.
+	Prefixes []string
+	// Additional audiences keyed by the URL prefix of the requests they authorize.
+	Audiences map[string]string
+	// Function used to create token sources for the additional audiences.
+	TokenSource func(context.Context, string) oauth2.TokenSource
+
+	mu     sync.Mutex
+	scoped map[string]oauth2.TokenSource
 }
 
 // RoundTrip ensures the audience value matches the request before adding tokens.
 func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Transport.Source != nil {
+		if audience := t.scopedAudience(req.URL); audience != "" {
+			rt := &oauth2.Transport{Source: t.scopedTokenSource(req.Context(), audience), Base: t.Base}
+			resp, err := rt.RoundTrip(req)
+			if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+				invalidate(rt.Source)
+			}
+			return resp, err
+		}
+	}
+
 	if t.Transport.Source != nil && t.requiresAuthorization(req.URL) {
-		return t.Transport.RoundTrip(req)
+		resp, err := t.Transport.RoundTrip(req)
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			invalidate(t.Transport.Source)
+		}
+		return resp, err
 	}
 
 	if t.Base != nil {
@@ -185,6 +477,40 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return http.DefaultTransport.RoundTrip(req)
 }
 
+// scopedAudience returns the additional audience with the longest URL prefix
+// matching the supplied URL, or an empty string.
+func (t *transport) scopedAudience(u *url.URL) string {
+	if t.TokenSource == nil {
+		return ""
+	}
+
+	var match, audience string
+	for prefix, aud := range t.Audiences {
+		if len(prefix) > len(match) && strings.HasPrefix(u.String(), prefix) {
+			match, audience = prefix, aud
+		}
+	}
+	return audience
+}
+
+// scopedTokenSource returns the cached token source for an additional audience.
+func (t *transport) scopedTokenSource(ctx context.Context, audience string) oauth2.TokenSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ts, ok := t.scoped[audience]; ok {
+		return ts
+	}
+
+	if t.scoped == nil {
+		t.scoped = make(map[string]oauth2.TokenSource)
+	}
+	// The token source outlives the request, only retain the context values
+	ts := t.TokenSource(context.WithoutCancel(ctx), audience)
+	t.scoped[audience] = ts
+	return ts
+}
+
 // requiresAuthorization tests the supplied URL to see if it matches the
 // effective audience.
 func (t *transport) requiresAuthorization(u *url.URL) bool {
@@ -193,35 +519,46 @@ func (t *transport) requiresAuthorization(u *url.URL) bool {
 		return true
 	}
 
-	// Support an alternate audience for testing the application service
-	if endpoint := os.Getenv("STORMFORGE_APPLICATIONS_ENDPOINT"); endpoint != "" {
-		if strings.HasPrefix(u.String(), endpoint) {
+	// Support alternate endpoints for testing individual services
+	for _, prefix := range t.Prefixes {
+		if strings.HasPrefix(u.String(), prefix) {
 			return true
 		}
-
-		// Special case other resources directly under /v2/
-		if c, err := url.Parse(endpoint); err == nil {
-			c.Path = path.Join(c.Path, "..", "clusters")
-			if strings.HasPrefix(u.String(), c.String()) {
-				return true
-			}
-			c.Path = path.Join(c.Path, "..", "application-activity")
-			if strings.HasPrefix(u.String(), c.String()) {
-				return true
-			}
-		}
 	}
 
-	// Support an alternate audience for testing the experiment service
-	if endpoint := os.Getenv("STORMFORGE_EXPERIMENTS_ENDPOINT"); endpoint != "" {
-		if strings.HasPrefix(u.String(), endpoint) {
-			return true
-		}
+	return false
+}
+
+// applicationsPrefixes returns the URL prefixes served by an overridden
+// applications endpoint.
+func applicationsPrefixes(endpoint string) []string {
+	prefixes := []string{endpoint}
+
+	// Special case other resources directly under /v2/
+	if c, err := url.Parse(endpoint); err == nil {
+		c.Path = path.Join(c.Path, "..", "clusters")
+		prefixes = append(prefixes, c.String())
+		c.Path = path.Join(c.Path, "..", "application-activity")
+		prefixes = append(prefixes, c.String())
 	}
 
-	return false
+	return prefixes
 }
 
+// invalidate discards cached tokens (if supported by the token source) so that
+// a token rejected by the server is not used again.
+func invalidate(ts oauth2.TokenSource) {
+	if inv, ok := ts.(interface{ Invalidate() }); ok {
+		inv.Invalidate()
+	}
+}
+
+// tokenSourceFunc adapts a function into a token source.
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+// Token returns the result of invoking the function.
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) { return f() }
+
 // errorTokenSource is a TokenSource that always returns an error.
 type errorTokenSource struct {
 	err error
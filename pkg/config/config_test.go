@@ -0,0 +1,71 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestConfig_TokenSource_leeway(t *testing.T) {
+	var issued int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":60}`, n)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		leeway   time.Duration
+		expected []string
+	}{
+		{desc: "default", expected: []string{"token-1", "token-1"}},
+		{desc: "within leeway", leeway: 2 * time.Minute, expected: []string{"token-1", "token-2"}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			atomic.StoreInt32(&issued, 0)
+			cfg := &Config{
+				Server:      "https://api.example.com/",
+				Issuer:      srv.URL + "/",
+				ClientID:    "test",
+				TokenLeeway: c.leeway,
+			}
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, srv.Client())
+			ts := cfg.TokenSource(ctx)
+
+			var actual []string
+			for range c.expected {
+				tok, err := ts.Token()
+				require.NoError(t, err)
+				actual = append(actual, tok.AccessToken)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// EnvVar describes an environment variable used for configuration.
+type EnvVar struct {
+	// The name of the environment variable.
+	Name string
+	// The value used when the environment variable is not set.
+	Default string
+	// Flag indicating the value is sensitive and should not be displayed.
+	Secret bool
+}
+
+// additionalEnvVars are the environment variables which are read directly
+// instead of being bound to a configuration field.
+var additionalEnvVars = []EnvVar{
+	{Name: "STORMFORGE_APPLICATIONS_AUDIENCE"},
+	{Name: "STORMFORGE_EXPERIMENTS_AUDIENCE"},
+	{Name: "STORMFORGE_API_POLL_INTERVAL"},
+	{Name: "STORMFORGE_LOCALE"},
+	{Name: "STORMFORGE_TZ"},
+}
+
+// EnvVars returns the environment variables supported by the configuration,
+// derived from the `env` tags of the configuration fields.
+func EnvVars() []EnvVar {
+	result := structEnvVars(reflect.TypeOf(Config{}))
+	return append(result, additionalEnvVars...)
+}
+
+// structEnvVars returns the environment variables bound to the fields of a
+// struct type, including the fields of nested structs.
+func structEnvVars(t reflect.Type) []EnvVar {
+	var result []EnvVar
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("env"), ",")
+		if name == "" {
+			if f.Type.Kind() == reflect.Struct {
+				result = append(result, structEnvVars(f.Type)...)
+			}
+			continue
+		}
+
+		result = append(result, EnvVar{
+			Name:    name,
+			Default: f.Tag.Get("envDefault"),
+			Secret:  isSecretEnv(name),
+		})
+	}
+	return result
+}
+
+// isSecretEnv checks if the named environment variable holds a credential.
+func isSecretEnv(name string) bool {
+	return strings.HasSuffix(name, "_SECRET") || strings.HasSuffix(name, "_TOKEN")
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// configFile tracks the file a configuration was loaded from.
+type configFile struct {
+	// The name of the configuration file.
+	filename string
+	// The sections of the configuration as it was last read or written.
+	base map[string]json.RawMessage
+}
+
+// LoadFile reads a configuration file. A file which does not exist yields an
+// empty configuration, which is created when the configuration is written.
+func LoadFile(filename string) (*Config, error) {
+	cfg := &Config{}
+
+	sections, err := readSections(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.setSections(filename, sections); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Write persists a configuration obtained from `LoadFile`. Concurrent writers
+// (e.g. multiple CLI invocations) are serialized using a lock file and changes
+// are merged with the current contents of the file: each top-level section
+// modified since the file was loaded overwrites the value on disk, all other
+// sections are preserved as they currently exist on disk. The file is replaced
+// atomically.
+func (cfg *Config) Write() error {
+	f := cfg.file
+	if f == nil {
+		return fmt.Errorf("configuration was not loaded from a file")
+	}
+
+	unlock, err := lockFile(f.filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := readSections(f.filename)
+	if err != nil {
+		return err
+	}
+
+	ours, err := sections(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Apply only the sections we changed on top of the current file
+	merged := make(map[string]json.RawMessage, len(current))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k := range union(ours, f.base) {
+		if bytes.Equal(ours[k], f.base[k]) {
+			continue
+		}
+		if v, ok := ours[k]; ok {
+			merged[k] = v
+		} else {
+			delete(merged, k)
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if data, err = yaml.JSONToYAML(data); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(f.filename, data, 0600); err != nil {
+		return err
+	}
+
+	return cfg.setSections(f.filename, merged)
+}
+
+// setSections replaces the configuration using the supplied file sections.
+func (cfg *Config) setSections(filename string, s map[string]json.RawMessage) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	loaded := Config{UnauthorizedFunc: cfg.UnauthorizedFunc}
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("invalid configuration file %q: %w", filename, err)
+	}
+
+	// The base must be normalized the same way the configuration is
+	base, err := sections(&loaded)
+	if err != nil {
+		return err
+	}
+
+	loaded.file = &configFile{filename: filename, base: base}
+	*cfg = loaded
+	return nil
+}
+
+// readSections reads the top-level sections of a configuration file.
+func readSections(filename string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]json.RawMessage{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %q: %w", filename, err)
+	}
+	if v == nil {
+		return map[string]json.RawMessage{}, nil
+	}
+	return sections(v)
+}
+
+// sections splits a value into normalized top-level JSON sections so they can
+// be compared byte-for-byte.
+func sections(v interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		if result[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func union(a, b map[string]json.RawMessage) map[string]struct{} {
+	result := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		result[k] = struct{}{}
+	}
+	for k := range b {
+		result[k] = struct{}{}
+	}
+	return result
+}
+
+// writeFileAtomic writes to a temporary file which is renamed over the target
+// so readers never observe a partially written file.
+func writeFileAtomic(filename string, data []byte, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+const (
+	// lockTimeout is the maximum amount of time to wait for the lock.
+	lockTimeout = 10 * time.Second
+	// lockStale is the age after which a lock is assumed to be abandoned.
+	lockStale = 30 * time.Second
+)
+
+// lockFile acquires an advisory lock on the supplied file using an exclusively
+// created lock file, returning a function that releases the lock.
+func lockFile(filename string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+
+	lockName := filename + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	delay := 10 * time.Millisecond
+	for {
+		lf, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, _ = fmt.Fprintf(lf, "%d\n", os.Getpid())
+			_ = lf.Close()
+			return func() { _ = os.Remove(lockName) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		// Break locks left behind by processes that did not clean up
+		if fi, err := os.Stat(lockName); err == nil && time.Since(fi.ModTime()) > lockStale {
+			_ = os.Remove(lockName)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for configuration lock %q", lockName)
+		}
+		time.Sleep(delay)
+		if delay < 200*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
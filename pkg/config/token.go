@@ -0,0 +1,88 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+// ErrOpaqueToken is returned for tokens which are not a JWT, the claims of an
+// opaque token can only be checked by the server.
+var ErrOpaqueToken = errors.New("token is not a valid JWT")
+
+// TokenClaims are the claims of a static token checked before it is used.
+type TokenClaims struct {
+	Subject  string    `json:"subject,omitempty"`
+	Issuer   string    `json:"issuer,omitempty"`
+	Audience []string  `json:"audience,omitempty"`
+	Expiry   time.Time `json:"expiry,omitempty"`
+}
+
+// ParseToken returns the claims of a JWT. The signature is NOT verified, the
+// claims are only used to detect tokens that the server would reject.
+func ParseToken(token string) (*TokenClaims, error) {
+	tok, err := jwt.ParseSigned(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOpaqueToken, err)
+	}
+
+	var claims jwt.Claims
+	if err := tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOpaqueToken, err)
+	}
+
+	result := &TokenClaims{
+		Subject:  claims.Subject,
+		Issuer:   claims.Issuer,
+		Audience: claims.Audience,
+	}
+	if claims.Expiry != nil {
+		result.Expiry = claims.Expiry.Time()
+	}
+	return result, nil
+}
+
+// ValidateToken checks that a static JWT has not expired and was issued for the
+// configured server. Opaque tokens cannot be checked, ErrOpaqueToken is returned
+// so callers may decide to use them anyway.
+func (cfg *Config) ValidateToken(token string) (*TokenClaims, error) {
+	claims, err := ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.Expiry.IsZero() && !claims.Expiry.After(time.Now()) {
+		return claims, fmt.Errorf("token expired at %s", claims.Expiry.Format(time.RFC3339))
+	}
+
+	if len(claims.Audience) > 0 {
+		server := strings.TrimRight(cfg.Server, "/")
+		for _, aud := range claims.Audience {
+			if strings.TrimRight(aud, "/") == server {
+				return claims, nil
+			}
+		}
+		return claims, fmt.Errorf("token audience %s does not include the server %s", strings.Join(claims.Audience, ", "), cfg.Server)
+	}
+
+	return claims, nil
+}
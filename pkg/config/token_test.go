@@ -0,0 +1,83 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+func TestConfig_ValidateToken(t *testing.T) {
+	cfg := &Config{Server: "https://api.example.com/"}
+	cases := []struct {
+		desc        string
+		token       string
+		expectedErr string
+		opaque      bool
+	}{
+		{
+			desc:  "valid",
+			token: signedToken(t, jwt.Claims{Audience: jwt.Audience{"https://api.example.com"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}),
+		},
+		{
+			desc:  "no claims",
+			token: signedToken(t, jwt.Claims{}),
+		},
+		{
+			desc:        "expired",
+			token:       signedToken(t, jwt.Claims{Expiry: jwt.NewNumericDate(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))}),
+			expectedErr: "token expired at 2020-01-01T00:00:00Z",
+		},
+		{
+			desc:        "wrong audience",
+			token:       signedToken(t, jwt.Claims{Audience: jwt.Audience{"https://other.example.com/"}}),
+			expectedErr: "token audience https://other.example.com/ does not include the server https://api.example.com/",
+		},
+		{
+			desc:   "opaque",
+			token:  "sfo_0123456789abcdef",
+			opaque: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			_, err := cfg.ValidateToken(c.token)
+			switch {
+			case c.opaque:
+				assert.ErrorIs(t, err, ErrOpaqueToken)
+			case c.expectedErr != "":
+				assert.EqualError(t, err, c.expectedErr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// signedToken returns a JWT with the supplied claims.
+func signedToken(t *testing.T, claims jwt.Claims) string {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
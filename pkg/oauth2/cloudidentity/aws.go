@@ -0,0 +1,250 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudidentity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSubjectTokenType is the subject token type of signed AWS STS
+// GetCallerIdentity requests.
+const AWSSubjectTokenType = "urn:ietf:params:aws:token-type:aws4_request"
+
+// AWSAudienceHeader is the signed header used to bind the caller identity
+// request to the audience of the exchange, preventing it from being replayed
+// elsewhere.
+const AWSAudienceHeader = "X-StormForge-Audience"
+
+// AWSCredentials are the credentials used to sign requests.
+type AWSCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"Token"`
+}
+
+// AWS produces subject tokens consisting of a signed STS GetCallerIdentity
+// request; the authorization server verifies the identity of the caller by
+// sending the request to STS. Credentials are read from the standard
+// environment variables or from the EC2 instance metadata service.
+type AWS struct {
+	// The audience the caller identity request is bound to.
+	Audience string
+	// The STS region. Defaults to the `AWS_REGION` or `AWS_DEFAULT_REGION`
+	// environment variables, or "us-east-1".
+	Region string
+	// The base URL of the instance metadata service. Defaults to "http://169.254.169.254/".
+	MetadataURL string
+	// The HTTP client used to contact the metadata service. Defaults to a client
+	// with a short timeout so requests fail quickly when not running on EC2.
+	Client *http.Client
+
+	now func() time.Time
+}
+
+// SubjectToken returns a new signed GetCallerIdentity request.
+func (a *AWS) SubjectToken(ctx context.Context) (string, error) {
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to obtain AWS credentials: %w", err)
+	}
+
+	region := a.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     "sts." + region + ".amazonaws.com",
+		Path:     "/",
+		RawQuery: "Action=GetCallerIdentity&Version=2011-06-15",
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if a.Audience != "" {
+		req.Header.Set(AWSAudienceHeader, a.Audience)
+	}
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	signAWSRequest(req, nil, creds, region, "sts", now())
+
+	// Serialize the request so it can be replayed by the authorization server
+	type header struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	token := struct {
+		URL     string   `json:"url"`
+		Method  string   `json:"method"`
+		Headers []header `json:"headers"`
+	}{URL: req.URL.String(), Method: req.Method}
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		token.Headers = append(token.Headers, header{Key: strings.ToLower(k), Value: req.Header.Get(k)})
+	}
+
+	data, err := json.Marshal(&token)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(string(data)), nil
+}
+
+// credentials returns the credentials used to sign the caller identity request.
+func (a *AWS) credentials(ctx context.Context) (*AWSCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &AWSCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	base := a.MetadataURL
+	if base == "" {
+		base = "http://169.254.169.254/"
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	// IMDSv2 requires a session token
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.JoinPath("latest/api/token").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	sessionToken, err := doMetadataRequest(a.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	get := func(p string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.JoinPath(p).String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(sessionToken))
+		return doMetadataRequest(a.Client, req)
+	}
+
+	role, err := get("latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return nil, err
+	}
+	roleName := strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0])
+	if roleName == "" {
+		return nil, fmt.Errorf("no IAM role is associated with the instance")
+	}
+
+	data, err := get("latest/meta-data/iam/security-credentials/" + roleName)
+	if err != nil {
+		return nil, err
+	}
+	creds := &AWSCredentials{}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers to the supplied request.
+func signAWSRequest(req *http.Request, body []byte, creds *AWSCredentials, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	}
+
+	// Canonical headers are lower case, sorted, with trimmed values
+	names := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		names = append(names, strings.ToLower(k))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	query := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
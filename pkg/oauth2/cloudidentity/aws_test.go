@@ -0,0 +1,135 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudidentity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+
+	creds := &AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestAWS_SubjectToken(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			_, _ = w.Write([]byte("session"))
+		case "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("role\n"))
+		case "/latest/meta-data/iam/security-credentials/role":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "session" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"AccessKeyId":"id","SecretAccessKey":"secret","Token":"token"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	a := &AWS{
+		Audience:    "https://api.example.com/",
+		Region:      "us-west-2",
+		MetadataURL: srv.URL,
+		now:         func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+
+	tok, err := a.SubjectToken(context.Background())
+	require.NoError(t, err)
+
+	data, err := url.QueryUnescape(tok)
+	require.NoError(t, err)
+
+	var actual struct {
+		URL     string              `json:"url"`
+		Method  string              `json:"method"`
+		Headers []map[string]string `json:"headers"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data), &actual))
+	assert.Equal(t, "https://sts.us-west-2.amazonaws.com/?Action=GetCallerIdentity&Version=2011-06-15", actual.URL)
+	assert.Equal(t, http.MethodPost, actual.Method)
+
+	headers := make(map[string]string)
+	for _, h := range actual.Headers {
+		headers[h["key"]] = h["value"]
+	}
+	assert.Equal(t, "https://api.example.com/", headers["x-stormforge-audience"])
+	assert.Equal(t, "token", headers["x-amz-security-token"])
+	assert.Equal(t, "20230102T030405Z", headers["x-amz-date"])
+	assert.True(t, strings.HasPrefix(headers["authorization"], "AWS4-HMAC-SHA256 Credential=id/20230102/us-west-2/sts/aws4_request, "))
+	assert.Contains(t, headers["authorization"], "x-stormforge-audience")
+}
+
+func TestGCP_SubjectToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/identity" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("id-token:" + r.URL.Query().Get("audience")))
+	}))
+	defer srv.Close()
+
+	g := &GCP{Audience: "https://api.example.com/", MetadataURL: srv.URL}
+	tok, err := g.SubjectToken(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "id-token:https://api.example.com/", tok)
+	}
+}
+
+func TestDoMetadataRequest_timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	defer func(c *http.Client) { defaultMetadataClient = c }(defaultMetadataClient)
+	defaultMetadataClient = &http.Client{Timeout: 10 * time.Millisecond}
+
+	start := time.Now()
+	g := &GCP{MetadataURL: srv.URL}
+	_, err := g.SubjectToken(context.Background())
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudidentity provides subject tokens for token exchange derived
+// from the identity of the cloud instance a program is running on, allowing
+// credential-less authorization from cloud VMs.
+package cloudidentity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/oauth2/tokenexchange"
+)
+
+// GCPSubjectTokenType is the subject token type of GCP identity tokens.
+const GCPSubjectTokenType = tokenexchange.TokenTypeIDToken
+
+// GCP produces Google signed identity tokens for the default service account
+// of a Compute Engine instance (or any environment exposing the metadata server).
+type GCP struct {
+	// The audience of the identity token, typically the StormForge API audience.
+	Audience string
+	// The base URL of the metadata server. Defaults to "http://metadata.google.internal/".
+	MetadataURL string
+	// The HTTP client used to contact the metadata server. Defaults to a client
+	// with a short timeout so requests fail quickly when not running on GCP.
+	Client *http.Client
+}
+
+// SubjectToken fetches a new identity token from the metadata server.
+func (g *GCP) SubjectToken(ctx context.Context) (string, error) {
+	base := g.MetadataURL
+	if base == "" {
+		base = "http://metadata.google.internal/"
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u = u.JoinPath("computeMetadata/v1/instance/service-accounts/default/identity")
+	u.RawQuery = url.Values{"audience": {g.Audience}, "format": {"full"}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, err := doMetadataRequest(g.Client, req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch GCP identity token: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// defaultMetadataClient is used to contact instance metadata services, which
+// are either local and fast or (when not running in the cloud) unreachable.
+var defaultMetadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// doMetadataRequest executes a request against an instance metadata service.
+func doMetadataRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = defaultMetadataClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected metadata server response (%s)", resp.Status)
+	}
+	return body, nil
+}
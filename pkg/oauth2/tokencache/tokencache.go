@@ -0,0 +1,207 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tokencache implements an encrypted on-disk cache of OAuth2 tokens so
+// that short-lived processes can reuse tokens across invocations.
+package tokencache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// FileCache stores a single token in an encrypted file.
+type FileCache struct {
+	// The name of the file used to store the token.
+	Filename string
+	// The 32 byte AES-256 key used to encrypt the token.
+	Key []byte
+}
+
+// NewFileCache returns a cache for the token identified by the supplied server
+// and client in the specified directory. The encryption key is derived from the
+// secret, which should be known only to the owner of the token (e.g. the client
+// secret used to obtain it).
+func NewFileCache(dir, server, clientID, secret string) *FileCache {
+	name := sha256.Sum256([]byte(server + "\x00" + clientID))
+	key := sha256.Sum256([]byte("optimize-go token cache\x00" + server + "\x00" + clientID + "\x00" + secret))
+	return &FileCache{
+		Filename: filepath.Join(dir, hex.EncodeToString(name[:16])+".token"),
+		Key:      key[:],
+	}
+}
+
+// Load returns the cached token, or nil if no usable token is available.
+func (c *FileCache) Load() (*oauth2.Token, error) {
+	data, err := os.ReadFile(c.Filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	aead, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, nil
+	}
+
+	// A file we cannot decrypt (e.g. the secret changed) is just a cache miss
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(filepath.Base(c.Filename)))
+	if err != nil {
+		return nil, nil
+	}
+
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(plaintext, tok); err != nil {
+		return nil, nil
+	}
+	return tok, nil
+}
+
+// Store encrypts and saves the supplied token.
+func (c *FileCache) Store(tok *oauth2.Token) error {
+	plaintext, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+
+	aead, err := c.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := aead.Seal(nonce, nonce, plaintext, []byte(filepath.Base(c.Filename)))
+
+	if err := os.MkdirAll(filepath.Dir(c.Filename), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial token
+	f, err := os.CreateTemp(filepath.Dir(c.Filename), ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.Filename)
+}
+
+// Clear removes the cached token.
+func (c *FileCache) Clear() error {
+	if err := os.Remove(c.Filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (c *FileCache) aead() (cipher.AEAD, error) {
+	if len(c.Key) != 32 {
+		return nil, fmt.Errorf("invalid token cache key length: %d", len(c.Key))
+	}
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// TokenSource returns a token source which reuses valid tokens from the cache,
+// only using the supplied source (which should not perform its own caching)
+// when the cached token has expired or has been invalidated. Errors reading or
+// writing the cache are ignored.
+func TokenSource(cache *FileCache, src oauth2.TokenSource) *CachingTokenSource {
+	return &CachingTokenSource{cache: cache, src: src}
+}
+
+// CachingTokenSource is a token source backed by an on-disk cache.
+type CachingTokenSource struct {
+	// The amount of time before expiry that a token is considered expired,
+	// allowing for clock skew between the client and the server.
+	Leeway time.Duration
+
+	cache *FileCache
+	src   oauth2.TokenSource
+
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+// Token returns a valid token from memory, the cache, or the underlying source.
+func (ts *CachingTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.valid(ts.tok) {
+		return ts.tok, nil
+	}
+
+	if tok, err := ts.cache.Load(); err == nil && ts.valid(tok) {
+		ts.tok = tok
+		return tok, nil
+	}
+
+	tok, err := ts.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	ts.tok = tok
+	_ = ts.cache.Store(tok)
+	return tok, nil
+}
+
+// valid checks the token, including the configured leeway.
+func (ts *CachingTokenSource) valid(tok *oauth2.Token) bool {
+	if !tok.Valid() {
+		return false
+	}
+	return tok.Expiry.IsZero() || time.Until(tok.Expiry) > ts.Leeway
+}
+
+// Invalidate discards the current token (e.g. after the server rejected it),
+// forcing a new token to be obtained on the next request.
+func (ts *CachingTokenSource) Invalidate() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.tok = nil
+	_ = ts.cache.Clear()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tokencache
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	c := NewFileCache(dir, "https://api.example.com/", "client", "secret")
+
+	tok, err := c.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, tok)
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	require.NoError(t, c.Store(&oauth2.Token{AccessToken: "access", Expiry: expiry}))
+
+	// The token must not be stored in plain text
+	data, err := os.ReadFile(c.Filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "access")
+
+	tok, err = c.Load()
+	if assert.NoError(t, err) && assert.NotNil(t, tok) {
+		assert.Equal(t, "access", tok.AccessToken)
+		assert.True(t, expiry.Equal(tok.Expiry))
+	}
+
+	// A different secret cannot read the token
+	tok, err = NewFileCache(dir, "https://api.example.com/", "client", "other").Load()
+	assert.NoError(t, err)
+	assert.Nil(t, tok)
+
+	// A different client uses a different file
+	assert.NotEqual(t, c.Filename, NewFileCache(dir, "https://api.example.com/", "other", "secret").Filename)
+
+	require.NoError(t, c.Clear())
+	tok, err = c.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, tok)
+}
+
+func TestCachingTokenSource(t *testing.T) {
+	dir := t.TempDir()
+	var calls int
+	src := tokenSourceFunc(func() (*oauth2.Token, error) {
+		calls++
+		return &oauth2.Token{AccessToken: strconv.Itoa(calls), Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	// The first source fetches and stores a token
+	tok, err := TokenSource(NewFileCache(dir, "server", "client", "secret"), src).Token()
+	require.NoError(t, err)
+	assert.Equal(t, "1", tok.AccessToken)
+
+	// A new source (e.g. the next invocation) reuses the stored token
+	ts := TokenSource(NewFileCache(dir, "server", "client", "secret"), src)
+	tok, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "1", tok.AccessToken)
+
+	// Invalidating forces a new token
+	ts.Invalidate()
+	tok, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "2", tok.AccessToken)
+	assert.Equal(t, 2, calls)
+
+	// Tokens expiring within the leeway are replaced
+	ts = TokenSource(NewFileCache(dir, "server", "client", "secret"), src)
+	ts.Leeway = 2 * time.Hour
+	tok, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "3", tok.AccessToken)
+}
+
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) { return f() }
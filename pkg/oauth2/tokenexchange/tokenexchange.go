@@ -0,0 +1,237 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tokenexchange implements the OAuth 2.0 Token Exchange (RFC 8693)
+// grant, allowing an externally issued credential (such as a Kubernetes
+// service account token) to be exchanged for an access token. Delegation is
+// supported by supplying an actor token in addition to the subject token.
+package tokenexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// GrantType is the grant type used for token exchange requests.
+	GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeJWT indicates the subject token is a JWT.
+	TokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+	// TokenTypeIDToken indicates the subject token is an OpenID Connect ID Token.
+	TokenTypeIDToken = "urn:ietf:params:oauth:token-type:id_token"
+	// TokenTypeAccessToken indicates the subject or requested token is an OAuth 2.0 access token.
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// SubjectTokenSource supplies the token that is exchanged for an access token.
+type SubjectTokenSource interface {
+	// SubjectToken returns the current subject token.
+	SubjectToken(ctx context.Context) (string, error)
+}
+
+// SubjectTokenFunc adapts a function into a subject token source.
+type SubjectTokenFunc func(ctx context.Context) (string, error)
+
+// SubjectToken returns the result of invoking the function.
+func (f SubjectTokenFunc) SubjectToken(ctx context.Context) (string, error) { return f(ctx) }
+
+// StaticSubjectToken returns a subject token source that always returns the
+// supplied value, for example the identifier of a user being impersonated.
+func StaticSubjectToken(token string) SubjectTokenSource {
+	return SubjectTokenFunc(func(context.Context) (string, error) { return token, nil })
+}
+
+// AccessTokenSubject adapts an OAuth2 token source into a subject token source
+// which returns the current access token.
+func AccessTokenSubject(src oauth2.TokenSource) SubjectTokenSource {
+	return SubjectTokenFunc(func(context.Context) (string, error) {
+		t, err := src.Token()
+		if err != nil {
+			return "", err
+		}
+		return t.AccessToken, nil
+	})
+}
+
+// FileSubjectToken returns a subject token source that reads the token from a
+// file. The file is read for every exchange since projected tokens are
+// periodically rotated.
+func FileSubjectToken(filename string) SubjectTokenSource {
+	return SubjectTokenFunc(func(context.Context) (string, error) {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return "", fmt.Errorf("unable to read subject token: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	})
+}
+
+// Config describes a token exchange.
+type Config struct {
+	// The token endpoint of the authorization server.
+	TokenURL string
+	// The optional client ID used to identify the caller.
+	ClientID string
+	// The audience of the requested token.
+	Audience string
+	// The list of scopes to request.
+	Scopes []string
+	// The type of the subject token. Defaults to a JWT.
+	SubjectTokenType string
+	// The type of the requested token. Defaults to an access token.
+	RequestedTokenType string
+	// The optional source of the token representing the acting party, the
+	// resulting token is issued to the actor on behalf of the subject.
+	Actor SubjectTokenSource
+	// The type of the actor token. Defaults to an access token.
+	ActorTokenType string
+	// Additional parameters to include with the exchange request.
+	EndpointParams url.Values
+	// How long before expiry tokens are exchanged again. Defaults to the
+	// early expiry used by `oauth2.ReuseTokenSource`.
+	ExpiryDelta time.Duration
+}
+
+// Exchange trades the supplied subject token for a new token.
+func (c *Config) Exchange(ctx context.Context, subjectToken string) (*oauth2.Token, error) {
+	v := url.Values{}
+	for k, vv := range c.EndpointParams {
+		v[k] = append([]string(nil), vv...)
+	}
+	v.Set("grant_type", GrantType)
+	v.Set("subject_token", subjectToken)
+	v.Set("subject_token_type", defaultString(c.SubjectTokenType, TokenTypeJWT))
+	v.Set("requested_token_type", defaultString(c.RequestedTokenType, TokenTypeAccessToken))
+	if c.ClientID != "" {
+		v.Set("client_id", c.ClientID)
+	}
+	if c.Audience != "" {
+		v.Set("audience", c.Audience)
+	}
+	if len(c.Scopes) > 0 {
+		v.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	if c.Actor != nil {
+		actorToken, err := c.Actor.SubjectToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to obtain actor token: %w", err)
+		}
+		v.Set("actor_token", actorToken)
+		v.Set("actor_token_type", defaultString(c.ActorTokenType, TokenTypeAccessToken))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := http.DefaultClient
+	if hc, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && hc != nil {
+		client = hc
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot exchange token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot exchange token: %w", err)
+	}
+
+	var tr struct {
+		AccessToken      string `json:"access_token"`
+		IssuedTokenType  string `json:"issued_token_type"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		RefreshToken     string `json:"refresh_token"`
+		Scope            string `json:"scope"`
+		ErrorCode        string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		ErrorURI         string `json:"error_uri"`
+	}
+	if ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); ct == "application/json" {
+		_ = json.Unmarshal(body, &tr)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || tr.ErrorCode != "" {
+		return nil, &oauth2.RetrieveError{
+			Response:         resp,
+			Body:             body,
+			ErrorCode:        tr.ErrorCode,
+			ErrorDescription: tr.ErrorDescription,
+			ErrorURI:         tr.ErrorURI,
+		}
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: server response missing access_token")
+	}
+
+	tok := &oauth2.Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+	}
+	if tr.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok.WithExtra(map[string]interface{}{
+		"issued_token_type": tr.IssuedTokenType,
+		"scope":             tr.Scope,
+	}), nil
+}
+
+// TokenSource returns a token source that exchanges subject tokens for access
+// tokens, the resulting tokens are reused until they expire.
+func (c *Config) TokenSource(ctx context.Context, subject SubjectTokenSource) oauth2.TokenSource {
+	return oauth2.ReuseTokenSourceWithExpiry(nil, &tokenSource{ctx: ctx, conf: c, subject: subject}, c.ExpiryDelta)
+}
+
+type tokenSource struct {
+	ctx     context.Context
+	conf    *Config
+	subject SubjectTokenSource
+}
+
+// Token obtains a new subject token and exchanges it.
+func (ts *tokenSource) Token() (*oauth2.Token, error) {
+	subjectToken, err := ts.subject.SubjectToken(ts.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ts.conf.Exchange(ts.ctx, subjectToken)
+}
+
+func defaultString(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
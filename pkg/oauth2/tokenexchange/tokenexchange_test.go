@@ -0,0 +1,141 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tokenexchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestConfig_Exchange(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("subject_token") != "subject" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"access","token_type":"Bearer","expires_in":300,"issued_token_type":"` + TokenTypeAccessToken + `"}`))
+	}))
+	defer srv.Close()
+
+	c := &Config{
+		TokenURL: srv.URL,
+		ClientID: "client",
+		Audience: "https://api.example.com/",
+		Scopes:   []string{"a", "b"},
+	}
+
+	tok, err := c.Exchange(context.Background(), "subject")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access", tok.AccessToken)
+		assert.Equal(t, "Bearer", tok.TokenType)
+		assert.False(t, tok.Expiry.IsZero())
+		assert.Equal(t, TokenTypeAccessToken, tok.Extra("issued_token_type"))
+	}
+	assert.Equal(t, url.Values{
+		"grant_type":           {GrantType},
+		"subject_token":        {"subject"},
+		"subject_token_type":   {TokenTypeJWT},
+		"requested_token_type": {TokenTypeAccessToken},
+		"client_id":            {"client"},
+		"audience":             {"https://api.example.com/"},
+		"scope":                {"a b"},
+	}, form)
+
+	_, err = c.Exchange(context.Background(), "invalid")
+	var retrieveErr *oauth2.RetrieveError
+	if assert.ErrorAs(t, err, &retrieveErr) {
+		assert.Equal(t, http.StatusUnauthorized, retrieveErr.Response.StatusCode)
+		assert.Equal(t, "invalid_grant", retrieveErr.ErrorCode)
+	}
+}
+
+func TestFileSubjectToken(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(filename, []byte("subject\n"), 0600))
+
+	tok, err := FileSubjectToken(filename).SubjectToken(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "subject", tok)
+	}
+
+	_, err = FileSubjectToken(filepath.Join(t.TempDir(), "missing")).SubjectToken(context.Background())
+	assert.Error(t, err)
+}
+
+func TestConfig_Exchange_Delegation(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"delegated","token_type":"Bearer"}`))
+	}))
+	defer srv.Close()
+
+	c := &Config{
+		TokenURL:         srv.URL,
+		SubjectTokenType: "urn:example:email",
+		Actor:            AccessTokenSubject(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "service"})),
+	}
+
+	ts := c.TokenSource(context.Background(), StaticSubjectToken("user@example.com"))
+	tok, err := ts.Token()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "delegated", tok.AccessToken)
+	}
+	assert.Equal(t, "user@example.com", form.Get("subject_token"))
+	assert.Equal(t, "urn:example:email", form.Get("subject_token_type"))
+	assert.Equal(t, "service", form.Get("actor_token"))
+	assert.Equal(t, TokenTypeAccessToken, form.Get("actor_token_type"))
+}
+
+func TestConfig_TokenSource_ExpiryDelta(t *testing.T) {
+	var exchanges int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"access","token_type":"Bearer","expires_in":60}`))
+	}))
+	defer srv.Close()
+
+	c := &Config{TokenURL: srv.URL}
+	ts := c.TokenSource(context.Background(), StaticSubjectToken("subject"))
+	_, _ = ts.Token()
+	_, _ = ts.Token()
+	assert.Equal(t, 1, exchanges)
+
+	c.ExpiryDelta = 2 * time.Minute
+	ts = c.TokenSource(context.Background(), StaticSubjectToken("subject"))
+	_, _ = ts.Token()
+	_, _ = ts.Token()
+	assert.Equal(t, 3, exchanges)
+}